@@ -22,11 +22,17 @@ const (
 // AppWriter writer for logging
 var AppWriter io.Writer
 
+// pastelupApp is the app with all commands, it is used to resolve effective values of their flags
+var pastelupApp *cli.App
+
 // NewApp inits a new command line interface.
 func NewApp(args []string) *cli.App {
 
 	app := cli.NewApp(appName)
 	AppWriter = app.Writer
+	pastelupApp = app
+	// flags not set on the command line are taken from config files and PASTELUP_* environment variables
+	cli.SetFlagValueSource(configs.LoadPastelupConfig())
 	app.SetUsage(appUsage)
 	app.SetVersion(version.Version())
 
@@ -47,6 +53,18 @@ func NewApp(args []string) *cli.App {
 		setupInfoCommand(configs.InitConfig(args)),
 		setupPingCommand(configs.InitConfig(args)),
 		setupUninstallCommand(configs.InitConfig(args)),
+		setupMasternodeCommand(configs.InitConfig(args)),
+		setupConfigCommand(configs.InitConfig(args)),
+		setupApplyCommand(configs.InitConfig(args)),
+		setupPlanCommand(configs.InitConfig(args)),
+		setupRPCCommand(configs.InitConfig(args)),
+		setupPeersCommand(configs.InitConfig(args)),
+		setupServiceCommand(configs.InitConfig(args)),
+		setupSuperviseCommand(configs.InitConfig(args)),
+		setupWatchCommand(configs.InitConfig(args)),
+		setupStatusCommand(configs.InitConfig(args)),
+		setupTopCommand(configs.InitConfig(args)),
+		setupSnapshotCommand(configs.InitConfig(args)),
 	)
 	return app
 }
@@ -57,6 +75,10 @@ func addLogFlags(command *cli.Command, config *configs.Config) {
 		cli.NewFlag("log-level", &config.LogLevel).SetUsage(green("Set the log `level`.")).SetValue(config.LogLevel),
 		cli.NewFlag("log-file", &config.LogFile).SetUsage(green("The log `file` to write to.")),
 		cli.NewFlag("quiet", &config.Quiet).SetUsage(green("Disallows log output to stdout.")).SetAliases("q"),
+		cli.NewFlag(cli.ProfileFlagName, &config.Profile).
+			SetUsage(green("Optional, named `profile` of flag values from pastelup config files, $PASTELUP_PROFILE is used if not set")),
+		cli.NewFlag("instance", &config.Instance).
+			SetUsage(green("Optional, `name` of the instance to run several nodes on one host, it has own working directory and services")),
 	)
 }
 
@@ -77,5 +99,14 @@ func configureLogging(ctx context.Context, logPrefix string, config *configs.Con
 	if err := log.SetLevelName(config.LogLevel); err != nil {
 		return nil, errors.Errorf("--log-level %q, %v", config.LogLevel, err)
 	}
+
+	// all commands configure logging first, so the instance is applied here before its directories are used
+	if err := applyInstance(config); err != nil {
+		return nil, err
+	}
+	// secrets are registered before any of them can be logged
+	if err := resolveSecrets(ctx, config); err != nil {
+		return nil, err
+	}
 	return ctx, nil
 }
@@ -0,0 +1,704 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/pastelnetwork/pastelup/common/cli"
+	"github.com/pastelnetwork/pastelup/common/log"
+	"github.com/pastelnetwork/pastelup/configs"
+	"github.com/pastelnetwork/pastelup/constants"
+	"github.com/pastelnetwork/pastelup/utils"
+)
+
+const appliedReleaseFile = "release"
+
+// HostSpec is the desired state of the host, it is read from the spec file of "pastelup apply" and "pastelup plan"
+type HostSpec struct {
+	Network    string          `yaml:"network,omitempty"`
+	Release    string          `yaml:"release,omitempty"`
+	Components []string        `yaml:"components,omitempty"`
+	NodePort   int             `yaml:"node-port,omitempty"`
+	RPCPort    int             `yaml:"rpc-port,omitempty"`
+	SNPort     int             `yaml:"sn-port,omitempty"`
+	P2PPort    int             `yaml:"p2p-port,omitempty"`
+	Peers      []string        `yaml:"peers,omitempty"`
+	Firewall   bool            `yaml:"firewall,omitempty"`
+	Systemd    SystemdSpec     `yaml:"systemd,omitempty"`
+	Masternode *MasternodeSpec `yaml:"masternode,omitempty"`
+}
+
+// SystemdSpec defines which components are registered as system services
+type SystemdSpec struct {
+	Enable []string `yaml:"enable,omitempty"`
+	Start  bool     `yaml:"start,omitempty"`
+}
+
+// MasternodeSpec defines masternode record of the supernode in the masternode.conf
+type MasternodeSpec struct {
+	Name           string `yaml:"name,omitempty"`
+	IP             string `yaml:"ip,omitempty"`
+	Port           int    `yaml:"port,omitempty"`
+	PrivateKey     string `yaml:"private-key,omitempty"`
+	TxID           string `yaml:"txid,omitempty"`
+	TxIndex        string `yaml:"ind,omitempty"`
+	PastelID       string `yaml:"pastelid,omitempty"`
+	PassphraseFile string `yaml:"passphrase-file,omitempty"`
+	Activate       bool   `yaml:"activate,omitempty"`
+}
+
+// specComponent maps spec component to the tool and its "install" and "update" sub commands
+type specComponent struct {
+	tool    constants.ToolType
+	install string
+	update  string
+}
+
+var specComponents = map[string]specComponent{
+	"node":       {tool: constants.PastelD, install: "node", update: "node"},
+	"walletnode": {tool: constants.WalletNode, install: "walletnode", update: "walletnode"},
+	"supernode":  {tool: constants.SuperNode, install: "supernode", update: "supernode"},
+	"rq-service": {tool: constants.RQService, install: "rq-service", update: "rq-service"},
+	"dd-service": {tool: constants.DDService, install: "dd-service", update: "dd-service"},
+	// hermes is updated together with supernode
+	"hermes": {tool: constants.Hermes, install: "hermes-service"},
+}
+
+// specPort is the port of the spec, it is passed to install with the flag and kept in the config option of the binding
+type specPort struct {
+	name      string
+	flag      string
+	component string
+	binding   portRef
+	field     func(ports *configs.Ports) *int
+}
+
+var specPorts = []specPort{
+	{
+		name: "node-port", flag: "node-port", component: "node",
+		binding: portRef{tool: constants.PastelD, key: "port"},
+		field:   func(ports *configs.Ports) *int { return &ports.Node },
+	},
+	{
+		name: "rpc-port", flag: "node-rpc-port", component: "node",
+		binding: portRef{tool: constants.PastelD, key: "rpcport"},
+		field:   func(ports *configs.Ports) *int { return &ports.NodeRPC },
+	},
+	{
+		name: "sn-port", flag: "sn-port", component: "supernode",
+		binding: portRef{tool: constants.SuperNode, key: "node.server.port"},
+		field:   func(ports *configs.Ports) *int { return &ports.SuperNode },
+	},
+	{
+		name: "p2p-port", flag: "p2p-port", component: "supernode",
+		binding: portRef{tool: constants.SuperNode, key: "p2p.port"},
+		field:   func(ports *configs.Ports) *int { return &ports.P2P },
+	},
+}
+
+// applyAction is a single step of the plan, it is either pastelup command or a function
+type applyAction struct {
+	description string
+	args        []string
+	run         func(ctx context.Context) error
+	// env holds PASTELUP_* values of the secret flags, so they don't appear in the process list
+	env []string
+}
+
+func loadHostSpec(path string) (*HostSpec, error) {
+	if path == "" {
+		return nil, fmt.Errorf("spec file is required, use -f <file>")
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read spec file: %v", err)
+	}
+	var spec HostSpec
+	if err = yaml.UnmarshalStrict(data, &spec); err != nil {
+		return nil, fmt.Errorf("failed to parse spec file %s: %v", path, err)
+	}
+	return &spec, spec.validate()
+}
+
+func (s *HostSpec) validate() error {
+	switch s.Network {
+	case "":
+		s.Network = constants.NetworkMainnet
+	case constants.NetworkMainnet, constants.NetworkTestnet, constants.NetworkDevnet:
+	default:
+		return fmt.Errorf("unknown network %q, must be one of: mainnet, testnet, devnet", s.Network)
+	}
+	for _, c := range s.Components {
+		if _, ok := specComponents[c]; !ok {
+			return fmt.Errorf("unknown component %q, must be one of: node, walletnode, supernode, rq-service, dd-service, hermes", c)
+		}
+	}
+	if s.hasComponent("supernode") && s.hasComponent("walletnode") {
+		return fmt.Errorf("supernode and walletnode can't be installed on the same host")
+	}
+	for _, tool := range s.Systemd.Enable {
+		if err := isToolValid(tool); err != nil {
+			return fmt.Errorf("systemd: %v", err)
+		}
+	}
+	if s.Masternode != nil {
+		if !s.hasComponent("supernode") {
+			return fmt.Errorf("masternode can only be defined for supernode component")
+		}
+		if s.Masternode.Name == "" {
+			return fmt.Errorf("masternode name is required")
+		}
+	}
+	ports := s.ports()
+	used := map[int]string{}
+	for _, p := range specPorts {
+		port := *p.field(&ports)
+		if port == 0 {
+			continue
+		}
+		if err := utils.CheckConfValue(utils.ConfPort, strconv.Itoa(port)); err != nil {
+			return fmt.Errorf("%s: %v", p.name, err)
+		}
+		if p.component == "supernode" && !s.hasComponent("supernode") {
+			return fmt.Errorf("%s can only be defined for supernode component", p.name)
+		}
+		if other, ok := used[port]; ok {
+			return fmt.Errorf("%s and %s can't be the same port %d", other, p.name, port)
+		}
+		used[port] = p.name
+	}
+	return nil
+}
+
+// ports returns the custom ports of the spec, not set ones are the defaults of the network
+func (s *HostSpec) ports() configs.Ports {
+	return configs.Ports{Node: s.NodePort, NodeRPC: s.RPCPort, SuperNode: s.SNPort, P2P: s.P2PPort}
+}
+
+func (s *HostSpec) hasComponent(name string) bool {
+	return utils.Contains(s.Components, name)
+}
+
+// hostState is the actual state of the host the spec is compared with
+type hostState struct {
+	installed   map[string]bool
+	release     string
+	pastelConf  *utils.PastelConf
+	masternodes map[string]masterNodeConf
+	services    map[string]bool
+	firewall    *ufwStatus
+}
+
+// ufwStatus is the firewall state parsed from "ufw status"
+type ufwStatus struct {
+	active bool
+	// rules of TCP ports from anywhere in the order ufw matches them
+	rules []ufwRule
+}
+
+type ufwRule struct {
+	first, last int
+	allow       bool
+}
+
+// ufwStatusOutput returns the output of "ufw status", the command must not prompt for sudo password
+var ufwStatusOutput = func() ([]byte, error) {
+	return exec.Command("sudo", "-n", "ufw", "status").CombinedOutput()
+}
+
+// parseUFWStatus parses the output of "ufw status". Only rules of TCP ports from anywhere are taken, rules of
+// applications, addresses and interfaces are skipped
+func parseUFWStatus(out string) (*ufwStatus, error) {
+	status := &ufwStatus{}
+	found := false
+	for _, line := range strings.Split(out, "\n") {
+		line = strings.TrimSpace(line)
+		if value, ok := strings.CutPrefix(line, "Status:"); ok {
+			status.active = strings.TrimSpace(value) == "active"
+			found = true
+			continue
+		}
+
+		// To, Action and From columns, e.g. "9933/tcp (v6)   ALLOW IN   Anywhere (v6)"
+		fields := strings.Fields(strings.ReplaceAll(line, "(v6)", ""))
+		if len(fields) < 3 || !utils.Contains([]string{"ALLOW", "LIMIT", "DENY", "REJECT"}, fields[1]) {
+			continue
+		}
+		from := fields[2:]
+		if from[0] == "OUT" || from[0] == "FWD" {
+			continue
+		}
+		if from[0] == "IN" {
+			from = from[1:]
+		}
+		if len(from) != 1 || from[0] != "Anywhere" {
+			continue
+		}
+
+		ports, proto, _ := strings.Cut(fields[0], "/")
+		if proto != "" && proto != "tcp" {
+			continue
+		}
+		var rules []ufwRule
+		for _, spec := range strings.Split(ports, ",") {
+			low, high, isRange := strings.Cut(spec, ":")
+			if !isRange {
+				high = low
+			}
+			first, err1 := strconv.Atoi(low)
+			last, err2 := strconv.Atoi(high)
+			if err1 != nil || err2 != nil {
+				// application profile, e.g. OpenSSH
+				rules = nil
+				break
+			}
+			rules = append(rules, ufwRule{first: first, last: last, allow: fields[1] == "ALLOW" || fields[1] == "LIMIT"})
+		}
+		status.rules = append(status.rules, rules...)
+	}
+	if !found {
+		return nil, fmt.Errorf("unexpected output of ufw status: %q", strings.TrimSpace(out))
+	}
+	return status, nil
+}
+
+// isAllowed checks if the firewall lets the TCP port in, the first rule of the port wins and the incoming
+// traffic is denied by default
+func (s *ufwStatus) isAllowed(port int) bool {
+	if !s.active {
+		return true
+	}
+	for _, r := range s.rules {
+		if port >= r.first && port <= r.last {
+			return r.allow
+		}
+	}
+	return false
+}
+
+func appliedReleasePath(config *configs.Config) string {
+	return filepath.Join(config.WorkingDir, constants.PastelupStateDir, appliedReleaseFile)
+}
+
+func readHostState(ctx context.Context, config *configs.Config, spec *HostSpec) (*hostState, error) {
+	state := &hostState{installed: map[string]bool{}, services: map[string]bool{}}
+
+	for name, component := range specComponents {
+		if component.tool == constants.DDService {
+			state.installed[name] = utils.CheckFileExist(filepath.Join(config.Configurer.DefaultHomeDir(), constants.DupeDetectionServiceDir))
+		} else {
+			state.installed[name] = utils.CheckFileExist(filepath.Join(config.PastelExecDir, constants.ServiceName[component.tool][utils.GetOS()]))
+		}
+	}
+	if data, err := os.ReadFile(appliedReleasePath(config)); err == nil {
+		state.release = strings.TrimSpace(string(data))
+	}
+	if conf, err := utils.LoadPastelConf(filepath.Join(config.WorkingDir, constants.PastelConfName)); err == nil {
+		state.pastelConf = conf
+	}
+	if spec.Masternode != nil && utils.CheckFileExist(getMasternodeConfPath(config, config.WorkingDir, "masternode.conf")) {
+		state.masternodes, _ = loadMasternodeConfFile(ctx, config)
+	}
+	if sm, err := NewServiceManager(utils.GetOS(), config.Configurer.DefaultHomeDir(), config.Instance); err == nil {
+		for _, tool := range spec.Systemd.Enable {
+			state.services[tool] = utils.CheckFileExist(filepath.Join(constants.SystemdSystemDir, sm.ServiceName(toolToToolType[tool])))
+		}
+	}
+	if spec.Firewall && spec.hasComponent("supernode") && utils.GetOS() == constants.Linux {
+		out, err := ufwStatusOutput()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read firewall rules with \"sudo -n ufw status\", passwordless sudo is required by firewall option: %v: %s",
+				err, strings.TrimSpace(string(out)))
+		}
+		if state.firewall, err = parseUFWStatus(string(out)); err != nil {
+			return nil, err
+		}
+	}
+	return state, nil
+}
+
+// pastelupArgs returns pastelup sub command with the directory flags, flags must precede positional arguments
+func pastelupArgs(config *configs.Config, command ...string) []string {
+	return append(command, "--dir", config.PastelExecDir, "--work-dir", config.WorkingDir)
+}
+
+// configSetArgs returns "config set" command for the option of the component's config
+func configSetArgs(config *configs.Config, add bool, tool constants.ToolType, key, value string) []string {
+	args := []string{"config", "set", "--work-dir", config.WorkingDir}
+	if add {
+		args = append(args, "--add")
+	}
+	return append(args, string(tool), key, value)
+}
+
+// findPortBinding returns the binding of the port option with the options referring to it
+func findPortBinding(ref portRef) portBinding {
+	for _, b := range portBindings {
+		if b.portRef == ref {
+			return b
+		}
+	}
+	return portBinding{portRef: ref}
+}
+
+// planHostSpec compares the spec with the actual state of the host and returns actions required to reach the spec
+func planHostSpec(ctx context.Context, config *configs.Config, spec *HostSpec) ([]applyAction, error) {
+	config.Network = spec.Network
+	config.Ports = spec.ports().Merge(config.Ports)
+	state, err := readHostState(ctx, config, spec)
+	if err != nil {
+		return nil, err
+	}
+
+	if state.pastelConf != nil && state.pastelConf.Network() != spec.Network {
+		return nil, fmt.Errorf("host is installed for %s, changing network to %s requires reinstall", state.pastelConf.Network(), spec.Network)
+	}
+
+	var actions []applyAction
+	installing := map[string]bool{}
+
+	// node is installed as dependency of walletnode and supernode
+	components := spec.Components
+	if !spec.hasComponent("node") && !spec.hasComponent("walletnode") && !spec.hasComponent("supernode") && len(components) > 0 {
+		components = append([]string{"node"}, components...)
+	}
+	for _, name := range components {
+		component := specComponents[name]
+		if state.installed[name] {
+			if spec.Release != "" && state.release != spec.Release && component.update != "" {
+				actions = append(actions, applyAction{
+					description: fmt.Sprintf("update %s from %s to %s", name, valueOrUnknown(state.release), spec.Release),
+					args:        pastelupArgs(config, "update", component.update, "--version", spec.Release),
+				})
+			}
+			continue
+		}
+		args := pastelupArgs(config, "install", component.install, "--network", spec.Network)
+		if spec.Release != "" {
+			args = append(args, "--version", spec.Release)
+		}
+		if len(spec.Peers) > 0 {
+			args = append(args, "--peers", strings.Join(spec.Peers, ","))
+		}
+		if name == "node" || name == "walletnode" || name == "supernode" {
+			for _, p := range specPorts {
+				if port := *p.field(&config.Ports); port != 0 && (p.component == "node" || p.component == name) {
+					args = append(args, fmt.Sprintf("--%s=%d", p.flag, port))
+				}
+			}
+		}
+		actions = append(actions, applyAction{description: "install " + name, args: args})
+		installing[name] = true
+		if name == "walletnode" || name == "supernode" {
+			installing["node"] = true
+		}
+	}
+
+	// ports and peers of the new components are set by install, the installed ones are changed in their configs
+	confs := portConfigs{}
+	for _, p := range specPorts {
+		port := *p.field(&config.Ports)
+		if port == 0 || installing[p.component] {
+			continue
+		}
+		binding := findPortBinding(p.binding)
+		editor, err := confs.get(config, binding.tool)
+		if err != nil {
+			return nil, err
+		}
+		if editor == nil {
+			continue
+		}
+		current, ok, err := binding.read(editor)
+		if err != nil {
+			return nil, fmt.Errorf("%s config: %v", binding.tool, err)
+		}
+		if !ok && binding.defaultPort != nil {
+			defaults := *config
+			defaults.Ports = configs.Ports{}
+			current = binding.defaultPort(&defaults)
+		}
+		if current == port {
+			continue
+		}
+		// hermes and the others referring to the port are changed if they are installed
+		for _, ref := range append([]portRef{binding.portRef}, binding.refs...) {
+			if refEditor, err := confs.get(config, ref.tool); err != nil {
+				return nil, err
+			} else if refEditor == nil {
+				continue
+			}
+			actions = append(actions, applyAction{
+				description: fmt.Sprintf("set %s to %d", ref, port),
+				args:        configSetArgs(config, false, ref.tool, ref.key, strconv.Itoa(port)),
+			})
+		}
+	}
+	if state.pastelConf != nil && !installing["node"] {
+		for _, peer := range spec.Peers {
+			if !utils.Contains(state.pastelConf.GetAll("addnode"), peer) {
+				actions = append(actions, applyAction{
+					description: "add peer " + peer,
+					args:        configSetArgs(config, true, constants.PastelD, "addnode", peer),
+				})
+			}
+		}
+	}
+
+	if state.firewall != nil && !installing["supernode"] {
+		var ports []int
+		for _, port := range GetSNPortList(config) {
+			if !state.firewall.isAllowed(port) {
+				ports = append(ports, port)
+			}
+		}
+		if len(ports) > 0 {
+			actions = append(actions, applyAction{
+				description: fmt.Sprintf("open firewall ports %v", ports),
+				run: func(ctx context.Context) error {
+					for _, port := range ports {
+						if _, err := RunSudoCMD(config, "ufw", "allow", strconv.Itoa(port)); err != nil {
+							return fmt.Errorf("failed to open port %d: %v", port, err)
+						}
+					}
+					return nil
+				},
+			})
+		}
+	}
+
+	if mn := spec.Masternode; mn != nil {
+		if _, ok := state.masternodes[mn.Name]; !ok {
+			action, err := masternodeInitAction(config, mn, state.masternodes != nil)
+			if err != nil {
+				return nil, err
+			}
+			actions = append(actions, action)
+		}
+	}
+
+	for _, tool := range spec.Systemd.Enable {
+		if state.services[tool] {
+			continue
+		}
+		args := pastelupArgs(config, "update", "install-service", "--tool", tool, "--autostart")
+		if spec.Systemd.Start {
+			args = append(args, "--start")
+		}
+		actions = append(actions, applyAction{description: "register system service " + tool, args: args})
+	}
+	return actions, nil
+}
+
+func masternodeInitAction(config *configs.Config, mn *MasternodeSpec, confExists bool) (applyAction, error) {
+	args := pastelupArgs(config, "init", "supernode", "--name", mn.Name)
+	if confExists {
+		args = append(args, "--add")
+	} else {
+		args = append(args, "--new")
+	}
+	if mn.IP != "" {
+		args = append(args, "--ip", mn.IP)
+	}
+	if mn.Port != 0 {
+		args = append(args, "--port", strconv.Itoa(mn.Port))
+	}
+	var env []string
+	if mn.PrivateKey != "" {
+		env = append(env, configs.PastelupEnvPrefix+"PKEY="+mn.PrivateKey)
+	}
+	if mn.TxID != "" {
+		args = append(args, "--txid", mn.TxID, "--ind", mn.TxIndex)
+	}
+	if mn.PastelID != "" {
+		args = append(args, "--pastelid", mn.PastelID)
+	}
+	if mn.PassphraseFile != "" {
+		args = append(args, "--passphrase-file", mn.PassphraseFile)
+	}
+	if mn.Activate {
+		args = append(args, "--activate")
+	}
+	return applyAction{description: fmt.Sprintf("initialize masternode %s", mn.Name), args: args, env: env}, nil
+}
+
+func valueOrUnknown(value string) string {
+	if value == "" {
+		return "unknown release"
+	}
+	return value
+}
+
+func printPlan(actions []applyAction) {
+	if len(actions) == 0 {
+		fmt.Println(green("Host is up to date with the spec, nothing to do"))
+		return
+	}
+	fmt.Printf("%d action(s) required:\n", len(actions))
+	for i, action := range actions {
+		fmt.Printf("  %d. %s\n", i+1, action.description)
+	}
+}
+
+func runPlanSubCommand(ctx context.Context, config *configs.Config) error {
+	spec, err := loadHostSpec(config.SpecFile)
+	if err != nil {
+		return err
+	}
+	actions, err := planHostSpec(ctx, config, spec)
+	if err != nil {
+		return err
+	}
+	printPlan(actions)
+	return nil
+}
+
+func runApplySubCommand(ctx context.Context, config *configs.Config) error {
+	spec, err := loadHostSpec(config.SpecFile)
+	if err != nil {
+		return err
+	}
+	actions, err := planHostSpec(ctx, config, spec)
+	if err != nil {
+		return err
+	}
+	printPlan(actions)
+
+	pastelup, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to get path of pastelup: %v", err)
+	}
+	for i, action := range actions {
+		log.WithContext(ctx).Infof("[%d/%d] %s", i+1, len(actions), action.description)
+		if action.run != nil {
+			err = action.run(ctx)
+		} else {
+			cmd := exec.Command(pastelup, action.args...)
+			cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+			cmd.Env = append(os.Environ(), action.env...)
+			if config.UserPw != "" {
+				cmd.Env = append(cmd.Env, configs.PastelupEnvPrefix+"USER_PW="+config.UserPw)
+			}
+			err = cmd.Run()
+		}
+		if err != nil {
+			return fmt.Errorf("failed to %s: %v", action.description, err)
+		}
+	}
+
+	if spec.Release != "" {
+		if err = os.MkdirAll(filepath.Dir(appliedReleasePath(config)), 0700); err == nil {
+			err = utils.WriteFileAtomic(appliedReleasePath(config), []byte(spec.Release+"\n"), 0644)
+		}
+		if err != nil {
+			log.WithContext(ctx).WithError(err).Warn("Failed to save applied release")
+		}
+	}
+	return nil
+}
+
+// runRemoteSpecCommand sends the spec file to the remote hosts and runs "apply" or "plan" there
+func runRemoteSpecCommand(ctx context.Context, config *configs.Config, command string) error {
+	if _, err := loadHostSpec(config.SpecFile); err != nil {
+		return err
+	}
+	remoteSpec := fmt.Sprintf("/tmp/.pastelup-spec-%s.yaml", utils.GenerateRandomString(8))
+	remoteCmd := fmt.Sprintf("%s %s -f %s", constants.RemotePastelupPath, command, remoteSpec)
+	if config.PastelExecDir != "" {
+		remoteCmd = fmt.Sprintf("%s --dir %s", remoteCmd, config.PastelExecDir)
+	}
+	if config.WorkingDir != "" {
+		remoteCmd = fmt.Sprintf("%s --work-dir %s", remoteCmd, config.WorkingDir)
+	}
+	// spec may contain secrets, so it is copied into a file readable by the owner only and removed right after use
+	cmd := fmt.Sprintf("%s; rc=$?; rm -f %s; exit $rc", remoteCmd, remoteSpec)
+
+	_, err := executeRemoteCommandsWithInventory(ctx, config, []string{cmd}, false, false,
+		remoteFile{localPath: config.SpecFile, remotePath: remoteSpec})
+	return err
+}
+
+func runRemotePlanSubCommand(ctx context.Context, config *configs.Config) error {
+	return runRemoteSpecCommand(ctx, config, "plan")
+}
+
+func runRemoteApplySubCommand(ctx context.Context, config *configs.Config) error {
+	return runRemoteSpecCommand(ctx, config, "apply")
+}
+
+func setupSpecSubCommand(config *configs.Config, name, usage string, remote bool,
+	f func(context.Context, *configs.Config) error,
+) *cli.Command {
+	commandFlags := []*cli.Flag{
+		cli.NewFlag("file", &config.SpecFile).SetAliases("f").
+			SetUsage(red("Required, path to the YAML spec file with the desired state of the host")),
+		cli.NewFlag("user-pw", &config.UserPw).
+			SetUsage(green("Optional, password of current sudo user - so no sudo password request is prompted")),
+		secretFileFlag("user-pw", &config.UserPwFile),
+	}
+	if !remote {
+		commandFlags = append(commandFlags,
+			cli.NewFlag("dir", &config.PastelExecDir).SetAliases("d").
+				SetUsage(green("Optional, Location of pastel node directory")).SetValue(config.Configurer.DefaultPastelExecutableDir()),
+			cli.NewFlag("work-dir", &config.WorkingDir).SetAliases("w").
+				SetUsage(green("Optional, location of working directory")).SetValue(config.Configurer.DefaultWorkingDir()),
+		)
+	} else {
+		commandFlags = append(commandFlags,
+			cli.NewFlag("dir", &config.PastelExecDir).SetAliases("d").
+				SetUsage(green("Optional, Location of pastel node directory on the remote computer (default: $HOME/pastel)")),
+			cli.NewFlag("work-dir", &config.WorkingDir).SetAliases("w").
+				SetUsage(green("Optional, Location of working directory on the remote computer (default: $HOME/.pastel)")),
+			cli.NewFlag("ssh-ip", &config.RemoteIP).
+				SetUsage(red("Required (if inventory not used), SSH address of the remote host")),
+			cli.NewFlag("ssh-port", &config.RemotePort).
+				SetUsage(yellow("Optional, SSH port of the remote host, default is 22")).SetValue(22),
+			cli.NewFlag("ssh-user", &config.RemoteUser).
+				SetUsage(yellow("Optional, Username of user at remote host")),
+			cli.NewFlag("ssh-key", &config.RemoteSSHKey).
+				SetUsage(yellow("Optional, Path to SSH private key for SSH Key Authentication")),
+			cli.NewFlag("inventory", &config.InventoryFile).
+				SetUsage(red("Optional, Path to the file with configuration of the remote hosts")),
+			cli.NewFlag("in-parallel", &config.AsyncRemote).
+				SetUsage(green("Optional, When using inventory file run remote tasks in parallel")),
+		)
+	}
+
+	subCommand := cli.NewCommand(name)
+	subCommand.SetUsage(cyan(usage))
+	subCommand.AddFlags(commandFlags...)
+	addLogFlags(subCommand, config)
+	subCommand.SetActionFunc(func(ctx context.Context, _ []string) error {
+		ctx, err := configureLogging(ctx, name, config)
+		if err != nil {
+			return fmt.Errorf("failed to configure logging option - %v", err)
+		}
+		return f(ctx, config)
+	})
+	return subCommand
+}
+
+func setupApplyCommand(config *configs.Config) *cli.Command {
+	applyCommand := setupSpecSubCommand(config, "apply",
+		"Bring the host to the state described in the spec file, only missing steps are performed", false, runApplySubCommand)
+	applyCommand.AddSubcommands(setupSpecSubCommand(config, "remote",
+		"Apply the spec file on the remote host(s)", true, runRemoteApplySubCommand))
+	applyCommand.SetUsage(blue("Bring the host to the state described in the spec file - pastelup apply -f host.yaml"))
+	return applyCommand
+}
+
+func setupPlanCommand(config *configs.Config) *cli.Command {
+	planCommand := setupSpecSubCommand(config, "plan",
+		"Show actions required to bring the host to the state described in the spec file", false, runPlanSubCommand)
+	planCommand.AddSubcommands(setupSpecSubCommand(config, "remote",
+		"Show plan of the spec file for the remote host(s)", true, runRemotePlanSubCommand))
+	planCommand.SetUsage(blue("Show actions required to bring the host to the state described in the spec file - pastelup plan -f host.yaml"))
+	return planCommand
+}
@@ -0,0 +1,199 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pastelnetwork/pastelup/configs"
+	"github.com/pastelnetwork/pastelup/constants"
+	"github.com/pastelnetwork/pastelup/utils"
+	"github.com/tj/assert"
+)
+
+const ufwStatusSample = `Status: active
+
+To                         Action      From
+--                         ------      ----
+22/tcp                     ALLOW       Anywhere
+OpenSSH                    ALLOW       Anywhere
+19933/tcp                  ALLOW       Anywhere
+15444                      DENY        Anywhere
+15444/tcp                  ALLOW       Anywhere
+14445:14446/tcp            ALLOW       Anywhere
+14447/udp                  ALLOW       Anywhere
+19000/tcp                  ALLOW       10.0.0.1
+8080,8443/tcp              LIMIT IN    Anywhere
+22/tcp (v6)                ALLOW       Anywhere (v6)
+`
+
+func TestParseUFWStatus(t *testing.T) {
+	status, err := parseUFWStatus(ufwStatusSample)
+	assert.NoError(t, err)
+	assert.True(t, status.active)
+
+	tests := []struct {
+		port    int
+		allowed bool
+	}{
+		{port: 22, allowed: true},
+		{port: 19933, allowed: true},
+		// "19933" doesn't open 9933
+		{port: 9933},
+		// the first rule of the port wins
+		{port: 15444},
+		{port: 14445, allowed: true},
+		{port: 14446, allowed: true},
+		// UDP rule doesn't let TCP in
+		{port: 14447},
+		// the rule is only for the address
+		{port: 19000},
+		{port: 8443, allowed: true},
+	}
+	for _, test := range tests {
+		t.Run(fmt.Sprint(test.port), func(t *testing.T) {
+			assert.Equal(t, test.allowed, status.isAllowed(test.port))
+		})
+	}
+
+	// inactive firewall lets everything in
+	status, err = parseUFWStatus("Status: inactive\n")
+	assert.NoError(t, err)
+	assert.True(t, status.isAllowed(9933))
+
+	_, err = parseUFWStatus("sudo: a password is required\n")
+	assert.Error(t, err)
+}
+
+func TestHostSpecValidate(t *testing.T) {
+	tests := []struct {
+		name string
+		spec HostSpec
+		err  string
+	}{
+		{
+			name: "supernode ports",
+			spec: HostSpec{Components: []string{"supernode"}, NodePort: 9933, RPCPort: 9000, SNPort: 4444, P2PPort: 4445},
+		},
+		{
+			name: "supernode port without supernode",
+			spec: HostSpec{Components: []string{"walletnode"}, P2PPort: 4445},
+			err:  "p2p-port can only be defined for supernode component",
+		},
+		{
+			name: "invalid port",
+			spec: HostSpec{Components: []string{"node"}, NodePort: 70000},
+			err:  "node-port",
+		},
+		{
+			name: "same port",
+			spec: HostSpec{Components: []string{"supernode"}, RPCPort: 4444, SNPort: 4444},
+			err:  "rpc-port and sn-port can't be the same port 4444",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := test.spec.validate()
+			if test.err == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.Error(t, err)
+			assert.Contains(t, err.Error(), test.err)
+		})
+	}
+}
+
+func TestPlanHostSpec(t *testing.T) {
+	defer func(f func() ([]byte, error)) { ufwStatusOutput = f }(ufwStatusOutput)
+	ufwOutput, ufwErr := ufwStatusSample, error(nil)
+	ufwStatusOutput = func() ([]byte, error) { return []byte(ufwOutput), ufwErr }
+
+	specPath := filepath.Join(t.TempDir(), "host.yaml")
+	assert.NoError(t, os.WriteFile(specPath, []byte(`network: testnet
+release: v2.1.0
+components: [supernode, hermes]
+node-port: 19933
+rpc-port: 19000
+sn-port: 15444
+p2p-port: 14445
+peers: [1.2.3.4]
+firewall: true
+`), 0644))
+
+	newConfig := func() *configs.Config {
+		config := configs.InitConfig(nil)
+		config.PastelExecDir = t.TempDir()
+		config.WorkingDir = t.TempDir()
+		return config
+	}
+	descriptions := func(actions []applyAction) []string {
+		var result []string
+		for _, action := range actions {
+			result = append(result, action.description)
+		}
+		return result
+	}
+	plan := func(config *configs.Config) ([]applyAction, error) {
+		spec, err := loadHostSpec(specPath)
+		assert.NoError(t, err)
+		return planHostSpec(context.Background(), config, spec)
+	}
+
+	t.Run("new host", func(t *testing.T) {
+		config := newConfig()
+		actions, err := plan(config)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"install supernode", "install hermes"}, descriptions(actions))
+		assert.Equal(t, append(pastelupArgs(config, "install", "supernode", "--network", "testnet"),
+			"--version", "v2.1.0", "--peers", "1.2.3.4",
+			"--node-port=19933", "--node-rpc-port=19000", "--sn-port=15444", "--p2p-port=14445"), actions[0].args)
+		assert.Equal(t, append(pastelupArgs(config, "install", "hermes-service", "--network", "testnet"),
+			"--version", "v2.1.0", "--peers", "1.2.3.4"), actions[1].args)
+	})
+
+	config := newConfig()
+	for _, tool := range []constants.ToolType{constants.PastelD, constants.SuperNode, constants.Hermes} {
+		path := filepath.Join(config.PastelExecDir, constants.ServiceName[tool][utils.GetOS()])
+		assert.NoError(t, os.WriteFile(path, nil, 0755))
+	}
+	writeConfig := func(tool constants.ToolType, data string) {
+		path := configComponents[tool].path(config)
+		assert.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		assert.NoError(t, os.WriteFile(path, []byte(data), 0644))
+	}
+	assert.NoError(t, os.MkdirAll(filepath.Dir(appliedReleasePath(config)), 0755))
+	assert.NoError(t, os.WriteFile(appliedReleasePath(config), []byte("v2.1.0\n"), 0644))
+	// pasteld listens on the default port 19933 of testnet
+	writeConfig(constants.PastelD, "testnet=1\nrpcport=19932\naddnode=1.2.3.4\n")
+	writeConfig(constants.SuperNode, "node:\n  server:\n    port: 14444\np2p:\n  port: 14445\n")
+	writeConfig(constants.Hermes, "sn_port: 14444\n")
+
+	t.Run("installed host", func(t *testing.T) {
+		actions, err := plan(config)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{
+			"set pasteld rpcport to 19000",
+			"set supernode node.server.port to 15444",
+			"set hermes sn_port to 15444",
+			"open firewall ports [19000 15444 14447]",
+		}, descriptions(actions))
+		assert.Equal(t, []string{"config", "set", "--work-dir", config.WorkingDir, "hermes", "sn_port", "15444"}, actions[2].args)
+	})
+
+	t.Run("inactive firewall", func(t *testing.T) {
+		ufwOutput = "Status: inactive\n"
+		actions, err := plan(config)
+		assert.NoError(t, err)
+		assert.Len(t, actions, 3)
+	})
+
+	t.Run("firewall rules can't be read", func(t *testing.T) {
+		ufwOutput, ufwErr = "sudo: a password is required\n", fmt.Errorf("exit status 1")
+		_, err := plan(config)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "sudo: a password is required")
+	})
+}
@@ -1,31 +1,94 @@
 package cmd
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
 	"strconv"
 	"strings"
-	"time"
 
 	"github.com/pastelnetwork/pastelup/common/log"
+	"github.com/pastelnetwork/pastelup/services/pastelcore"
+	"github.com/pastelnetwork/pastelup/utils"
 )
 
 const (
 	minBalanceForTicketReg = 1000.0
+	// ticketTxFeeMargin covers network fees of the transaction, which funds the ticket address, and of the ticket
+	ticketTxFeeMargin = 1.0
 )
 
-func (r *ColdHotRunner) getBalance(ctx context.Context, cold bool) (balance float64, err error) {
-	var out string
-	var output []byte
-	if cold {
-		out, err = RunPastelCLI(ctx, r.config, "getbalance")
-	} else {
-		cmd := fmt.Sprintf("%s %s", r.opts.remotePastelCli, "getbalance")
-		output, err = r.sshClient.Cmd(cmd).Output()
-		out = string(output)
+// remotePastelCliRPC runs RPC commands with pastel-cli of the remote node. The arguments starting with the first
+// secret one are passed to pastel-cli on stdin, so they are not seen in the remote process list
+type remotePastelCliRPC struct {
+	run       func(script string) ([]byte, error)
+	pastelCli string
+	secrets   []string
+}
+
+// RunCommand implements pastelcore.RPCCommunicator
+func (c remotePastelCliRPC) RunCommand(cmd string, response interface{}) error {
+	return c.RunCommandWithArgs(cmd, []string{}, response)
+}
+
+// RunCommandWithArgs implements pastelcore.RPCCommunicator, the output of pastel-cli is put into the result
+// of the response
+func (c remotePastelCliRPC) RunCommandWithArgs(cmd string, args interface{}, response interface{}) error {
+	cliArgs := []string{cmd}
+	switch args := args.(type) {
+	case []string:
+		cliArgs = append(cliArgs, args...)
+	case []interface{}:
+		for _, arg := range args {
+			cliArgs = append(cliArgs, fmt.Sprint(arg))
+		}
+	default:
+		return fmt.Errorf("unsupported arguments of %s: %T", cmd, args)
+	}
+
+	var secretArgs []string
+	for i, arg := range cliArgs {
+		if arg != "" && utils.Contains(c.secrets, arg) {
+			cliArgs, secretArgs = cliArgs[:i], cliArgs[i:]
+			break
+		}
+	}
+	for i, arg := range cliArgs {
+		cliArgs[i] = utils.ShellQuote(arg)
+	}
+
+	out, err := c.run(remotePastelCliCommand(c.pastelCli, cliArgs, secretArgs...))
+	if err != nil {
+		return fmt.Errorf("remote pastel-cli %s failed: %v: %s", cmd, err, strings.TrimSpace(string(out)))
+	}
+	// pastel-cli prints the string results without quotes
+	result := json.RawMessage(bytes.TrimSpace(out))
+	if !json.Valid(result) {
+		if result, err = json.Marshal(string(result)); err != nil {
+			return err
+		}
+	}
+	data, err := json.Marshal(struct {
+		Result json.RawMessage `json:"result"`
+	}{result})
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, response)
+}
+
+// remoteRPC returns RPC client of pasteld on the remote (HOT) node
+func (r *ColdHotRunner) remoteRPC() remotePastelCliRPC {
+	return remotePastelCliRPC{
+		run:       func(script string) ([]byte, error) { return r.sshClient.Script(script).SmartOutput() },
+		pastelCli: r.opts.remotePastelCli,
+		secrets:   []string{r.config.MasterNodePassPhrase},
 	}
+}
 
+func (r *ColdHotRunner) getLocalBalance(ctx context.Context) (balance float64, err error) {
+	out, err := RunPastelCLI(ctx, r.config, "getbalance")
 	if err != nil {
 		log.WithContext(ctx).WithError(err).WithField("out", string(out)).
 			Error("Failed to get balance")
@@ -61,47 +124,6 @@ func (r *ColdHotRunner) sendAmountToAddrFromLocal(ctx context.Context, zcashAddr
 	return strings.TrimSpace(strings.Trim(string(out), "\n")), nil
 }
 
-func (r *ColdHotRunner) handleAskForBalance(ctx context.Context, remoteBalance float64, localBalance float64, address string) (bool, error) {
-	if ok, _ := AskUserToContinue(ctx, fmt.Sprintf(` Neither remote (%v PSL) nor
-	local (%v PSL)  has enough balance.\n Would you like to trasnfer balance from any of your wallet to an address & continue? Y/N`,
-		remoteBalance, localBalance)); !ok {
-		return false, nil
-	}
-
-	if ok, _ := AskUserToContinue(ctx, fmt.Sprintf(`Please send 1,000 PSL coins to this address: %s  -- 
-	and press Y to continue. Or N to cancel. Y/N`, address)); !ok {
-		return false, nil
-	}
-
-	return r.handleWaitForBalance(ctx)
-}
-
-func (r *ColdHotRunner) handleWaitForBalance(ctx context.Context) (bool, error) {
-	i := 0
-	for {
-		fmt.Println("checking remote for balance...")
-		remoteBalance, err := r.getBalance(ctx, false)
-		if err != nil {
-			log.WithContext(ctx).WithError(err).Error("unable to get remote balance")
-			return false, fmt.Errorf("handleWaitForBalance: getRemoteBalance: %s", err)
-		}
-
-		if remoteBalance >= minBalanceForTicketReg {
-			return true, nil
-		}
-		time.Sleep(6 * time.Second)
-
-		if i == 9 {
-			if ok, _ := AskUserToContinue(ctx, `Enough balance not received yet. 
-			Would you like to continue & wait? Y/N`); !ok {
-				return false, nil
-			}
-			i = 0
-		}
-		i++
-	}
-}
-
 func (r *ColdHotRunner) handleTransferBalance(ctx context.Context, remoteBalance float64, localBalance float64, addr string) (txid string, err error) {
 	yes, _ := AskUserToContinue(ctx, fmt.Sprintf(`Remote Node does not have enough balance (%v PSL) but 
 	your local one does! (%v PSL) \\nDo you want us to transfer 1,000 PSL from your local to remote
@@ -121,84 +143,59 @@ func (r *ColdHotRunner) handleTransferBalance(ctx context.Context, remoteBalance
 	return txid, nil
 }
 
+// registerTicketPastelID registers mnid ticket of the remote (HOT) node, the fee is paid by its wallet. The local (COLD)
+// node offers to transfer the fee, if the remote wallet doesn't have enough and the local one does
 func (r *ColdHotRunner) registerTicketPastelID(ctx context.Context) (err error) {
-	cmd := fmt.Sprintf("%s %s %s %s", r.opts.remotePastelCli, "tickets register mnid",
-		r.config.MasterNodePastelID, r.config.MasterNodePassPhrase)
+	ticketConfig := *r.config
+	ticketConfig.TicketAddress = ""
+	if ticketConfig.Confirmations == 0 {
+		ticketConfig.Confirmations = defaultTicketConfirmations
+	}
+	if ticketConfig.TicketRegTimeout == 0 {
+		ticketConfig.TicketRegTimeout = defaultTicketRegTimeout
+	}
+	remote := r.remoteRPC()
 
-	remoteBalance, err := r.getBalance(ctx, false)
+	remoteBalance, err := getTicketFundsBalance(remote, "", ticketConfig.Confirmations)
 	if err != nil {
 		log.WithContext(ctx).WithError(err).Error("unable to get remote balance")
 		return fmt.Errorf("getRemoteBalance: %s", err)
 	}
-
 	log.WithContext(ctx).WithField("balance", remoteBalance).Info("got remote balance")
 
-	localBalance, err := r.getBalance(ctx, true)
-	if err != nil {
-		log.WithContext(ctx).WithError(err).Error("unable to get local balance")
-		return fmt.Errorf("getlocalBalance: %s", err)
-	}
-	log.WithContext(ctx).WithField("balance", localBalance).Info("got local balance")
-
-	addr, err := r.getRemotePastelAddr(ctx)
-	if err != nil {
-		log.WithContext(ctx).WithError(err).Error("unable to get remote pastel address")
-		return fmt.Errorf("get remote pastel addr: %s", err)
-	}
-
-	var balanceEnough bool
-	var txid string
-	if remoteBalance < minBalanceForTicketReg && localBalance < minBalanceForTicketReg {
-		balanceEnough, err = r.handleAskForBalance(ctx, remoteBalance, localBalance, addr)
-		if err != nil {
-			return fmt.Errorf("handleWaitForBalance: %s", err)
-		}
-	} else if remoteBalance < minBalanceForTicketReg && localBalance > minBalanceForTicketReg {
-		txid, err = r.handleTransferBalance(ctx, remoteBalance, localBalance, addr)
+	if remoteBalance < minBalanceForTicketReg {
+		localBalance, err := r.getLocalBalance(ctx)
 		if err != nil {
-			return fmt.Errorf("handleTransferBalance: %s", err)
+			log.WithContext(ctx).WithError(err).Error("unable to get local balance")
+			return fmt.Errorf("getlocalBalance: %s", err)
 		}
+		log.WithContext(ctx).WithField("balance", localBalance).Info("got local balance")
 
-		balanceEnough, err = r.handleWaitForBalance(ctx)
-		if err != nil {
-			return fmt.Errorf("handleWaitForBalance after transfer: %s", err)
+		if localBalance >= minBalanceForTicketReg {
+			addr, err := r.getRemotePastelAddr(ctx)
+			if err != nil {
+				log.WithContext(ctx).WithError(err).Error("unable to get remote pastel address")
+				return fmt.Errorf("get remote pastel addr: %s", err)
+			}
+			txid, err := r.handleTransferBalance(ctx, remoteBalance, localBalance, addr)
+			if err != nil {
+				return fmt.Errorf("handleTransferBalance: %s", err)
+			}
+			if txid != "" {
+				if err = waitForTxConfirmations(ctx, pastelcore.NewClient(r.config), &ticketConfig, txid); err != nil {
+					return err
+				}
+			}
 		}
-	} else {
-		balanceEnough = true
-	}
-	if !balanceEnough && txid == "" {
-		fmt.Println("please execute the following command when remote has enough balance.")
-		fmt.Println("\n*******************************************************************")
-		fmt.Println(cmd)
-		fmt.Println("*******************************************************************")
-		return nil
 	}
 
-	out, err := r.sshClient.Cmd(cmd).Output()
+	// the missing funds are requested to the new address of the remote wallet
+	txid, err := registerSupernodeTicket(ctx, remote, &ticketConfig)
 	if err != nil {
-		log.WithContext(ctx).WithError(err).Error("Failed to register ticket mnid")
-		fmt.Println("please execute the following command when remote has enough balance.")
-		fmt.Println("\n*******************************************************************")
-		fmt.Println(cmd)
-		fmt.Println("*******************************************************************")
 		return err
 	}
-
-	type ticketRegOut struct {
-		TXID string `json:"txid"`
-	}
-	regOut := &ticketRegOut{}
-	if err = json.Unmarshal(out, &regOut); err != nil {
-		log.WithContext(ctx).WithError(err).Error("Failed to parse ticket register output")
-		return err
+	if txid != "" {
+		log.WithContext(ctx).Infof("Register ticket pastelid txid = %s", txid)
 	}
-
-	if regOut.TXID == "" {
-		err = fmt.Errorf("registerTicketPastelID: unexpected response: %s", string(out))
-		log.WithContext(ctx).WithError(err).Error("Received unexpected response on register")
-		return err
-	}
-
-	log.WithContext(ctx).Infof("Register ticket pastelid result = %s", string(out))
 	return nil
 }
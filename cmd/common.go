@@ -22,6 +22,7 @@ import (
 	"github.com/pastelnetwork/pastelup/configs"
 	"github.com/pastelnetwork/pastelup/constants"
 	"github.com/pastelnetwork/pastelup/services/pastelcore"
+	"github.com/pastelnetwork/pastelup/services/synctracker"
 	"github.com/pastelnetwork/pastelup/structure"
 	"github.com/pastelnetwork/pastelup/utils"
 )
@@ -54,61 +55,39 @@ func ParsePastelConf(ctx context.Context, config *configs.Config) error {
 		return err
 	}
 
-	var file, err = os.OpenFile(pastelConfPath, os.O_RDWR, 0644)
+	pastelConf, err := utils.LoadPastelConf(pastelConfPath)
 	if err != nil {
 		log.WithContext(ctx).WithError(err).Errorf("Could not open pastel config - %s", pastelConfPath)
 		return err
 	}
-	defer file.Close()
 
-	scanner := bufio.NewScanner(file)
-	for scanner.Scan() {
-		line := scanner.Text()
-		if strings.HasPrefix(line, "rpcuser=") {
-			config.RPCUser = strings.TrimPrefix(line, "rpcuser=")
-		}
-		if strings.HasPrefix(line, "rpcpassword=") {
-			config.RPCPwd = strings.TrimPrefix(line, "rpcpassword=")
-		}
-		if strings.HasPrefix(line, "rpcport=") {
-			config.RPCPort, _ = strconv.Atoi(strings.TrimPrefix(line, "rpcport="))
-		}
-		if strings.HasPrefix(line, "testnet=") {
-			isTestnet, _ := strconv.ParseBool(strings.TrimPrefix(line, "testnet="))
-			config.IsTestnet = isTestnet
-			if isTestnet {
-				config.Network = constants.NetworkTestnet
-			}
-		}
-		if strings.HasPrefix(line, "devnet=") {
-			isDevnet, _ := strconv.ParseBool(strings.TrimPrefix(line, "devnet="))
-			config.IsDevnet = isDevnet
-			if isDevnet {
-				config.Network = constants.NetworkDevnet
-			}
-		}
-		if strings.HasPrefix(line, "regtest=") {
-			isRegTest, _ := strconv.ParseBool(strings.TrimPrefix(line, "regtest="))
-			if isRegTest {
-				config.Network = constants.NetworkRegTest
-			}
-		}
-		if strings.HasPrefix(line, "txindex=") {
-			config.TxIndex, _ = strconv.Atoi(strings.TrimPrefix(line, "txindex="))
-		}
+	config.RPCUser = pastelConf.Get("rpcuser")
+	config.RPCPwd = pastelConf.Get("rpcpassword")
+	log.AddSecret(config.RPCPwd)
+	config.RPCPort = pastelConf.GetInt("rpcport")
+	config.TxIndex = pastelConf.GetInt("txindex")
+	config.IsTestnet = pastelConf.GetBool("testnet")
+	config.IsDevnet = pastelConf.GetBool("devnet")
+	if network := pastelConf.Network(); network != constants.NetworkMainnet {
+		config.Network = network
 	}
 	// if neither of testnet=1, devnet=1 or regtest=1 are set in pastel.conf -- mainnet mode is on
 	if config.Network == "" {
 		config.Network = constants.NetworkMainnet
 	}
+	// without rpcuser pasteld uses cookie authentication
+	config.RPCCookieFile = ""
+	if config.RPCUser == "" {
+		config.RPCCookieFile = getMasternodeConfPath(config, config.WorkingDir, constants.PastelRPCCookieName)
+	}
 	return nil
 }
 
 // GetProcessCmdInput gets the arguments of a process. returns true if it was running and false if it wasn't or there was an error
-func GetProcessCmdInput(toolType constants.ToolType) (bool, []string) {
+func GetProcessCmdInput(ctx context.Context, config *configs.Config, toolType constants.ToolType) (bool, []string) {
 	var cmdArgs []string
-	pid, err := GetRunningProcessPid(toolType)
-	if err != nil {
+	pid, err := servicePid(ctx, config, toolType)
+	if err != nil || pid == 0 {
 		return false, cmdArgs
 	}
 	output, err := RunCMD("bash", "-c", fmt.Sprintf("ps -o args= -f -p %v", pid))
@@ -124,15 +103,16 @@ func GetProcessCmdInput(toolType constants.ToolType) (bool, []string) {
 	return true, cmdArgs
 }
 
-// CheckProcessRunning checks if the process is running
-func CheckProcessRunning(toolType constants.ToolType) bool {
-	if pid, err := GetRunningProcessPid(toolType); pid != 0 && err == nil {
+// CheckProcessRunning checks if the process of the component, which uses the work dir, is running
+func CheckProcessRunning(ctx context.Context, config *configs.Config, toolType constants.ToolType) bool {
+	if pid, err := servicePid(ctx, config, toolType); pid != 0 && err == nil {
 		return true
 	}
 	return false
 }
 
-// GetRunningProcessPid returns process id, if the pastel service is running
+// GetRunningProcessPid returns process id, if the pastel service is running. The process is matched by name,
+// use servicePid to find the component of the work dir
 func GetRunningProcessPid(toolType constants.ToolType) (int, error) {
 	execName := constants.ServiceName[toolType][utils.GetOS()]
 	proc, err := ps.Processes()
@@ -170,9 +150,9 @@ func KillProcessByPid(ctx context.Context, pid int) error {
 }
 
 // KillProcess kills pastel service if it is running
-func KillProcess(ctx context.Context, toolType constants.ToolType) error {
+func KillProcess(ctx context.Context, config *configs.Config, toolType constants.ToolType) error {
 
-	if pid, err := GetRunningProcessPid(toolType); err != nil {
+	if pid, err := servicePid(ctx, config, toolType); err != nil {
 		log.WithContext(ctx).WithError(err).Error("Failed to check running processes")
 		return err
 	} else if pid != 0 {
@@ -180,6 +160,8 @@ func KillProcess(ctx context.Context, toolType constants.ToolType) error {
 			log.WithContext(ctx).WithError(err).Errorf("Failed to kill service - %s", toolType)
 			return err
 		}
+		_ = utils.RemovePidFile(pidFilePath(config, toolType))
+		return nil
 	}
 
 	log.WithContext(ctx).Infof("Application %s is not running", toolType)
@@ -220,7 +202,9 @@ func RunPastelCLI(ctx context.Context, config *configs.Config, args ...string) (
 // else it runs the sudo command and asks the user to input their password
 func RunSudoCMD(config *configs.Config, args ...string) (string, error) {
 	if len(config.UserPw) > 0 {
-		return RunCMD("bash", "-c", "echo "+config.UserPw+" | sudo -S "+strings.Join(args, " "))
+		// password is passed through the environment and printed by the shell builtin, so it is not in the process list
+		return RunCMDWithEnvVariable("bash", "PASTELUP_SUDO_PW", config.UserPw,
+			"-c", `printf '%s\n' "$PASTELUP_SUDO_PW" | sudo -S `+strings.Join(args, " "))
 	}
 	return RunCMD("sudo", args...)
 }
@@ -328,13 +312,33 @@ func FindRunningProcess(searchTerm string) (string, error) {
 	return "", err
 }
 
-// GetSNPortList returns array of SuperNode ports for network
+// GetSNPortList returns array of SuperNode ports for network, with the port offset and custom ports of the instance applied
 func GetSNPortList(config *configs.Config) []int {
-	if config.Network == constants.NetworkTestnet {
+	defaults := networkPortList(config.Network)
+	ports := resolvePorts(config)
+	custom := map[int]int{
+		constants.NodePort:    ports.Node,
+		constants.NodeRPCPort: ports.NodeRPC,
+		constants.SNPort:      ports.SuperNode,
+		constants.P2PPort:     ports.P2P,
+		constants.MDLPort:     ports.MDL,
+		constants.RAFTPort:    ports.RAFT,
+	}
+	portList := make([]int, len(defaults))
+	for i, port := range defaults {
+		portList[i] = customPort(custom[i], port, ports.Offset)
+	}
+	return portList
+}
+
+// networkPortList returns the default ports of the network, they are used by the remote hosts
+func networkPortList(network string) []int {
+	switch network {
+	case constants.NetworkTestnet:
 		return constants.TestnetPortList
-	} else if config.Network == constants.NetworkRegTest {
+	case constants.NetworkRegTest:
 		return constants.RegTestPortList
-	} else if config.Network == constants.NetworkDevnet {
+	case constants.NetworkDevnet:
 		return constants.DevnetPortList
 	}
 	return constants.MainnetPortList
@@ -371,96 +375,129 @@ func GetPastelInfo(ctx context.Context, config *configs.Config) (structure.RPCGe
 	return info, nil
 }
 
-func containsAny(str string, substrings []string) bool {
-	for _, substring := range substrings {
-		if strings.Contains(str, substring) {
-			return true
-		}
-	}
-	return false
-}
-
 // WaitingForPastelDToStart whether pasteld is running
 func WaitingForPastelDToStart(ctx context.Context, config *configs.Config) bool {
-	log.WithContext(ctx).Info("Waiting the pasteld to start...")
-	var attempts = 0
-	var maxAttempts = 30
-	for attempts <= maxAttempts {
-		info, err := GetPastelInfo(ctx, config)
-		if err == nil {
-			log.WithContext(ctx).Info("pasteld started successfully")
-			return true
-		}
-		if info.Error != nil {
-			errorMap, ok := info.Error.(map[string]interface{})
-			if ok {
-				//errorCode, _ := errorMap["code"].(int)
-				errorMessage, _ := errorMap["message"].(string)
-				subMessages := []string{"Rescanning", "Activating best chain", "Loading block index"}
-				if containsAny(errorMessage, subMessages) {
-					if attempts == maxAttempts-1 {
-						maxAttempts += 30
-					}
-				}
-			}
-		}
-
-		time.Sleep(10 * time.Second)
-		attempts++
+	if err := waitForReady(ctx, config, constants.PastelD); err != nil {
+		log.WithContext(ctx).WithError(err).Error("pasteld didn't start")
+		return false
 	}
-	return false
+	recordPasteldPid(ctx, config)
+	return true
 }
 
-// StopPastelDAndWait sends stop command to pasteld and waits 10 seconds
+// StopPastelDAndWait sends stop command to pasteld and waits until it exits, pasteld is killed after the stop timeout
 func StopPastelDAndWait(ctx context.Context, config *configs.Config) error {
 	log.WithContext(ctx).Info("Stopping local pasteld...")
-	var resp map[string]interface{}
-	err := pastelcore.NewClient(config).RunCommand(pastelcore.StopCmd, &resp)
-	if err != nil {
+	if _, err := stopPasteld(ctx, config); err != nil {
 		log.WithContext(ctx).Errorf("unable to stop pastel: %v", err)
 		return err
 	}
-	time.Sleep(10 * time.Second)
-	log.WithContext(ctx).Infof("Stopped local pasteld: %+v", resp)
 	return nil
 }
 
-// CheckMasterNodeSync checks and waits until mnsync is "Finished", return number of synced blocks
+// CheckMasterNodeSync waits until pasteld has synced blocks and mnsync is "Finished", return number of synced blocks.
+// It fails after --sync-timeout and when the sync makes no progress for --sync-stall-timeout
 func CheckMasterNodeSync(ctx context.Context, config *configs.Config) (int, error) {
-	var getinfo structure.RPCGetInfo
-	var err error
-	t := time.Now()
+	opts := synctracker.Options{
+		Timeout:      config.SyncTimeout,
+		StallTimeout: config.SyncStallTimeout,
+		Report: func(p synctracker.Progress) {
+			log.WithContext(ctx).Infof("Waiting for sync... block #%d of %d, headers %d, %s; Node has %d connection; mnstatus=%v (elapsed: %v)",
+				p.Blocks, p.Target(), p.Headers, syncRateLine(p), p.Connections, p.Phase, p.Elapsed.Round(time.Second))
+		},
+	}
+	if config.RestartOnStall {
+		opts.Restart = func(ctx context.Context) error { return restartStalledPasteld(ctx, config) }
+		opts.MaxRestarts = 1
+	}
+
 	fmt.Println() // add some space for loading line
-	for {
-		getinfo, err = GetPastelInfo(ctx, config)
-		if err != nil {
-			log.WithContext(ctx).WithError(err).Error("master node getinfo call has failed")
-			return 0, err
-		}
-		// Checking mnsync status
-		mnstatus, err := GetMNSyncInfo(ctx, config)
-		if err != nil {
-			log.WithContext(ctx).WithError(err).Error("master node mnsync status call has failed")
-			return 0, err
-		}
-		// line overwrites itself to avoid abundant loggin
-		log.WithContext(ctx).Infof("Waiting for sync... Loading blocks - block #%d; Node has %d connection; mnstatus=%v, isSynced=%v (elapsed: %v)\r", getinfo.Result.Blocks, getinfo.Result.Connections, mnstatus.Result.AssetName, mnstatus.Result.IsSynced, time.Since(t))
-		if mnstatus.Result.AssetName == "Initial" {
-			var output interface{}
-			err := pastelcore.NewClient(config).RunCommandWithArgs(pastelcore.MasterNodeSyncCmd, []string{"reset"}, &output)
-			if err != nil {
-				log.WithContext(ctx).WithError(err).Error("\nmaster node reset has failed")
-				return 0, err
+	status, err := synctracker.New(pasteldSyncRPC{config: config}, opts).Wait(ctx)
+	fmt.Println()
+	if err != nil {
+		log.WithContext(ctx).WithError(err).Error("pasteld sync has failed")
+		return status.Blocks, err
+	}
+	log.WithContext(ctx).Info("masternodes lists are synced!")
+	return status.Blocks, nil
+}
+
+func syncRateLine(p synctracker.Progress) string {
+	if p.Blocks >= p.Target() {
+		return "blocks are synced"
+	}
+	line := fmt.Sprintf("%.1f blocks/s", p.Rate)
+	if p.ETAKnown {
+		return line + ", ETA " + p.ETA.Round(time.Second).String()
+	}
+	return line + ", ETA unknown"
+}
+
+// pasteldSyncRPC gets the sync state of the local pasteld for the sync tracker
+type pasteldSyncRPC struct {
+	config *configs.Config
+}
+
+func (r pasteldSyncRPC) Status(ctx context.Context) (synctracker.Status, error) {
+	var status synctracker.Status
+	getinfo, err := GetPastelInfo(ctx, r.config)
+	if err != nil {
+		return status, fmt.Errorf("master node getinfo call has failed: %v", err)
+	}
+	status.Blocks = getinfo.Result.Blocks
+	status.Connections = getinfo.Result.Connections
+
+	client := pastelcore.NewClient(r.config)
+	// headers and heights of the peers only make the ETA better, old pasteld may miss them
+	var chain structure.RPCBlockchainInfo
+	if err = client.RunCommand(pastelcore.GetBlockchainInfoCmd, &chain); err == nil {
+		status.Headers = chain.Result.Headers
+	}
+	var peers structure.RPCPeerInfo
+	if err = client.RunCommand(pastelcore.GetPeerInfoCmd, &peers); err == nil {
+		for _, peer := range peers.Result {
+			if peer.StartingHeight > status.NetworkHeight {
+				status.NetworkHeight = peer.StartingHeight
 			}
-			time.Sleep(10 * time.Second)
 		}
-		if mnstatus.Result.IsSynced {
-			log.WithContext(ctx).Info("\nmasternodes lists are synced!")
-			break
+	}
+
+	mnstatus, err := GetMNSyncInfo(ctx, r.config)
+	if err != nil {
+		return status, fmt.Errorf("master node mnsync status call has failed: %v", err)
+	}
+	status.Phase = mnstatus.Result.AssetName
+	status.Synced = mnstatus.Result.IsSynced
+	status.Failed = mnstatus.Result.IsFailed
+	return status, nil
+}
+
+func (r pasteldSyncRPC) ResetMNSync(_ context.Context) error {
+	var output interface{}
+	return pastelcore.NewClient(r.config).RunCommandWithArgs(pastelcore.MasterNodeSyncCmd, []string{"reset"}, &output)
+}
+
+// restartStalledPasteld restarts pasteld with its current arguments, so it reconnects to the peers. Reindex
+// isn't repeated, it would start the sync over
+func restartStalledPasteld(ctx context.Context, config *configs.Config) error {
+	log.WithContext(ctx).Warn("\npasteld sync has stalled, restarting pasteld")
+	_, args := GetProcessCmdInput(ctx, config, constants.PastelD)
+	var pasteldArgs []string
+	for _, arg := range args {
+		if arg != "--reindex" && arg != "-reindex" {
+			pasteldArgs = append(pasteldArgs, arg)
 		}
-		time.Sleep(10 * time.Second)
 	}
-	return getinfo.Result.Blocks, nil
+	if err := stopServices(ctx, []constants.ToolType{constants.PastelD}, config); err != nil {
+		return err
+	}
+	if err := startPasteldWithArgs(ctx, config, pasteldArgs); err != nil {
+		return err
+	}
+	if !WaitingForPastelDToStart(ctx, config) {
+		return fmt.Errorf("pasteld didn't start")
+	}
+	return nil
 }
 
 // CheckZksnarkParams validates Zksnark files
@@ -568,60 +605,18 @@ func checkHotAndColdNodesNetworkMode(ctx context.Context, client *utils.Client,
 }
 
 func getNetworkModeFromRemote(ctx context.Context, confFilePath string) (remoteNetwork string, err error) {
-	log.WithContext(ctx).Info("opening the copied remote.conf...")
-	file, err := os.OpenFile(confFilePath, os.O_RDWR, 0644)
+	log.WithContext(ctx).Info("Parsing network mode from remote.conf")
+	pastelConf, err := utils.LoadPastelConf(confFilePath)
 	if err != nil {
 		log.WithContext(ctx).WithError(err).Errorf("Could not open remote pastel config - %s", confFilePath)
 		return remoteNetwork, err
 	}
-	log.WithContext(ctx).Info("File opened")
-	defer file.Close()
-
-	log.WithContext(ctx).Info("Parsing network mode from remote.conf")
-
-	scanner := bufio.NewScanner(file)
-	for scanner.Scan() {
-		line := scanner.Text()
-		if strings.HasPrefix(line, "testnet=") {
-			isTestnet, err := strconv.ParseBool(strings.TrimPrefix(line, "testnet="))
-			if err != nil {
-				log.WithContext(ctx).WithError(err).Errorf("Could not parse testnet= bool from file: - %s", confFilePath)
-				return remoteNetwork, err
-			}
-
-			if isTestnet {
-				log.WithContext(ctx).Infof("remote node is operating in testnet")
-				return constants.NetworkTestnet, nil
-			}
-		}
-		if strings.HasPrefix(line, "devnet=") {
-			isDevnet, err := strconv.ParseBool(strings.TrimPrefix(line, "devnet="))
-			if err != nil {
-				log.WithContext(ctx).WithError(err).Errorf("Could not parse devnet= bool from file: - %s", confFilePath)
-				return remoteNetwork, err
-			}
-
-			if isDevnet {
-				log.WithContext(ctx).Infof("remote node is operating in devnet")
-				return constants.NetworkDevnet, nil
-			}
-		}
-		if strings.HasPrefix(line, "regtest=") {
-			isRegTest, err := strconv.ParseBool(strings.TrimPrefix(line, "regtest="))
-			if err != nil {
-				log.WithContext(ctx).WithError(err).Errorf("Could not parse regtest= bool from file: - %s", confFilePath)
-				return remoteNetwork, err
-			}
-
-			if isRegTest {
-				log.WithContext(ctx).Infof("remote node is operating in regtest")
-				return constants.NetworkRegTest, nil
-			}
-		}
-	}
 
-	return constants.NetworkMainnet, nil
+	remoteNetwork = pastelConf.Network()
+	log.WithContext(ctx).Infof("remote node is operating in %s", remoteNetwork)
+	return remoteNetwork, nil
 }
+
 func prepareRemoteSession(ctx context.Context, config *configs.Config) (*utils.Client, error) {
 	var err error
 
@@ -661,7 +656,13 @@ func prepareRemoteSession(ctx context.Context, config *configs.Config) (*utils.C
 	return client, nil
 }
 
-func executeRemoteCommandsWithInventory(ctx context.Context, config *configs.Config, commands []string, tryStop bool, needOutput bool) ([][]byte, error) {
+// remoteFile is a local file copied to the remote host before the commands are executed there
+type remoteFile struct {
+	localPath  string
+	remotePath string
+}
+
+func executeRemoteCommandsWithInventory(ctx context.Context, config *configs.Config, commands []string, tryStop bool, needOutput bool, files ...remoteFile) ([][]byte, error) {
 	if len(config.InventoryFile) > 0 {
 		var inv Inventory
 		err := inv.ReadAnsibleYamlInventory(config.InventoryFile)
@@ -671,9 +672,9 @@ func executeRemoteCommandsWithInventory(ctx context.Context, config *configs.Con
 		}
 		var outs [][]byte
 		if config.AsyncRemote {
-			outs, err = inv.ExecuteCommandsAsync(ctx, config, commands, needOutput)
+			outs, err = inv.ExecuteCommandsAsync(ctx, config, commands, needOutput, files...)
 		} else {
-			outs, err = inv.ExecuteCommands(ctx, config, commands, needOutput)
+			outs, err = inv.ExecuteCommands(ctx, config, commands, needOutput, files...)
 		}
 		if err != nil {
 			log.WithContext(ctx).WithError(err).Error("Failed to execute command on remote host from inventory")
@@ -681,7 +682,7 @@ func executeRemoteCommandsWithInventory(ctx context.Context, config *configs.Con
 		}
 		return outs, nil
 	}
-	out, err := executeRemoteCommands(ctx, config, commands, tryStop, needOutput)
+	out, err := executeRemoteCommands(ctx, config, commands, tryStop, needOutput, files...)
 	if err != nil {
 		log.WithContext(ctx).WithError(err).Error("Failed to execute command on remote host")
 		return nil, err
@@ -689,7 +690,7 @@ func executeRemoteCommandsWithInventory(ctx context.Context, config *configs.Con
 	return [][]byte{out}, nil
 }
 
-func executeRemoteCommands(ctx context.Context, config *configs.Config, commands []string, tryStop bool, needOutput bool) ([]byte, error) {
+func executeRemoteCommands(ctx context.Context, config *configs.Config, commands []string, tryStop bool, needOutput bool, files ...remoteFile) ([]byte, error) {
 	// Connect to remote
 	client, err := prepareRemoteSession(ctx, config)
 	if err != nil {
@@ -704,6 +705,13 @@ func executeRemoteCommands(ctx context.Context, config *configs.Config, commands
 		}
 	}
 
+	// files may contain secrets, so they are copied readable by the owner only and never put into the commands
+	for _, file := range files {
+		if err = client.Scp(ctx, file.localPath, file.remotePath, "0600"); err != nil {
+			return nil, fmt.Errorf("failed to copy %s to remote host: %v", file.localPath, err)
+		}
+	}
+
 	var outs []byte
 	for _, command := range commands {
 		if needOutput {
@@ -776,7 +784,7 @@ func ReserveSNPorts(ctx context.Context, config *configs.Config) {
 		portList[constants.SNPort],
 		portList[constants.NodePort],
 		portList[constants.P2PPort],
-		constants.RQServiceDefaultPort,
+		rqServicePort(config),
 		constants.DDServerDefaultPort)
 	log.WithContext(ctx).Infof("Running: sysctl -w %s", cmd)
 	out, err := RunSudoCMD(config, "sysctl", "-w", cmd)
@@ -0,0 +1,417 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pastelnetwork/pastelup/common/cli"
+	"github.com/pastelnetwork/pastelup/common/log"
+	"github.com/pastelnetwork/pastelup/configs"
+	"github.com/pastelnetwork/pastelup/constants"
+	"github.com/pastelnetwork/pastelup/utils"
+)
+
+var (
+	flagConfigAdd   bool
+	flagConfigValue string
+)
+
+// configEditor is a key/value view of the component's config file
+type configEditor interface {
+	Keys() []string
+	Values(key string) []string
+	Lookup(key string) (string, bool, error)
+	Set(key, value string) error
+	Add(key, value string) error
+	Unset(key, value string) bool
+	Bytes() ([]byte, error)
+	Save(path string) error
+}
+
+type pastelConfEditor struct {
+	conf *utils.PastelConf
+}
+
+func (e pastelConfEditor) Keys() []string             { return e.conf.Keys() }
+func (e pastelConfEditor) Values(key string) []string { return e.conf.GetAll(key) }
+func (e pastelConfEditor) Save(path string) error     { return e.conf.Save(path) }
+
+func (e pastelConfEditor) Bytes() ([]byte, error) { return e.conf.Bytes(), nil }
+
+func (e pastelConfEditor) Lookup(key string) (string, bool, error) {
+	return e.conf.Get(key), e.conf.Has(key), nil
+}
+
+func (e pastelConfEditor) Set(key, value string) error {
+	e.conf.Set(key, value)
+	return nil
+}
+
+func (e pastelConfEditor) Add(key, value string) error {
+	if !utils.IsPastelConfMultiValueKey(key) {
+		return fmt.Errorf("%s can't have multiple values", key)
+	}
+	e.conf.Add(key, value)
+	return nil
+}
+
+func (e pastelConfEditor) Unset(key, value string) bool {
+	if value == "" {
+		return e.conf.Unset(key)
+	}
+	return e.conf.RemoveValue(key, value)
+}
+
+// singleValueEditor adapts single value config files (yaml, toml) to configEditor
+type singleValueEditor struct {
+	keys   func() []string
+	get    func(key string) (string, bool)
+	lookup func(key string) (string, bool, error)
+	set    func(key, value string) error
+	unset  func(key string) bool
+	bytes  func() ([]byte, error)
+	save   func(path string) error
+}
+
+func (e singleValueEditor) Keys() []string              { return e.keys() }
+func (e singleValueEditor) Set(key, value string) error { return e.set(key, value) }
+func (e singleValueEditor) Save(path string) error      { return e.save(path) }
+
+func (e singleValueEditor) Lookup(key string) (string, bool, error) { return e.lookup(key) }
+func (e singleValueEditor) Bytes() ([]byte, error)                  { return e.bytes() }
+
+func (e singleValueEditor) Values(key string) []string {
+	if value, ok := e.get(key); ok {
+		return []string{value}
+	}
+	return nil
+}
+
+func (e singleValueEditor) Add(key, _ string) error {
+	return fmt.Errorf("%s can't have multiple values", key)
+}
+
+func (e singleValueEditor) Unset(key, value string) bool {
+	if current, ok := e.get(key); !ok || (value != "" && current != value) {
+		return false
+	}
+	return e.unset(key)
+}
+
+func parsePastelConfEditor(data []byte) (configEditor, error) {
+	return pastelConfEditor{conf: utils.ParsePastelConf(data)}, nil
+}
+
+func parseYAMLConfEditor(data []byte) (configEditor, error) {
+	conf, err := utils.ParseYAMLConf(data)
+	if err != nil {
+		return nil, err
+	}
+	return singleValueEditor{keys: conf.Keys, get: conf.Get, lookup: conf.Lookup, set: conf.Set, unset: conf.Unset,
+		bytes: conf.Bytes, save: conf.Save}, nil
+}
+
+func parseTOMLConfEditor(data []byte) (configEditor, error) {
+	conf, err := utils.ParseTOMLConf(data)
+	if err != nil {
+		return nil, err
+	}
+	bytes := func() ([]byte, error) { return conf.Bytes(), nil }
+	return singleValueEditor{keys: conf.Keys, get: conf.Get, lookup: conf.Lookup, set: conf.Set, unset: conf.Unset,
+		bytes: bytes, save: conf.Save}, nil
+}
+
+// configComponent describes config file of the Pastel component
+type configComponent struct {
+	path  func(config *configs.Config) string
+	parse func(data []byte) (configEditor, error)
+	// template and its data are used to find out known keys of yaml and toml configs
+	template     string
+	templateData interface{}
+	// defaults renders default config of the component for the current pastelup version
+	defaults func(config *configs.Config) (string, error)
+	// schema is used by "config validate" and before start of the component
+	schema utils.ConfSchema
+	// check is optional validation which can't be described by the schema
+	check func(conf configEditor) []utils.ConfIssue
+}
+
+var configComponents = map[constants.ToolType]configComponent{
+	constants.PastelD: {
+		path:   func(config *configs.Config) string { return filepath.Join(config.WorkingDir, constants.PastelConfName) },
+		parse:  parsePastelConfEditor,
+		schema: pastelConfSchema,
+		check:  checkPastelConf,
+	},
+	constants.SuperNode: {
+		path:         func(config *configs.Config) string { return config.Configurer.GetSuperNodeConfFile(config.WorkingDir) },
+		parse:        parseYAMLConfEditor,
+		template:     configs.SupernodeDefaultConfig,
+		templateData: configs.SuperNodeConfig{},
+		defaults:     GetSNConfigs,
+		schema:       superNodeConfSchema,
+	},
+	constants.WalletNode: {
+		path:         func(config *configs.Config) string { return config.Configurer.GetWalletNodeConfFile(config.WorkingDir) },
+		parse:        parseYAMLConfEditor,
+		template:     configs.WalletDefaultConfig,
+		templateData: configs.WalletNodeConfig{},
+		defaults:     func(config *configs.Config) (string, error) { return GetWNConfigs(config, false) },
+		schema:       walletNodeConfSchema,
+	},
+	constants.Hermes: {
+		path:         func(config *configs.Config) string { return config.Configurer.GetHermesConfFile(config.WorkingDir) },
+		parse:        parseYAMLConfEditor,
+		template:     configs.HermesDefaultConfig,
+		templateData: configs.HermesConfig{},
+		defaults:     GetHermesConfigs,
+		schema:       hermesConfSchema,
+	},
+	constants.Bridge: {
+		path:         func(config *configs.Config) string { return config.Configurer.GetBridgeConfFile(config.WorkingDir) },
+		parse:        parseYAMLConfEditor,
+		template:     configs.BridgeDefaultConfig,
+		templateData: configs.BridgeConfig{},
+		defaults:     GetBridgeConfigs,
+		schema:       bridgeConfSchema,
+	},
+	constants.RQService: {
+		path:         func(config *configs.Config) string { return config.Configurer.GetRQServiceConfFile(config.WorkingDir) },
+		parse:        parseTOMLConfEditor,
+		template:     configs.RQServiceDefaultConfig,
+		templateData: configs.RQServiceConfig{},
+		defaults:     GetRQServiceConfigs,
+		schema:       rqServiceConfSchema,
+	},
+}
+
+// load reads and parses the component's config file
+func (c configComponent) load(path string) (configEditor, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %v", path, err)
+	}
+	return c.parse(data)
+}
+
+// isKnownKey checks the key against keys of the component's default config
+func (c configComponent) isKnownKey(key string) (bool, error) {
+	if c.template == "" {
+		return utils.IsPastelConfKnownKey(key), nil
+	}
+
+	data, err := utils.GetServiceConfig("known-keys", c.template, c.templateData)
+	if err != nil {
+		return false, err
+	}
+	defaults, err := c.parse([]byte(data))
+	if err != nil {
+		return false, err
+	}
+	return utils.Contains(defaults.Keys(), key), nil
+}
+
+func getConfigComponent(args []string, minArgs int, usage string) (constants.ToolType, configComponent, error) {
+	if len(args) < minArgs {
+		return "", configComponent{}, fmt.Errorf("usage: pastelup config %s", usage)
+	}
+	tool := constants.ToolType(args[0])
+	component, ok := configComponents[tool]
+	if !ok {
+		var names []string
+		for name := range configComponents {
+			names = append(names, string(name))
+		}
+		sort.Strings(names)
+		return "", configComponent{}, fmt.Errorf("unknown component %q, must be one of: %s", args[0], strings.Join(names, ", "))
+	}
+	return tool, component, nil
+}
+
+func setupConfigSubCommand(config *configs.Config, name, usage string, flags []*cli.Flag,
+	f func(context.Context, *configs.Config, []string) error,
+) *cli.Command {
+	commandFlags := []*cli.Flag{
+		cli.NewFlag("work-dir", &config.WorkingDir).SetAliases("w").
+			SetUsage(green("Optional, Location of the working directory")).SetValue(config.Configurer.DefaultWorkingDir()),
+	}
+	commandFlags = append(commandFlags, flags...)
+
+	subCommand := cli.NewCommand(name)
+	subCommand.SetUsage(cyan(usage))
+	subCommand.AddFlags(commandFlags...)
+	addLogFlags(subCommand, config)
+	subCommand.SetActionFunc(func(ctx context.Context, args []string) error {
+		ctx, err := configureLogging(ctx, "config", config)
+		if err != nil {
+			return fmt.Errorf("failed to configure logging option - %v", err)
+		}
+		return f(ctx, config, args)
+	})
+	return subCommand
+}
+
+func setupConfigCommand(config *configs.Config) *cli.Command {
+	listSubCommand := setupConfigSubCommand(config, "list",
+		"List all options of the component's config - pastelup config list <component>", nil, runConfigList)
+	getSubCommand := setupConfigSubCommand(config, "get",
+		"Print value of the option - pastelup config get <component> <key>", nil, runConfigGet)
+	setSubCommand := setupConfigSubCommand(config, "set",
+		"Set value of the option - pastelup config set <component> <key> <value>",
+		[]*cli.Flag{
+			cli.NewFlag("add", &flagConfigAdd).
+				SetUsage(green("Optional, add one more value to the multi value option (like addnode) instead of replacing all values")),
+			cli.NewFlag("force", &config.Force).SetAliases("f").
+				SetUsage(green("Optional, set option even if it is not known to pastelup")),
+		}, runConfigSet)
+	validateSubCommand := setupConfigSubCommand(config, "validate",
+		"Validate config files - pastelup config validate [component], all existing configs are validated if component is not set",
+		nil, runConfigValidate)
+	diffSubCommand := setupConfigSubCommand(config, "diff",
+		"Show changes the next update will make to the config - pastelup config diff <component>", nil, runConfigDiff)
+	unsetSubCommand := setupConfigSubCommand(config, "unset",
+		"Remove the option - pastelup config unset <component> <key>",
+		[]*cli.Flag{
+			cli.NewFlag("value", &flagConfigValue).
+				SetUsage(green("Optional, remove only this value of the multi value option (like addnode)")),
+		}, runConfigUnset)
+
+	showEffectiveSubCommand := setupConfigSubCommand(config, "show-effective",
+		"Show values of the command's flags taken from defaults, pastelup config files and PASTELUP_* environment variables"+
+			" - pastelup config show-effective [--profile <name>] <command> [subcommand]", nil, runConfigShowEffective)
+
+	configCommand := cli.NewCommand("config")
+	configCommand.SetUsage(blue("View and edit config files of the components: pasteld, supernode, walletnode, hermes, bridge, rq-service"))
+	configCommand.AddSubcommands(listSubCommand, getSubCommand, setSubCommand, unsetSubCommand, validateSubCommand, diffSubCommand,
+		showEffectiveSubCommand)
+
+	return configCommand
+}
+
+func runConfigList(_ context.Context, config *configs.Config, args []string) error {
+	_, component, err := getConfigComponent(args, 1, "list <component>")
+	if err != nil {
+		return err
+	}
+	editor, err := component.load(component.path(config))
+	if err != nil {
+		return err
+	}
+	for _, key := range editor.Keys() {
+		for _, value := range editor.Values(key) {
+			fmt.Printf("%s=%s\n", key, value)
+		}
+	}
+	return nil
+}
+
+func runConfigGet(_ context.Context, config *configs.Config, args []string) error {
+	_, component, err := getConfigComponent(args, 2, "get <component> <key>")
+	if err != nil {
+		return err
+	}
+	editor, err := component.load(component.path(config))
+	if err != nil {
+		return err
+	}
+	values := editor.Values(args[1])
+	if values == nil {
+		return fmt.Errorf("%s is not set", args[1])
+	}
+	for _, value := range values {
+		fmt.Println(value)
+	}
+	return nil
+}
+
+func runConfigSet(ctx context.Context, config *configs.Config, args []string) error {
+	tool, component, err := getConfigComponent(args, 3, "set <component> <key> <value>")
+	if err != nil {
+		return err
+	}
+	key, value := args[1], args[2]
+	path := component.path(config)
+	editor, err := component.load(path)
+	if err != nil {
+		return err
+	}
+
+	if !config.Force && !utils.Contains(editor.Keys(), key) {
+		known, err := component.isKnownKey(key)
+		if err != nil {
+			return err
+		}
+		if !known {
+			return fmt.Errorf("unknown %s option %q, use --force to set it anyway", tool, key)
+		}
+	}
+
+	if flagConfigAdd {
+		err = editor.Add(key, value)
+	} else {
+		err = editor.Set(key, value)
+	}
+	if err != nil {
+		return err
+	}
+	if err = editor.Save(path); err != nil {
+		return err
+	}
+	log.WithContext(ctx).Infof("%s updated, restart %s to apply changes", path, tool)
+	return nil
+}
+
+func runConfigUnset(ctx context.Context, config *configs.Config, args []string) error {
+	tool, component, err := getConfigComponent(args, 2, "unset <component> <key>")
+	if err != nil {
+		return err
+	}
+	path := component.path(config)
+	editor, err := component.load(path)
+	if err != nil {
+		return err
+	}
+	if !editor.Unset(args[1], flagConfigValue) {
+		return fmt.Errorf("%s is not set", args[1])
+	}
+	if err = editor.Save(path); err != nil {
+		return err
+	}
+	log.WithContext(ctx).Infof("%s updated, restart %s to apply changes", path, tool)
+	return nil
+}
+
+// isSecretFlag checks if value of the flag must not be printed
+func isSecretFlag(name string) bool {
+	return name == "pkey" || strings.HasSuffix(name, "-pw") || strings.HasPrefix(name, "passphrase") && name != "passphrase-file"
+}
+
+func runConfigShowEffective(_ context.Context, config *configs.Config, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: pastelup config show-effective [--profile <name>] <command> [subcommand]")
+	}
+	values, err := pastelupApp.EffectiveFlagValues(args, config.Profile)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Effective flags of 'pastelup %s' (command line flags override them):\n", strings.Join(args, " "))
+	for _, value := range values {
+		v := value.Value
+		if isSecretFlag(value.Name) && v != "" {
+			v = "********"
+		}
+		origin := value.Origin
+		if origin == "default" {
+			origin = cyan(origin)
+		} else {
+			origin = yellow(origin)
+		}
+		fmt.Printf("  --%s=%q\t(%s)\n", value.Name, v, origin)
+	}
+	return nil
+}
@@ -0,0 +1,225 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pastelnetwork/pastelup/common/log"
+	"github.com/pastelnetwork/pastelup/configs"
+	"github.com/pastelnetwork/pastelup/constants"
+	"github.com/pastelnetwork/pastelup/utils"
+)
+
+// mergeableEditor adapts configEditor to utils.ConfWriter
+type mergeableEditor struct {
+	configEditor
+}
+
+func (e mergeableEditor) Unset(key string) bool {
+	return e.configEditor.Unset(key, "")
+}
+
+func configTemplatesDir(config *configs.Config) string {
+	return filepath.Join(config.WorkingDir, constants.PastelupStateDir, constants.ConfigTemplatesDir)
+}
+
+// configTemplateBasePath returns path of the default config the component's config file was created from
+func configTemplateBasePath(config *configs.Config, tool constants.ToolType, confPath string, version int) string {
+	return filepath.Join(configTemplatesDir(config), fmt.Sprintf("%s.v%d%s", tool, version, filepath.Ext(confPath)))
+}
+
+// findConfigTemplateBase returns path and version of the stored default config of the component
+func findConfigTemplateBase(config *configs.Config, tool constants.ToolType) (string, int, bool) {
+	matches, _ := filepath.Glob(filepath.Join(configTemplatesDir(config), string(tool)+".v*"))
+	path, version := "", 0
+	for _, match := range matches {
+		var v int
+		if _, err := fmt.Sscanf(strings.TrimPrefix(filepath.Base(match), string(tool)+".v"), "%d", &v); err == nil && v > version {
+			path, version = match, v
+		}
+	}
+	return path, version, path != ""
+}
+
+// saveConfigTemplateBase stores the default config the component's config file was created from,
+// it is used as a base of the three-way merge by the next update
+func saveConfigTemplateBase(config *configs.Config, tool constants.ToolType, confPath string, defaults string) error {
+	if err := os.MkdirAll(configTemplatesDir(config), 0700); err != nil {
+		return fmt.Errorf("failed to create %s: %v", configTemplatesDir(config), err)
+	}
+	path := configTemplateBasePath(config, tool, confPath, configs.ConfigTemplateVersions[string(tool)])
+	if err := utils.WriteFileAtomic(path, []byte(defaults), 0600); err != nil {
+		return err
+	}
+	matches, _ := filepath.Glob(filepath.Join(configTemplatesDir(config), string(tool)+".v*"))
+	for _, match := range matches {
+		if match != path {
+			_ = os.Remove(match)
+		}
+	}
+	return nil
+}
+
+// backUpConfigFile copies the config file next to it with the timestamp suffix
+func backUpConfigFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %v", path, err)
+	}
+	fi, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+	backupPath := fmt.Sprintf("%s.%s.bak", path, time.Now().Format("20060102-150405"))
+	if err = utils.WriteFileAtomic(backupPath, data, fi.Mode().Perm()); err != nil {
+		return "", err
+	}
+	return backupPath, nil
+}
+
+// mergeComponentConfig merges new defaults into the component's config file and returns the merged config.
+// Config file itself is not changed
+func mergeComponentConfig(config *configs.Config, tool constants.ToolType, confPath string, defaults string,
+) (configEditor, []utils.ConfMergeChange, error) {
+	component := configComponents[tool]
+	current, err := component.load(confPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	newDefaults, err := component.parse([]byte(defaults))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse default %s config: %v", tool, err)
+	}
+
+	var base utils.ConfReader
+	if basePath, _, ok := findConfigTemplateBase(config, tool); ok {
+		if base, err = component.load(basePath); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	changes, err := utils.MergeConf(base, newDefaults, mergeableEditor{current})
+	if err != nil {
+		return nil, nil, err
+	}
+	return current, changes, nil
+}
+
+// migrateComponentConfig applies new defaults to the existing config file of the component
+// keeping operator's changes. The config file is backed up before it is changed
+func migrateComponentConfig(ctx context.Context, config *configs.Config, tool constants.ToolType,
+	confPath string, defaults string) error {
+
+	if !utils.CheckFileExist(confPath) {
+		log.WithContext(ctx).Infof("%s config %s not found, creating it from defaults", tool, confPath)
+		if err := os.MkdirAll(filepath.Dir(confPath), 0700); err != nil {
+			return fmt.Errorf("failed to create %s: %v", filepath.Dir(confPath), err)
+		}
+		// configs have rpc credentials of pasteld
+		if err := utils.WriteSecretFile(confPath, []byte(defaults)); err != nil {
+			return err
+		}
+		return saveConfigTemplateBase(config, tool, confPath, defaults)
+	}
+
+	_, version, hasBase := findConfigTemplateBase(config, tool)
+	newVersion := configs.ConfigTemplateVersions[string(tool)]
+	if !hasBase {
+		log.WithContext(ctx).Warnf("Default config %s was created from is unknown, only new %s options will be added", confPath, tool)
+	} else if version != newVersion {
+		log.WithContext(ctx).Infof("Migrating %s config from template v%d to v%d", tool, version, newVersion)
+	}
+
+	merged, changes, err := mergeComponentConfig(config, tool, confPath, defaults)
+	if err != nil {
+		return err
+	}
+
+	modified := false
+	for _, change := range changes {
+		if change.Action == utils.ConfMergeConflict {
+			log.WithContext(ctx).Warnf("%s config: %s", tool, change)
+			continue
+		}
+		log.WithContext(ctx).Infof("%s config: %s", tool, change)
+		modified = true
+	}
+
+	if modified {
+		backupPath, err := backUpConfigFile(confPath)
+		if err != nil {
+			return err
+		}
+		log.WithContext(ctx).Infof("%s backed up to %s", confPath, backupPath)
+		if err = merged.Save(confPath); err != nil {
+			return err
+		}
+	}
+	return saveConfigTemplateBase(config, tool, confPath, defaults)
+}
+
+func runConfigDiff(ctx context.Context, config *configs.Config, args []string) error {
+	tool, component, err := getConfigComponent(args, 1, "diff <component>")
+	if err != nil {
+		return err
+	}
+	if component.defaults == nil {
+		return fmt.Errorf("%s config has no default template", tool)
+	}
+	// defaults depend on the network and the RPC settings of pasteld, as they do when the update renders them
+	if err = ParsePastelConf(ctx, config); err != nil {
+		return err
+	}
+	defaults, err := component.defaults(config)
+	if err != nil {
+		return err
+	}
+
+	confPath := component.path(config)
+	data, err := os.ReadFile(confPath)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %v", confPath, err)
+	}
+	merged, changes, err := mergeComponentConfig(config, tool, confPath, defaults)
+	if err != nil {
+		return err
+	}
+	mergedData, err := merged.Bytes()
+	if err != nil {
+		return err
+	}
+
+	diff := utils.DiffLines(string(data), string(mergedData), 3)
+	if len(diff) == 0 && len(changes) == 0 {
+		fmt.Printf("%s is up to date with the defaults\n", confPath)
+		return nil
+	}
+	if len(diff) > 0 {
+		fmt.Printf("--- %s\n+++ %s (after update)\n", confPath, confPath)
+		printDiff(diff)
+	}
+	for _, change := range changes {
+		if change.Action == utils.ConfMergeConflict {
+			fmt.Println(yellow(change.String()))
+		}
+	}
+	return nil
+}
+
+// printDiff prints line diff with added lines in green and removed lines in red
+func printDiff(diff []string) {
+	for _, line := range diff {
+		switch line[0] {
+		case '+':
+			fmt.Println(green(line))
+		case '-':
+			fmt.Println(red(line))
+		default:
+			fmt.Println(line)
+		}
+	}
+}
@@ -0,0 +1,29 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pastelnetwork/pastelup/configs"
+	"github.com/pastelnetwork/pastelup/constants"
+	"github.com/tj/assert"
+)
+
+func TestMigrateComponentConfigCreatesMissing(t *testing.T) {
+	config := configs.InitConfig(nil)
+	config.WorkingDir = t.TempDir()
+	confPath := filepath.Join(config.WorkingDir, "supernode", "supernode.yml")
+	const defaults = "pastel-api:\n  username: user\n  password: password\n"
+
+	assert.NoError(t, migrateComponentConfig(context.Background(), config, constants.SuperNode, confPath, defaults))
+	data, err := os.ReadFile(confPath)
+	assert.NoError(t, err)
+	assert.Equal(t, defaults, string(data))
+	info, err := os.Stat(confPath)
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+	_, _, hasBase := findConfigTemplateBase(config, constants.SuperNode)
+	assert.True(t, hasBase)
+}
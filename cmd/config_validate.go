@@ -0,0 +1,226 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/pastelnetwork/pastelup/common/log"
+	"github.com/pastelnetwork/pastelup/configs"
+	"github.com/pastelnetwork/pastelup/constants"
+	"github.com/pastelnetwork/pastelup/utils"
+)
+
+var logConfSchema = utils.ConfSchema{
+	{Key: "log-config.log-file", Type: utils.ConfFilePath},
+	{Key: "log-config.log-compress", Type: utils.ConfBool},
+	{Key: "log-config.log-max-size-mb", Type: utils.ConfInt},
+	{Key: "log-config.log-max-age-days", Type: utils.ConfInt},
+	{Key: "log-config.log-max-backups", Type: utils.ConfInt},
+	{Key: "quiet", Type: utils.ConfBool},
+}
+
+var pastelConfSchema = utils.ConfSchema{
+	{Key: "rpcuser", Type: utils.ConfString},
+	{Key: "rpcpassword", Type: utils.ConfString},
+	// pasteld listens on the default rpc port of the network, if it isn't set
+	{Key: "rpcport", Type: utils.ConfPort},
+	{Key: "port", Type: utils.ConfPort},
+	{Key: "server", Type: utils.ConfBool},
+	{Key: "listen", Type: utils.ConfBool},
+	{Key: "txindex", Type: utils.ConfBool},
+	{Key: "masternode", Type: utils.ConfBool},
+	{Key: "testnet", Type: utils.ConfBool},
+	{Key: "devnet", Type: utils.ConfBool},
+	{Key: "regtest", Type: utils.ConfBool},
+	{Key: "maxmempool", Type: utils.ConfInt},
+	{Key: "rpcworkqueue", Type: utils.ConfInt},
+}
+
+var superNodeConfSchema = append(utils.ConfSchema{
+	{Key: "temp-dir", Type: utils.ConfString, Required: true},
+	{Key: "work-dir", Type: utils.ConfString, Required: true},
+	{Key: "rq-files-dir", Type: utils.ConfString, Required: true},
+	{Key: "dd-service-dir", Type: utils.ConfExistingDir, Required: true},
+	{Key: "node.pastel_id", Type: utils.ConfPastelID},
+	{Key: "node.server.port", Type: utils.ConfPort, Required: true},
+	{Key: "node.number_of_challenge_replicas", Type: utils.ConfInt},
+	{Key: "p2p.port", Type: utils.ConfPort, Required: true},
+	{Key: "p2p.data_dir", Type: utils.ConfString, Required: true},
+	{Key: "metadb.http_port", Type: utils.ConfPort, Required: true},
+	{Key: "metadb.raft_port", Type: utils.ConfPort, Required: true},
+	{Key: "metadb.data_dir", Type: utils.ConfString, Required: true},
+	{Key: "raptorq.host", Type: utils.ConfString, Required: true},
+	{Key: "raptorq.port", Type: utils.ConfPort, Required: true},
+	{Key: "dd-server.host", Type: utils.ConfString, Required: true},
+	{Key: "dd-server.port", Type: utils.ConfPort, Required: true},
+}, logConfSchema...)
+
+var walletNodeConfSchema = append(utils.ConfSchema{
+	{Key: "temp-dir", Type: utils.ConfString, Required: true},
+	{Key: "work-dir", Type: utils.ConfString, Required: true},
+	{Key: "rq-files-dir", Type: utils.ConfString, Required: true},
+	{Key: "node.api.hostname", Type: utils.ConfString, Required: true},
+	{Key: "node.api.port", Type: utils.ConfPort, Required: true},
+	{Key: "raptorq.host", Type: utils.ConfString, Required: true},
+	{Key: "raptorq.port", Type: utils.ConfPort, Required: true},
+	{Key: "bridge.port", Type: utils.ConfPort},
+	{Key: "bridge.switch", Type: utils.ConfBool},
+}, logConfSchema...)
+
+var hermesConfSchema = append(utils.ConfSchema{
+	{Key: "temp-dir", Type: utils.ConfString, Required: true},
+	{Key: "work-dir", Type: utils.ConfString, Required: true},
+	{Key: "dd-service-dir", Type: utils.ConfExistingDir, Required: true},
+	{Key: "pastel_id", Type: utils.ConfPastelID},
+	{Key: "sn_host", Type: utils.ConfString, Required: true},
+	{Key: "sn_port", Type: utils.ConfPort, Required: true},
+}, logConfSchema...)
+
+// bridge config has log options at the top level
+var bridgeConfSchema = utils.ConfSchema{
+	{Key: "log-file", Type: utils.ConfFilePath},
+	{Key: "work-dir", Type: utils.ConfString, Required: true},
+	{Key: "download.pastel_id", Type: utils.ConfPastelID},
+	{Key: "download.connections", Type: utils.ConfInt},
+	{Key: "download.connections_refresh_timeout", Type: utils.ConfInt},
+	{Key: "server.listen_address", Type: utils.ConfString, Required: true},
+	{Key: "server.port", Type: utils.ConfPort, Required: true},
+}
+
+var rqServiceConfSchema = utils.ConfSchema{
+	{Key: "grpc-service", Type: utils.ConfHostPort, Required: true},
+}
+
+// startConfigComponents are the components, configs of which are validated before the start command
+var startConfigComponents = map[startCommand][]constants.ToolType{
+	nodeStart:      {constants.PastelD},
+	masterNode:     {constants.PastelD},
+	walletStart:    {constants.PastelD, constants.RQService, constants.WalletNode, constants.Bridge},
+	superNodeStart: {constants.PastelD, constants.RQService, constants.SuperNode, constants.Hermes},
+	rqService:      {constants.RQService},
+	wnService:      {constants.WalletNode},
+	snService:      {constants.SuperNode},
+	hermesService:  {constants.Hermes},
+	bridgeService:  {constants.Bridge},
+}
+
+// checkPastelConf finds conflicting network options and repeated single value options
+func checkPastelConf(conf configEditor) []utils.ConfIssue {
+	var issues []utils.ConfIssue
+	var networks []string
+	for _, key := range []string{"testnet", "devnet", "regtest"} {
+		if values := conf.Values(key); len(values) > 0 && values[len(values)-1] == "1" {
+			networks = append(networks, key)
+		}
+	}
+	if len(networks) > 1 {
+		issues = append(issues, utils.ConfIssue{Message: fmt.Sprintf("only one network can be enabled, found: %s", strings.Join(networks, ", "))})
+	}
+	_, hasUser, _ := conf.Lookup("rpcuser")
+	_, hasPassword, _ := conf.Lookup("rpcpassword")
+	if hasUser != hasPassword {
+		issues = append(issues, utils.ConfIssue{
+			Message: "rpcuser and rpcpassword must be set together, cookie authentication is used when both are missing"})
+	}
+	for _, key := range conf.Keys() {
+		if !utils.IsPastelConfMultiValueKey(key) && len(conf.Values(key)) > 1 {
+			issues = append(issues, utils.ConfIssue{Key: key, Message: "set more than once, the last value is used", Warning: true})
+		}
+	}
+	return issues
+}
+
+// validate checks the component's config file and returns found issues
+func (c configComponent) validate(path string) ([]utils.ConfIssue, error) {
+	conf, err := c.load(path)
+	if err != nil {
+		return nil, err
+	}
+	issues := c.schema.Validate(conf.Lookup)
+	if c.check != nil {
+		issues = append(issues, c.check(conf)...)
+	}
+	for _, key := range conf.Keys() {
+		if c.schema.Has(key) {
+			continue
+		}
+		known, err := c.isKnownKey(key)
+		if err != nil {
+			return nil, err
+		}
+		if !known {
+			issues = append(issues, utils.ConfIssue{Key: key, Message: "unknown option", Warning: true})
+		}
+	}
+	return issues, nil
+}
+
+// validateConfigs validates configs of the tools, missing config files are skipped if optional is set
+func validateConfigs(ctx context.Context, config *configs.Config, tools []constants.ToolType, optional bool) error {
+	var failed []string
+	for _, tool := range tools {
+		component := configComponents[tool]
+		path := component.path(config)
+		if _, err := os.Stat(path); os.IsNotExist(err) && optional {
+			log.WithContext(ctx).Debugf("%s config %s not found, skipping validation", tool, path)
+			continue
+		}
+
+		issues, err := component.validate(path)
+		if err != nil {
+			log.WithContext(ctx).WithError(err).Errorf("%s config %s is invalid", tool, path)
+			failed = append(failed, string(tool))
+			continue
+		}
+		hasErrors := false
+		for _, issue := range issues {
+			if issue.Warning {
+				log.WithContext(ctx).Warnf("%s config %s: %s", tool, path, issue)
+			} else {
+				log.WithContext(ctx).Errorf("%s config %s: %s", tool, path, issue)
+				hasErrors = true
+			}
+		}
+		if hasErrors {
+			failed = append(failed, string(tool))
+			continue
+		}
+		log.WithContext(ctx).Infof("%s config %s is valid", tool, path)
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("invalid config of: %s", strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+// validateStartConfigs validates configs of the components started by the start command
+func validateStartConfigs(ctx context.Context, config *configs.Config, command startCommand) error {
+	tools, ok := startConfigComponents[command]
+	if !ok || config.SkipConfigValidation {
+		return nil
+	}
+	if err := validateConfigs(ctx, config, tools, true); err != nil {
+		return fmt.Errorf("%v - fix it with 'pastelup config set' or use --skip-config-validation", err)
+	}
+	return nil
+}
+
+func runConfigValidate(ctx context.Context, config *configs.Config, args []string) error {
+	if len(args) > 0 {
+		tool, _, err := getConfigComponent(args, 1, "validate [component]")
+		if err != nil {
+			return err
+		}
+		return validateConfigs(ctx, config, []constants.ToolType{tool}, false)
+	}
+
+	var tools []constants.ToolType
+	for tool := range configComponents {
+		tools = append(tools, tool)
+	}
+	sort.Slice(tools, func(i, j int) bool { return tools[i] < tools[j] })
+	return validateConfigs(ctx, config, tools, true)
+}
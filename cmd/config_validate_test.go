@@ -0,0 +1,43 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pastelnetwork/pastelup/constants"
+	"github.com/pastelnetwork/pastelup/utils"
+	"github.com/tj/assert"
+)
+
+func TestValidatePastelConf(t *testing.T) {
+	tests := []struct {
+		name   string
+		conf   string
+		issues []utils.ConfIssue
+	}{
+		{
+			// pasteld uses the default rpc port of the network
+			name: "without rpcport",
+			conf: "testnet=1\nrpcuser=user\nrpcpassword=password\n",
+		},
+		{
+			name: "custom rpcport",
+			conf: "rpcport=19932\n",
+		},
+		{
+			name:   "invalid rpcport",
+			conf:   "rpcport=port\n",
+			issues: []utils.ConfIssue{{Key: "rpcport", Message: `"port" is not a valid port (1-65535)`}},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), constants.PastelConfName)
+			assert.NoError(t, os.WriteFile(path, []byte(test.conf), 0600))
+			issues, err := configComponents[constants.PastelD].validate(path)
+			assert.NoError(t, err)
+			assert.Equal(t, test.issues, issues)
+		})
+	}
+}
@@ -48,7 +48,7 @@ func GetSNConfigs(config *configs.Config) (string, error) {
 		MDLPort:                         portList[constants.MDLPort],
 		RAFTPort:                        portList[constants.RAFTPort],
 		MDLDataDir:                      mdlDataPath,
-		RaptorqPort:                     constants.RQServiceDefaultPort,
+		RaptorqPort:                     rqServicePort(config),
 		NumberOfChallengeReplicas:       constants.NumberOfChallengeReplicas,
 		StorageChallengeExpiredDuration: constants.StorageChallengeExpiredDuration,
 		DDServerPort:                    constants.DDServerDefaultPort,
@@ -90,6 +90,81 @@ func GetHermesConfigs(config *configs.Config) (string, error) {
 	return toolConfig, nil
 }
 
+// GetWNConfigs returns WN configs
+func GetWNConfigs(config *configs.Config, bridgeOn bool) (string, error) {
+	wnTempDirPath := filepath.Join(config.WorkingDir, constants.TempDir)
+	rqWorkDirPath := filepath.Join(config.WorkingDir, constants.RQServiceDir)
+
+	toolConfig, err := utils.GetServiceConfig(string(constants.WalletNode), configs.WalletDefaultConfig, &configs.WalletNodeConfig{
+		LogLevel:      constants.WalletNodeDefaultLogLevel,
+		LogFilePath:   config.Configurer.GetWalletNodeLogFile(config.WorkingDir),
+		LogCompress:   constants.LogConfigDefaultCompress,
+		LogMaxSizeMB:  constants.LogConfigDefaultMaxSizeMB,
+		LogMaxAgeDays: constants.LogConfigDefaultMaxAgeDays,
+		LogMaxBackups: constants.LogConfigDefaultMaxBackups,
+		WNTempDir:     wnTempDirPath,
+		WNWorkDir:     config.WorkingDir,
+		RQDir:         rqWorkDirPath,
+		BurnAddress:   getBurnAddress(config),
+		RaptorqPort:   rqServicePort(config),
+		BridgePort:    bridgePort(config),
+		BridgeOn:      bridgeOn,
+		APIPort:       wnAPIPort(config),
+	})
+	if err != nil {
+		return "", errors.Errorf("failed to get walletnode config: %v", err)
+	}
+
+	return toolConfig, nil
+}
+
+// GetBridgeConfigs returns bridge configs
+func GetBridgeConfigs(config *configs.Config) (string, error) {
+	toolConfig, err := utils.GetServiceConfig(string(constants.Bridge), configs.BridgeDefaultConfig, &configs.BridgeConfig{
+		LogLevel:           constants.WalletNodeDefaultLogLevel,
+		LogFilePath:        config.Configurer.GetBridgeLogFile(config.WorkingDir),
+		LogCompress:        constants.LogConfigDefaultCompress,
+		LogMaxSizeMB:       constants.LogConfigDefaultMaxSizeMB,
+		LogMaxAgeDays:      constants.LogConfigDefaultMaxAgeDays,
+		LogMaxBackups:      constants.LogConfigDefaultMaxBackups,
+		WNTempDir:          filepath.Join(config.WorkingDir, constants.TempDir),
+		WNWorkDir:          config.WorkingDir,
+		BurnAddress:        getBurnAddress(config),
+		ConnRefreshTimeout: 300,
+		Connections:        10,
+		ListenAddress:      "127.0.0.1",
+		Port:               bridgePort(config),
+	})
+	if err != nil {
+		return "", errors.Errorf("failed to get bridge config: %v", err)
+	}
+
+	return toolConfig, nil
+}
+
+// GetRQServiceConfigs returns rq-service configs
+func GetRQServiceConfigs(config *configs.Config) (string, error) {
+	toolConfig, err := utils.GetServiceConfig(string(constants.RQService), configs.RQServiceDefaultConfig, &configs.RQServiceConfig{
+		HostName: "127.0.0.1",
+		Port:     rqServicePort(config),
+	})
+	if err != nil {
+		return "", errors.Errorf("failed to get rqservice config: %v", err)
+	}
+
+	return toolConfig, nil
+}
+
+func getBurnAddress(config *configs.Config) string {
+	switch config.Network {
+	case constants.NetworkTestnet, constants.NetworkRegTest:
+		return constants.BurnAddressTestnet
+	case constants.NetworkDevnet:
+		return constants.BurnAddressDevnet
+	}
+	return constants.BurnAddressMainnet
+}
+
 func checkBridgeConfigPastelID(ctx context.Context, config *configs.Config, confPath string) error {
 	bridgeConfFile, err := os.ReadFile(confPath)
 	if err != nil {
@@ -252,7 +327,8 @@ func checkBridgeConfigPastelID(ctx context.Context, config *configs.Config, conf
 		return err
 	}
 
-	if os.WriteFile(confPath, bridgeConfFileUpdated, 0644) != nil {
+	// bridge.yml has the passphrase of the PastelID
+	if err = utils.WriteSecretFile(confPath, bridgeConfFileUpdated); err != nil {
 		log.WithContext(ctx).WithError(err).Errorf("Failed to update bridge.yml file at - %s", confPath)
 		return err
 	}
@@ -2,103 +2,45 @@ package cmd
 
 import (
 	"context"
-	"crypto/sha256"
-	"encoding/hex"
 	"encoding/json"
 	"fmt"
-	"io"
-	"math"
+	"net/http"
 	"os"
 	"path/filepath"
+	"runtime"
 	"strconv"
 	"strings"
+	"time"
 
 	sigar "github.com/cloudfoundry/gosigar"
 	"github.com/olekukonko/tablewriter"
+	"gopkg.in/yaml.v2"
 
 	"github.com/pastelnetwork/pastelup/common/cli"
 	"github.com/pastelnetwork/pastelup/common/log"
 	"github.com/pastelnetwork/pastelup/common/sys"
+	"github.com/pastelnetwork/pastelup/common/version"
 	"github.com/pastelnetwork/pastelup/configs"
 	"github.com/pastelnetwork/pastelup/constants"
+	"github.com/pastelnetwork/pastelup/services/nodeinfo"
 	"github.com/pastelnetwork/pastelup/services/pastelcore"
 	"github.com/pastelnetwork/pastelup/structure"
 	"github.com/pastelnetwork/pastelup/utils"
 )
 
 var (
-	flagHostInfo   bool
-	flagPastelInfo bool
-	flagOutput     string
+	flagHostInfo     bool
+	flagPastelInfo   bool
+	flagCheckUpdates bool
+	flagOutput       string
 )
 
-var (
-	pastelTools = []constants.ToolType{
-		constants.PastelD,
-		constants.SuperNode,
-		constants.WalletNode,
-		constants.DDService,
-		constants.RQService,
-		constants.DDImgService,
-	}
+// Timeouts of the latest release check, the release is downloaded to be hashed if the server publishes no checksum
+const (
+	infoReleaseTimeout     = 10 * time.Second
+	infoReleaseHashTimeout = 5 * time.Minute
 )
 
-type hostInfo struct {
-	Hostname string
-	OS       string
-}
-
-type processInfo struct {
-	Process   string
-	Pid       string
-	CPU       string
-	Virtmem   string
-	Rmem      string
-	Starttime string
-	Runtime   string
-	Path      string
-	Args      []string
-	Version   string
-	Hash      string
-}
-
-type memoryInfo struct {
-	Memory string
-	Total  string
-	Used   string
-	Free   string
-}
-
-type filesystemInfo struct {
-	Filesystem string
-	Size       string
-	Used       string
-	Avail      string
-	Use        string
-	Mounted    string
-}
-
-type systemInfo struct {
-	HostInfo hostInfo
-	MemInfo  []memoryInfo
-	FsInfo   []filesystemInfo
-	ProcInfo []processInfo
-}
-
-type pastelInfo struct {
-	Args       []string
-	WorkingDir string
-	ExecDir    string
-	GetInfo    structure.GetInfoResult
-	MNStatus   structure.MNStatusResult
-	MNConfig   structure.MasternodeConfResult
-}
-
-type allInfo struct {
-	SystemInfo systemInfo
-	PastelInfo pastelInfo
-}
-
 type infoCommand uint8
 
 const (
@@ -127,8 +69,10 @@ func setupInfoSubCommand(config *configs.Config,
 			SetUsage(green("Get Host info (Host name, OS version, ")).SetValue(true),
 		cli.NewFlag("pastel", &flagPastelInfo).
 			SetUsage(green("Get Pastel info (Working Directory, Executables Directory")).SetValue(true),
+		cli.NewFlag("check-updates", &flagCheckUpdates).
+			SetUsage(green("Compare the components with the latest release on the download server")),
 		cli.NewFlag("output", &flagOutput).
-			SetUsage(green("How to present information. Available choices are: 'console' and 'json'")).SetValue("console"),
+			SetUsage(green("How to present information. Available choices are: 'console', 'json' and 'yaml'")).SetValue("console"),
 	}
 
 	var dirsFlags []*cli.Flag
@@ -217,126 +161,286 @@ func setupInfoCommand(config *configs.Config) *cli.Command {
 func formatMemory(val uint64) string {
 	return strconv.Itoa(int(val / 1024))
 }
-func formatSize(size uint64) string {
-	return sigar.FormatSize(size * 1024)
-}
 
 func runInfoSubCommand(ctx context.Context, config *configs.Config) error {
-	//var err error
-	var sysInfo systemInfo
-	if flagHostInfo {
-		if flagOutput == "console" {
+	if flagOutput != "console" && flagOutput != "json" && flagOutput != "yaml" {
+		return fmt.Errorf("unknown output %q, use 'console', 'json' or 'yaml'", flagOutput)
+	}
+	report := collectNodeInfo(ctx, config)
+
+	switch flagOutput {
+	case "json":
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+	case "yaml":
+		data, err := yaml.Marshal(report)
+		if err != nil {
+			return err
+		}
+		fmt.Print(string(data))
+	default:
+		if report.Host != nil {
 			log.WithContext(ctx).Info(green("\n=== System info ==="))
+			printHostInfo(report.Host)
+			printMemoryInfo(report.Host)
+			printFSInfo(report.Host.Filesystems)
+			printComponentsInfo(report.Components)
+		}
+		if report.Pasteld != nil {
+			log.WithContext(ctx).Info(blue("\n=== Pastel info ==="))
+			printPastelInfo(report.Pasteld)
 		}
+	}
+	return nil
+}
 
-		host, _ := os.Hostname()
-		sysInfo.HostInfo = hostInfo{
-			Hostname: host,
-			OS:       string(utils.GetOS()),
+// collectNodeInfo gets the report of the host and the components, the parts, which can't be got, are left out
+func collectNodeInfo(ctx context.Context, config *configs.Config) nodeinfo.Report {
+	report := nodeinfo.Report{
+		SchemaVersion: nodeinfo.SchemaVersion,
+		GeneratedAt:   time.Now().UTC(),
+	}
+	if flagHostInfo {
+		report.Host = getHostInfo()
+		for _, tool := range append([]constants.ToolType{constants.Pastelup}, configs.SystemdTools...) {
+			if component, ok := getComponentInfo(ctx, config, tool); ok {
+				report.Components = append(report.Components, component)
+			}
 		}
+	}
+	if flagPastelInfo {
+		report.Pasteld = getPasteldInfo(ctx, config)
+	}
+	return report
+}
 
-		pastelProcNames := make(map[string]bool)
-		pastelProcNamesShort := make(map[string]bool)
-		for _, tool := range pastelTools {
-			name := constants.ServiceName[tool][utils.GetOS()]
-			pastelProcNames[name] = true
+func getHostInfo() *nodeinfo.Host {
+	host := &nodeinfo.Host{
+		OS:   string(utils.GetOS()),
+		Arch: runtime.GOARCH,
+		CPUs: runtime.NumCPU(),
+	}
+	host.Hostname, _ = os.Hostname()
+
+	mem := sigar.Mem{}
+	if err := mem.Get(); err == nil {
+		host.Memory = nodeinfo.Memory{Total: mem.Total, Used: mem.Used, Free: mem.Free, Available: mem.ActualFree}
+	}
+	swap := sigar.Swap{}
+	if err := swap.Get(); err == nil {
+		host.Swap = nodeinfo.Memory{Total: swap.Total, Used: swap.Used, Free: swap.Free}
+	}
 
-			short := int(math.Min(15, float64(len(name))))
-			shortName := name[:short]
-			pastelProcNamesShort[shortName] = true
+	fsList := sigar.FileSystemList{}
+	_ = fsList.Get()
+	for _, fs := range fsList.List {
+		if strings.HasPrefix(fs.DevName, "/dev/loop") || !strings.HasPrefix(fs.DevName, "/dev/") {
+			continue
 		}
-		// for old SN installations
-		pastelProcNames["supernode-ubunt"] = true
-		pastelProcNames["rq-service-ubun"] = true
-
-		//dd and img-server
-		pastelProcNames["python3"] = true //TODO - get command line parameters and check for `dupe_detection_server.py`
-		pastelProcNames["start_dd_img_se"] = true
-
-		sysInfo.MemInfo = getMemoryInfo()
-		sysInfo.FsInfo = getFSInfo()
-		sysInfo.ProcInfo = getPastelProcessesInfo(&pastelProcNames, &pastelProcNamesShort)
-
-		if flagOutput == "console" {
-			printHostInfo(sysInfo.HostInfo)
-			printMemoryInfo(sysInfo.MemInfo)
-			printFSInfo(sysInfo.FsInfo)
-			printProcessInfo(sysInfo.ProcInfo)
+		usage := sigar.FileSystemUsage{}
+		if err := usage.Get(fs.DirName); err != nil {
+			continue
 		}
+		// sigar reports filesystem usage in kilobytes
+		host.Filesystems = append(host.Filesystems, nodeinfo.Filesystem{
+			Device:      fs.DevName,
+			MountPoint:  fs.DirName,
+			Total:       usage.Total * 1024,
+			Used:        usage.Used * 1024,
+			Available:   usage.Avail * 1024,
+			UsedPercent: usage.UsePercent(),
+		})
 	}
+	return host
+}
 
-	pastelInfo := pastelInfo{}
-
-	if flagPastelInfo {
-		if flagOutput == "console" {
-			log.WithContext(ctx).Info(blue("\n=== Pastel info ==="))
+// getComponentInfo describes the component, ok is false if it is neither installed nor running
+func getComponentInfo(ctx context.Context, config *configs.Config, tool constants.ToolType) (component nodeinfo.Component, ok bool) {
+	component.Name = string(tool)
+	if tool == constants.Pastelup {
+		component.Binary, _ = os.Executable()
+		component.Version = version.Version()
+	} else {
+		_, _, binaries, err := serviceExecCommand(ctx, config, config.Configurer.DefaultHomeDir(), tool, false, "")
+		if err == nil && len(binaries) > 0 {
+			component.Binary = binaries[0]
 		}
+	}
+	installed := component.Binary != "" && utils.CheckFileExist(component.Binary)
+	if !installed {
+		component.Binary = ""
+	}
 
-		for _, process := range sysInfo.ProcInfo {
-			if strings.HasPrefix(process.Process, "pasteld") {
-				config.PastelExecDir = process.Path
-				config.WorkingDir = config.Configurer.DefaultWorkingDir()
-				if len(process.Args) > 0 {
-					for _, arg := range process.Args[1:] {
-						if strings.Contains(arg, "--datadir") {
-							datadir := strings.Split(arg, "=")
-							if len(datadir) == 1 {
-								datadir = strings.Split(arg, " ")
-							}
-							if len(datadir) == 2 {
-								config.WorkingDir = datadir[1]
-							}
-						}
-					}
-				}
-				pastelInfo.Args = process.Args
-				pastelInfo.WorkingDir = config.WorkingDir
-				pastelInfo.ExecDir = config.PastelExecDir
-
-				var info structure.RPCGetInfo
-				err := pastelcore.NewClient(config).RunCommand(pastelcore.GetInfoCmd, &info)
-				if err != nil {
-					log.WithContext(ctx).Errorf("unable to get pastel info: %v", err)
-				} else {
-					pastelInfo.GetInfo = info.Result
-				}
-
-				var mnStatus structure.RPCPastelMNStatus
-				err = pastelcore.NewClient(config).RunCommandWithArgs(pastelcore.MasterNodeCmd, []string{"status"}, &mnStatus)
-				if err != nil {
-					log.WithContext(ctx).Errorf("unable to get masternode status: %v", err)
-				} else {
-					pastelInfo.MNStatus = mnStatus.Result
-				}
+	// pastelup isn't a service, the only running pastelup is this one
+	if tool != constants.Pastelup {
+		pid, err := servicePid(ctx, config, tool)
+		if err != nil {
+			component.Error = fmt.Sprintf("unable to get process: %v", err)
+		} else if pid != 0 {
+			component.Running = true
+			component.Process = getProcessInfo(pid, &sigar.ProcCpu{})
+		}
+	}
+	if !installed && !component.Running {
+		return component, false
+	}
 
-				var mnConfig structure.RPCMasternodeConf
-				err = pastelcore.NewClient(config).RunCommandWithArgs(pastelcore.MasterNodeCmd, []string{"list-conf"}, &mnConfig)
-				if err != nil {
-					log.WithContext(ctx).Errorf("unable to get masternode config: %v", err)
-				} else {
-					pastelInfo.MNConfig = mnConfig.Result
-				}
-			}
+	if component.Configs = componentConfigs(config, tool); len(component.Configs) == 0 {
+		component.Configs = nil
+	}
+	if !installed {
+		return component, true
+	}
+	var err error
+	if component.SHA256, err = nodeinfo.FileHash(component.Binary); err != nil {
+		component.Error = fmt.Sprintf("unable to hash binary: %v", err)
+	}
+	// dd-service is the python script, which doesn't report its version
+	if component.Version == "" && tool != constants.DDService {
+		if component.Version, err = nodeinfo.BinaryVersion(ctx, component.Binary); err != nil {
+			log.WithContext(ctx).WithError(err).Debugf("Unable to get version of %s", tool)
 		}
+	}
+	if flagCheckUpdates {
+		checkComponentUpdate(ctx, config, &component)
+	}
+	return component, true
+}
 
-		if flagOutput == "console" {
-			printPastelInfo(pastelInfo)
+// componentConfigs returns the existing config files of the component
+func componentConfigs(config *configs.Config, tool constants.ToolType) []string {
+	var paths []string
+	if component, ok := configComponents[tool]; ok {
+		paths = append(paths, component.path(config))
+	}
+	if tool == constants.PastelD {
+		paths = append(paths, getMasternodeConfPath(config, config.WorkingDir, "masternode.conf"))
+	}
+	var existing []string
+	for _, path := range paths {
+		if utils.CheckFileExist(path) {
+			existing = append(existing, path)
 		}
 	}
+	return existing
+}
+
+// checkComponentUpdate compares the component with the latest release of the network, pastelup has single release
+func checkComponentUpdate(ctx context.Context, config *configs.Config, component *nodeinfo.Component) {
+	tool := constants.ToolType(component.Name)
+	network := config.Network
+	if tool == constants.Pastelup {
+		network = ""
+	}
+	downloadURL, archiveName, err := config.Configurer.GetDownloadURL(network, "", tool)
+	if err != nil {
+		return
+	}
+	releaseCtx, cancel := context.WithTimeout(ctx, infoReleaseTimeout)
+	defer cancel()
+	release, err := nodeinfo.LatestRelease(releaseCtx, http.DefaultClient, downloadURL.String())
+	if err != nil {
+		log.WithContext(ctx).WithError(err).Warnf("Unable to check the latest release of %s", tool)
+		return
+	}
+	component.Latest = release
+	// the archives, e.g. pasteld with pastel-cli, can't be compared with the binary
+	if strings.HasSuffix(archiveName, ".zip") {
+		return
+	}
+	// the release of another size is surely another build, so it isn't downloaded to be hashed
+	if stat, err := os.Stat(component.Binary); err == nil && release.Size > 0 && stat.Size() != release.Size {
+		available := true
+		component.UpdateAvailable = &available
+		return
+	}
+	hashCtx, cancel := context.WithTimeout(ctx, infoReleaseHashTimeout)
+	defer cancel()
+	if release.SHA256, err = nodeinfo.ReleaseHash(hashCtx, http.DefaultClient, downloadURL.String()); err != nil {
+		log.WithContext(ctx).WithError(err).Warnf("Unable to get hash of the latest release of %s", tool)
+		return
+	}
+	component.UpdateAvailable = nodeinfo.UpdateAvailable(component.SHA256, release)
+}
+
+// getProcessInfo gets the process stats, cpu keeps the previous CPU times, so the usage is measured since the last call
+func getProcessInfo(pid int, cpu *sigar.ProcCpu) *nodeinfo.Process {
+	process := &nodeinfo.Process{Pid: pid}
+	mem := sigar.ProcMem{}
+	if err := mem.Get(pid); err == nil {
+		process.Resident = mem.Resident
+		process.Virtual = mem.Size
+	}
+	procTime := sigar.ProcTime{}
+	if err := procTime.Get(pid); err == nil && procTime.StartTime > 0 {
+		process.StartedAt = time.UnixMilli(int64(procTime.StartTime)).UTC()
+	}
+	if err := cpu.Get(pid); err == nil {
+		process.CPUPercent = cpu.Percent * 100
+	}
+	args := sigar.ProcArgs{}
+	if err := args.Get(pid); err == nil {
+		process.Args = redactArgs(args.List)
+	}
+	return process
+}
 
-	if flagOutput == "json" {
-		allInfo := allInfo{
-			SystemInfo: sysInfo,
-			PastelInfo: pastelInfo,
+// redactArgs hides values of the arguments with secrets, e.g. pasteld's --masternodeprivkey
+func redactArgs(args []string) []string {
+	redacted := make([]string, len(args))
+	for i, arg := range args {
+		if name, _, found := strings.Cut(arg, "="); found && strings.Contains(strings.ToLower(name), "privkey") {
+			arg = name + "=***"
 		}
-		//data, _ := json.MarshalIndent(allInfo, "", "  ")
-		data, _ := json.Marshal(allInfo)
-		//if err != nil {
-		//	fmt.Printf("Error %v\n", err)
-		//}
-		fmt.Println(string(data))
+		redacted[i] = arg
 	}
-	return nil
+	return redacted
+}
+
+// getPasteldInfo gets the chain and masternode from pasteld RPC
+func getPasteldInfo(ctx context.Context, config *configs.Config) *nodeinfo.Pasteld {
+	info := &nodeinfo.Pasteld{
+		Network:    config.Network,
+		WorkingDir: config.WorkingDir,
+		ExecDir:    config.PastelExecDir,
+	}
+
+	var getInfo structure.RPCGetInfo
+	if err := pastelcore.NewClient(config).RunCommand(pastelcore.GetInfoCmd, &getInfo); err != nil {
+		log.WithContext(ctx).WithError(err).Warn("Unable to get pastel info")
+		return info
+	}
+	info.Chain = &nodeinfo.Chain{
+		Version:         getInfo.Result.Version,
+		ProtocolVersion: getInfo.Result.Protocolversion,
+		Blocks:          getInfo.Result.Blocks,
+		Connections:     getInfo.Result.Connections,
+		Difficulty:      getInfo.Result.Difficulty,
+		Balance:         getInfo.Result.Balance,
+	}
+
+	conf, err := utils.LoadPastelConf(filepath.Join(config.WorkingDir, constants.PastelConfName))
+	if err != nil || !conf.GetBool("masternode") {
+		return info
+	}
+	info.Masternode = &nodeinfo.Masternode{}
+	var mnStatus structure.RPCPastelMNStatus
+	if err = pastelcore.NewClient(config).RunCommandWithArgs(pastelcore.MasterNodeCmd, []string{"status"}, &mnStatus); err == nil {
+		info.Masternode.Outpoint = mnStatus.Result.Outpoint
+		info.Masternode.Status = mnStatus.Result.Status
+	}
+	var mnConfig structure.RPCMasternodeConf
+	if err = pastelcore.NewClient(config).RunCommandWithArgs(pastelcore.MasterNodeCmd, []string{"list-conf"}, &mnConfig); err == nil {
+		mn := mnConfig.Result.Masternode
+		info.Masternode.Alias = mn.Alias
+		info.Masternode.Address = mn.Address
+		info.Masternode.ExtAddress = mn.ExtAddress
+		info.Masternode.ExtP2P = mn.ExtP2P
+	}
+	return info
 }
 
 func runRemoteInfoSubCommand(ctx context.Context, config *configs.Config) error {
@@ -347,37 +451,45 @@ func runRemoteInfoSubCommand(ctx context.Context, config *configs.Config) error
 	if flagPastelInfo {
 		infoOptions = fmt.Sprintf("%s --pastel", infoOptions)
 	}
+	if flagCheckUpdates {
+		infoOptions = fmt.Sprintf("%s --check-updates", infoOptions)
+	}
 	if len(flagOutput) > 0 {
 		infoOptions = fmt.Sprintf("%s --output %s", infoOptions, flagOutput)
 	}
-	if config.Quiet && flagOutput == "json" {
+	if config.Quiet && flagOutput != "console" {
 		infoOptions = fmt.Sprintf("%s -q", infoOptions)
 	}
 	if len(config.LogLevel) > 0 {
 		infoOptions = fmt.Sprintf("%s --log-level %s", infoOptions, config.LogLevel)
 	}
 	infoCmd := fmt.Sprintf("%s info %s", constants.RemotePastelupPath, infoOptions)
-	if outs, err := executeRemoteCommandsWithInventory(ctx, config, []string{infoCmd}, false, flagOutput == "json"); err != nil {
+	if outs, err := executeRemoteCommandsWithInventory(ctx, config, []string{infoCmd}, false, flagOutput != "console"); err != nil {
 		log.WithContext(ctx).WithError(err).Error("Failed to get info from remote hosts")
 	} else {
-		if flagOutput == "json" {
-			sliceOfStrings := make([]json.RawMessage, len(outs))
-			for i, byteSlice := range outs {
-				sliceOfStrings[i] = byteSlice
+		switch flagOutput {
+		case "json":
+			reports := make([]json.RawMessage, len(outs))
+			for i, out := range outs {
+				reports[i] = out
 			}
-			jsonData, err := json.MarshalIndent(sliceOfStrings, "", "  ")
+			jsonData, err := json.MarshalIndent(reports, "", "  ")
 			if err != nil {
-				fmt.Println("Error:", err)
 				log.WithContext(ctx).WithError(err).Error("Failed to format responses as JSON")
 				return err
 			}
-			fmt.Printf("Info from remote hosts: %s\n", string(jsonData))
+			fmt.Println(string(jsonData))
+		case "yaml":
+			// every host's report is a document of the YAML stream
+			for _, out := range outs {
+				fmt.Printf("---\n%s", out)
+			}
 		}
 	}
 	return nil
 }
 
-func printHostInfo(info hostInfo) {
+func printHostInfo(info *nodeinfo.Host) {
 	table := tablewriter.NewWriter(os.Stdout)
 	table.SetHeader([]string{"", ""})
 	table.SetColumnColor(
@@ -390,134 +502,14 @@ func printHostInfo(info hostInfo) {
 	})
 	table.Append([]string{
 		"OS",
-		info.OS,
+		fmt.Sprintf("%s/%s, %d CPUs", info.OS, info.Arch, info.CPUs),
 	})
 	table.Render()
 }
 
-func calcFileHash(filename string) (string, error) {
-	file, err := os.Open(filename)
-	if err != nil {
-		return "", err
-	}
-	defer file.Close()
-
-	hash := sha256.New()
-	if _, err := io.Copy(hash, file); err != nil {
-		return "", err
-	}
-
-	hashInBytes := hash.Sum(nil)[:20]
-	return hex.EncodeToString(hashInBytes), nil
-}
-
-func getPastelProcessesInfo(procNames *map[string]bool, procNamesShort *map[string]bool) []processInfo {
-	pids := sigar.ProcList{}
-	pids.Get()
-
-	var procInfo []processInfo
-
-	for _, pid := range pids.List {
-		state := sigar.ProcState{}
-		if err := state.Get(pid); err != nil {
-			continue
-		}
-		found := false
-		if _, found = (*procNames)[state.Name]; !found {
-			if _, found = (*procNamesShort)[state.Name]; !found {
-				continue
-			}
-		}
-
-		//fmt.Printf("%s\n", state.Name)
-
-		var dir, file, vmem, rmem, stime, rtime, cpup string
-		var pasteldArgs []string
-
-		if strings.HasPrefix(state.Name, "python") ||
-			strings.HasPrefix(state.Name, "start_dd_img_se") ||
-			strings.HasPrefix(state.Name, "pasteld") {
-			args := sigar.ProcArgs{}
-			args.Get(pid)
-			if strings.HasPrefix(state.Name, "pasteld") {
-				pasteldArgs = args.List
-				goto foundDDorISorPD
-			}
-			for _, arg := range args.List {
-				if strings.Contains(arg, "dupe_detection_server.py") ||
-					strings.Contains(arg, "start_dd_img_server.sh") {
-					dir, file = filepath.Split(arg)
-					goto foundDDorISorPD
-				}
-			}
-			continue
-		}
-
-	foundDDorISorPD:
-		if len(dir) == 0 && len(file) == 0 {
-			file = state.Name
-			exe := sigar.ProcExe{}
-			if err := exe.Get(pid); err == nil {
-				dir, file = filepath.Split(exe.Name)
-			}
-		}
-
-		mem := sigar.ProcMem{}
-		if err := mem.Get(pid); err == nil {
-			vmem = strconv.Itoa(int(mem.Size / 1024))
-			rmem = strconv.Itoa(int(mem.Resident / 1024))
-		}
-		time := sigar.ProcTime{}
-		if err := time.Get(pid); err == nil {
-			stime = time.FormatStartTime()
-			rtime = time.FormatTotal()
-		}
-		cpu := sigar.ProcCpu{}
-		if err := cpu.Get(pid); err == nil {
-			cpup = strconv.Itoa(int(cpu.Percent))
-		}
-
-		hash, err := calcFileHash(dir + file)
-		if err != nil {
-			log.WithContext(context.Background()).WithError(err).Errorf("Failed to calculate hash of %s", dir+file)
-		}
-
-		var version = ""
-		//if file != "dupe_detection_server.py" && file != "start_dd_img_server.sh" {
-		//	version, err := RunCMD(dir+file, "--version")
-		//	if err != nil {
-		//		log.WithContext(context.Background()).WithError(err).Errorf("Failed to get version of %s", dir+file)
-		//	}
-		//	if file == "pasteld" || file == "pastel-cli" {
-		//		lines := strings.SplitN(version, "\n", 2)
-		//		// Extract the first line
-		//		if len(lines) > 0 {
-		//			version = lines[0]
-		//		} else {
-		//			version = ""
-		//		}
-		//	}
-		//}
-
-		procInfo = append(procInfo, processInfo{
-			Pid:       strconv.Itoa(pid),
-			Process:   file,
-			Path:      dir,
-			Virtmem:   vmem,
-			Rmem:      rmem,
-			Starttime: stime,
-			Runtime:   rtime,
-			CPU:       cpup,
-			Args:      pasteldArgs,
-			Hash:      hash,
-			Version:   version,
-		})
-	}
-	return procInfo
-}
-func printProcessInfo(info []processInfo) {
+func printComponentsInfo(components []nodeinfo.Component) {
 	table := tablewriter.NewWriter(os.Stdout)
-	table.SetHeader([]string{"Process", "Pid", "CPU%", "VirtMem", "RMem", "StartTime", "RunTime", "Path", "Version", "Hash"})
+	table.SetHeader([]string{"Component", "Pid", "CPU%", "RMem", "StartTime", "Version", "Update", "Binary", "SHA256"})
 	table.SetColumnColor(
 		tablewriter.Colors{tablewriter.Bold, tablewriter.FgHiRedColor},
 		tablewriter.Colors{tablewriter.Bold, tablewriter.FgBlackColor},
@@ -528,54 +520,41 @@ func printProcessInfo(info []processInfo) {
 		tablewriter.Colors{tablewriter.Bold, tablewriter.FgBlackColor},
 		tablewriter.Colors{tablewriter.Bold, tablewriter.FgBlackColor},
 		tablewriter.Colors{tablewriter.Bold, tablewriter.FgBlackColor},
-		tablewriter.Colors{tablewriter.Bold, tablewriter.FgBlackColor},
 	)
-	for _, process := range info {
+	for _, component := range components {
+		pid, cpu, rmem, started := "-", "", "", ""
+		if component.Process != nil {
+			pid = strconv.Itoa(component.Process.Pid)
+			cpu = strconv.Itoa(int(component.Process.CPUPercent))
+			rmem = formatMemory(component.Process.Resident)
+			if !component.Process.StartedAt.IsZero() {
+				started = component.Process.StartedAt.Local().Format(time.DateTime)
+			}
+		}
+		update := ""
+		if component.UpdateAvailable != nil {
+			update = strconv.FormatBool(*component.UpdateAvailable)
+		}
+		hash := component.SHA256
+		if len(hash) > 16 {
+			hash = hash[:16]
+		}
 		table.Append([]string{
-			process.Process,
-			process.Pid,
-			process.CPU,
-			process.Virtmem,
-			process.Rmem,
-			process.Starttime,
-			process.Runtime,
-			process.Path,
-			process.Version,
-			process.Hash,
+			component.Name,
+			pid,
+			cpu,
+			rmem,
+			started,
+			component.Version,
+			update,
+			component.Binary,
+			hash,
 		})
 	}
 	table.Render()
 }
 
-func getMemoryInfo() []memoryInfo {
-	mem := sigar.Mem{}
-	mem.Get()
-
-	swap := sigar.Swap{}
-	swap.Get()
-
-	return []memoryInfo{
-		{
-			Memory: "RAM",
-			Total:  formatMemory(mem.Total),
-			Used:   formatMemory(mem.Used),
-			Free:   formatMemory(mem.Free),
-		},
-		{
-			Memory: "-/+ buffers/cache",
-			Total:  "",
-			Used:   formatMemory(mem.ActualUsed),
-			Free:   formatMemory(mem.ActualFree),
-		},
-		{
-			Memory: "Swap",
-			Total:  formatMemory(swap.Total),
-			Used:   formatMemory(swap.Used),
-			Free:   formatMemory(swap.Free),
-		},
-	}
-}
-func printMemoryInfo(info []memoryInfo) {
+func printMemoryInfo(info *nodeinfo.Host) {
 	table := tablewriter.NewWriter(os.Stdout)
 	table.SetHeader([]string{"Memory", "Total", "Used", "Free"})
 	table.SetColumnColor(
@@ -584,46 +563,13 @@ func printMemoryInfo(info []memoryInfo) {
 		tablewriter.Colors{tablewriter.Bold, tablewriter.FgWhiteColor},
 		tablewriter.Colors{tablewriter.Bold, tablewriter.FgWhiteColor},
 	)
-	for _, memInfo := range info {
-		table.Append([]string{
-			memInfo.Memory,
-			memInfo.Total,
-			memInfo.Used,
-			memInfo.Free,
-		})
-	}
+	table.Append([]string{"RAM", formatMemory(info.Memory.Total), formatMemory(info.Memory.Used), formatMemory(info.Memory.Free)})
+	table.Append([]string{"-/+ buffers/cache", "", formatMemory(info.Memory.Total - info.Memory.Available), formatMemory(info.Memory.Available)})
+	table.Append([]string{"Swap", formatMemory(info.Swap.Total), formatMemory(info.Swap.Used), formatMemory(info.Swap.Free)})
 	table.Render()
 }
 
-func getFSInfo() []filesystemInfo {
-	fsList := sigar.FileSystemList{}
-	fsList.Get()
-
-	var fsInfo []filesystemInfo
-	for _, fs := range fsList.List {
-
-		if strings.HasPrefix(fs.DevName, "/dev/loop") ||
-			!strings.HasPrefix(fs.DevName, "/dev/") {
-			continue
-		}
-
-		dirName := fs.DirName
-
-		usage := sigar.FileSystemUsage{}
-		usage.Get(dirName)
-
-		fsInfo = append(fsInfo, filesystemInfo{
-			Filesystem: fs.DevName,
-			Size:       formatSize(usage.Total),
-			Used:       formatSize(usage.Used),
-			Avail:      formatSize(usage.Avail),
-			Use:        sigar.FormatPercent(usage.UsePercent()),
-			Mounted:    dirName,
-		})
-	}
-	return fsInfo
-}
-func printFSInfo(info []filesystemInfo) {
+func printFSInfo(info []nodeinfo.Filesystem) {
 	table := tablewriter.NewWriter(os.Stdout)
 	table.SetHeader([]string{"Filesystem", "Size", "Used", "Avail", "Use%", "Mounted on"})
 	table.SetColumnColor(
@@ -636,18 +582,18 @@ func printFSInfo(info []filesystemInfo) {
 	)
 	for _, fs := range info {
 		table.Append([]string{
-			fs.Filesystem,
-			fs.Size,
-			fs.Used,
-			fs.Avail,
-			fs.Use,
-			fs.Mounted,
+			fs.Device,
+			sigar.FormatSize(fs.Total),
+			sigar.FormatSize(fs.Used),
+			sigar.FormatSize(fs.Available),
+			sigar.FormatPercent(fs.UsedPercent),
+			fs.MountPoint,
 		})
 	}
 	table.Render()
 }
 
-func printPastelInfo(info pastelInfo) {
+func printPastelInfo(info *nodeinfo.Pasteld) {
 	table := tablewriter.NewWriter(os.Stdout)
 	table.SetRowLine(true)
 	table.SetHeader([]string{"", ""})
@@ -655,27 +601,20 @@ func printPastelInfo(info pastelInfo) {
 		tablewriter.Colors{tablewriter.Bold, tablewriter.FgHiGreenColor},
 		tablewriter.Colors{tablewriter.Bold, tablewriter.FgWhiteColor},
 	)
-
-	cmdArgs := ""
-	if len(info.Args) > 0 {
-		for _, arg := range info.Args[1:] {
-			cmdArgs = fmt.Sprintf("%s\t%s\n", cmdArgs, arg)
-		}
+	table.Append([]string{"Network", info.Network})
+	table.Append([]string{"Working Directory", info.WorkingDir})
+	table.Append([]string{"Installation Directory", info.ExecDir})
+	if info.Chain != nil {
+		table.Append([]string{"Version", strconv.Itoa(info.Chain.Version)})
+		table.Append([]string{"Blocks", strconv.Itoa(info.Chain.Blocks)})
+		table.Append([]string{"Connections", strconv.Itoa(info.Chain.Connections)})
+		table.Append([]string{"Balance", strconv.FormatFloat(info.Chain.Balance, 'f', -1, 64)})
+	} else {
+		table.Append([]string{"Blockchain", "pasteld doesn't answer"})
+	}
+	if mn := info.Masternode; mn != nil {
+		table.Append([]string{"Masternode", fmt.Sprintf("%s %s %s", mn.Alias, mn.Outpoint, mn.Status)})
+		table.Append([]string{"Masternode address", mn.ExtAddress})
 	}
-	table.Append([]string{
-		"Command line arguments",
-		cmdArgs,
-	})
-	table.Append([]string{
-		"Working Directory",
-		info.WorkingDir,
-	})
-	table.Append([]string{
-		"Installation Directory",
-		info.ExecDir,
-	})
 	table.Render()
-	fmt.Printf("Blockchain info:\n%s\n", info.GetInfo)
-	fmt.Printf("Masternode status:\n%s\n", info.MNStatus)
-	fmt.Printf("Masternode config:\n%s\n", info.MNConfig)
 }
@@ -15,6 +15,7 @@ import (
 	"github.com/pastelnetwork/pastelup/common/log"
 	"github.com/pastelnetwork/pastelup/configs"
 	"github.com/pastelnetwork/pastelup/constants"
+	"github.com/pastelnetwork/pastelup/utils"
 )
 
 type initCommand uint8
@@ -92,6 +93,7 @@ func setupInitSubCommand(config *configs.Config,
 
 		cli.NewFlag("pkey", &config.MasterNodePrivateKey).
 			SetUsage(yellow("Optional, Masternode private key, if omitted, new masternode private key will be created")),
+		secretFileFlag("pkey", &config.MasterNodePrivateKeyFile),
 
 		cli.NewFlag("txid", &config.MasterNodeTxID).
 			SetUsage(yellow("Optional, collateral payment txid, transaction id of 5M collateral MN payment")),
@@ -107,6 +109,7 @@ func setupInitSubCommand(config *configs.Config,
 			SetUsage(yellow("Optional, pastelid of the Masternode. If omitted, new pastelid will be created and registered")),
 		cli.NewFlag("passphrase", &config.MasterNodePassPhrase).
 			SetUsage(yellow("Optional, passphrase to pastelid private key. If omitted, user will be asked interactively")),
+		secretFileFlag("passphrase", &config.PassphraseFile),
 
 		cli.NewFlag("rpc-ip", &config.MasterNodeRPCIP).
 			SetUsage(yellow("Optional, SuperNode IP address. If omitted, value passed to --ip will be used")),
@@ -306,6 +309,7 @@ func runInitRemoteSuperNodeSubCommand(ctx context.Context, config *configs.Confi
 	}
 
 	startOptions := ""
+	var secrets remoteSecrets
 
 	if len(config.MasterNodeName) > 0 {
 		startOptions = fmt.Sprintf("--name=%s", config.MasterNodeName)
@@ -316,7 +320,7 @@ func runInitRemoteSuperNodeSubCommand(ctx context.Context, config *configs.Confi
 	}
 
 	if len(config.MasterNodePrivateKey) > 0 {
-		startOptions = fmt.Sprintf("%s --pkey=%s", startOptions, config.MasterNodePrivateKey)
+		startOptions += secrets.option("pkey", config.MasterNodePrivateKey)
 	}
 
 	if config.CreateNewMasterNodeConf {
@@ -342,7 +346,7 @@ func runInitRemoteSuperNodeSubCommand(ctx context.Context, config *configs.Confi
 	}
 
 	if len(config.MasterNodePassPhrase) > 0 {
-		startOptions = fmt.Sprintf("%s --passphrase=%s", startOptions, config.MasterNodePassPhrase)
+		startOptions += secrets.option("passphrase", config.MasterNodePassPhrase)
 	}
 
 	if config.MasterNodePort > 0 {
@@ -380,8 +384,9 @@ func runInitRemoteSuperNodeSubCommand(ctx context.Context, config *configs.Confi
 	if len(config.WorkingDir) > 0 {
 		startOptions = fmt.Sprintf("%s --work-dir=%s", startOptions, config.WorkingDir)
 	}
+	startOptions += instanceOptions(config, false)
 
-	initSuperNodeCmd := fmt.Sprintf("%s init supernode %s", constants.RemotePastelupPath, startOptions)
+	initSuperNodeCmd := secrets.wrap(fmt.Sprintf("%s init supernode %s", constants.RemotePastelupPath, startOptions))
 	if _, err := executeRemoteCommands(ctx, config, []string{initSuperNodeCmd}, false, false); err != nil {
 		log.WithContext(ctx).WithError(err).Error("Failed to init remote Supernode services")
 		return err
@@ -411,8 +416,6 @@ func createOrUpdateMasternodeConf(ctx context.Context, config *configs.Config) e
 	} else {
 		conf = make(map[string]masterNodeConf)
 	}
-	log.WithContext(ctx).Infof("CONFIG: %+v", config)
-
 	conf[config.MasterNodeName] = masterNodeConf{
 		MnAddress:  config.NodeExtIP + ":" + fmt.Sprintf("%d", config.MasterNodePort),
 		MnPrivKey:  config.MasterNodePrivateKey,
@@ -449,7 +452,7 @@ func writeMasterNodeConfFile(ctx context.Context, config *configs.Config, conf m
 		return err
 	}
 
-	if err := os.WriteFile(masternodeConfPath, confData, 0644); err != nil {
+	if err := utils.WriteSecretFile(masternodeConfPath, confData); err != nil {
 		log.WithContext(ctx).WithError(err).Error("Failed to create and write new masternode.conf file")
 		return err
 	}
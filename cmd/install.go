@@ -1,12 +1,10 @@
 package cmd
 
 import (
-	"archive/tar"
-	"bytes"
-	"compress/gzip"
 	"context"
 	"fmt"
 	"io"
+	"io/fs"
 	"os"
 	"os/exec"
 	"path"
@@ -16,13 +14,13 @@ import (
 	"strings"
 	"time"
 
-	"github.com/klauspost/compress/zstd"
 	"github.com/pastelnetwork/pastelup/common/cli"
 	"github.com/pastelnetwork/pastelup/common/errors"
 	"github.com/pastelnetwork/pastelup/common/log"
 	"github.com/pastelnetwork/pastelup/common/sys"
 	"github.com/pastelnetwork/pastelup/configs"
 	"github.com/pastelnetwork/pastelup/constants"
+	"github.com/pastelnetwork/pastelup/services/snapshot"
 	"github.com/pastelnetwork/pastelup/utils"
 )
 
@@ -115,6 +113,8 @@ func setupSubCommand(config *configs.Config,
 			SetUsage(green("Optional, Force to overwrite config files and re-download ZKSnark parameters")),
 		cli.NewFlag("regen-rpc", &config.RegenRPC).
 			SetUsage(green("Optional, regenerate the random rpc user, password and chosen port. This will happen automatically if not defined already in your pastel.conf file")),
+		cli.NewFlag("rpc-cookie", &config.RPCCookie).
+			SetUsage(green("Optional, use cookie authentication of pasteld RPC instead of rpcuser and rpcpassword in pastel.conf")),
 		cli.NewFlag("ignore-dependencies", &flagIgnoreDependencies).
 			SetUsage(green("Optional, ignore checking dependencies and continue installation even if dependencies are not met")),
 	}
@@ -128,6 +128,10 @@ func setupSubCommand(config *configs.Config,
 			SetUsage(green("Optional, type of snapshot archive - can be \"tar.zst\" or \"tar.gz\". Ignored if \"snapshot-name\" is specified")).SetValue("tar.zst"),
 		cli.NewFlag("snapshot-name", &config.SnapshotName).SetAliases("sn").
 			SetUsage(green("Optional, Set the specific snapshot name to install with")),
+		cli.NewFlag("snapshot-height", &config.SnapshotHeight).
+			SetUsage(green("Optional, install with the snapshot at this block height from the snapshot index of the network, install fails if it can't be installed")),
+		cli.NewFlag("snapshot-url", &config.SnapshotURL).
+			SetUsage(green("Optional, `URL` of the snapshot archive (.tar.zst or .tar.gz), index.json or the base of the snapshot indexes of the networks, install fails if it can't be installed")),
 	}
 
 	pastelFlags := []*cli.Flag{
@@ -142,6 +146,7 @@ func setupSubCommand(config *configs.Config,
 	userFlags := []*cli.Flag{
 		cli.NewFlag("user-pw", &config.UserPw).
 			SetUsage(green("Optional, password of current sudo user - so no sudo password request is prompted")),
+		secretFileFlag("user-pw", &config.UserPwFile),
 	}
 
 	var dirsFlags []*cli.Flag
@@ -171,6 +176,7 @@ func setupSubCommand(config *configs.Config,
 			SetUsage(yellow("Optional, SSH user")),
 		cli.NewFlag("ssh-user-pw", &config.UserPw).
 			SetUsage(yellow("Optional, password of remote user - so no sudo password request is prompted")),
+		secretFileFlag("ssh-user-pw", &config.UserPwFile),
 		cli.NewFlag("ssh-key", &config.RemoteSSHKey).
 			SetUsage(yellow("Optional, Path to SSH private key")),
 		cli.NewFlag("inventory", &config.InventoryFile).
@@ -211,6 +217,10 @@ func setupSubCommand(config *configs.Config,
 		commandFlags = append(commandFlags, ddServiceFlags...)
 	}
 
+	if installCommand != installDDService && installCommand != installDDImgServer {
+		commandFlags = append(commandFlags, portFlags(config)...)
+	}
+
 	subCommand := cli.NewCommand(commandName)
 	subCommand.SetUsage(cyan(commandMessage))
 	subCommand.AddFlags(commandFlags...)
@@ -494,6 +504,12 @@ func runRemoteInstall(ctx context.Context, config *configs.Config, tool string)
 	if len(config.SnapshotType) > 0 {
 		remoteOptions = fmt.Sprintf("%s --snapshot-archive-type=%s", remoteOptions, config.SnapshotType)
 	}
+	if config.SnapshotHeight > 0 {
+		remoteOptions = fmt.Sprintf("%s --snapshot-height=%d", remoteOptions, config.SnapshotHeight)
+	}
+	if len(config.SnapshotURL) > 0 {
+		remoteOptions = fmt.Sprintf("%s --snapshot-url=%s", remoteOptions, config.SnapshotURL)
+	}
 
 	if config.Network == constants.NetworkTestnet {
 		remoteOptions = fmt.Sprintf("%s -n=testnet", remoteOptions)
@@ -505,11 +521,13 @@ func runRemoteInstall(ctx context.Context, config *configs.Config, tool string)
 		remoteOptions = fmt.Sprintf("%s -n=devnet", remoteOptions)
 	}
 
+	var secrets remoteSecrets
 	if len(config.UserPw) > 0 {
-		remoteOptions = fmt.Sprintf("%s --user-pw=%s", remoteOptions, config.UserPw)
+		remoteOptions += secrets.option("user-pw", config.UserPw)
 	}
+	remoteOptions += instanceOptions(config, true)
 
-	installSuperNodeCmd := fmt.Sprintf("yes Y | %s install %s", constants.RemotePastelupPath, remoteOptions)
+	installSuperNodeCmd := secrets.wrap(fmt.Sprintf("yes Y | %s install %s", constants.RemotePastelupPath, remoteOptions))
 	if _, err := executeRemoteCommandsWithInventory(ctx, config, []string{installSuperNodeCmd}, false, false); err != nil {
 		log.WithContext(ctx).WithError(err).Errorf("Failed to install remote %s", tool)
 	}
@@ -534,7 +552,7 @@ func runServicesInstall(ctx context.Context, config *configs.Config, installComm
 		(installCommand == constants.SuperNode && withDependencies) {
 
 		// need to stop pasteld else we'll get a text file busy error
-		if CheckProcessRunning(constants.PastelD) {
+		if CheckProcessRunning(ctx, config, constants.PastelD) {
 			_, err := GetPastelInfo(ctx, config) // this needed to check if pasteld is running in the same mode
 			if err == nil {
 				log.WithContext(ctx).Infof("pasteld is already running")
@@ -544,18 +562,18 @@ func runServicesInstall(ctx context.Context, config *configs.Config, installComm
 					return fmt.Errorf("user terminated installation")
 				}
 
-				sm, err := NewServiceManager(utils.GetOS(), config.Configurer.DefaultHomeDir())
+				sm, err := NewServiceManager(utils.GetOS(), config.Configurer.DefaultHomeDir(), config.Instance)
 				if err == nil {
 					_ = sm.StopService(ctx, config, constants.PastelD)
 				}
-				if CheckProcessRunning(constants.PastelD) {
+				if CheckProcessRunning(ctx, config, constants.PastelD) {
 					if err = ParsePastelConf(ctx, config); err != nil {
 						return err
 					}
 					err = stopPatelCLI(ctx, config)
 					if err != nil {
 						log.WithContext(ctx).Warnf("Encountered error trying to stop pasteld %v, will try to kill it", err)
-						_ = KillProcess(ctx, constants.PastelD)
+						_ = KillProcess(ctx, config, constants.PastelD)
 					}
 				}
 				log.WithContext(ctx).Info("pasteld stopped or was not running")
@@ -585,9 +603,15 @@ func runServicesInstall(ctx context.Context, config *configs.Config, installComm
 			return err
 		}
 
-		if config.UseSnapshot {
-			log.WithContext(ctx).Info("using latest snapshot..")
-			if err := downloadLatestSnapshot(ctx, *config, installCommand); err != nil {
+		if config.UseSnapshot || config.SnapshotHeight > 0 || config.SnapshotURL != "" {
+			// the node syncs from scratch if the latest snapshot fails, but the requested one must be installed
+			requested := config.SnapshotHeight > 0 || config.SnapshotURL != "" || config.SnapshotName != ""
+			log.WithContext(ctx).Info("using snapshot..")
+			if err := installSnapshot(ctx, *config, installCommand); err != nil {
+				if requested {
+					log.WithContext(ctx).WithError(err).Error("Failed to install the requested snapshot")
+					return err
+				}
 				log.WithContext(ctx).WithError(err).Error("error configuring network with latest snapshot, proceeding without snapshot")
 			}
 		}
@@ -690,12 +714,9 @@ func installRaptorQService(ctx context.Context, config *configs.Config) error {
 	toolPath := constants.PastelRQServiceExecName[utils.GetOS()]
 	rqWorkDirPath := filepath.Join(config.WorkingDir, constants.RQServiceDir)
 
-	toolConfig, err := utils.GetServiceConfig(string(constants.RQService), configs.RQServiceDefaultConfig, &configs.RQServiceConfig{
-		HostName: "127.0.0.1",
-		Port:     constants.RQServiceDefaultPort,
-	})
+	toolConfig, err := GetRQServiceConfigs(config)
 	if err != nil {
-		return errors.Errorf("failed to get rqservice config: %v", err)
+		return err
 	}
 
 	if err = downloadComponents(ctx, config, constants.RQService, ""); err != nil {
@@ -860,33 +881,9 @@ func installWalletNodeService(ctx context.Context, config *configs.Config) error
 
 	wnPath := constants.WalletNodeExecName[utils.GetOS()]
 	bridgePath := constants.BridgeExecName[utils.GetOS()]
-	burnAddress := constants.BurnAddressMainnet
-	if config.Network == constants.NetworkTestnet {
-		burnAddress = constants.BurnAddressTestnet
-	} else if config.Network == constants.NetworkDevnet {
-		burnAddress = constants.BurnAddressDevnet
-	} else if config.Network == constants.NetworkRegTest {
-		burnAddress = constants.BurnAddressTestnet
-	}
-	wnTempDirPath := filepath.Join(config.WorkingDir, constants.TempDir)
-	rqWorkDirPath := filepath.Join(config.WorkingDir, constants.RQServiceDir)
-	wnConfig, err := utils.GetServiceConfig(string(constants.WalletNode), configs.WalletDefaultConfig, &configs.WalletNodeConfig{
-		LogLevel:      constants.WalletNodeDefaultLogLevel,
-		LogFilePath:   config.Configurer.GetWalletNodeLogFile(config.WorkingDir),
-		LogCompress:   constants.LogConfigDefaultCompress,
-		LogMaxSizeMB:  constants.LogConfigDefaultMaxSizeMB,
-		LogMaxAgeDays: constants.LogConfigDefaultMaxAgeDays,
-		LogMaxBackups: constants.LogConfigDefaultMaxBackups,
-		WNTempDir:     wnTempDirPath,
-		WNWorkDir:     config.WorkingDir,
-		RQDir:         rqWorkDirPath,
-		BurnAddress:   burnAddress,
-		RaptorqPort:   constants.RQServiceDefaultPort,
-		BridgePort:    constants.BridgeServiceDefaultPort,
-		BridgeOn:      installBridge,
-	})
+	wnConfig, err := GetWNConfigs(config, installBridge)
 	if err != nil {
-		return errors.Errorf("failed to get walletnode config: %v", err)
+		return err
 	}
 
 	if err = downloadComponents(ctx, config, constants.WalletNode, ""); err != nil {
@@ -895,24 +892,9 @@ func installWalletNodeService(ctx context.Context, config *configs.Config) error
 	}
 
 	if installBridge {
-		bridgeConfig, err := utils.GetServiceConfig(string(constants.Bridge),
-			configs.BridgeDefaultConfig, &configs.BridgeConfig{
-				LogLevel:           constants.WalletNodeDefaultLogLevel,
-				LogFilePath:        config.Configurer.GetBridgeLogFile(config.WorkingDir),
-				LogCompress:        constants.LogConfigDefaultCompress,
-				LogMaxSizeMB:       constants.LogConfigDefaultMaxSizeMB,
-				LogMaxAgeDays:      constants.LogConfigDefaultMaxAgeDays,
-				LogMaxBackups:      constants.LogConfigDefaultMaxBackups,
-				WNTempDir:          wnTempDirPath,
-				WNWorkDir:          config.WorkingDir,
-				BurnAddress:        burnAddress,
-				ConnRefreshTimeout: 300,
-				Connections:        10,
-				ListenAddress:      "127.0.0.1",
-				Port:               constants.BridgeServiceDefaultPort,
-			})
+		bridgeConfig, err := GetBridgeConfigs(config)
 		if err != nil {
-			return errors.Errorf("failed to get bridge config: %v", err)
+			return err
 		}
 
 		if err = downloadComponents(ctx, config, constants.Bridge, ""); err != nil {
@@ -1268,6 +1250,15 @@ func makeExecutable(ctx context.Context, dirPath string, fileName string) error
 func setupComponentConfigFile(ctx context.Context, config *configs.Config,
 	toolName string, configFilePath string, toolConfig string) error {
 
+	// On update new defaults are merged into the existing config
+	if config.OpMode == "update" {
+		if err := migrateComponentConfig(ctx, config, constants.ToolType(toolName), configFilePath, toolConfig); err != nil {
+			log.WithContext(ctx).WithError(err).Errorf("Failed to migrate %s config", toolName)
+			return err
+		}
+		return nil
+	}
+
 	// Ignore if not in "install" mode
 	if config.OpMode != "install" {
 		return nil
@@ -1285,6 +1276,19 @@ func setupComponentConfigFile(ctx context.Context, config *configs.Config,
 		return err
 	}
 
+	// PastelID passphrase is stored in these configs later, so they are readable by the owner only
+	switch constants.ToolType(toolName) {
+	case constants.SuperNode, constants.Hermes, constants.Bridge:
+		if err = os.Chmod(configFilePath, utils.SecretFilePerm); err != nil {
+			log.WithContext(ctx).WithError(err).Errorf("Failed to restrict permissions of %s", configFilePath)
+			return err
+		}
+	}
+
+	if err = saveConfigTemplateBase(config, constants.ToolType(toolName), configFilePath, toolConfig); err != nil {
+		log.WithContext(ctx).WithError(err).Warnf("Failed to save default %s config", toolName)
+	}
+
 	return nil
 }
 
@@ -1300,15 +1304,22 @@ func setupBasePastelWorkingEnvironment(ctx context.Context, config *configs.Conf
 		return err
 	}
 
-	// create pastel.conf file
+	if config.Instance != "" && resolvePorts(config).IsDefault() {
+		log.WithContext(ctx).Warnf("Instance %s uses default ports of %s, set --port-offset if another %s node runs on this host",
+			config.Instance, config.Network, config.Network)
+	}
+	if err := saveInstanceState(config); err != nil {
+		log.WithContext(ctx).WithError(err).Errorf("Failed to save instance settings")
+		return err
+	}
+
+	// create or update pastel.conf file
 	pastelConfigPath := filepath.Join(config.WorkingDir, constants.PastelConfName)
-	err := utils.CreateFile(ctx, pastelConfigPath, config.Force)
-	if err != nil {
-		log.WithContext(ctx).WithError(err).Errorf("Failed to create %s", pastelConfigPath)
-		return fmt.Errorf("failed to create %s - %v", pastelConfigPath, err)
+	if utils.CheckFileExist(pastelConfigPath) && !config.Force {
+		log.WithContext(ctx).Errorf("%s already exists, use --force to update it", pastelConfigPath)
+		return fmt.Errorf("failed to create %s - %v", pastelConfigPath, fs.ErrExist)
 	}
-	// write to file
-	if err = updatePastelConfigFile(ctx, pastelConfigPath, config); err != nil {
+	if err := updatePastelConfigFile(ctx, pastelConfigPath, config); err != nil {
 		log.WithContext(ctx).WithError(err).Errorf("Failed to update %s", pastelConfigPath)
 		return fmt.Errorf("failed to update %s - %v", pastelConfigPath, err)
 	}
@@ -1334,55 +1345,66 @@ func getDefaultRPCParameters(config *configs.Config) {
 		portList := GetSNPortList(config)
 		config.RPCPort = portList[constants.NodeRPCPort]
 	}
+	if config.RPCCookie {
+		config.RPCUser, config.RPCPwd = "", ""
+		return
+	}
 	if config.RPCUser == "" || config.RegenRPC {
 		config.RPCUser = utils.GenerateRandomString(8)
 	}
 	if config.RPCPwd == "" || config.RegenRPC {
 		config.RPCPwd = utils.GenerateRandomString(15)
 	}
+	log.AddSecret(config.RPCPwd)
 }
 
+// updatePastelConfigFile creates pastel.conf or updates existing one in place, keeping operator's comments
+// and options which are not managed by pastelup
 func updatePastelConfigFile(ctx context.Context, filePath string, config *configs.Config) error {
-	cfgBuffer := bytes.Buffer{}
-
-	// Populate pastel.conf line-by-line to file.
-	cfgBuffer.WriteString("server=1\n")                                     // creates server line
-	cfgBuffer.WriteString("listen=1\n\n")                                   // creates server line
-	cfgBuffer.WriteString("rpcuser=" + config.RPCUser + "\n")               // creates rpcuser line
-	cfgBuffer.WriteString("rpcpassword=" + config.RPCPwd + "\n")            // creates rpcpassword line
-	cfgBuffer.WriteString("rpcport=" + strconv.Itoa(config.RPCPort) + "\n") // creates rpcport line
-	cfgBuffer.WriteString("maxmempool=20000\n")
-	cfgBuffer.WriteString("rpcworkqueue=512\n")
-
-	if config.Network == constants.NetworkTestnet {
-		cfgBuffer.WriteString("testnet=1\n") // creates testnet line
-	}
-
-	if config.Network == constants.NetworkDevnet {
-		cfgBuffer.WriteString("devnet=1\n") // creates devnet line
+	pastelConf := utils.NewPastelConf()
+	if utils.CheckFileExist(filePath) {
+		var err error
+		if pastelConf, err = utils.LoadPastelConf(filePath); err != nil {
+			log.WithContext(ctx).WithError(err).Error("Error reading file")
+			return err
+		}
 	}
 
-	if config.Network == constants.NetworkRegTest {
-		cfgBuffer.WriteString("regtest=1\n") // creates regtest line
+	pastelConf.Set("server", "1")
+	pastelConf.Set("listen", "1")
+	setPastelConfRPCCredentials(pastelConf, config.RPCUser, config.RPCPwd)
+	pastelConf.Set("rpcport", strconv.Itoa(config.RPCPort))
+	if ports := resolvePorts(config); ports.Node != 0 || ports.Offset != 0 {
+		pastelConf.Set("port", strconv.Itoa(GetSNPortList(config)[constants.NodePort]))
 	}
+	pastelConf.SetDefault("maxmempool", "20000")
+	pastelConf.SetDefault("rpcworkqueue", "512")
+	pastelConf.SetNetwork(config.Network)
 
 	if config.Peers != "" {
 		nodes := strings.Split(config.Peers, ",")
 		for _, node := range nodes {
-			cfgBuffer.WriteString("addnode=" + node + "\n") // creates addnode line
+			pastelConf.Add("addnode", strings.TrimSpace(node))
 		}
 	}
 
 	if config.ExtraFlags != "" {
 		extraFlags := strings.Split(config.ExtraFlags, ",")
 		for _, flag := range extraFlags {
-			cfgBuffer.WriteString(flag + "\n")
+			key, value, found := strings.Cut(strings.TrimSpace(flag), "=")
+			if !found || key == "" {
+				return errors.Errorf("invalid extra flag %q, must be in the format - \"key=value\"", flag)
+			}
+			if utils.IsPastelConfMultiValueKey(key) {
+				pastelConf.Add(key, value)
+			} else {
+				pastelConf.Set(key, value)
+			}
 		}
 	}
 
 	// Save file changes.
-	err := os.WriteFile(filePath, cfgBuffer.Bytes(), 0644)
-	if err != nil {
+	if err := pastelConf.Save(filePath); err != nil {
 		log.WithContext(ctx).WithError(err).Error("Error saving file")
 		return errors.Errorf("failed to save file changes: %v", err)
 	}
@@ -1533,7 +1555,11 @@ func openPorts(ctx context.Context, config *configs.Config, portList []int) (err
 		portStr := fmt.Sprintf("%d", portList[k])
 		switch utils.GetOS() {
 		case constants.Linux:
-			out, err = RunSudoCMD(config, "ufw", "allow", portStr)
+			if config.Instance != "" {
+				out, err = RunSudoCMD(config, "ufw", "allow", portStr, "comment", constants.SystemdServicePrefix+config.Instance)
+			} else {
+				out, err = RunSudoCMD(config, "ufw", "allow", portStr)
+			}
 			/*		case constants.Windows:
 						out, err = RunCMD("netsh", "advfirewall", "firewall", "add", "rule", "name=TCP Port "+portStr, "dir=in", "action=allow", "protocol=TCP", "localport="+portStr)
 					case constants.Mac:
@@ -1559,7 +1585,7 @@ func increaseOpenFilesOnLinux(ctx context.Context, config *configs.Config) {
 
 	systemWideLimit := "fs.file-max = 100000"
 	userLimit := "* soft nofile 4096\n* hard nofile 65535"
-	defaultLimit := "DefaultLimitNOFILE=65536"
+	defaultLimit := fmt.Sprintf("DefaultLimitNOFILE=%d", configs.SystemdLimitNOFILE)
 
 	// Increase system-wide file descriptor limit
 	err := writeToFile(config, "/etc/sysctl.conf", systemWideLimit)
@@ -1626,64 +1652,61 @@ func writeToFile(config *configs.Config, filename, content string) error {
 	return err
 }
 
-func downloadLatestSnapshot(ctx context.Context, config configs.Config, installCommand constants.ToolType) error {
-
-	url := snapshotsBaseURL + config.Network + "/"
-
-	snapshotName := config.SnapshotName
-	var extension, snapshotURL string
-
-	if snapshotName == "" {
-		switch installCommand {
-		case constants.PastelD:
-			snapshotName = "snapshot-latest-mainnet"
-		case constants.WalletNode:
-			snapshotName = "snapshot-latest-mainnet-txind"
-		case constants.SuperNode:
-			snapshotName = "snapshot-latest-mainnet-explorer"
-		default:
-			return fmt.Errorf("unknown installation type: %s", installCommand)
-		}
-
-		extension = "." + config.SnapshotType
-		snapshotURL = url + snapshotName + extension
-	} else {
-		if strings.HasSuffix(snapshotName, ".zst") {
-			extension = ".tar.zst"
-		} else if strings.HasSuffix(snapshotName, ".gz") {
-			extension = ".tar.gz"
-		} else {
-			return errors.Errorf("extension is not supported")
-		}
-		snapshotURL = url + snapshotName
+// installSnapshot downloads the snapshot of the network and extracts it into the data directory as it is downloaded.
+// The chain of the node is replaced only when the whole snapshot is extracted and verified
+func installSnapshot(ctx context.Context, config configs.Config, installCommand constants.ToolType) error {
+	req := snapshot.Request{
+		BaseURL: snapshotsBaseURL,
+		Network: config.Network,
+		Format:  config.SnapshotType,
+		Height:  config.SnapshotHeight,
+		Name:    config.SnapshotName,
+		URL:     config.SnapshotURL,
+	}
+	switch installCommand {
+	case constants.PastelD:
+		req.Kind = snapshot.KindNode
+	case constants.WalletNode:
+		req.Kind = snapshot.KindTxIndex
+	case constants.SuperNode:
+		req.Kind = snapshot.KindExplorer
+	default:
+		return fmt.Errorf("unknown installation type: %s", installCommand)
 	}
 
-	log.WithContext(ctx).WithField("url", snapshotURL).Info("Downloading snapshot for " + installCommand)
-
-	tmpDir := os.TempDir()
-	tmpFilePath := filepath.Join(tmpDir, "latest_snapshot"+extension)
-
-	err := utils.DownloadFile(ctx, tmpFilePath, snapshotURL)
+	client := snapshot.NewClient()
+	source, err := snapshot.Resolve(ctx, client, req)
 	if err != nil {
-		return fmt.Errorf("error downloading file: %w", err)
+		return fmt.Errorf("unable to find snapshot: %w", err)
+	}
+	logger := log.WithContext(ctx).WithField("url", source.URL)
+	if source.Height > 0 {
+		logger = logger.WithField("height", source.Height)
+	}
+	if source.SHA256 == "" {
+		logger.Warn("snapshot has no published checksum, only its content is validated")
+	}
+	// chain files must not be replaced under running pasteld
+	if err = ensurePasteldExited(ctx, &config); err != nil {
+		return err
 	}
-	defer os.Remove(tmpFilePath)
-	log.WithContext(ctx).Info(fmt.Sprintf("snapshot downloaded successfully to %s", tmpFilePath))
 
-	// Clean up the working directory
-	err = cleanWorkingDir(config.WorkingDir)
+	logger.Info("Downloading snapshot for " + installCommand)
+	body, _, err := snapshot.Open(ctx, client, source.URL)
 	if err != nil {
-		return fmt.Errorf("failed to clean working directory: %w", err)
+		return fmt.Errorf("error downloading file: %w", err)
 	}
-	log.WithContext(ctx).Info(fmt.Sprintf("existing content has been cleared from wor-dir:%s", config.WorkingDir))
+	defer body.Close()
 
-	// Extract the downloaded file
-	err = extractTar(tmpFilePath, config.WorkingDir)
+	dataDir := getMasternodeConfPath(&config, config.WorkingDir, "")
+	counter := &utils.WriteCounter{Context: ctx}
+	err = snapshot.Install(io.TeeReader(body, counter), dataDir, source.Format, source.SHA256)
+	// the progress uses the same line
+	fmt.Println()
 	if err != nil {
-		return fmt.Errorf("failed to extract file: %w", err)
+		return fmt.Errorf("failed to install snapshot, existing chain is kept: %w", err)
 	}
-	log.WithContext(ctx).Info(fmt.Sprintf("snapshot has been extracted to wor-dir: %s", config.WorkingDir))
-
+	log.WithContext(ctx).Infof("snapshot has been installed to %s", dataDir)
 	return nil
 }
 
@@ -1821,76 +1844,3 @@ func installChocolatey(ctx context.Context) {
 		time.Sleep(5 * time.Second) // Wait before retrying
 	}
 }
-
-func cleanWorkingDir(dir string) error {
-	files := []string{"blocks", "chainstate", "db.log", "debug.log", "fee_estimates.dat", "messages.dat", "mncache.dat", "mnpayments.dat", "netfulfilled.dat", "peers.dat", "tickets"}
-	for _, file := range files {
-		path := filepath.Join(dir, file)
-		if err := os.RemoveAll(path); err != nil {
-			return err
-		}
-	}
-	return nil
-}
-
-func extractTar(archivePath, dest string) error {
-	file, err := os.Open(archivePath)
-	if err != nil {
-		return err
-	}
-	defer file.Close()
-
-	var reader io.Reader
-	if strings.HasSuffix(archivePath, ".tar.gz") {
-		// Gzip decompression
-		gzr, err := gzip.NewReader(file)
-		if err != nil {
-			return err
-		}
-		defer gzr.Close()
-		reader = gzr
-	} else if strings.HasSuffix(archivePath, ".tar.zst") {
-		// Zstandard decompression
-		zr, err := zstd.NewReader(file)
-		if err != nil {
-			return err
-		}
-		defer zr.Close()
-		reader = zr
-	} else {
-		return fmt.Errorf("unsupported archive format: %s", archivePath)
-	}
-
-	tarReader := tar.NewReader(reader)
-
-	for {
-		header, err := tarReader.Next()
-		if err == io.EOF {
-			break
-		}
-		if err != nil {
-			return err
-		}
-
-		path := filepath.Join(dest, header.Name)
-		switch header.Typeflag {
-		case tar.TypeDir:
-			if err := os.MkdirAll(path, 0755); err != nil {
-				return err
-			}
-		case tar.TypeReg:
-			outFile, err := os.Create(path)
-			if err != nil {
-				return err
-			}
-			if _, err := io.Copy(outFile, tarReader); err != nil {
-				outFile.Close()
-				return err
-			}
-			outFile.Close()
-		default:
-			return fmt.Errorf("unsupported file type %v in tar archive", header.Typeflag)
-		}
-	}
-	return nil
-}
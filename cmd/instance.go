@@ -0,0 +1,159 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+
+	"github.com/pastelnetwork/pastelup/common/cli"
+	"github.com/pastelnetwork/pastelup/configs"
+	"github.com/pastelnetwork/pastelup/constants"
+	"github.com/pastelnetwork/pastelup/utils"
+	"gopkg.in/yaml.v2"
+)
+
+const instanceStateFile = "instance.yaml"
+
+var instanceNameRe = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,31}$`)
+
+// instanceState is stored in the working directory, so all commands use the ports chosen at install
+type instanceState struct {
+	Instance string        `yaml:"instance,omitempty"`
+	Ports    configs.Ports `yaml:"ports,omitempty"`
+}
+
+func portFlags(config *configs.Config) []*cli.Flag {
+	return []*cli.Flag{
+		cli.NewFlag("port-offset", &config.Ports.Offset).
+			SetUsage(green("Optional, `offset` added to all default ports of the network, use it to run several instances on one host")),
+		cli.NewFlag("node-port", &config.Ports.Node).
+			SetUsage(green("Optional, P2P port of pasteld")),
+		cli.NewFlag("node-rpc-port", &config.Ports.NodeRPC).
+			SetUsage(green("Optional, RPC port of pasteld")),
+		cli.NewFlag("sn-port", &config.Ports.SuperNode).
+			SetUsage(green("Optional, gRPC port of supernode")),
+		cli.NewFlag("p2p-port", &config.Ports.P2P).
+			SetUsage(green("Optional, P2P port of supernode")),
+		cli.NewFlag("mdl-port", &config.Ports.MDL).
+			SetUsage(green("Optional, metadb port of supernode")),
+		cli.NewFlag("raft-port", &config.Ports.RAFT).
+			SetUsage(green("Optional, raft port of supernode")),
+		cli.NewFlag("rq-port", &config.Ports.RQService).
+			SetUsage(green("Optional, port of rq-service")),
+		cli.NewFlag("bridge-port", &config.Ports.Bridge).
+			SetUsage(green("Optional, port of bridge service")),
+		cli.NewFlag("wn-api-port", &config.Ports.WNAPI).
+			SetUsage(green("Optional, API port of walletnode")),
+	}
+}
+
+// applyInstance validates the instance name and moves default directories of the named instance to their own place
+func applyInstance(config *configs.Config) error {
+	if config.Instance == "" {
+		return nil
+	}
+	if !instanceNameRe.MatchString(config.Instance) {
+		return fmt.Errorf("invalid instance name %q, must be up to 32 lowercase letters, digits or '-'", config.Instance)
+	}
+	suffix := "-" + config.Instance
+	if config.WorkingDir == config.Configurer.DefaultWorkingDir() {
+		config.WorkingDir += suffix
+	}
+	if config.ArchiveDir == config.Configurer.DefaultArchiveDir() {
+		config.ArchiveDir += suffix
+	}
+	return nil
+}
+
+func instanceStatePath(config *configs.Config) string {
+	return filepath.Join(config.WorkingDir, constants.PastelupStateDir, instanceStateFile)
+}
+
+// loadInstanceState reads instance settings stored at install, missing file means the default instance
+func loadInstanceState(config *configs.Config) (instanceState, error) {
+	var state instanceState
+	data, err := os.ReadFile(instanceStatePath(config))
+	if os.IsNotExist(err) {
+		return state, nil
+	}
+	if err != nil {
+		return state, err
+	}
+	if err = yaml.Unmarshal(data, &state); err != nil {
+		return state, fmt.Errorf("failed to parse %s: %v", instanceStatePath(config), err)
+	}
+	return state, nil
+}
+
+// saveInstanceState stores instance name and custom ports in the working directory
+func saveInstanceState(config *configs.Config) error {
+	state := instanceState{Instance: config.Instance, Ports: resolvePorts(config)}
+	if state.Instance == "" && state.Ports.IsDefault() {
+		return nil
+	}
+	data, err := yaml.Marshal(state)
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(filepath.Dir(instanceStatePath(config)), 0700); err != nil {
+		return err
+	}
+	return utils.WriteFileAtomic(instanceStatePath(config), data, 0600)
+}
+
+// resolvePorts returns custom ports set by flags, not set ones are taken from the instance state
+func resolvePorts(config *configs.Config) configs.Ports {
+	state, _ := loadInstanceState(config)
+	return config.Ports.Merge(state.Ports)
+}
+
+func customPort(port, defaultPort, offset int) int {
+	if port != 0 {
+		return port
+	}
+	return defaultPort + offset
+}
+
+// rqServicePort returns port of rq-service of the instance
+func rqServicePort(config *configs.Config) int {
+	ports := resolvePorts(config)
+	return customPort(ports.RQService, constants.RQServiceDefaultPort, ports.Offset)
+}
+
+// bridgePort returns port of bridge service of the instance
+func bridgePort(config *configs.Config) int {
+	ports := resolvePorts(config)
+	return customPort(ports.Bridge, constants.BridgeServiceDefaultPort, ports.Offset)
+}
+
+// wnAPIPort returns API port of walletnode of the instance
+func wnAPIPort(config *configs.Config) int {
+	ports := resolvePorts(config)
+	return customPort(ports.WNAPI, constants.WalletNodeDefaultAPIPort, ports.Offset)
+}
+
+// instanceOptions returns flags to pass the instance to pastelup on the remote host
+func instanceOptions(config *configs.Config, withPorts bool) string {
+	var options string
+	if config.Instance != "" {
+		options += fmt.Sprintf(" --instance=%s", config.Instance)
+	}
+	if !withPorts {
+		return options
+	}
+	for _, p := range []struct {
+		name string
+		port int
+	}{
+		{"port-offset", config.Ports.Offset}, {"node-port", config.Ports.Node}, {"node-rpc-port", config.Ports.NodeRPC},
+		{"sn-port", config.Ports.SuperNode}, {"p2p-port", config.Ports.P2P}, {"mdl-port", config.Ports.MDL},
+		{"raft-port", config.Ports.RAFT}, {"rq-port", config.Ports.RQService}, {"bridge-port", config.Ports.Bridge},
+		{"wn-api-port", config.Ports.WNAPI},
+	} {
+		if p.port != 0 {
+			options += fmt.Sprintf(" --%s=%d", p.name, p.port)
+		}
+	}
+	return options
+}
@@ -131,7 +131,7 @@ func (i *Inventory) ReadAnsibleYamlInventory(path string) error {
 }
 
 // ExecuteCommands executes commands on all hosts from inventory
-func (i *Inventory) ExecuteCommands(ctx context.Context, config *configs.Config, commands []string, needOutput bool) ([][]byte, error) {
+func (i *Inventory) ExecuteCommands(ctx context.Context, config *configs.Config, commands []string, needOutput bool, files ...remoteFile) ([][]byte, error) {
 	var filters []string
 	if config.InventoryFilter != "" {
 		filters = strings.Split(config.InventoryFilter, ",")
@@ -175,7 +175,7 @@ func (i *Inventory) ExecuteCommands(ctx context.Context, config *configs.Config,
 			if config.RemotePort == 0 {
 				config.RemotePort = 22
 			}
-			out, err := executeRemoteCommands(ctx, config, commands, false, needOutput)
+			out, err := executeRemoteCommands(ctx, config, commands, false, needOutput, files...)
 			if err != nil {
 				log.WithContext(ctx).WithError(err).Errorf("Failed to execute command on remote host %s"+
 					" [IP:%s; Port:%d; User:%s; KeyFile:%s; ]",
@@ -188,7 +188,7 @@ func (i *Inventory) ExecuteCommands(ctx context.Context, config *configs.Config,
 }
 
 // ExecuteCommandsAsync executes commands on all hosts from inventory in parallel
-func (i *Inventory) ExecuteCommandsAsync(ctx context.Context, config *configs.Config, commands []string, needOutput bool) ([][]byte, error) {
+func (i *Inventory) ExecuteCommandsAsync(ctx context.Context, config *configs.Config, commands []string, needOutput bool, files ...remoteFile) ([][]byte, error) {
 	var filters []string
 	if config.InventoryFilter != "" {
 		filters = strings.Split(config.InventoryFilter, ",")
@@ -243,7 +243,7 @@ func (i *Inventory) ExecuteCommandsAsync(ctx context.Context, config *configs.Co
 					config.RemotePort = 22
 				}
 
-				out, err := executeRemoteCommands(ctx, config, commands, false, needOutput)
+				out, err := executeRemoteCommands(ctx, config, commands, false, needOutput, files...)
 				if err != nil {
 					log.WithContext(ctx).WithError(err).Errorf("Failed to execute command on remote host %s"+
 						" [IP:%s; Port:%d; User:%s; KeyFile:%s; ]",
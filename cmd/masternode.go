@@ -0,0 +1,474 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/pastelnetwork/pastelup/common/cli"
+	"github.com/pastelnetwork/pastelup/common/log"
+	"github.com/pastelnetwork/pastelup/common/sys"
+	"github.com/pastelnetwork/pastelup/configs"
+	"github.com/pastelnetwork/pastelup/constants"
+	"github.com/pastelnetwork/pastelup/services/pastelcore"
+	"github.com/pastelnetwork/pastelup/structure"
+)
+
+type masternodeCommand uint8
+
+const (
+	masternodeRegisterTicket masternodeCommand = iota
+	masternodeRemote
+)
+
+const (
+	defaultTicketConfirmations = 1
+	defaultTicketRegTimeout    = 30 * time.Minute
+)
+
+// ticketPollInterval is how often the balance, the transaction and the ticket are checked while waiting for them
+var ticketPollInterval = 10 * time.Second
+
+var (
+	masternodeCommandName = map[masternodeCommand]string{
+		masternodeRegisterTicket: "register-ticket",
+		masternodeRemote:         "remote",
+	}
+	masternodeCommandMessage = map[masternodeCommand]string{
+		masternodeRegisterTicket: "Register supernode's PastelID (mnid) ticket",
+		masternodeRemote:         "Register supernode's PastelID (mnid) ticket on the remote host",
+	}
+)
+
+func setupMasternodeSubCommand(config *configs.Config,
+	mnCommand masternodeCommand, remote bool,
+	f func(context.Context, *configs.Config) error,
+) *cli.Command {
+
+	ticketFlags := []*cli.Flag{
+		cli.NewFlag("pastelid", &config.MasterNodePastelID).
+			SetUsage(red("Required, supernode's PastelID to register mnid ticket for")),
+		cli.NewFlag("passphrase-file", &config.PassphraseFile).
+			SetUsage(red("Required, path to the file with passphrase of the PastelID")),
+		cli.NewFlag("address", &config.TicketAddress).
+			SetUsage(yellow("Optional, address to pay the ticket fee from, if not set, fee is paid from any address of the node's wallet")),
+		cli.NewFlag("fund", &config.TicketAutoFund).
+			SetUsage(yellow("Optional, transfer missing ticket fee from other addresses of the node's wallet without asking")),
+		cli.NewFlag("confirmations", &config.Confirmations).
+			SetUsage(yellow("Optional, number of confirmations required for the funds before registering ticket")).SetValue(defaultTicketConfirmations),
+		cli.NewFlag("timeout", &config.TicketRegTimeout).
+			SetUsage(yellow("Optional, how long to wait for funds and for ticket to become visible in the network")).SetValue(defaultTicketRegTimeout),
+	}
+
+	var dirsFlags []*cli.Flag
+	if !remote {
+		dirsFlags = []*cli.Flag{
+			cli.NewFlag("dir", &config.PastelExecDir).SetAliases("d").
+				SetUsage(green("Optional, Location of the pastel node directory")).SetValue(config.Configurer.DefaultPastelExecutableDir()),
+			cli.NewFlag("work-dir", &config.WorkingDir).SetAliases("w").
+				SetUsage(green("Optional, Location of the working directory")).SetValue(config.Configurer.DefaultWorkingDir()),
+		}
+	} else {
+		dirsFlags = []*cli.Flag{
+			cli.NewFlag("dir", &config.PastelExecDir).SetAliases("d").
+				SetUsage(green("Optional, Location of the pastel node directory on the remote computer (default: $HOME/pastel)")),
+			cli.NewFlag("work-dir", &config.WorkingDir).SetAliases("w").
+				SetUsage(green("Optional, Location of the working directory on the remote computer (default: $HOME/.pastel)")),
+		}
+	}
+
+	remoteFlags := []*cli.Flag{
+		cli.NewFlag("ssh-ip", &config.RemoteIP).
+			SetUsage(red("Required, SSH address of the remote host")),
+		cli.NewFlag("ssh-port", &config.RemotePort).
+			SetUsage(yellow("Optional, SSH port of the remote host, default is 22")).SetValue(22),
+		cli.NewFlag("ssh-user", &config.RemoteUser).
+			SetUsage(yellow("Optional, Username of user at remote host")),
+		cli.NewFlag("ssh-key", &config.RemoteSSHKey).
+			SetUsage(yellow("Optional, Path to SSH private key for SSH Key Authentication")),
+	}
+
+	var commandName, commandMessage string
+	if !remote {
+		commandName = masternodeCommandName[mnCommand]
+		commandMessage = masternodeCommandMessage[mnCommand]
+	} else {
+		commandName = masternodeCommandName[masternodeRemote]
+		commandMessage = masternodeCommandMessage[masternodeRemote]
+	}
+
+	commandFlags := append(ticketFlags, dirsFlags[:]...)
+	if remote {
+		commandFlags = append(commandFlags, remoteFlags[:]...)
+	}
+
+	subCommand := cli.NewCommand(commandName)
+	subCommand.SetUsage(cyan(commandMessage))
+	subCommand.AddFlags(commandFlags...)
+	addLogFlags(subCommand, config)
+
+	if f != nil {
+		subCommand.SetActionFunc(func(ctx context.Context, _ []string) error {
+			ctx, err := configureLogging(ctx, commandMessage, config)
+			if err != nil {
+				return fmt.Errorf("failed to configure logging option - %v", err)
+			}
+
+			sys.RegisterInterruptHandler(func() {
+				log.WithContext(ctx).Info("Interrupt signal received. Gracefully shutting down...")
+				os.Exit(0)
+			})
+
+			if !remote {
+				if err = ParsePastelConf(ctx, config); err != nil {
+					return err
+				}
+			}
+			log.WithContext(ctx).Info("Started")
+			if err = f(ctx, config); err != nil {
+				return err
+			}
+			log.WithContext(ctx).Info("Finished successfully!")
+			return nil
+		})
+	}
+	return subCommand
+}
+
+func setupMasternodeCommand(config *configs.Config) *cli.Command {
+	registerTicketSubCommand := setupMasternodeSubCommand(config, masternodeRegisterTicket, false, runRegisterTicketSubCommand)
+	registerTicketSubCommand.AddSubcommands(setupMasternodeSubCommand(config, masternodeRegisterTicket, true, runRemoteRegisterTicketSubCommand))
+
+	masternodeCommand := cli.NewCommand("masternode")
+	masternodeCommand.SetUsage(blue("Perform supernode (masternode) management tasks"))
+	masternodeCommand.AddSubcommands(registerTicketSubCommand)
+
+	return masternodeCommand
+}
+
+func checkRegisterTicketParams(config *configs.Config) error {
+	if len(config.MasterNodePastelID) == 0 {
+		return fmt.Errorf("--pastelid <PastelID> - Required, supernode's PastelID to register mnid ticket for")
+	}
+	if len(config.PassphraseFile) == 0 {
+		return fmt.Errorf("--passphrase-file <path> - Required, path to the file with passphrase of the PastelID")
+	}
+	if config.Confirmations < 0 {
+		return fmt.Errorf("--confirmations must not be negative")
+	}
+	return nil
+}
+
+func runRegisterTicketSubCommand(ctx context.Context, config *configs.Config) (err error) {
+	// passphrase is already read from --passphrase-file by resolveSecrets
+	if err = checkRegisterTicketParams(config); err != nil {
+		return err
+	}
+
+	// the stopped node is started for the registration and stopped again when it is done
+	if _, err = GetPastelInfo(ctx, config); err != nil {
+		log.WithContext(ctx).Info("pasteld is not running, starting it to register mnid ticket")
+		if err = runPastelNode(ctx, config, true, false, "", ""); err != nil {
+			log.WithContext(ctx).WithError(err).Error("Failed to start pasteld")
+			return err
+		}
+		defer func() {
+			log.WithContext(ctx).Info("Stopping pasteld, which has been started to register mnid ticket")
+			if stopErr := StopPastelDAndWait(ctx, config); stopErr != nil {
+				log.WithContext(ctx).WithError(stopErr).Error("Failed to stop pasteld")
+			}
+		}()
+		if _, err = CheckMasterNodeSync(ctx, config); err != nil {
+			log.WithContext(ctx).WithError(err).Error("pasteld sync failure")
+			return err
+		}
+	}
+
+	rpc := pastelcore.NewClient(config)
+	txid, err := registerSupernodeTicket(ctx, rpc, config)
+	if err != nil || txid == "" {
+		return err
+	}
+	return waitForMNIDTicket(ctx, rpc, config, config.MasterNodePastelID)
+}
+
+// registerSupernodeTicket registers mnid ticket of config.MasterNodePastelID with the node's wallet paying the fee.
+// It returns txid of the ticket, which is empty if the ticket has been registered before
+func registerSupernodeTicket(ctx context.Context, rpc pastelcore.RPCCommunicator, config *configs.Config) (string, error) {
+	found, err := isMNIDTicketRegistered(ctx, rpc, config.MasterNodePastelID)
+	if err != nil {
+		return "", err
+	}
+	if found {
+		log.WithContext(ctx).Infof("mnid ticket for PastelID %s is already registered", config.MasterNodePastelID)
+		return "", nil
+	}
+
+	var mnstatus structure.RPCPastelMNSyncStatus
+	if err = rpc.RunCommandWithArgs(pastelcore.MasterNodeSyncCmd, []string{"status"}, &mnstatus); err == nil && !mnstatus.Result.IsBlockchainSynced {
+		log.WithContext(ctx).Warn(yellow("Blockchain is not synced yet, balance and ticket checks might be not accurate"))
+	}
+
+	address, err := ensureTicketFunds(ctx, rpc, config)
+	if err != nil {
+		return "", err
+	}
+
+	txid, err := registerMNIDTicket(ctx, rpc, config, address)
+	if err != nil {
+		return "", err
+	}
+	log.WithContext(ctx).Infof("mnid ticket submitted, txid = %s", txid)
+	return txid, nil
+}
+
+func runRemoteRegisterTicketSubCommand(ctx context.Context, config *configs.Config) (err error) {
+	if len(config.RemoteIP) == 0 {
+		return fmt.Errorf("--ssh-ip <IP address> - Required, SSH address of the remote host")
+	}
+	if err = checkRegisterTicketParams(config); err != nil {
+		return err
+	}
+
+	client, err := prepareRemoteSession(ctx, config)
+	if err != nil {
+		log.WithContext(ctx).WithError(err).Error("Failed to prepare remote session")
+		return fmt.Errorf("failed to prepare remote session: %v", err)
+	}
+	defer client.Close()
+
+	// passphrase is never put on the remote command line, it is written into the private file
+	// and removed right after the command completes
+	var secrets remoteSecrets
+	regOptions := fmt.Sprintf("--pastelid %s%s --confirmations %d --timeout %s",
+		config.MasterNodePastelID, secrets.option("passphrase", config.MasterNodePassPhrase), config.Confirmations, config.TicketRegTimeout)
+	if len(config.TicketAddress) > 0 {
+		regOptions = fmt.Sprintf("%s --address %s", regOptions, config.TicketAddress)
+	}
+	if config.TicketAutoFund {
+		regOptions = fmt.Sprintf("%s --fund", regOptions)
+	}
+	if len(config.PastelExecDir) > 0 {
+		regOptions = fmt.Sprintf("%s --dir %s", regOptions, config.PastelExecDir)
+	}
+	if len(config.WorkingDir) > 0 {
+		regOptions = fmt.Sprintf("%s --work-dir %s", regOptions, config.WorkingDir)
+	}
+
+	regCmd := secrets.wrap(fmt.Sprintf("%s masternode register-ticket %s", constants.RemotePastelupPath, regOptions))
+	if err = client.ShellCmd(ctx, regCmd); err != nil {
+		log.WithContext(ctx).WithError(err).Error("Failed to register mnid ticket on remote host")
+		return err
+	}
+	log.WithContext(ctx).Info("Remote mnid ticket registered")
+	return nil
+}
+
+// getTicketFundsBalance returns balance available to pay ticket fee - balance of the address if set,
+// otherwise balance of the whole wallet
+func getTicketFundsBalance(rpc pastelcore.RPCCommunicator, address string, confirmations int) (float64, error) {
+	var resp structure.RPCBalance
+	var err error
+	if len(address) > 0 {
+		err = rpc.RunCommandWithArgs(pastelcore.ZGetBalanceCmd, []interface{}{address, confirmations}, &resp)
+	} else {
+		err = rpc.RunCommandWithArgs(pastelcore.GetBalanceCmd, []interface{}{"*", confirmations}, &resp)
+	}
+	if err != nil {
+		return 0.0, err
+	}
+	if resp.Error != nil {
+		return 0.0, fmt.Errorf("failed to get balance: %s", resp.Error.Message)
+	}
+	return resp.Result, nil
+}
+
+// ensureTicketFunds waits until the ticket fee and the transaction fee can be paid and returns the address to pay them
+// from, the empty address means any address of the wallet. If the wallet doesn't have enough funds, they are requested
+// to the new address, which then pays the fees
+func ensureTicketFunds(ctx context.Context, rpc pastelcore.RPCCommunicator, config *configs.Config) (string, error) {
+	fundAddress := config.TicketAddress
+	balance, err := getTicketFundsBalance(rpc, fundAddress, config.Confirmations)
+	if err != nil {
+		log.WithContext(ctx).WithError(err).Error("Failed to get balance")
+		return "", err
+	}
+	log.WithContext(ctx).WithField("balance", balance).Info("got balance")
+	required := minBalanceForTicketReg + ticketTxFeeMargin
+	if balance >= required {
+		return fundAddress, nil
+	}
+
+	if len(fundAddress) > 0 {
+		walletBalance, err := getTicketFundsBalance(rpc, "", config.Confirmations)
+		if err != nil {
+			log.WithContext(ctx).WithError(err).Error("Failed to get wallet balance")
+			return "", err
+		}
+
+		missing := required - balance
+		// the transfer fee is paid by the other addresses
+		if walletBalance-balance >= missing+ticketTxFeeMargin {
+			doTransfer := config.TicketAutoFund
+			if !doTransfer {
+				doTransfer, _ = AskUserToContinue(ctx, fmt.Sprintf(`Address %s does not have enough balance (%v PSL),
+				but other addresses of this wallet do (%v PSL). Do you want to transfer %v PSL to it? Y/N`,
+					fundAddress, balance, walletBalance-balance, missing))
+			}
+			if doTransfer {
+				var resp structure.RPCStringResult
+				err = rpc.RunCommandWithArgs(pastelcore.SendToAddressCmd, []interface{}{fundAddress, missing}, &resp)
+				if err == nil && resp.Error != nil {
+					err = fmt.Errorf("%s", resp.Error.Message)
+				}
+				if err != nil {
+					log.WithContext(ctx).WithError(err).Error("Failed to send funds to the ticket address")
+					return "", err
+				}
+				log.WithContext(ctx).Infof("Sent %v PSL to %s, txid = %s", missing, fundAddress, resp.Result)
+				if err = waitForTxConfirmations(ctx, rpc, config, resp.Result); err != nil {
+					return "", err
+				}
+				return fundAddress, waitForTicketFunds(ctx, rpc, config, fundAddress)
+			}
+		}
+	} else {
+		var resp structure.RPCStringResult
+		err = rpc.RunCommand(pastelcore.GetNewAddressCmd, &resp)
+		if err == nil && resp.Error != nil {
+			err = fmt.Errorf("%s", resp.Error.Message)
+		}
+		if err != nil {
+			log.WithContext(ctx).WithError(err).Error("Failed to generate new address")
+			return "", fmt.Errorf("failed to generate new address: %v", err)
+		}
+		fundAddress, balance = resp.Result, 0
+	}
+
+	log.WithContext(ctx).Warnf(red("Not enough balance to register mnid ticket (%v PSL, required %v PSL)"), balance, required)
+	log.WithContext(ctx).Warnf(red("Please send %v PSL to the address %s, waiting for funds..."), required-balance, fundAddress)
+	return fundAddress, waitForTicketFunds(ctx, rpc, config, fundAddress)
+}
+
+// waitForTicketFunds waits until the ticket fee funds have required number of confirmations
+func waitForTicketFunds(ctx context.Context, rpc pastelcore.RPCCommunicator, config *configs.Config, address string) error {
+	deadline := time.Now().Add(config.TicketRegTimeout)
+	for {
+		balance, err := getTicketFundsBalance(rpc, address, config.Confirmations)
+		if err != nil {
+			log.WithContext(ctx).WithError(err).Error("Failed to get balance")
+			return err
+		}
+		if balance >= minBalanceForTicketReg+ticketTxFeeMargin {
+			log.WithContext(ctx).WithField("balance", balance).Info("Balance is enough to register mnid ticket")
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("balance with %d confirmation(s) is still %v PSL after %v, required %v PSL",
+				config.Confirmations, balance, config.TicketRegTimeout, minBalanceForTicketReg+ticketTxFeeMargin)
+		}
+		log.WithContext(ctx).Infof("Waiting for funds with %d confirmation(s)... balance is %v PSL", config.Confirmations, balance)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(ticketPollInterval):
+		}
+	}
+}
+
+// waitForTxConfirmations waits until the transaction gets required number of confirmations
+func waitForTxConfirmations(ctx context.Context, rpc pastelcore.RPCCommunicator, config *configs.Config, txid string) error {
+	deadline := time.Now().Add(config.TicketRegTimeout)
+	for {
+		var resp structure.RPCGetTransaction
+		err := rpc.RunCommandWithArgs(pastelcore.GetTransactionCmd, []string{txid}, &resp)
+		if err == nil && resp.Error != nil {
+			err = fmt.Errorf("%s", resp.Error.Message)
+		}
+		if err != nil {
+			log.WithContext(ctx).WithError(err).Errorf("Failed to get transaction %s", txid)
+			return err
+		}
+		if resp.Result.Confirmations >= config.Confirmations {
+			log.WithContext(ctx).Infof("Transaction %s has %d confirmation(s)", txid, resp.Result.Confirmations)
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("transaction %s has %d confirmation(s) after %v, required %d",
+				txid, resp.Result.Confirmations, config.TicketRegTimeout, config.Confirmations)
+		}
+		log.WithContext(ctx).Infof("Waiting for transaction %s confirmations (%d of %d)...", txid, resp.Result.Confirmations, config.Confirmations)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(ticketPollInterval):
+		}
+	}
+}
+
+// registerMNIDTicket submits mnid ticket, its fee is paid from the address if it is set
+func registerMNIDTicket(ctx context.Context, rpc pastelcore.RPCCommunicator, config *configs.Config, address string) (string, error) {
+	args := []string{"register", "mnid", config.MasterNodePastelID, config.MasterNodePassPhrase}
+	if len(address) > 0 {
+		args = append(args, address)
+	}
+
+	var resp structure.RPCTicketRegister
+	err := rpc.RunCommandWithArgs(pastelcore.TicketsCmd, args, &resp)
+	if err == nil && resp.Error != nil {
+		err = fmt.Errorf("%s", resp.Error.Message)
+	}
+	if err == nil && resp.Result.TxID == "" {
+		err = fmt.Errorf("unexpected response: txid not found")
+	}
+	if err != nil {
+		log.WithContext(ctx).WithError(err).Error("Failed to register ticket mnid")
+		fmt.Println("please execute the following command when the issue is resolved.")
+		fmt.Println("\n*******************************************************************")
+		fmt.Printf("pastel-cli tickets register mnid %s <passphrase> %s\n", config.MasterNodePastelID, address)
+		fmt.Println("*******************************************************************")
+		return "", err
+	}
+	return resp.Result.TxID, nil
+}
+
+func isMNIDTicketRegistered(ctx context.Context, rpc pastelcore.RPCCommunicator, pastelID string) (bool, error) {
+	var resp structure.RPCTicketFind
+	err := rpc.RunCommandWithArgs(pastelcore.TicketsCmd, []string{"find", "mnid", pastelID}, &resp)
+	if err == nil && resp.Error != nil {
+		err = fmt.Errorf("%s", resp.Error.Message)
+	}
+	if err != nil {
+		log.WithContext(ctx).WithError(err).Error("Failed to find mnid ticket")
+		return false, err
+	}
+	return resp.Found(), nil
+}
+
+// waitForMNIDTicket polls the network until registered mnid ticket becomes visible
+func waitForMNIDTicket(ctx context.Context, rpc pastelcore.RPCCommunicator, config *configs.Config, pastelID string) error {
+	deadline := time.Now().Add(config.TicketRegTimeout)
+	for {
+		found, err := isMNIDTicketRegistered(ctx, rpc, pastelID)
+		if err != nil {
+			return err
+		}
+		if found {
+			log.WithContext(ctx).Infof("mnid ticket for PastelID %s is visible in the network", pastelID)
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("mnid ticket for PastelID %s is not visible after %v", pastelID, config.TicketRegTimeout)
+		}
+		log.WithContext(ctx).Info("Waiting for mnid ticket to be mined...")
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(ticketPollInterval):
+		}
+	}
+}
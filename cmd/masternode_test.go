@@ -0,0 +1,271 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pastelnetwork/pastelup/configs"
+	"github.com/pastelnetwork/pastelup/services/pastelcore"
+	"github.com/pastelnetwork/pastelup/structure"
+	"github.com/tj/assert"
+)
+
+// fakeTicketRPC answers RPC commands of the ticket registration. Balances of the address ("" is the whole wallet)
+// and results of tickets find are returned in turn, the last one is repeated
+type fakeTicketRPC struct {
+	balances   map[string][]float64
+	found      []bool
+	newAddress string
+	addrError  string
+	regError   string
+	calls      []string
+}
+
+func (f *fakeTicketRPC) RunCommand(cmd string, response interface{}) error {
+	return f.RunCommandWithArgs(cmd, []string{}, response)
+}
+
+func (f *fakeTicketRPC) RunCommandWithArgs(cmd string, args interface{}, response interface{}) error {
+	var strArgs []string
+	switch args := args.(type) {
+	case []string:
+		strArgs = args
+	case []interface{}:
+		for _, arg := range args {
+			strArgs = append(strArgs, fmt.Sprint(arg))
+		}
+	}
+	f.calls = append(f.calls, strings.TrimSpace(cmd+" "+strings.Join(strArgs, " ")))
+
+	var result interface{}
+	var rpcErr *structure.RPCError
+	switch cmd {
+	case pastelcore.GetBalanceCmd:
+		result = nextBalance(f.balances, "")
+	case pastelcore.ZGetBalanceCmd:
+		result = nextBalance(f.balances, strArgs[0])
+	case pastelcore.GetNewAddressCmd:
+		result = f.newAddress
+		if f.addrError != "" {
+			rpcErr = &structure.RPCError{Code: -1, Message: f.addrError}
+		}
+	case pastelcore.SendToAddressCmd:
+		result = "fund-txid"
+	case pastelcore.GetTransactionCmd:
+		result = map[string]interface{}{"txid": strArgs[0], "confirmations": 1}
+	case pastelcore.MasterNodeSyncCmd:
+		result = map[string]interface{}{"IsBlockchainSynced": true}
+	case pastelcore.TicketsCmd:
+		switch {
+		case strArgs[0] == "find":
+			found := f.found[0]
+			if len(f.found) > 1 {
+				f.found = f.found[1:]
+			}
+			result = "Key is not found"
+			if found {
+				result = map[string]interface{}{"ticket": map[string]interface{}{"pastelID": strArgs[2]}}
+			}
+		case f.regError != "":
+			rpcErr = &structure.RPCError{Code: -1, Message: f.regError}
+		default:
+			result = map[string]interface{}{"txid": "ticket-txid"}
+		}
+	default:
+		return fmt.Errorf("unexpected command %s", cmd)
+	}
+
+	data, err := json.Marshal(map[string]interface{}{"result": result, "error": rpcErr})
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, response)
+}
+
+func nextBalance(balances map[string][]float64, address string) float64 {
+	values := balances[address]
+	if len(values) == 0 {
+		return 0
+	}
+	if len(values) > 1 {
+		balances[address] = values[1:]
+	}
+	return values[0]
+}
+
+func TestRegisterSupernodeTicket(t *testing.T) {
+	defer func(interval time.Duration) { ticketPollInterval = interval }(ticketPollInterval)
+	ticketPollInterval = time.Millisecond
+
+	tests := []struct {
+		name          string
+		ticketAddress string
+		rpc           fakeTicketRPC
+		txid          string
+		err           string
+		calls         []string
+	}{
+		{
+			name: "already registered",
+			rpc:  fakeTicketRPC{found: []bool{true}},
+			calls: []string{
+				"tickets find mnid jXpid",
+			},
+		},
+		{
+			name: "wallet has funds",
+			rpc:  fakeTicketRPC{found: []bool{false}, balances: map[string][]float64{"": {1500}}},
+			txid: "ticket-txid",
+			calls: []string{
+				"tickets find mnid jXpid",
+				"mnsync status",
+				"getbalance * 1",
+				"tickets register mnid jXpid secret",
+			},
+		},
+		{
+			name:          "ticket address is funded from the wallet",
+			ticketAddress: "PtAddr",
+			rpc: fakeTicketRPC{found: []bool{false}, balances: map[string][]float64{
+				"PtAddr": {400, 1001},
+				"":       {2000},
+			}},
+			txid: "ticket-txid",
+			calls: []string{
+				"tickets find mnid jXpid",
+				"mnsync status",
+				"z_getbalance PtAddr 1",
+				"getbalance * 1",
+				"sendtoaddress PtAddr 601",
+				"gettransaction fund-txid",
+				"z_getbalance PtAddr 1",
+				"tickets register mnid jXpid secret PtAddr",
+			},
+		},
+		{
+			name: "funds are requested to the new address, which pays the fee",
+			rpc: fakeTicketRPC{found: []bool{false}, newAddress: "PtNew", balances: map[string][]float64{
+				"":      {300},
+				"PtNew": {0, 0, 1001},
+			}},
+			txid: "ticket-txid",
+			calls: []string{
+				"tickets find mnid jXpid",
+				"mnsync status",
+				"getbalance * 1",
+				"getnewaddress",
+				"z_getbalance PtNew 1",
+				"z_getbalance PtNew 1",
+				"z_getbalance PtNew 1",
+				"tickets register mnid jXpid secret PtNew",
+			},
+		},
+		{
+			// the other addresses can't pay the transfer fee
+			name:          "ticket address isn't funded without the fee",
+			ticketAddress: "PtAddr",
+			rpc: fakeTicketRPC{found: []bool{false}, balances: map[string][]float64{
+				"PtAddr": {400},
+				"":       {1001},
+			}},
+			err: "balance with 1 confirmation(s) is still 400 PSL",
+		},
+		{
+			name: "new address fails",
+			rpc:  fakeTicketRPC{found: []bool{false}, addrError: "wallet is locked", balances: map[string][]float64{"": {300}}},
+			err:  "failed to generate new address: wallet is locked",
+		},
+		{
+			name: "funds don't come",
+			rpc:  fakeTicketRPC{found: []bool{false}, newAddress: "PtNew", balances: map[string][]float64{"": {300}}},
+			err:  "balance with 1 confirmation(s) is still 0 PSL",
+		},
+		{
+			name: "register fails",
+			rpc:  fakeTicketRPC{found: []bool{false}, balances: map[string][]float64{"": {1500}}, regError: "invalid passphrase"},
+			err:  "invalid passphrase",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			config := &configs.Config{}
+			config.MasterNodePastelID = "jXpid"
+			config.MasterNodePassPhrase = "secret"
+			config.TicketAddress = test.ticketAddress
+			config.TicketAutoFund = true
+			config.Confirmations = defaultTicketConfirmations
+			config.TicketRegTimeout = 50 * time.Millisecond
+
+			rpc := test.rpc
+			txid, err := registerSupernodeTicket(context.Background(), &rpc, config)
+			if test.err != "" {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), test.err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, test.txid, txid)
+			assert.Equal(t, test.calls, rpc.calls)
+		})
+	}
+}
+
+func TestWaitForMNIDTicket(t *testing.T) {
+	defer func(interval time.Duration) { ticketPollInterval = interval }(ticketPollInterval)
+	ticketPollInterval = time.Millisecond
+	config := &configs.Config{}
+	config.TicketRegTimeout = 50 * time.Millisecond
+
+	rpc := &fakeTicketRPC{found: []bool{false, false, true}}
+	assert.NoError(t, waitForMNIDTicket(context.Background(), rpc, config, "jXpid"))
+	assert.Len(t, rpc.calls, 3)
+
+	rpc = &fakeTicketRPC{found: []bool{false}}
+	err := waitForMNIDTicket(context.Background(), rpc, config, "jXpid")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "is not visible after 50ms")
+}
+
+func TestRemotePastelCliRPC(t *testing.T) {
+	var scripts []string
+	output := "PtAddr\n"
+	rpc := remotePastelCliRPC{
+		run: func(script string) ([]byte, error) {
+			scripts = append(scripts, script)
+			return []byte(output), nil
+		},
+		pastelCli: "/home/user/pastel/pastel-cli",
+		secrets:   []string{"pass phrase"},
+	}
+
+	var address structure.RPCStringResult
+	assert.NoError(t, rpc.RunCommand(pastelcore.GetNewAddressCmd, &address))
+	assert.Equal(t, "PtAddr", address.Result)
+
+	output = "1500.5\n"
+	var balance structure.RPCBalance
+	assert.NoError(t, rpc.RunCommandWithArgs(pastelcore.GetBalanceCmd, []interface{}{"*", 1}, &balance))
+	assert.Equal(t, 1500.5, balance.Result)
+
+	output = "{\n  \"txid\": \"ticket-txid\"\n}\n"
+	var reg structure.RPCTicketRegister
+	assert.NoError(t, rpc.RunCommandWithArgs(pastelcore.TicketsCmd, []string{"register", "mnid", "jXpid", "pass phrase", "PtAddr"}, &reg))
+	assert.Equal(t, "ticket-txid", reg.Result.TxID)
+
+	assert.Equal(t, []string{
+		"/home/user/pastel/pastel-cli 'getnewaddress'",
+		"/home/user/pastel/pastel-cli 'getbalance' '*' '1'",
+		// the passphrase and the arguments after it are read from stdin
+		"printf '%s\\n' 'pass phrase' 'PtAddr' | /home/user/pastel/pastel-cli -stdin 'tickets' 'register' 'mnid' 'jXpid'",
+	}, scripts)
+
+	output = "error code: -4\nerror message:\nInsufficient funds"
+	rpc.run = func(string) ([]byte, error) { return []byte(output), fmt.Errorf("exit status 1") }
+	err := rpc.RunCommand(pastelcore.GetNewAddressCmd, &address)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "Insufficient funds")
+}
@@ -0,0 +1,427 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/pastelnetwork/pastelup/common/cli"
+	"github.com/pastelnetwork/pastelup/common/log"
+	"github.com/pastelnetwork/pastelup/configs"
+	"github.com/pastelnetwork/pastelup/constants"
+	"github.com/pastelnetwork/pastelup/services/pastelcore"
+	"github.com/pastelnetwork/pastelup/structure"
+	"github.com/pastelnetwork/pastelup/utils"
+)
+
+var (
+	flagPeersConnect bool
+	flagPeersBanTime time.Duration
+	flagPeersUnban   bool
+	flagPeersCount   int
+	flagPeersSave    bool
+)
+
+func setupPeersSubCommand(config *configs.Config, name, usage string, flags []*cli.Flag,
+	f func(context.Context, *configs.Config, []string) error,
+) *cli.Command {
+	commandFlags := []*cli.Flag{
+		cli.NewFlag("work-dir", &config.WorkingDir).SetAliases("w").
+			SetUsage(green("Optional, Location of the working directory")).SetValue(config.Configurer.DefaultWorkingDir()),
+	}
+	commandFlags = append(commandFlags, flags...)
+
+	subCommand := cli.NewCommand(name)
+	subCommand.SetUsage(cyan(usage))
+	subCommand.AddFlags(commandFlags...)
+	addLogFlags(subCommand, config)
+	subCommand.SetActionFunc(func(ctx context.Context, args []string) error {
+		ctx, err := configureLogging(ctx, "peers", config)
+		if err != nil {
+			return fmt.Errorf("failed to configure logging option - %v", err)
+		}
+		if err = ParsePastelConf(ctx, config); err != nil {
+			return err
+		}
+		return f(ctx, config, args)
+	})
+	return subCommand
+}
+
+func setupPeersCommand(config *configs.Config) *cli.Command {
+	connectFlag := cli.NewFlag("connect", &flagPeersConnect).
+		SetUsage(green("Optional, manage connect= entries instead of addnode= - pasteld connects only to these peers"))
+
+	listSubCommand := setupPeersSubCommand(config, "list",
+		"List connected peers with their latency and height, and peers configured in pastel.conf - pastelup peers list",
+		nil, runPeersList)
+	addSubCommand := setupPeersSubCommand(config, "add",
+		"Add peers to pastel.conf and connect to them - pastelup peers add <ip[:port]>...",
+		[]*cli.Flag{connectFlag}, runPeersAdd)
+	removeSubCommand := setupPeersSubCommand(config, "remove",
+		"Remove peers from pastel.conf and disconnect from them - pastelup peers remove <ip[:port]>...",
+		[]*cli.Flag{connectFlag}, runPeersRemove)
+	banSubCommand := setupPeersSubCommand(config, "ban",
+		"Ban peers in the running pasteld and remove them from pastel.conf - pastelup peers ban <ip>...",
+		[]*cli.Flag{
+			cli.NewFlag("bantime", &flagPeersBanTime).
+				SetUsage(green("Optional, how long the peers are banned")).SetValue(24 * time.Hour),
+			cli.NewFlag("unban", &flagPeersUnban).
+				SetUsage(green("Optional, remove the ban instead")),
+		}, runPeersBan)
+	seedSubCommand := setupPeersSubCommand(config, "seed",
+		"Connect to enabled masternodes from the masternode list to recover the node, which stopped syncing - pastelup peers seed",
+		[]*cli.Flag{
+			cli.NewFlag("count", &flagPeersCount).
+				SetUsage(green("Optional, number of masternodes to connect to")).SetValue(8),
+			cli.NewFlag("save", &flagPeersSave).
+				SetUsage(green("Optional, also add the masternodes to pastel.conf as addnode= entries")),
+		}, runPeersSeed)
+
+	peersCommand := cli.NewCommand("peers")
+	peersCommand.SetUsage(blue("Manage peers of pasteld - addnode/connect entries in pastel.conf and connections of the running node"))
+	peersCommand.AddSubcommands(listSubCommand, addSubCommand, removeSubCommand, banSubCommand, seedSubCommand)
+
+	return peersCommand
+}
+
+func peersConfKey() string {
+	if flagPeersConnect {
+		return "connect"
+	}
+	return "addnode"
+}
+
+// runPeersRPC runs RPC command, which doesn't return anything
+func runPeersRPC(config *configs.Config, cmd string, args ...interface{}) error {
+	var resp structure.RPCNullResult
+	err := pastelcore.NewClient(config).RunCommandWithArgs(cmd, args, &resp)
+	if err == nil && resp.Error != nil {
+		err = fmt.Errorf("%s", resp.Error.Message)
+	}
+	return err
+}
+
+func getPeerInfo(config *configs.Config) ([]structure.PeerInfo, error) {
+	var resp structure.RPCPeerInfo
+	if err := pastelcore.NewClient(config).RunCommand(pastelcore.GetPeerInfoCmd, &resp); err != nil {
+		return nil, err
+	}
+	if resp.Error != nil {
+		return nil, fmt.Errorf("%s", resp.Error.Message)
+	}
+	return resp.Result, nil
+}
+
+// isPasteldAvailable checks if RPC of pasteld responds, peers are changed in pastel.conf only if it doesn't
+func isPasteldAvailable(ctx context.Context, config *configs.Config) bool {
+	if _, err := GetPastelInfo(ctx, config); err != nil {
+		log.WithContext(ctx).Warn("pasteld is not running, only pastel.conf is changed")
+		return false
+	}
+	return true
+}
+
+func parsePeerArgs(args []string, usage string) ([]string, error) {
+	if len(args) == 0 {
+		return nil, fmt.Errorf("usage: pastelup peers %s", usage)
+	}
+	var peers []string
+	for _, arg := range args {
+		peer, err := utils.NormalizePeerAddress(arg)
+		if err != nil {
+			return nil, err
+		}
+		peers = append(peers, peer)
+	}
+	return peers, nil
+}
+
+// updatePeersConf applies the change to pastel.conf and saves it if anything has been changed
+func updatePeersConf(ctx context.Context, config *configs.Config, change func(conf *utils.PastelConf) bool) error {
+	path := configComponents[constants.PastelD].path(config)
+	conf, err := utils.LoadPastelConf(path)
+	if err != nil {
+		return err
+	}
+	if !change(conf) {
+		return nil
+	}
+	if err = conf.Save(path); err != nil {
+		return err
+	}
+	log.WithContext(ctx).Infof("%s updated", path)
+	return nil
+}
+
+func runPeersList(ctx context.Context, config *configs.Config, _ []string) error {
+	conf, err := utils.LoadPastelConf(configComponents[constants.PastelD].path(config))
+	if err != nil {
+		return err
+	}
+
+	var peers []structure.PeerInfo
+	height := 0
+	if info, err := GetPastelInfo(ctx, config); err == nil {
+		height = info.Result.Blocks
+		if peers, err = getPeerInfo(config); err != nil {
+			return fmt.Errorf("failed to get peers: %v", err)
+		}
+	} else {
+		log.WithContext(ctx).Warn("pasteld is not running, only peers from pastel.conf are listed")
+	}
+
+	// configured peers are matched by host, they may be set without port
+	configured := map[string]string{}
+	for _, key := range []string{"addnode", "connect"} {
+		for _, peer := range conf.GetAll(key) {
+			configured[utils.PeerHost(peer)] = key
+		}
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "ADDRESS\tDIRECTION\tVERSION\tHEIGHT\tLATENCY\tCONNECTED\tCONFIG\n")
+	connected := map[string]bool{}
+	for _, p := range peers {
+		host := utils.PeerHost(p.Addr)
+		connected[host] = true
+		direction := "out"
+		if p.Inbound {
+			direction = "in"
+		}
+		peerHeight := fmt.Sprintf("%d", p.SyncedBlocks)
+		if p.SyncedBlocks < height {
+			peerHeight += fmt.Sprintf(" (-%d)", height-p.SyncedBlocks)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", p.Addr, direction, p.SubVer, peerHeight,
+			time.Duration(p.PingTime*float64(time.Second)).Round(time.Millisecond),
+			(time.Duration(time.Now().Unix()-p.ConnTime) * time.Second).String(), valueOrDash(configured[host]))
+	}
+	for _, key := range []string{"addnode", "connect"} {
+		for _, peer := range conf.GetAll(key) {
+			if !connected[utils.PeerHost(peer)] {
+				fmt.Fprintf(w, "%s\t-\t-\t-\t-\tno\t%s\n", peer, key)
+			}
+		}
+	}
+	if err = w.Flush(); err != nil {
+		return err
+	}
+	if height > 0 {
+		fmt.Printf("\nlocal height %d, %d peer(s) connected\n", height, len(peers))
+	}
+	return nil
+}
+
+func valueOrDash(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
+
+func runPeersAdd(ctx context.Context, config *configs.Config, args []string) error {
+	peers, err := parsePeerArgs(args, "add <ip[:port]>...")
+	if err != nil {
+		return err
+	}
+	key := peersConfKey()
+	err = updatePeersConf(ctx, config, func(conf *utils.PastelConf) bool {
+		changed := false
+		for _, peer := range peers {
+			if conf.Add(key, peer) {
+				changed = true
+			} else {
+				log.WithContext(ctx).Infof("%s=%s is already in pastel.conf", key, peer)
+			}
+		}
+		return changed
+	})
+	if err != nil {
+		return err
+	}
+	if key == "connect" {
+		log.WithContext(ctx).Warn("connect= entries are used after pasteld restart, pasteld connects to them only")
+	}
+	if !isPasteldAvailable(ctx, config) {
+		return nil
+	}
+	for _, peer := range peers {
+		if err = runPeersRPC(config, pastelcore.AddNode, peer, "onetry"); err != nil {
+			log.WithContext(ctx).WithError(err).Warnf("Failed to connect to %s", peer)
+			continue
+		}
+		log.WithContext(ctx).Infof("Connecting to %s", peer)
+	}
+	return nil
+}
+
+func runPeersRemove(ctx context.Context, config *configs.Config, args []string) error {
+	peers, err := parsePeerArgs(args, "remove <ip[:port]>...")
+	if err != nil {
+		return err
+	}
+	key := peersConfKey()
+	removed, err := removePeersFromConf(ctx, config, key, peers)
+	if err != nil {
+		return err
+	}
+	if len(removed) == 0 {
+		log.WithContext(ctx).Infof("%v not found in pastel.conf as %s", peers, key)
+	}
+	if !isPasteldAvailable(ctx, config) {
+		return nil
+	}
+	disconnectPeers(ctx, config, peers)
+	return nil
+}
+
+// removePeersFromConf removes entries of the peers and returns the removed ones.
+// Peer without port removes entries of its host with any port
+func removePeersFromConf(ctx context.Context, config *configs.Config, key string, peers []string) ([]string, error) {
+	var removed []string
+	err := updatePeersConf(ctx, config, func(conf *utils.PastelConf) bool {
+		for _, peer := range peers {
+			_, _, err := net.SplitHostPort(peer)
+			anyPort := err != nil
+			for _, value := range conf.GetAll(key) {
+				if value != peer && (!anyPort || utils.PeerHost(value) != utils.PeerHost(peer)) {
+					continue
+				}
+				if conf.RemoveValue(key, value) {
+					removed = append(removed, value)
+				}
+			}
+		}
+		return len(removed) > 0
+	})
+	return removed, err
+}
+
+// disconnectPeers removes peers from the added nodes of the running pasteld and drops connections to them
+func disconnectPeers(ctx context.Context, config *configs.Config, peers []string) {
+	connected, err := getPeerInfo(config)
+	if err != nil {
+		log.WithContext(ctx).WithError(err).Warn("Failed to get connected peers")
+	}
+	for _, peer := range peers {
+		// peer may be not in the added nodes list, it is fine
+		_ = runPeersRPC(config, pastelcore.AddNode, peer, "remove")
+		for _, p := range connected {
+			if p.Addr != peer && utils.PeerHost(p.Addr) != utils.PeerHost(peer) {
+				continue
+			}
+			if err = runPeersRPC(config, pastelcore.DisconnectNodeCmd, p.Addr); err != nil {
+				log.WithContext(ctx).WithError(err).Warnf("Failed to disconnect %s", p.Addr)
+				continue
+			}
+			log.WithContext(ctx).Infof("Disconnected %s", p.Addr)
+		}
+	}
+}
+
+func runPeersBan(ctx context.Context, config *configs.Config, args []string) error {
+	peers, err := parsePeerArgs(args, "ban <ip>...")
+	if err != nil {
+		return err
+	}
+	if _, err = GetPastelInfo(ctx, config); err != nil {
+		return fmt.Errorf("pasteld is not running, peers can be banned in the running node only")
+	}
+	if !flagPeersUnban {
+		// banned peer must not be connected again by pasteld at start
+		var hosts []string
+		for _, peer := range peers {
+			hosts = append(hosts, utils.PeerHost(peer))
+		}
+		for _, key := range []string{"addnode", "connect"} {
+			removed, err := removePeersFromConf(ctx, config, key, hosts)
+			if err != nil {
+				return err
+			}
+			if len(removed) > 0 {
+				log.WithContext(ctx).Warnf("Banned peers %v removed from pastel.conf %s entries", removed, key)
+			}
+		}
+	}
+	for _, peer := range peers {
+		host := utils.PeerHost(peer)
+		if flagPeersUnban {
+			err = runPeersRPC(config, pastelcore.SetBanCmd, host, "remove")
+		} else {
+			err = runPeersRPC(config, pastelcore.SetBanCmd, host, "add", int64(flagPeersBanTime.Seconds()))
+		}
+		if err != nil {
+			return fmt.Errorf("failed to change ban of %s: %v", host, err)
+		}
+		if flagPeersUnban {
+			log.WithContext(ctx).Infof("%s unbanned", host)
+		} else {
+			log.WithContext(ctx).Infof("%s banned for %s", host, flagPeersBanTime)
+		}
+	}
+	return nil
+}
+
+func runPeersSeed(ctx context.Context, config *configs.Config, _ []string) error {
+	if _, err := GetPastelInfo(ctx, config); err != nil {
+		return fmt.Errorf("pasteld is not running, start it first with 'pastelup start node'")
+	}
+	var resp structure.RPCMasternodeList
+	err := pastelcore.NewClient(config).RunCommandWithArgs(pastelcore.MasterNodeCmd, []string{"list", "full"}, &resp)
+	if err == nil && resp.Error != nil {
+		err = fmt.Errorf("%s", resp.Error.Message)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get masternode list: %v", err)
+	}
+
+	connected, err := getPeerInfo(config)
+	if err != nil {
+		return fmt.Errorf("failed to get peers: %v", err)
+	}
+	skip := map[string]bool{}
+	for _, p := range connected {
+		skip[utils.PeerHost(p.Addr)] = true
+	}
+	if config.NodeExtIP != "" {
+		skip[config.NodeExtIP] = true
+	}
+
+	var seeds []string
+	for _, mn := range utils.ParseMasternodeListFull(resp.Result) {
+		if len(seeds) >= flagPeersCount {
+			break
+		}
+		if mn.Status != "ENABLED" || skip[utils.PeerHost(mn.Address)] {
+			continue
+		}
+		skip[utils.PeerHost(mn.Address)] = true
+		seeds = append(seeds, mn.Address)
+	}
+	if len(seeds) == 0 {
+		log.WithContext(ctx).Warn("No enabled masternodes to connect to, the masternode list may be not synced yet")
+		return nil
+	}
+
+	for _, peer := range seeds {
+		if err = runPeersRPC(config, pastelcore.AddNode, peer, "onetry"); err != nil {
+			log.WithContext(ctx).WithError(err).Warnf("Failed to connect to %s", peer)
+			continue
+		}
+		log.WithContext(ctx).Infof("Connecting to masternode %s", peer)
+	}
+	if !flagPeersSave {
+		return nil
+	}
+	return updatePeersConf(ctx, config, func(conf *utils.PastelConf) bool {
+		changed := false
+		for _, peer := range seeds {
+			changed = conf.Add("addnode", peer) || changed
+		}
+		return changed
+	})
+}
@@ -2,23 +2,37 @@ package cmd
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
 	"time"
 
 	"github.com/pastelnetwork/pastelup/common/cli"
 	"github.com/pastelnetwork/pastelup/common/log"
 	"github.com/pastelnetwork/pastelup/common/sys"
 	"github.com/pastelnetwork/pastelup/configs"
-
-	pb "github.com/pastelnetwork/pastelup/proto/healthcheck"
-	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
+	"github.com/pastelnetwork/pastelup/constants"
+	"github.com/pastelnetwork/pastelup/services/fleet"
+	"github.com/pastelnetwork/pastelup/services/healthcheck"
+	"github.com/pastelnetwork/pastelup/services/pastelcore"
+	"github.com/pastelnetwork/pastelup/structure"
+	"github.com/pastelnetwork/pastelup/utils"
+	"golang.org/x/exp/slices"
 )
 
+const pingTimeout = 5 * time.Second
+
 var (
 	superNodeIP   string
 	superNodePort int
+
+	flagFleetTimeout     time.Duration
+	flagFleetConcurrency int
+	flagFleetWNPort      int
 )
 
 func setupPingCommand(config *configs.Config) *cli.Command {
@@ -34,7 +48,7 @@ func setupPingCommand(config *configs.Config) *cli.Command {
 	}
 
 	pingSuperCommand := cli.NewCommand("supernode")
-	pingSuperCommand.SetUsage(cyan("check supernode healthcheck"))
+	pingSuperCommand.SetUsage(cyan("check supernode healthcheck and status of its dependencies"))
 	pingSuperCommand.AddFlags(pingSuperCommandFlags...)
 	pingSuperCommand.SetActionFunc(func(ctx context.Context, _ []string) error {
 		ctx, err := configureLogging(ctx, "ping ", config)
@@ -56,7 +70,35 @@ func setupPingCommand(config *configs.Config) *cli.Command {
 		return nil
 	})
 
-	pingCommand.AddSubcommands(pingSuperCommand)
+	pingFleetCommand := cli.NewCommand("fleet")
+	pingFleetCommand.SetUsage(cyan("check pasteld, supernode and walletnode of every host in the inventory or every masternode from the masternode list"))
+	pingFleetCommand.AddFlags(
+		cli.NewFlag("work-dir", &config.WorkingDir).SetAliases("w").
+			SetUsage(green("Optional, Location of the working directory of the local pasteld, which provides the masternode list")).SetValue(config.Configurer.DefaultWorkingDir()),
+		cli.NewFlag("network", &config.Network).SetAliases("n").
+			SetUsage(green("Optional, network of the hosts, which sets the default ports, it is read from pastel.conf if there is one")),
+		cli.NewFlag("inventory", &config.InventoryFile).
+			SetUsage(green("Optional, Path to the inventory file with the hosts, otherwise the masternodes from the masternode list are checked")),
+		cli.NewFlag("filter", &config.InventoryFilter).
+			SetUsage(green("Optional, use only specified host groups from the inventory file, comma separated list")),
+		cli.NewFlag("wn-port", &flagFleetWNPort).
+			SetUsage(green("Optional, port of walletnode API, 0 disables the check")).SetValue(constants.WalletNodeDefaultAPIPort),
+		cli.NewFlag("timeout", &flagFleetTimeout).
+			SetUsage(green("Optional, timeout of every check")).SetValue(pingTimeout),
+		cli.NewFlag("concurrency", &flagFleetConcurrency).
+			SetUsage(green("Optional, how many hosts are checked at once")).SetValue(32),
+		cli.NewFlag("output", &flagOutput).
+			SetUsage(green("Optional, how to present the results. Available choices are: 'console' and 'json'")).SetValue("console"),
+	)
+	pingFleetCommand.SetActionFunc(func(ctx context.Context, _ []string) error {
+		ctx, err := configureLogging(ctx, "ping fleet", config)
+		if err != nil {
+			return fmt.Errorf("failed to configure logging option - %v", err)
+		}
+		return runPingFleet(ctx, config)
+	})
+
+	pingCommand.AddSubcommands(pingSuperCommand, pingFleetCommand)
 	return pingCommand
 }
 
@@ -71,35 +113,203 @@ func runPingSuperNode(ctx context.Context, _ *configs.Config) error {
 	}
 
 	serverAddr := fmt.Sprintf("%s:%d", superNodeIP, superNodePort)
-	// Prepare the client
-	log.WithContext(ctx).Info("Connecting to supernode service...")
-	subCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
-	defer cancel()
-	conn, err := grpc.DialContext(subCtx, serverAddr,
-		// grpc.WithInsecure(),
-		// grpc.WithInsecure is deprecated: use WithTransportCredentials and insecure.NewCredentials() instead
-		grpc.WithTransportCredentials(insecure.NewCredentials()), //
-		grpc.WithBlock(),
-	)
+	log.WithContext(ctx).Info("Sending ping command to supernode service...")
+	report, err := healthcheck.Check(ctx, serverAddr, pingTimeout)
 	if err != nil {
-		log.WithContext(ctx).WithError(err).Error("Failed to connect to supernode service ")
+		log.WithContext(ctx).WithError(err).Error("Failed to ping supernode service")
 		return err
 	}
-	defer conn.Close()
-	client := pb.NewHealthCheckClient(conn)
-	log.WithContext(ctx).Info("Connected successful to supernode service ")
+	printHealthReport(report)
+	if problems := report.Problems(); len(problems) > 0 {
+		return fmt.Errorf("supernode is unhealthy: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
 
-	// Send ping request
-	log.WithContext(ctx).Info("Sending ping command...")
+// pingSuperNode sends ping to the healthcheck service of supernode and returns the reply
+func pingSuperNode(ctx context.Context, serverAddr string) (string, error) {
+	return healthcheck.Ping(ctx, serverAddr, pingTimeout)
+}
 
-	subCtx, cancel = context.WithTimeout(ctx, 5*time.Second)
-	defer cancel()
-	res, err := client.Ping(subCtx, &pb.PingRequest{Msg: "hello"})
-	if err != nil {
-		log.WithContext(ctx).WithError(err).Error("Failed to send ping command ")
-		return err
+// printHealthReport prints the supernode and its dependencies, older supernodes report only the ping reply
+func printHealthReport(report *healthcheck.Report) {
+	if report.Legacy {
+		fmt.Printf("Supernode %s replied to ping in %v: %s\n", report.Addr, report.Latency.Round(time.Millisecond), report.Reply)
+		fmt.Println("The supernode doesn't report status of its dependencies, update it to get them")
+		return
+	}
+	status := report.Status
+	fmt.Printf("Supernode %s %s, PastelID %s, up %v, replied in %v\n", report.Addr, status.Version, status.PastelId,
+		formatUptime(status.UptimeSeconds), report.Latency.Round(time.Millisecond))
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "DEPENDENCY\tSTATE\tVERSION\tUPTIME\tLAST ERROR")
+	for _, dep := range status.Dependencies {
+		version := dep.Version
+		if version == "" {
+			version = "-"
+		}
+		lastError := "-"
+		if dep.LastError != "" {
+			lastError = dep.LastError
+			if dep.LastErrorTime > 0 {
+				lastError = fmt.Sprintf("%s (%v ago)", dep.LastError, time.Since(time.Unix(dep.LastErrorTime, 0)).Round(time.Second))
+			}
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", dep.Name, dep.State, version, formatUptime(dep.UptimeSeconds), lastError)
+	}
+	_ = w.Flush()
+}
+
+func formatUptime(seconds int64) string {
+	if seconds <= 0 {
+		return "-"
+	}
+	return (time.Duration(seconds) * time.Second).String()
+}
+
+// runPingFleet checks the hosts of the inventory or the masternodes and reports mismatches of their advertised and actual state
+func runPingFleet(ctx context.Context, config *configs.Config) error {
+	if flagOutput != "console" && flagOutput != "json" {
+		return fmt.Errorf("unknown output %q, use 'console' or 'json'", flagOutput)
+	}
+
+	// the masternode list is required without inventory, with it the list is only used to get the advertised status
+	var confErr error
+	if _, err := os.Stat(filepath.Join(config.WorkingDir, constants.PastelConfName)); config.InventoryFile == "" || err == nil {
+		confErr = ParsePastelConf(ctx, config)
+	} else {
+		confErr = err
+	}
+	var entries []utils.MasternodeListEntry
+	var listErr error
+	if confErr == nil {
+		entries, listErr = masternodeList(config)
+	}
+	if config.InventoryFile == "" {
+		if confErr != nil {
+			return fmt.Errorf("pasteld config is required to get the masternode list, use --inventory to check other hosts: %v", confErr)
+		}
+		if listErr != nil {
+			return fmt.Errorf("failed to get masternode list: %v", listErr)
+		}
+	} else if confErr != nil || listErr != nil {
+		log.WithContext(ctx).Warn("Masternode list is not available, the advertised status of the hosts is unknown")
+	}
+
+	ports := networkPortList(config.Network)
+	var targets []fleet.Target
+	if config.InventoryFile != "" {
+		var err error
+		if targets, err = inventoryTargets(config, ports); err != nil {
+			return err
+		}
+		fleet.Advertise(targets, entries)
+	} else {
+		targets = fleet.MasternodeTargets(entries, ports[constants.SNPort])
+	}
+	if len(targets) == 0 {
+		return fmt.Errorf("no hosts to check")
 	}
-	log.WithContext(ctx).Infof("Ping sucessfully, received reply: %s", res.Reply)
+	for i := range targets {
+		targets[i].WalletNodePort = flagFleetWNPort
+	}
+
+	log.WithContext(ctx).Infof("Checking %d host(s)...", len(targets))
+	results := fleet.Scan(ctx, targets, fleet.Options{Timeout: flagFleetTimeout, Concurrency: flagFleetConcurrency})
 
+	if flagOutput == "json" {
+		data, err := json.MarshalIndent(results, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+	} else {
+		printFleetResults(results)
+	}
+
+	mismatched := 0
+	for _, result := range results {
+		if len(result.Mismatches) > 0 {
+			mismatched++
+		}
+	}
+	if mismatched > 0 {
+		return fmt.Errorf("%d of %d host(s) don't match their advertised status", mismatched, len(results))
+	}
 	return nil
 }
+
+// masternodeList gets "masternode list full" from the local pasteld
+func masternodeList(config *configs.Config) ([]utils.MasternodeListEntry, error) {
+	var list structure.RPCMasternodeList
+	if err := pastelcore.NewClient(config).RunCommandWithArgs(pastelcore.MasterNodeCmd, []string{"list", "full"}, &list); err != nil {
+		return nil, err
+	}
+	if list.Error != nil {
+		return nil, fmt.Errorf("masternode list full: %s", list.Error.Message)
+	}
+	return utils.ParseMasternodeListFull(list.Result), nil
+}
+
+// inventoryTargets returns the hosts of the inventory groups, which pass the filter, with the default ports of the network
+func inventoryTargets(config *configs.Config, ports []int) ([]fleet.Target, error) {
+	var inv Inventory
+	if err := inv.ReadAnsibleYamlInventory(config.InventoryFile); err != nil {
+		return nil, err
+	}
+	var filters []string
+	if config.InventoryFilter != "" {
+		filters = strings.Split(config.InventoryFilter, ",")
+	}
+	var targets []fleet.Target
+	for _, sg := range inv.ServerGroups {
+		if len(filters) > 0 && !slices.Contains(filters, sg.Name) {
+			continue
+		}
+		for _, srv := range sg.Servers {
+			targets = append(targets, fleet.Target{
+				Name:          srv.Name,
+				Host:          srv.Host,
+				NodePort:      ports[constants.NodePort],
+				SuperNodePort: ports[constants.SNPort],
+			})
+		}
+	}
+	// groups are read from the map, so they are sorted to get the same order every time
+	sort.Slice(targets, func(i, j int) bool { return targets[i].Name < targets[j].Name })
+	return targets, nil
+}
+
+func printFleetResults(results []fleet.Result) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tHOST\tADVERTISED\tPASTELD\tSUPERNODE\tWALLETNODE\tMISMATCHES")
+	for _, result := range results {
+		advertised := result.Advertised
+		if advertised == "" {
+			advertised = "-"
+		}
+		mismatches := "-"
+		if len(result.Mismatches) > 0 {
+			mismatches = strings.Join(result.Mismatches, "; ")
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", result.Name, result.Host, advertised,
+			formatProbe(result.Node), formatProbe(result.SuperNode), formatProbe(result.WalletNode), mismatches)
+	}
+	_ = w.Flush()
+}
+
+// formatProbe returns latency of the reachable service and the problem, if it has one
+func formatProbe(probe *fleet.Probe) string {
+	if probe == nil {
+		return "-"
+	}
+	if !probe.Reachable {
+		return "down"
+	}
+	text := probe.Latency.Round(time.Millisecond).String()
+	if probe.Error != "" {
+		text += " (" + probe.Error + ")"
+	}
+	return text
+}
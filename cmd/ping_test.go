@@ -0,0 +1,33 @@
+package cmd
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pastelnetwork/pastelup/configs"
+	"github.com/tj/assert"
+)
+
+func TestMasternodeList(t *testing.T) {
+	response := `{"result":{},"error":null}`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(response))
+	}))
+	defer server.Close()
+
+	config := configs.InitConfig(nil)
+	config.RPCUser, config.RPCPwd = "user", "password"
+	config.RPCPort = server.Listener.Addr().(*net.TCPAddr).Port
+
+	entries, err := masternodeList(config)
+	assert.NoError(t, err)
+	assert.Empty(t, entries)
+
+	// pasteld reports the error in the response, e.g. while it is loading the chain
+	response = `{"result":null,"error":{"code":-28,"message":"Loading block index..."}}`
+	_, err = masternodeList(config)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "Loading block index...")
+}
@@ -0,0 +1,298 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/pastelnetwork/pastelup/common/log"
+	"github.com/pastelnetwork/pastelup/configs"
+	"github.com/pastelnetwork/pastelup/constants"
+	"github.com/pastelnetwork/pastelup/utils"
+)
+
+// portRef is the port option in the component's config file
+type portRef struct {
+	tool constants.ToolType
+	key  string
+	// hostPort is set if the value is "host:port"
+	hostPort bool
+}
+
+// portBinding is the port the component listens on
+type portBinding struct {
+	portRef
+	// defaultPort is used when the option is not set in the config file
+	defaultPort func(config *configs.Config) int
+	// refs are the options of other components which point to this port
+	refs []portRef
+	// custom returns the field of the instance ports the port is stored in
+	custom func(ports *configs.Ports) *int
+	// fixed is the reason why the port can't be moved by --auto-ports
+	fixed string
+}
+
+var portBindings = []portBinding{
+	{
+		portRef:     portRef{tool: constants.PastelD, key: "port"},
+		defaultPort: func(config *configs.Config) int { return GetSNPortList(config)[constants.NodePort] },
+		fixed:       "peers and masternode.conf expect it",
+	},
+	{
+		portRef:     portRef{tool: constants.PastelD, key: "rpcport"},
+		defaultPort: func(config *configs.Config) int { return GetSNPortList(config)[constants.NodeRPCPort] },
+		custom:      func(ports *configs.Ports) *int { return &ports.NodeRPC },
+	},
+	{
+		portRef: portRef{tool: constants.SuperNode, key: "node.server.port"},
+		refs:    []portRef{{tool: constants.Hermes, key: "sn_port"}},
+		fixed:   "it is registered in the masternode list",
+	},
+	{
+		portRef: portRef{tool: constants.SuperNode, key: "p2p.port"},
+		fixed:   "other supernodes expect it",
+	},
+	{
+		portRef: portRef{tool: constants.SuperNode, key: "metadb.http_port"},
+		fixed:   "other supernodes expect it",
+	},
+	{
+		portRef: portRef{tool: constants.SuperNode, key: "metadb.raft_port"},
+		fixed:   "other supernodes expect it",
+	},
+	{
+		portRef: portRef{tool: constants.RQService, key: "grpc-service", hostPort: true},
+		refs: []portRef{
+			{tool: constants.SuperNode, key: "raptorq.port"},
+			{tool: constants.WalletNode, key: "raptorq.port"},
+		},
+		custom: func(ports *configs.Ports) *int { return &ports.RQService },
+	},
+	{
+		portRef: portRef{tool: constants.DDService},
+		defaultPort: func(_ *configs.Config) int {
+			return constants.DDServerDefaultPort
+		},
+		fixed: "dd-service is shared by all instances on the host",
+	},
+	{
+		portRef: portRef{tool: constants.WalletNode, key: "node.api.port"},
+		custom:  func(ports *configs.Ports) *int { return &ports.WNAPI },
+	},
+	{
+		portRef: portRef{tool: constants.Bridge, key: "server.port"},
+		refs:    []portRef{{tool: constants.WalletNode, key: "bridge.port"}},
+		custom:  func(ports *configs.Ports) *int { return &ports.Bridge },
+	},
+}
+
+// startPortComponents are the components, ports of which are checked before the start command
+var startPortComponents = map[startCommand][]constants.ToolType{
+	nodeStart:      {constants.PastelD},
+	masterNode:     {constants.PastelD},
+	walletStart:    {constants.PastelD, constants.RQService, constants.WalletNode, constants.Bridge},
+	superNodeStart: {constants.PastelD, constants.RQService, constants.DDService, constants.SuperNode},
+	rqService:      {constants.RQService},
+	ddService:      {constants.DDService},
+	wnService:      {constants.WalletNode},
+	snService:      {constants.SuperNode},
+	bridgeService:  {constants.Bridge},
+}
+
+// portConfigs caches config files of the components loaded by the port check
+type portConfigs map[constants.ToolType]configEditor
+
+func (c portConfigs) get(config *configs.Config, tool constants.ToolType) (configEditor, error) {
+	if editor, ok := c[tool]; ok {
+		return editor, nil
+	}
+	component, ok := configComponents[tool]
+	if !ok || !utils.CheckFileExist(component.path(config)) {
+		c[tool] = nil
+		return nil, nil
+	}
+	editor, err := component.load(component.path(config))
+	if err != nil {
+		return nil, err
+	}
+	c[tool] = editor
+	return editor, nil
+}
+
+func (r portRef) read(editor configEditor) (int, bool, error) {
+	if editor == nil || r.key == "" {
+		return 0, false, nil
+	}
+	value, ok, err := editor.Lookup(r.key)
+	if err != nil || !ok {
+		return 0, false, err
+	}
+	if r.hostPort {
+		if _, value, err = net.SplitHostPort(value); err != nil {
+			return 0, false, fmt.Errorf("%s: %v", r.key, err)
+		}
+	}
+	port, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil {
+		return 0, false, fmt.Errorf("%s: invalid port %q", r.key, value)
+	}
+	return port, true, nil
+}
+
+func (r portRef) write(editor configEditor, port int) error {
+	value := strconv.Itoa(port)
+	if r.hostPort {
+		current, _, err := editor.Lookup(r.key)
+		if err != nil {
+			return err
+		}
+		host, _, err := net.SplitHostPort(current)
+		if err != nil {
+			return fmt.Errorf("%s: %v", r.key, err)
+		}
+		value = net.JoinHostPort(host, value)
+	}
+	return editor.Set(r.key, value)
+}
+
+func (r portRef) String() string {
+	if r.key == "" {
+		return string(r.tool)
+	}
+	return fmt.Sprintf("%s %s", r.tool, r.key)
+}
+
+// isOwnedBy checks if the port owner is the component of the work dir itself, e.g. it is already running. The owner
+// is matched by name only for the default instance, as the components of other instances have the same names
+func isOwnedBy(ctx context.Context, config *configs.Config, owner utils.PortOwner, tool constants.ToolType) bool {
+	if pid, err := servicePid(ctx, config, tool); err == nil && pid != 0 {
+		return owner.Pid == pid
+	}
+	if config.Instance != "" {
+		return false
+	}
+	if tool == constants.DDService {
+		// dd-service is the script run by python of its venv, other python processes aren't it
+		script := filepath.Join(config.PastelExecDir, utils.GetDupeDetectionExecName())
+		for _, arg := range owner.Args {
+			if filepath.Clean(arg) == script {
+				return true
+			}
+		}
+		return false
+	}
+	execName := constants.ServiceName[tool][utils.GetOS()]
+	// process names are truncated to 15 characters by the kernel
+	return owner.Name != "" && execName != "" && strings.HasPrefix(execName, owner.Name)
+}
+
+// checkStartPorts probes ports of the components started by the start command. Busy ports are reported
+// with the process holding them, with autoPorts they are moved to free ports in all configs referring to them
+func checkStartPorts(ctx context.Context, config *configs.Config, command startCommand, autoPorts bool) error {
+	tools, ok := startPortComponents[command]
+	if !ok {
+		return nil
+	}
+
+	confs := portConfigs{}
+	type binding struct {
+		portBinding
+		port int
+	}
+	var bindings []binding
+	var used []int
+	for _, b := range portBindings {
+		if !utils.ContainsToolType(tools, b.tool) {
+			continue
+		}
+		editor, err := confs.get(config, b.tool)
+		if err != nil {
+			return err
+		}
+		port, ok, err := b.read(editor)
+		if err != nil {
+			return fmt.Errorf("%s config: %v", b.tool, err)
+		}
+		if !ok {
+			if b.defaultPort == nil || (editor == nil && b.key != "") {
+				continue
+			}
+			port = b.defaultPort(config)
+		}
+		bindings = append(bindings, binding{portBinding: b, port: port})
+		used = append(used, port)
+	}
+
+	var busy []string
+	modified := map[constants.ToolType]bool{}
+	for _, b := range bindings {
+		if utils.IsPortFree(b.port) {
+			continue
+		}
+		owner := utils.FindPortOwner(b.port)
+		if isOwnedBy(ctx, config, owner, b.tool) {
+			log.WithContext(ctx).Infof("Port %d of %s is used by running %s", b.port, b.portRef, owner)
+			continue
+		}
+		if !autoPorts || b.fixed != "" || b.custom == nil {
+			msg := fmt.Sprintf("port %d of %s is in use by %s", b.port, b.portRef, owner)
+			if autoPorts && b.fixed != "" {
+				msg += fmt.Sprintf(", it can't be changed automatically - %s", b.fixed)
+			}
+			busy = append(busy, msg)
+			continue
+		}
+
+		port, err := utils.FindFreePort(b.port, used)
+		if err != nil {
+			return err
+		}
+		used = append(used, port)
+		log.WithContext(ctx).Warnf("Port %d of %s is in use by %s, moving it to %d", b.port, b.portRef, owner, port)
+		for _, ref := range append([]portRef{b.portRef}, b.refs...) {
+			editor, err := confs.get(config, ref.tool)
+			if err != nil {
+				return err
+			}
+			if editor == nil {
+				continue
+			}
+			if err := ref.write(editor, port); err != nil {
+				return fmt.Errorf("failed to set %s: %v", ref, err)
+			}
+			modified[ref.tool] = true
+		}
+		*b.custom(&config.Ports) = port
+		if b.tool == constants.PastelD && b.key == "rpcport" {
+			config.RPCPort = port
+		}
+	}
+
+	for tool := range modified {
+		path := configComponents[tool].path(config)
+		if backupPath, err := backUpConfigFile(path); err == nil {
+			log.WithContext(ctx).Infof("%s backed up to %s", path, backupPath)
+		}
+		if err := confs[tool].Save(path); err != nil {
+			return err
+		}
+	}
+	if len(modified) > 0 {
+		// ports are stored in the instance state, so new configs rendered by update use them too
+		if err := saveInstanceState(config); err != nil {
+			return fmt.Errorf("failed to save instance ports: %v", err)
+		}
+	}
+
+	if len(busy) > 0 {
+		for _, msg := range busy {
+			log.WithContext(ctx).Error(msg)
+		}
+		return fmt.Errorf("%d port(s) are busy: %s - free them, use --auto-ports or --skip-port-check",
+			len(busy), strings.Join(busy, "; "))
+	}
+	return nil
+}
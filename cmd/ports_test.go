@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pastelnetwork/pastelup/configs"
+	"github.com/pastelnetwork/pastelup/constants"
+	"github.com/pastelnetwork/pastelup/utils"
+	"github.com/tj/assert"
+)
+
+func TestIsOwnedBy(t *testing.T) {
+	config := configs.InitConfig(nil)
+	config.PastelExecDir = "/home/user/pastel"
+	config.WorkingDir = t.TempDir()
+	script := filepath.Join(config.PastelExecDir, utils.GetDupeDetectionExecName())
+	venvPython := filepath.Join(config.PastelExecDir, constants.DupeDetectionSubFolder, "venv", "bin", "python3")
+
+	tests := []struct {
+		name  string
+		owner utils.PortOwner
+		tool  constants.ToolType
+		owned bool
+	}{
+		{
+			name:  "dd-service script",
+			owner: utils.PortOwner{Pid: 1, Name: "python3", Args: []string{venvPython, script, "config.ini"}},
+			tool:  constants.DDService,
+			owned: true,
+		},
+		{
+			name:  "other python process",
+			owner: utils.PortOwner{Pid: 1, Name: "python3", Args: []string{"python3", "-m", "http.server", "8000"}},
+			tool:  constants.DDService,
+		},
+		{
+			name:  "dd-service of another pastel directory",
+			owner: utils.PortOwner{Pid: 1, Name: "python3", Args: []string{"python3", "/opt/pastel/" + utils.GetDupeDetectionExecName()}},
+			tool:  constants.DDService,
+		},
+		{
+			// the name is truncated by the kernel
+			name:  "supernode",
+			owner: utils.PortOwner{Pid: 1, Name: constants.SuperNodeExecName[utils.GetOS()][:15]},
+			tool:  constants.SuperNode,
+			owned: true,
+		},
+		{
+			name:  "another process",
+			owner: utils.PortOwner{Pid: 1, Name: "nginx"},
+			tool:  constants.SuperNode,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.owned, isOwnedBy(context.Background(), config, test.owner, test.tool))
+		})
+	}
+
+	// components of the named instance are found by their pid files
+	config.Instance = "testnet2"
+	supernode := utils.PortOwner{Pid: os.Getpid(), Name: constants.SuperNodeExecName[utils.GetOS()]}
+	assert.False(t, isOwnedBy(context.Background(), config, supernode, constants.SuperNode))
+	assert.NoError(t, utils.WritePidFile(pidFilePath(config, constants.SuperNode), os.Getpid()))
+	assert.True(t, isOwnedBy(context.Background(), config, supernode, constants.SuperNode))
+	// supernode of another instance has the same name
+	supernode.Pid = 1
+	assert.False(t, isOwnedBy(context.Background(), config, supernode, constants.SuperNode))
+}
@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/pastelnetwork/pastelup/common/log"
+	"github.com/pastelnetwork/pastelup/configs"
+	"github.com/pastelnetwork/pastelup/constants"
+	"github.com/pastelnetwork/pastelup/utils"
+)
+
+const pidFileDir = "run"
+
+// pidFilePath returns path of the pid file of the component started by pastelup
+func pidFilePath(config *configs.Config, tool constants.ToolType) string {
+	return filepath.Join(config.WorkingDir, constants.PastelupStateDir, pidFileDir, string(tool)+".pid")
+}
+
+// startProcess starts the component in background and records its pid file, the output goes to stdout of pastelup
+func startProcess(ctx context.Context, config *configs.Config, tool constants.ToolType, command string, args ...string) error {
+	cmd := exec.Command(command, args...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("unable to start %s: %v", tool, err)
+	}
+	go func() {
+		_ = cmd.Wait()
+	}()
+
+	if err := utils.WritePidFile(pidFilePath(config, tool), cmd.Process.Pid); err != nil {
+		log.WithContext(ctx).WithError(err).Warnf("Unable to write pid file of %s", tool)
+	}
+	return nil
+}
+
+// recordPasteldPid records pid file of the started pasteld. pasteld forks with --daemon, so its pid is known
+// only once it holds the data dir lock
+func recordPasteldPid(ctx context.Context, config *configs.Config) {
+	pid, err := utils.LockHolderPid(pasteldLockPath(config))
+	if err != nil || pid == 0 {
+		return
+	}
+	if err = utils.WritePidFile(pidFilePath(config, constants.PastelD), pid); err != nil {
+		log.WithContext(ctx).WithError(err).Warn("Unable to write pid file of pasteld")
+	}
+}
+
+// servicePid returns pid of the component, which uses the work dir, or 0 if it isn't running. The component is found
+// by its pid file, the processes are matched by name only for the default instance, as the name can't tell instances apart
+func servicePid(ctx context.Context, config *configs.Config, tool constants.ToolType) (int, error) {
+	if tool == constants.PastelD {
+		return pasteldPid(config)
+	}
+	if pid, err := utils.ReadPidFile(pidFilePath(config, tool)); err != nil || pid != 0 {
+		return pid, err
+	}
+	if config.Instance != "" {
+		return 0, nil
+	}
+	if override, ok := serviceToProcessOverrides[string(tool)]; ok {
+		return FindRunningProcessPid(ctx, override)
+	}
+	if _, ok := constants.ServiceName[tool]; !ok {
+		return 0, nil
+	}
+	return GetRunningProcessPid(tool)
+}
@@ -0,0 +1,128 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"time"
+
+	"github.com/pastelnetwork/pastelup/common/log"
+	"github.com/pastelnetwork/pastelup/configs"
+	"github.com/pastelnetwork/pastelup/constants"
+	"github.com/pastelnetwork/pastelup/services/pastelcore"
+	"github.com/pastelnetwork/pastelup/structure"
+	"github.com/pastelnetwork/pastelup/utils"
+)
+
+const (
+	defaultStartTimeout = 5 * time.Minute
+	// defaultSyncStallTimeout is how long pasteld may sync without progress
+	defaultSyncStallTimeout = 30 * time.Minute
+	readinessInterval       = 2 * time.Second
+	// processStartGrace is how long the started process may be not found before it is considered exited
+	processStartGrace = 10 * time.Second
+	// hermesStableTime is how long hermes must keep running if it doesn't write its log
+	hermesStableTime = 10 * time.Second
+
+	// rpcInWarmupCode is the RPC error code of pasteld, which is still loading
+	rpcInWarmupCode = -28
+)
+
+// readinessProbe checks that the component serves requests, started is the time the component was started at
+type readinessProbe func(ctx context.Context, config *configs.Config, started time.Time) error
+
+// readinessProbes are the probes of the components, the other components are ready when their process is running
+var readinessProbes = map[constants.ToolType]readinessProbe{
+	constants.PastelD: probePasteld,
+	constants.RQService: func(ctx context.Context, config *configs.Config, _ time.Time) error {
+		return probeTCPPort(ctx, rqServicePort(config))
+	},
+	constants.DDService: func(ctx context.Context, _ *configs.Config, _ time.Time) error {
+		return probeTCPPort(ctx, constants.DDServerDefaultPort)
+	},
+	constants.SuperNode: func(ctx context.Context, config *configs.Config, _ time.Time) error {
+		_, err := pingSuperNode(ctx, fmt.Sprintf("localhost:%d", GetSNPortList(config)[constants.SNPort]))
+		return err
+	},
+	constants.Hermes: probeHermes,
+}
+
+// waitForReady waits until the started component is ready, the error tells which probe has failed.
+// The component fails at once if its process exits
+func waitForReady(ctx context.Context, config *configs.Config, tool constants.ToolType) error {
+	timeout := config.StartTimeout
+	if timeout == 0 {
+		timeout = defaultStartTimeout
+	}
+	probe := readinessProbes[tool]
+	started := time.Now()
+	seen := false
+
+	log.WithContext(ctx).Infof("Waiting for %s to be ready...", tool)
+	err := utils.WaitReady(ctx, timeout, readinessInterval, func(ctx context.Context) error {
+		// dd-service runs in python, it is found by its port only
+		if _, ok := constants.ServiceName[tool]; ok {
+			if !CheckProcessRunning(ctx, config, tool) {
+				err := fmt.Errorf("process check failed: %s is not running", tool)
+				if seen || time.Since(started) > processStartGrace {
+					return utils.PermanentProbeError(err)
+				}
+				return err
+			}
+			seen = true
+		}
+		if probe == nil {
+			return nil
+		}
+		if err := probe(ctx, config, started); err != nil {
+			return fmt.Errorf("readiness probe failed: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("%s is not ready: %w", tool, err)
+	}
+	log.WithContext(ctx).Infof("%s is ready (%v)", tool, time.Since(started).Round(time.Second))
+	return nil
+}
+
+// probePasteld checks that pasteld answers getinfo RPC call
+func probePasteld(_ context.Context, config *configs.Config, _ time.Time) error {
+	var info structure.RPCGetInfo
+	if err := pastelcore.NewClient(config).RunCommand(pastelcore.GetInfoCmd, &info); err != nil {
+		return fmt.Errorf("getinfo: %v", err)
+	}
+	if info.Result.Version != 0 {
+		return nil
+	}
+	if rpcErr, ok := info.Error.(map[string]interface{}); ok {
+		if code, _ := rpcErr["code"].(float64); code == rpcInWarmupCode {
+			return fmt.Errorf("getinfo: %v: %w", rpcErr["message"], utils.ErrWarmingUp)
+		}
+		return fmt.Errorf("getinfo: %v", rpcErr["message"])
+	}
+	return fmt.Errorf("getinfo: empty response")
+}
+
+// probeTCPPort checks that the local port accepts connections
+func probeTCPPort(ctx context.Context, port int) error {
+	dialer := net.Dialer{Timeout: readinessInterval}
+	conn, err := dialer.DialContext(ctx, "tcp", fmt.Sprintf("localhost:%d", port))
+	if err != nil {
+		return fmt.Errorf("port %d: %v", port, err)
+	}
+	return conn.Close()
+}
+
+// probeHermes checks that hermes writes its log after start or keeps running if there is no log
+func probeHermes(_ context.Context, config *configs.Config, started time.Time) error {
+	logPath := config.Configurer.GetHermesLogFile(config.WorkingDir)
+	if info, err := os.Stat(logPath); err == nil && !info.ModTime().Before(started) {
+		return nil
+	}
+	if time.Since(started) >= hermesStableTime {
+		return nil
+	}
+	return fmt.Errorf("hermes has not written %s yet", logPath)
+}
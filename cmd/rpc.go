@@ -0,0 +1,331 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/pastelnetwork/pastelup/common/cli"
+	"github.com/pastelnetwork/pastelup/common/log"
+	"github.com/pastelnetwork/pastelup/configs"
+	"github.com/pastelnetwork/pastelup/constants"
+	"github.com/pastelnetwork/pastelup/utils"
+)
+
+const (
+	rpcUserLen     = 8
+	rpcPasswordLen = 32
+
+	pastelAPIUserKey     = "pastel-api.username"
+	pastelAPIPasswordKey = "pastel-api.password"
+)
+
+// rpcDependents are the components, which talk to pasteld, in the order they are started.
+// They read credentials from pastel.conf, pastel-api options are updated only if the operator has set them
+var rpcDependents = []constants.ToolType{constants.SuperNode, constants.Hermes, constants.WalletNode, constants.Bridge}
+
+// rotatedConfig is the config file with its current and new content
+type rotatedConfig struct {
+	path     string
+	old, new []byte
+}
+
+func setupRPCCommand(config *configs.Config) *cli.Command {
+	rotateFlags := []*cli.Flag{
+		cli.NewFlag("dir", &config.PastelExecDir).SetAliases("d").
+			SetUsage(green("Optional, Location of the pastel node directory")).SetValue(config.Configurer.DefaultPastelExecutableDir()),
+		cli.NewFlag("work-dir", &config.WorkingDir).SetAliases("w").
+			SetUsage(green("Optional, Location of the working directory")).SetValue(config.Configurer.DefaultWorkingDir()),
+		cli.NewFlag("cookie", &config.RPCCookie).
+			SetUsage(green("Optional, switch to cookie authentication - rpcuser and rpcpassword are removed from pastel.conf, " +
+				"pasteld generates new credentials at every start")),
+		cli.NewFlag("no-restart", &config.NoRestart).
+			SetUsage(green("Optional, only update config files, running components keep old credentials until restarted")),
+	}
+
+	rotateSubCommand := cli.NewCommand("rotate-credentials")
+	rotateSubCommand.SetUsage(cyan("Generate new RPC credentials of pasteld, update pastel.conf and configs of the dependent " +
+		"components, restart running components and verify the connection"))
+	rotateSubCommand.AddFlags(rotateFlags...)
+	addLogFlags(rotateSubCommand, config)
+	rotateSubCommand.SetActionFunc(func(ctx context.Context, _ []string) error {
+		ctx, err := configureLogging(ctx, "rpc", config)
+		if err != nil {
+			return fmt.Errorf("failed to configure logging option - %v", err)
+		}
+		if err = ParsePastelConf(ctx, config); err != nil {
+			return err
+		}
+		return runRPCRotateCredentials(ctx, config)
+	})
+
+	rpcCommand := cli.NewCommand("rpc")
+	rpcCommand.SetUsage(blue("Manage RPC access to pasteld"))
+	rpcCommand.AddSubcommands(rotateSubCommand)
+
+	return rpcCommand
+}
+
+// setPastelConfRPCCredentials sets RPC credentials in pastel.conf, empty user switches pasteld to cookie authentication
+func setPastelConfRPCCredentials(conf *utils.PastelConf, user, password string) {
+	if user == "" {
+		conf.Unset("rpcuser")
+		conf.Unset("rpcpassword")
+		return
+	}
+	conf.Set("rpcuser", user)
+	conf.Set("rpcpassword", password)
+}
+
+func runRPCRotateCredentials(ctx context.Context, config *configs.Config) error {
+	// running components are found before the change, pasteld is checked with the current credentials
+	_, pasteldErr := GetPastelInfo(ctx, config)
+	pasteldRunning := pasteldErr == nil
+	var pasteldArgs []string
+	if pasteldRunning {
+		_, pasteldArgs = GetProcessCmdInput(ctx, config, constants.PastelD)
+	}
+	var running []constants.ToolType
+	for _, tool := range rpcDependents {
+		if CheckProcessRunning(ctx, config, tool) {
+			running = append(running, tool)
+		}
+	}
+
+	var user, password string
+	if !config.RPCCookie {
+		var err error
+		if user, err = utils.GenerateSecret(rpcUserLen); err != nil {
+			return err
+		}
+		if password, err = utils.GenerateSecret(rpcPasswordLen); err != nil {
+			return err
+		}
+		log.AddSecret(password)
+	}
+
+	rotated, err := rotateRPCConfigs(ctx, config, user, password)
+	if err != nil {
+		return err
+	}
+	if !pasteldRunning || config.NoRestart {
+		if err = writeRotatedConfigs(ctx, rotated); err != nil {
+			return err
+		}
+		logRotatedConfigs(ctx, config, rotated)
+		if !pasteldRunning {
+			log.WithContext(ctx).Info("pasteld is not running, new credentials are used at the next start")
+		} else {
+			log.WithContext(ctx).Warnf("Restart pasteld and %v to apply new credentials", running)
+		}
+		return nil
+	}
+
+	err = applyRotatedConfigs(ctx, rotated, func() error {
+		return restartPasteldWithConfig(ctx, config, pasteldArgs, running)
+	})
+	if err == nil {
+		logRotatedConfigs(ctx, config, rotated)
+	}
+	// dependents are started with the credentials pasteld runs with, new or restored ones
+	if startErr := startRPCDependents(ctx, config, running); err == nil {
+		err = startErr
+	}
+	return err
+}
+
+func logRotatedConfigs(ctx context.Context, config *configs.Config, rotated []rotatedConfig) {
+	if config.RPCCookie {
+		log.WithContext(ctx).Info("pastel.conf switched to cookie authentication")
+	} else {
+		log.WithContext(ctx).Infof("New RPC credentials written to %d config file(s)", len(rotated))
+	}
+}
+
+// rotateRPCConfigs renders pastel.conf and configs of the dependent components with the new credentials
+func rotateRPCConfigs(ctx context.Context, config *configs.Config, user, password string) ([]rotatedConfig, error) {
+	pastelConfPath := configComponents[constants.PastelD].path(config)
+	pastelConf, err := utils.LoadPastelConf(pastelConfPath)
+	if err != nil {
+		return nil, err
+	}
+	old, err := os.ReadFile(pastelConfPath)
+	if err != nil {
+		return nil, err
+	}
+	setPastelConfRPCCredentials(pastelConf, user, password)
+	rotated := []rotatedConfig{{path: pastelConfPath, old: old, new: pastelConf.Bytes()}}
+
+	for _, tool := range rpcDependents {
+		component := configComponents[tool]
+		path := component.path(config)
+		if !utils.CheckFileExist(path) {
+			continue
+		}
+		editor, err := component.load(path)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok, _ := editor.Lookup(pastelAPIUserKey); !ok {
+			continue
+		}
+		if user == "" {
+			log.WithContext(ctx).Warnf("%s has pasteld credentials in pastel-api options, they are removed - "+
+				"make sure %s supports cookie authentication", path, tool)
+			editor.Unset(pastelAPIUserKey, "")
+			editor.Unset(pastelAPIPasswordKey, "")
+		} else {
+			if err = editor.Set(pastelAPIUserKey, user); err != nil {
+				return nil, fmt.Errorf("%s: %v", path, err)
+			}
+			if err = editor.Set(pastelAPIPasswordKey, password); err != nil {
+				return nil, fmt.Errorf("%s: %v", path, err)
+			}
+		}
+		data, err := editor.Bytes()
+		if err != nil {
+			return nil, err
+		}
+		if old, err = os.ReadFile(path); err != nil {
+			return nil, err
+		}
+		rotated = append(rotated, rotatedConfig{path: path, old: old, new: data})
+	}
+	return rotated, nil
+}
+
+// writeConfigFile writes the rotated configs, they keep credentials, so only the owner can read them
+var writeConfigFile = utils.WriteSecretFile
+
+// writeRotatedConfigs backs up and writes all configs, if any write fails already written configs are restored,
+// so pasteld and its dependents never end up with different credentials
+func writeRotatedConfigs(ctx context.Context, rotated []rotatedConfig) error {
+	for _, conf := range rotated {
+		backupPath, err := backUpConfigFile(conf.path)
+		if err != nil {
+			return fmt.Errorf("failed to back up %s: %v", conf.path, err)
+		}
+		// backups keep the old credentials
+		if err = os.Chmod(backupPath, utils.SecretFilePerm); err != nil {
+			return err
+		}
+		log.WithContext(ctx).Infof("%s backed up to %s", conf.path, backupPath)
+	}
+
+	for i, conf := range rotated {
+		if err := writeConfigFile(conf.path, conf.new); err != nil {
+			restoreRotatedConfigs(ctx, rotated[:i])
+			return fmt.Errorf("failed to write %s: %v", conf.path, err)
+		}
+	}
+	return nil
+}
+
+// restoreRotatedConfigs writes back the old content of the configs, it returns false if any of them is left with
+// the new one
+func restoreRotatedConfigs(ctx context.Context, rotated []rotatedConfig) bool {
+	restored := true
+	for _, conf := range rotated {
+		if err := writeConfigFile(conf.path, conf.old); err != nil {
+			log.WithContext(ctx).WithError(err).Errorf("Failed to restore %s, restore it from the backup", conf.path)
+			restored = false
+		}
+	}
+	return restored
+}
+
+// applyRotatedConfigs writes the configs and restarts pasteld with them. If pasteld doesn't start with the new
+// credentials, the old configs are restored and pasteld is restarted with them again
+func applyRotatedConfigs(ctx context.Context, rotated []rotatedConfig, restart func() error) error {
+	if err := writeRotatedConfigs(ctx, rotated); err != nil {
+		return err
+	}
+	err := restart()
+	if err == nil {
+		return nil
+	}
+	log.WithContext(ctx).WithError(err).Error("pasteld doesn't start with new RPC credentials, restoring old configs")
+	if !restoreRotatedConfigs(ctx, rotated) {
+		return fmt.Errorf("%v, old configs are kept in *.bak files", err)
+	}
+	if restartErr := restart(); restartErr != nil {
+		return fmt.Errorf("%v, old RPC credentials are restored but pasteld doesn't start with them either: %v", err, restartErr)
+	}
+	return fmt.Errorf("%v, old RPC credentials are restored", err)
+}
+
+// restartPasteldWithConfig stops running dependent components and restarts pasteld, so it uses credentials
+// of the current pastel.conf
+func restartPasteldWithConfig(ctx context.Context, config *configs.Config, pasteldArgs []string, running []constants.ToolType) error {
+	// dependents are stopped first, so they don't lose pasteld in the middle of the work
+	var stopOrder []constants.ToolType
+	for i := len(running) - 1; i >= 0; i-- {
+		stopOrder = append(stopOrder, running[i])
+	}
+	if err := stopServices(ctx, append(stopOrder, constants.PastelD), config); err != nil {
+		return err
+	}
+
+	// credentials or cookie file are loaded from the updated pastel.conf
+	if err := ParsePastelConf(ctx, config); err != nil {
+		return err
+	}
+	if err := startPasteldWithArgs(ctx, config, pasteldArgs); err != nil {
+		return err
+	}
+	if !WaitingForPastelDToStart(ctx, config) {
+		return fmt.Errorf("pasteld doesn't accept RPC credentials of %s", configComponents[constants.PastelD].path(config))
+	}
+	log.WithContext(ctx).Info("pasteld accepts RPC credentials of pastel.conf")
+	return nil
+}
+
+// startRPCDependents starts dependent components, which were running before the credentials were rotated
+func startRPCDependents(ctx context.Context, config *configs.Config, running []constants.ToolType) error {
+	var failed []constants.ToolType
+	for _, tool := range running {
+		var err error
+		switch tool {
+		case constants.SuperNode:
+			// hermes is started together with supernode
+			err = runSuperNodeService(ctx, config)
+		case constants.Hermes:
+			if !utils.ContainsToolType(running, constants.SuperNode) {
+				err = runHermesService(ctx, config)
+			}
+		case constants.WalletNode:
+			err = runWalletNodeService(ctx, config)
+		case constants.Bridge:
+			err = runBridgeService(ctx, config)
+		}
+		if err != nil || !CheckProcessRunning(ctx, config, tool) {
+			log.WithContext(ctx).WithError(err).Errorf("Failed to restart %s", tool)
+			failed = append(failed, tool)
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("failed to restart %v, start them with 'pastelup start'", failed)
+	}
+	return nil
+}
+
+// startPasteldWithArgs starts pasteld as system service if it is registered, otherwise with the arguments it was running with
+func startPasteldWithArgs(ctx context.Context, config *configs.Config, args []string) error {
+	if sm, err := NewServiceManager(utils.GetOS(), config.Configurer.DefaultHomeDir(), config.Instance); err == nil {
+		started, err := sm.StartService(ctx, config, constants.PastelD)
+		if err != nil {
+			return err
+		}
+		if started {
+			return nil
+		}
+	}
+	if len(args) == 0 {
+		args = []string{fmt.Sprintf("--datadir=%s", config.WorkingDir), "--daemon"}
+	}
+	pastelDPath := filepath.Join(config.PastelExecDir, constants.PasteldName[utils.GetOS()])
+	log.WithContext(ctx).Infof("Starting %s", pastelDPath)
+	go RunCMD(pastelDPath, args...)
+	return nil
+}
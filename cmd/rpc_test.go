@@ -0,0 +1,126 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pastelnetwork/pastelup/configs"
+	"github.com/pastelnetwork/pastelup/constants"
+	"github.com/tj/assert"
+)
+
+func TestApplyRotatedConfigs(t *testing.T) {
+	defer func(f func(string, []byte) error) { writeConfigFile = f }(writeConfigFile)
+	ctx := context.Background()
+	const (
+		oldPastelConf = "testnet=1\nrpcuser=olduser\nrpcpassword=oldpassword\n"
+		oldSuperNode  = "pastel-api:\n  username: olduser\n  password: oldpassword\n"
+	)
+
+	setup := func(t *testing.T) (*configs.Config, []rotatedConfig) {
+		config := configs.InitConfig(nil)
+		config.WorkingDir = t.TempDir()
+		for tool, data := range map[constants.ToolType]string{constants.PastelD: oldPastelConf, constants.SuperNode: oldSuperNode} {
+			path := configComponents[tool].path(config)
+			assert.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+			assert.NoError(t, os.WriteFile(path, []byte(data), 0644))
+		}
+		rotated, err := rotateRPCConfigs(ctx, config, "newuser", "newpassword")
+		assert.NoError(t, err)
+		assert.Len(t, rotated, 2)
+		return config, rotated
+	}
+	read := func(config *configs.Config, tool constants.ToolType) string {
+		data, err := os.ReadFile(configComponents[tool].path(config))
+		assert.NoError(t, err)
+		return string(data)
+	}
+
+	t.Run("restart succeeds", func(t *testing.T) {
+		writeConfigFile = func(path string, data []byte) error { return os.WriteFile(path, data, 0600) }
+		config, rotated := setup(t)
+		restarts := 0
+		assert.NoError(t, applyRotatedConfigs(ctx, rotated, func() error {
+			restarts++
+			return nil
+		}))
+		assert.Equal(t, 1, restarts)
+		assert.Contains(t, read(config, constants.PastelD), "rpcuser=newuser")
+		assert.Contains(t, read(config, constants.SuperNode), "username: newuser")
+		backups, err := filepath.Glob(filepath.Join(config.WorkingDir, "*.bak"))
+		assert.NoError(t, err)
+		assert.NotEmpty(t, backups)
+	})
+
+	t.Run("write fails", func(t *testing.T) {
+		config, rotated := setup(t)
+		failPath := configComponents[constants.SuperNode].path(config)
+		writeConfigFile = func(path string, data []byte) error {
+			if path == failPath {
+				return fmt.Errorf("disk full")
+			}
+			return os.WriteFile(path, data, 0600)
+		}
+		err := applyRotatedConfigs(ctx, rotated, func() error {
+			t.Fatal("pasteld must not be restarted")
+			return nil
+		})
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "disk full")
+		// pastel.conf written before the failure is restored
+		assert.Equal(t, oldPastelConf, read(config, constants.PastelD))
+		assert.Equal(t, oldSuperNode, read(config, constants.SuperNode))
+	})
+
+	t.Run("restart fails", func(t *testing.T) {
+		writeConfigFile = func(path string, data []byte) error { return os.WriteFile(path, data, 0600) }
+		config, rotated := setup(t)
+		var seen []string
+		err := applyRotatedConfigs(ctx, rotated, func() error {
+			seen = append(seen, read(config, constants.PastelD))
+			if len(seen) == 1 {
+				return fmt.Errorf("pasteld doesn't accept RPC credentials")
+			}
+			return nil
+		})
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "old RPC credentials are restored")
+		// pasteld is restarted with the old credentials
+		assert.Len(t, seen, 2)
+		assert.Contains(t, seen[0], "rpcuser=newuser")
+		assert.Equal(t, oldPastelConf, seen[1])
+		assert.Equal(t, oldSuperNode, read(config, constants.SuperNode))
+	})
+
+	t.Run("restart fails with old credentials too", func(t *testing.T) {
+		writeConfigFile = func(path string, data []byte) error { return os.WriteFile(path, data, 0600) }
+		config, rotated := setup(t)
+		err := applyRotatedConfigs(ctx, rotated, func() error { return fmt.Errorf("pasteld doesn't start") })
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "doesn't start with them either")
+		assert.Equal(t, oldPastelConf, read(config, constants.PastelD))
+	})
+
+	t.Run("restore fails", func(t *testing.T) {
+		config, rotated := setup(t)
+		writeConfigFile = func(path string, data []byte) error {
+			if strings.Contains(string(data), "olduser") {
+				return fmt.Errorf("read-only file system")
+			}
+			return os.WriteFile(path, data, 0600)
+		}
+		restarts := 0
+		err := applyRotatedConfigs(ctx, rotated, func() error {
+			restarts++
+			return fmt.Errorf("pasteld doesn't accept RPC credentials")
+		})
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "old configs are kept in *.bak files")
+		assert.Equal(t, 1, restarts)
+		assert.Contains(t, read(config, constants.PastelD), "rpcuser=newuser")
+	})
+}
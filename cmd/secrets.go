@@ -0,0 +1,101 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/pastelnetwork/pastelup/common/cli"
+	"github.com/pastelnetwork/pastelup/common/log"
+	"github.com/pastelnetwork/pastelup/configs"
+	"github.com/pastelnetwork/pastelup/utils"
+)
+
+// secretFileFlag returns flag with the file the secret flag is read from
+func secretFileFlag(name string, dst *string) *cli.Flag {
+	return cli.NewFlag(name+"-file", dst).
+		SetUsage(green(fmt.Sprintf("Optional, read --%s from the first line of the `file`, \"-\" reads it from stdin, "+
+			"keeps the secret out of the process list and shell history", name)))
+}
+
+// resolveSecrets reads secrets from the files given by --*-file flags and registers all known secrets,
+// so they are never written to the log
+func resolveSecrets(ctx context.Context, config *configs.Config) error {
+	fromStdin := 0
+	for _, secret := range []struct {
+		flag string
+		file string
+		dst  *string
+	}{
+		{"user-pw", config.UserPwFile, &config.UserPw},
+		{"pkey", config.MasterNodePrivateKeyFile, &config.MasterNodePrivateKey},
+		{"passphrase", config.PassphraseFile, &config.MasterNodePassPhrase},
+	} {
+		if secret.file == "" {
+			continue
+		}
+		if *secret.dst != "" {
+			return fmt.Errorf("--%s and --%s-file can't be used together", secret.flag, secret.flag)
+		}
+		if secret.file == "-" {
+			if fromStdin++; fromStdin > 1 {
+				return fmt.Errorf("only one secret can be read from stdin")
+			}
+		} else if utils.IsSecretFileExposed(secret.file) {
+			log.WithContext(ctx).Warnf("%s is readable by other users, restrict it with 'chmod 600 %s'", secret.file, secret.file)
+		}
+		value, err := utils.ReadSecretFile(secret.file)
+		if err != nil {
+			return err
+		}
+		*secret.dst = value
+	}
+
+	for _, secret := range []string{config.UserPw, config.MasterNodePrivateKey, config.MasterNodePassPhrase, config.RPCPwd} {
+		log.AddSecret(secret)
+	}
+	return nil
+}
+
+// remoteSecrets passes secrets to pastelup on the remote host through private temporary files.
+// The remote shell reads commands from stdin and printf is its builtin, so secrets never appear
+// in the process list of the remote host
+type remoteSecrets struct {
+	setup []string
+	files []string
+}
+
+// option returns --<flag>-file option with the temporary file the secret is written to
+func (s *remoteSecrets) option(flag, secret string) string {
+	file := fmt.Sprintf("pastelup_secret_%d", len(s.files))
+	// remote commands are logged, the quoted form differs from the secret if it contains quotes
+	log.AddSecret(utils.ShellQuote(secret))
+	s.setup = append(s.setup, fmt.Sprintf(`%s=$(mktemp /tmp/.pastelup-secret-XXXXXX) && printf '%%s\n' %s > "$%s"`,
+		file, utils.ShellQuote(secret), file))
+	s.files = append(s.files, fmt.Sprintf(`"$%s"`, file))
+	return fmt.Sprintf(` --%s-file="$%s"`, flag, file)
+}
+
+// wrap adds creation of the temporary files before the command and their removal after it
+func (s *remoteSecrets) wrap(command string) string {
+	if len(s.setup) == 0 {
+		return command
+	}
+	return fmt.Sprintf("%s && %s; rc=$?; rm -f %s; exit $rc",
+		strings.Join(s.setup, " && "), command, strings.Join(s.files, " "))
+}
+
+// remotePastelCliCommand returns pastel-cli command, which reads the secret arguments from stdin (-stdin), they are
+// printed by the builtin printf. Run it with Client.Script, so the remote shell reads the command from stdin too and
+// the secrets never appear in the process list or shell history of the remote host
+func remotePastelCliCommand(pastelCli string, args []string, secretArgs ...string) string {
+	if len(secretArgs) == 0 {
+		return pastelCli + " " + strings.Join(args, " ")
+	}
+	quoted := make([]string, len(secretArgs))
+	for i, secret := range secretArgs {
+		quoted[i] = utils.ShellQuote(secret)
+		log.AddSecret(quoted[i])
+	}
+	return fmt.Sprintf("printf '%%s\\n' %s | %s -stdin %s", strings.Join(quoted, " "), pastelCli, strings.Join(args, " "))
+}
@@ -0,0 +1,108 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pastelnetwork/pastelup/common/cli"
+	"github.com/pastelnetwork/pastelup/common/log"
+	"github.com/pastelnetwork/pastelup/configs"
+	"github.com/pastelnetwork/pastelup/utils"
+)
+
+func setupServiceSubCommand(config *configs.Config, name, usage string, repair bool) *cli.Command {
+	commandFlags := []*cli.Flag{
+		cli.NewFlag("dir", &config.PastelExecDir).SetAliases("d").
+			SetUsage(green("Optional, Location of the pastel node directory")).SetValue(config.Configurer.DefaultPastelExecutableDir()),
+		cli.NewFlag("work-dir", &config.WorkingDir).SetAliases("w").
+			SetUsage(green("Optional, Location of the working directory")).SetValue(config.Configurer.DefaultWorkingDir()),
+		cli.NewFlag("name", &config.MasterNodeName).
+			SetUsage(green("Optional, name of the Masternode in masternode.conf to render pasteld unit of the masternode")),
+	}
+	if repair {
+		commandFlags = append(commandFlags,
+			cli.NewFlag("user-pw", &config.UserPw).
+				SetUsage(green("Optional, password of current sudo user - so no sudo password request is prompted")),
+			secretFileFlag("user-pw", &config.UserPwFile),
+		)
+	}
+
+	subCommand := cli.NewCommand(name)
+	subCommand.SetUsage(cyan(usage))
+	subCommand.AddFlags(commandFlags...)
+	addLogFlags(subCommand, config)
+	subCommand.SetActionFunc(func(ctx context.Context, _ []string) error {
+		ctx, err := configureLogging(ctx, "service", config)
+		if err != nil {
+			return fmt.Errorf("failed to configure logging option - %v", err)
+		}
+		if err = ParsePastelConf(ctx, config); err != nil {
+			return err
+		}
+		return runServiceVerify(ctx, config, repair)
+	})
+	return subCommand
+}
+
+func setupServiceCommand(config *configs.Config) *cli.Command {
+	verifySubCommand := setupServiceSubCommand(config, "verify",
+		"Compare installed system service units with the ones expected for the current setup and report missing executables", false)
+	repairSubCommand := setupServiceSubCommand(config, "repair",
+		"Rewrite drifted system service units and reload systemd", true)
+
+	serviceCommand := cli.NewCommand("service")
+	serviceCommand.SetUsage(blue("Check system services installed by 'pastelup update install-service'"))
+	serviceCommand.AddSubcommands(verifySubCommand, repairSubCommand)
+
+	return serviceCommand
+}
+
+func runServiceVerify(ctx context.Context, config *configs.Config, repair bool) error {
+	sm, err := NewServiceManager(utils.GetOS(), config.Configurer.DefaultHomeDir(), config.Instance)
+	if err != nil {
+		return err
+	}
+	checks, err := sm.VerifyServices(ctx, config)
+	if err != nil {
+		return err
+	}
+	if len(checks) == 0 {
+		fmt.Println("No system services are installed")
+		return nil
+	}
+
+	var drifted, broken int
+	for _, check := range checks {
+		switch {
+		case check.Drifted():
+			drifted++
+			fmt.Printf("%s %s\n", yellow("DRIFTED"), check.Path)
+			fmt.Printf("--- %s\n+++ %s (expected)\n", check.Path, check.Path)
+			// diff of pasteld unit may contain masternode private key
+			for i := range check.Diff {
+				check.Diff[i] = log.Redact(check.Diff[i])
+			}
+			printDiff(check.Diff)
+		default:
+			fmt.Printf("%s %s\n", green("OK"), check.Path)
+		}
+		if len(check.MissingBinaries) > 0 {
+			broken++
+			fmt.Printf("%s %s runs missing executables %v\n", red("BROKEN"), check.Unit, check.MissingBinaries)
+		}
+	}
+
+	if repair {
+		if err = sm.RepairServices(ctx, config, checks); err != nil {
+			return err
+		}
+		if broken > 0 {
+			return fmt.Errorf("%d unit(s) run missing executables, install them with 'pastelup update'", broken)
+		}
+		return nil
+	}
+	if drifted > 0 || broken > 0 {
+		return fmt.Errorf("%d unit(s) drifted and %d unit(s) run missing executables, fix them with 'pastelup service repair'", drifted, broken)
+	}
+	return nil
+}
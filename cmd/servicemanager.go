@@ -4,7 +4,9 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"strconv"
 	"strings"
 
 	"github.com/pastelnetwork/pastelup/common/log"
@@ -24,6 +26,24 @@ type ServiceManager interface {
 	IsRunning(context.Context, *configs.Config, constants.ToolType) bool
 	IsRegistered(context.Context, *configs.Config, constants.ToolType) bool
 	ServiceName(constants.ToolType) string
+	VerifyServices(context.Context, *configs.Config) ([]UnitCheck, error)
+	RepairServices(context.Context, *configs.Config, []UnitCheck) error
+}
+
+// UnitCheck is the result of comparing installed unit file of the service with the one pastelup renders for the current setup
+type UnitCheck struct {
+	Unit string
+	Path string
+	// Diff is the line diff from the installed to the expected unit file, it is empty if the unit is up to date
+	Diff []string
+	// MissingBinaries are the executables of the expected unit, which don't exist
+	MissingBinaries []string
+	Expected        string
+}
+
+// Drifted returns true if installed unit file differs from the expected one
+func (c UnitCheck) Drifted() bool {
+	return len(c.Diff) > 0
 }
 
 /*type systemdCmd string
@@ -38,12 +58,21 @@ const (
 */
 
 // NewServiceManager returns a new serviceManager, if the OS does not have one configured, the error will be set and Noop Manager will be returned
-func NewServiceManager(os constants.OSType, homeDir string) (ServiceManager, error) {
+// instance is the name of the pastelup instance, it is added to the service names of its tools.
+// Services run under 'pastelup supervise' on the hosts without systemd, like containers, WSL and macOS
+func NewServiceManager(os constants.OSType, homeDir string, instance string) (ServiceManager, error) {
 	switch os {
 	case constants.Linux:
-		return LinuxSystemdManager{
-			homeDir: homeDir,
-		}, nil
+		if utils.CheckFileExist(constants.SystemdRuntimeDir) {
+			return LinuxSystemdManager{
+				homeDir:  homeDir,
+				instance: instance,
+				unitDir:  constants.SystemdSystemDir,
+			}, nil
+		}
+		return SupervisorManager{homeDir: homeDir, instance: instance}, nil
+	case constants.Mac:
+		return SupervisorManager{homeDir: homeDir, instance: instance}, nil
 	}
 	// if you don't want to check error, we return a noop manager that will do nothing since
 	// the user's system is not supported for system management
@@ -99,9 +128,22 @@ func (nm NoopManager) ServiceName(constants.ToolType) string {
 	return ""
 }
 
+// VerifyServices compares installed unit files with the expected ones
+func (nm NoopManager) VerifyServices(context.Context, *configs.Config) ([]UnitCheck, error) {
+	return nil, nil
+}
+
+// RepairServices rewrites drifted unit files
+func (nm NoopManager) RepairServices(context.Context, *configs.Config, []UnitCheck) error {
+	return nil
+}
+
 // LinuxSystemdManager is a service manager for linux based OS
 type LinuxSystemdManager struct {
-	homeDir string
+	homeDir  string
+	instance string
+	// unitDir is where unit files are installed
+	unitDir string
 }
 
 // RegisterService registers the service and starts it
@@ -112,155 +154,384 @@ func (sm LinuxSystemdManager) RegisterService(ctx context.Context, config *confi
 		return nil // already registered
 	}
 
-	var systemdFile string
+	var extIP string
 	var err error
-	var execCmd, execPath, workDir string
+	if app == constants.PastelD {
+		if extIP, err = utils.GetExternalIPAddress(); err != nil {
+			log.WithContext(ctx).WithError(err).Error("Could not get external IP address")
+			return err
+		}
+	}
+
+	systemdFile, binaries, err := sm.renderUnit(ctx, config, app, isMn, extIP)
+	if err != nil {
+		return err
+	}
+	if systemdFile == "" {
+		return nil // not a service
+	}
+	if missing := missingFiles(binaries); len(missing) > 0 {
+		err = fmt.Errorf("could not find %v executable file %s", app, missing[0])
+		log.WithContext(ctx).WithError(err).Error("Failed to install service")
+		return err
+	}
 
 	// Service file - will be installed at /etc/systemd/system
-	appServiceFileName := sm.ServiceName(app)
-	appServiceFilePath := filepath.Join(constants.SystemdSystemDir, appServiceFileName)
-	appServiceTempFilePath := filepath.Join("/tmp/", appServiceFileName)
+	if err = sm.writeUnitFile(config, sm.ServiceName(app), systemdFile); err != nil {
+		log.WithContext(ctx).WithError(err).Error("Failed to update")
+		return err
+	}
+	if err = sm.updateTarget(ctx, config); err != nil {
+		return err
+	}
+
+	// reload systemctl daemon
+	_, err = RunSudoCMD(config, "systemctl", "daemon-reload")
+	if err != nil {
+		return fmt.Errorf("unable to reload systemctl daemon (%v): %v", app, err)
+	}
+	return nil
+}
+
+// renderUnit returns unit file of the tool and the executables it runs, unit file is empty for tools, which are not services.
+// extIP is the external IP address pasteld is started with
+func (sm LinuxSystemdManager) renderUnit(ctx context.Context, config *configs.Config, app constants.ToolType,
+	isMn bool, extIP string) (string, []string, error) {
+	username, err := exec.Command("whoami").Output()
+	if err != nil {
+		return "", nil, fmt.Errorf("unable to get own user name (%v): %v", app, err)
+	}
+
+	args, workDir, binaries, err := serviceExecCommand(ctx, config, sm.homeDir, app, isMn, extIP)
+	if err != nil || len(args) == 0 {
+		return "", nil, err
+	}
 
-	username, err := RunCMD("whoami")
+	script := configs.NewSystemdServiceScript(app, sm.targetName(), sm.ServiceName, sm.isUnitFilePresent)
+	script.ExecCmd = configs.SystemdCommandLine(args)
+	script.WorkDir = workDir
+	script.User = strings.TrimSpace(string(username))
+	script.Backoff = systemdVersion() >= 254
+	systemdFile, err := utils.GetServiceConfig(string(app), configs.SystemdService, script)
 	if err != nil {
-		return fmt.Errorf("unable to get own user name (%v): %v", app, err)
+		e := fmt.Errorf("unable ot create service file for (%v): %v", app, err)
+		log.WithContext(ctx).WithError(err).Error(e.Error())
+		return "", nil, e
 	}
+	return systemdFile, binaries, nil
+}
 
+// serviceExecCommand returns command with args and working dir of the tool run as service and the executables it runs.
+// Command is empty for tools, which are not services
+func serviceExecCommand(ctx context.Context, config *configs.Config, homeDir string, app constants.ToolType,
+	isMn bool, extIP string) (args []string, workDir string, binaries []string, err error) {
+	var execPath string
 	pastelConfigPath := filepath.Join(config.WorkingDir, constants.PastelConfName)
 
 	switch app {
 	case constants.DDImgService:
-		appBaseDir := filepath.Join(sm.homeDir, constants.DupeDetectionServiceDir)
+		appBaseDir := filepath.Join(homeDir, constants.DupeDetectionServiceDir)
 		appServiceWorkDirPath := filepath.Join(appBaseDir, "img_server")
-		execCmd = "python3 -m  http.server 8000"
+		args = []string{"python3", "-m", "http.server", "8000"}
 		workDir = appServiceWorkDirPath
 	case constants.PastelD:
-		var extIP string
-		// Get pasteld path
 		execPath = filepath.Join(config.PastelExecDir, constants.PasteldName[utils.GetOS()])
-		if exists := utils.CheckFileExist(execPath); !exists {
-			log.WithContext(ctx).WithError(err).Error(fmt.Sprintf("Could not find %v executable file", app))
-			return err
-		}
-		// Get external IP
-		if extIP, err = utils.GetExternalIPAddress(); err != nil {
-			log.WithContext(ctx).WithError(err).Error("Could not get external IP address")
-			return err
-		}
-		execCmd = execPath + " --datadir=" + config.WorkingDir + " --externalip=" + extIP // + " --reindex"
+		args = []string{execPath, "--datadir=" + config.WorkingDir, "--externalip=" + extIP}
 		if isMn {
 			privKey, _ /*extIP*/, _ /*extPort*/, err := getMasternodeConfData(ctx, config, config.MasterNodeName, extIP)
 			if err != nil {
 				log.WithContext(ctx).WithError(err).Error("Failed to get masternode details from masternode.conf")
-				return err
+				return nil, "", nil, err
 			}
-			execCmd += " --txindex=1 --masternode --masternodeprivkey=" + privKey
+			log.AddSecret(privKey)
+			if err = setPastelConfMasternodePrivKey(pastelConfigPath, privKey); err != nil {
+				return nil, "", nil, err
+			}
+			args = append(args, "--txindex=1", "--masternode")
 		}
 		workDir = config.PastelExecDir
 	case constants.RQService:
 		execPath = filepath.Join(config.PastelExecDir, constants.PastelRQServiceExecName[utils.GetOS()])
-		if exists := utils.CheckFileExist(execPath); !exists {
-			log.WithContext(ctx).WithError(err).Errorf("Could not find %v executable file", app)
-			return err
-		}
-		rqServiceArgs := fmt.Sprintf("--config-file=%s", config.Configurer.GetRQServiceConfFile(config.WorkingDir))
-		execCmd = execPath + " " + rqServiceArgs
+		args = []string{execPath, "--config-file=" + config.Configurer.GetRQServiceConfFile(config.WorkingDir)}
 		workDir = config.PastelExecDir
 	case constants.DDService:
 		execPath = filepath.Join(config.PastelExecDir, utils.GetDupeDetectionExecName())
-		if exists := utils.CheckFileExist(execPath); !exists {
-			log.WithContext(ctx).WithError(err).Errorf("Could not find %v executable file", app)
-			return err
-		}
 		envPythonPath := filepath.Join(config.PastelExecDir, constants.DupeDetectionSubFolder, "/venv/bin/python3")
-		if exists := utils.CheckFileExist(envPythonPath); !exists {
-			log.WithContext(ctx).WithError(err).Errorf("Could not find venv python executable file at %s", envPythonPath)
-			return err
-		}
-		ddConfigFilePath := filepath.Join(sm.homeDir,
+		binaries = append(binaries, envPythonPath)
+		ddConfigFilePath := filepath.Join(homeDir,
 			constants.DupeDetectionServiceDir,
 			constants.DupeDetectionSupportFilePath,
 			constants.DupeDetectionConfigFilename)
-		execCmd = envPythonPath + " " + execPath + " " + ddConfigFilePath
+		args = []string{envPythonPath, execPath, ddConfigFilePath}
 		workDir = config.PastelExecDir
 	case constants.SuperNode:
 		execPath = filepath.Join(config.PastelExecDir, constants.SuperNodeExecName[utils.GetOS()])
-		if exists := utils.CheckFileExist(execPath); !exists {
-			log.WithContext(ctx).WithError(err).Error(fmt.Sprintf("Could not find %v executable file", app))
-			return err
-		}
 		supernodeConfigPath := config.Configurer.GetSuperNodeConfFile(config.WorkingDir)
-		execCmd = execPath + " --config-file=" + supernodeConfigPath + " --pastel-config-file=" + pastelConfigPath
+		args = []string{execPath, "--config-file=" + supernodeConfigPath, "--pastel-config-file=" + pastelConfigPath}
 		workDir = config.PastelExecDir
 	case constants.Hermes:
 		execPath = filepath.Join(config.PastelExecDir, constants.HermesExecName[utils.GetOS()])
-		if exists := utils.CheckFileExist(execPath); !exists {
-			log.WithContext(ctx).WithError(err).Error(fmt.Sprintf("Could not find %v executable file", app))
-			return err
-		}
-
 		hermesConfigPath := config.Configurer.GetHermesConfFile(config.WorkingDir)
-		execCmd = execPath + " --config-file=" + hermesConfigPath + " --pastel-config-file=" + pastelConfigPath
+		args = []string{execPath, "--config-file=" + hermesConfigPath, "--pastel-config-file=" + pastelConfigPath}
 		workDir = config.PastelExecDir
 	case constants.WalletNode:
 		execPath = filepath.Join(config.PastelExecDir, constants.WalletNodeExecName[utils.GetOS()])
-		if exists := utils.CheckFileExist(execPath); !exists {
-			log.WithContext(ctx).WithError(err).Error(fmt.Sprintf("Could not find %v executable file", app))
-			return err
-		}
 		walletnodeConfigFile := config.Configurer.GetWalletNodeConfFile(config.WorkingDir)
-		execCmd = execPath + " --config-file=" + walletnodeConfigFile + " --pastel-config-file=" + pastelConfigPath
+		args = []string{execPath, "--config-file=" + walletnodeConfigFile, "--pastel-config-file=" + pastelConfigPath}
 		if config.DevMode {
-			execCmd += " --swagger"
+			args = append(args, "--swagger")
 		}
 		workDir = config.PastelExecDir
 	case constants.Bridge:
 		execPath = filepath.Join(config.PastelExecDir, constants.BridgeExecName[utils.GetOS()])
-		if exists := utils.CheckFileExist(execPath); !exists {
-			log.WithContext(ctx).WithError(err).Error(fmt.Sprintf("Could not find %v executable file", app))
-			return err
-		}
-
 		bridgeConfigPath := config.Configurer.GetBridgeConfFile(config.WorkingDir)
-		execCmd = execPath + " --config-file=" + bridgeConfigPath + " --pastel-config-file=" + pastelConfigPath
+		args = []string{execPath, "--config-file=" + bridgeConfigPath, "--pastel-config-file=" + pastelConfigPath}
 		workDir = config.PastelExecDir
 	default:
+		return nil, "", nil, nil
+	}
+	if execPath != "" {
+		binaries = append([]string{execPath}, binaries...)
+	}
+	return args, workDir, binaries, nil
+}
+
+// setPastelConfMasternodePrivKey writes masternode private key to pastel.conf, which is readable by the owner only,
+// so the key isn't kept in the world-readable unit file and the process list
+func setPastelConfMasternodePrivKey(path string, privKey string) error {
+	conf, err := utils.LoadPastelConf(path)
+	if err != nil {
+		return err
+	}
+	if conf.Get("masternodeprivkey") == privKey {
 		return nil
 	}
+	conf.Set("masternodeprivkey", privKey)
+	if err = conf.Save(path); err != nil {
+		return fmt.Errorf("failed to write masternode private key to %s: %v", path, err)
+	}
+	return nil
+}
+
+// missingFiles returns the files, which don't exist
+func missingFiles(paths []string) []string {
+	var missing []string
+	for _, path := range paths {
+		if !utils.CheckFileExist(path) {
+			missing = append(missing, path)
+		}
+	}
+	return missing
+}
 
-	// Create systemd file
-	systemdFile, err = utils.GetServiceConfig(string(app), configs.SystemdService,
-		&configs.SystemdServiceScript{
-			Desc:    fmt.Sprintf("%v daemon", app),
-			ExecCmd: execCmd,
-			WorkDir: workDir,
-			User:    username,
+// VerifyServices renders unit files of the registered services and pastel.target and compares them with the installed ones
+func (sm LinuxSystemdManager) VerifyServices(ctx context.Context, config *configs.Config) ([]UnitCheck, error) {
+	var checks []UnitCheck
+	for _, app := range configs.SystemdTools {
+		if !sm.isUnitFilePresent(app) {
+			continue
+		}
+		path := sm.unitPath(sm.ServiceName(app))
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		installed := string(data)
+
+		var isMn bool
+		var extIP string
+		unitConfig := config
+		switch app {
+		case constants.WalletNode:
+			// service commands have no dev mode flag, swagger of the walletnode installed in dev mode is kept
+			devConfig := *config
+			devConfig.DevMode = unitHasArg(installed, "--swagger")
+			unitConfig = &devConfig
+		case constants.PastelD:
+			if key := unitArgValue(installed, "--masternodeprivkey"); key != "" {
+				log.AddSecret(key)
+			}
+			isMn = unitHasArg(installed, "--masternode")
+			// external IP is kept, so the unit doesn't drift with every change of the dynamic IP address
+			if extIP = unitArgValue(installed, "--externalip"); extIP == "" {
+				if extIP, err = utils.GetExternalIPAddress(); err != nil {
+					return nil, fmt.Errorf("could not get external IP address: %v", err)
+				}
+			}
+		}
+		expected, binaries, err := sm.renderUnit(ctx, unitConfig, app, isMn, extIP)
+		if err != nil {
+			return nil, fmt.Errorf("unable to render %s: %v", sm.ServiceName(app), err)
+		}
+		checks = append(checks, UnitCheck{
+			Unit:            sm.ServiceName(app),
+			Path:            path,
+			Diff:            utils.DiffLines(installed, expected, 3),
+			MissingBinaries: missingFiles(binaries),
+			Expected:        expected,
 		})
+	}
+	if len(checks) == 0 {
+		return nil, nil
+	}
+
+	path := sm.unitPath(sm.targetName())
+	expected, err := utils.GetServiceConfig(sm.targetName(), configs.SystemdTarget,
+		configs.NewSystemdTargetScript(sm.ServiceName, sm.isUnitFilePresent))
 	if err != nil {
-		e := fmt.Errorf("unable ot create service file for (%v): %v", app, err)
-		log.WithContext(ctx).WithError(err).Error(e.Error())
-		return e
+		return nil, err
 	}
+	var diff []string
+	if installed, err := os.ReadFile(path); err == nil {
+		diff = utils.DiffLines(string(installed), expected, 3)
+	} else {
+		// target is missing for the services registered by the older pastelup
+		for _, line := range strings.Split(strings.TrimSuffix(expected, "\n"), "\n") {
+			diff = append(diff, "+"+line)
+		}
+	}
+	checks = append(checks, UnitCheck{
+		Unit:     sm.targetName(),
+		Path:     path,
+		Diff:     diff,
+		Expected: expected,
+	})
+	return checks, nil
+}
 
-	// write systemdFile to SystemdUserDir with mode 0644
-	if err := os.WriteFile(appServiceTempFilePath, []byte(systemdFile), 0644); err != nil {
-		log.WithContext(ctx).WithError(err).Error("unable to write " + appServiceFileName + " file")
+// RepairServices rewrites drifted unit files and reloads systemd, running services use new units after restart
+func (sm LinuxSystemdManager) RepairServices(ctx context.Context, config *configs.Config, checks []UnitCheck) error {
+	var repaired []string
+	for _, check := range checks {
+		if !check.Drifted() {
+			continue
+		}
+		if len(check.MissingBinaries) > 0 {
+			log.WithContext(ctx).Warnf("%s runs missing executables %v, install them with 'pastelup update'", check.Unit, check.MissingBinaries)
+		}
+		if err := sm.writeUnitFile(config, check.Unit, check.Expected); err != nil {
+			return fmt.Errorf("unable to write %s: %v", check.Path, err)
+		}
+		log.WithContext(ctx).Infof("%s rewritten", check.Path)
+		repaired = append(repaired, check.Unit)
+	}
+	if len(repaired) == 0 {
+		return nil
 	}
 
-	_, err = RunSudoCMD(config, "cp", appServiceTempFilePath, appServiceFilePath)
-	if err != nil {
-		log.WithContext(ctx).WithError(err).Error("Failed to update")
-		return err
+	if _, err := RunSudoCMD(config, "systemctl", "daemon-reload"); err != nil {
+		return fmt.Errorf("unable to reload systemctl daemon: %v", err)
+	}
+	var running []string
+	for _, unit := range repaired {
+		if out, _ := exec.Command("systemctl", "is-active", unit).Output(); strings.TrimSpace(string(out)) == "active" {
+			running = append(running, unit)
+		}
 	}
+	if len(running) > 0 {
+		log.WithContext(ctx).Warnf("Running services use old units until restarted: sudo systemctl restart %s", strings.Join(running, " "))
+	}
+	return nil
+}
 
-	// reload systemctl daemon
-	_, err = RunSudoCMD(config, "systemctl", "daemon-reload")
+// unitArgValue returns value of the "--name=value" argument in ExecStart of the unit file
+func unitArgValue(unit string, name string) string {
+	for _, line := range strings.Split(unit, "\n") {
+		if !strings.HasPrefix(line, "ExecStart=") {
+			continue
+		}
+		for _, arg := range configs.SplitSystemdCommandLine(strings.TrimPrefix(line, "ExecStart=")) {
+			if strings.HasPrefix(arg, name+"=") {
+				return strings.TrimPrefix(arg, name+"=")
+			}
+		}
+	}
+	return ""
+}
+
+// unitHasArg checks if ExecStart of the unit file has the "--name" argument without value
+func unitHasArg(unit string, name string) bool {
+	for _, line := range strings.Split(unit, "\n") {
+		if !strings.HasPrefix(line, "ExecStart=") {
+			continue
+		}
+		for _, arg := range configs.SplitSystemdCommandLine(strings.TrimPrefix(line, "ExecStart=")) {
+			if arg == name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// writeUnitFile writes unit file to /etc/systemd/system with mode 0644
+func (sm LinuxSystemdManager) writeUnitFile(config *configs.Config, name string, content string) error {
+	tempPath := filepath.Join("/tmp/", name)
+	if err := os.WriteFile(tempPath, []byte(content), 0644); err != nil {
+		return fmt.Errorf("unable to write %s file: %v", name, err)
+	}
+	defer os.Remove(tempPath)
+
+	_, err := RunSudoCMD(config, "cp", tempPath, sm.unitPath(name))
+	return err
+}
+
+// updateTarget rewrites pastel.target with the registered services, the target is removed with the last service
+func (sm LinuxSystemdManager) updateTarget(ctx context.Context, config *configs.Config) error {
+	script := configs.NewSystemdTargetScript(sm.ServiceName, sm.isUnitFilePresent)
+	if len(script.Wants) == 0 {
+		targetPath := sm.unitPath(sm.targetName())
+		if utils.CheckFileExist(targetPath) {
+			if _, err := RunSudoCMD(config, "rm", targetPath); err != nil {
+				return fmt.Errorf("unable to remove %s: %v", sm.targetName(), err)
+			}
+		}
+		return nil
+	}
+	targetFile, err := utils.GetServiceConfig(sm.targetName(), configs.SystemdTarget, script)
 	if err != nil {
-		return fmt.Errorf("unable to reload systemctl daemon (%v): %v", app, err)
+		return fmt.Errorf("unable to create %s: %v", sm.targetName(), err)
 	}
+	if err = sm.writeUnitFile(config, sm.targetName(), targetFile); err != nil {
+		return fmt.Errorf("unable to write %s: %v", sm.targetName(), err)
+	}
+	log.WithContext(ctx).Infof("%s groups %v", sm.targetName(), script.Wants)
 	return nil
 }
 
+// isUnitFilePresent checks if the unit file of the service exists
+func (sm LinuxSystemdManager) isUnitFilePresent(app constants.ToolType) bool {
+	return utils.CheckFileExist(sm.unitPath(sm.ServiceName(app)))
+}
+
+// unitPath returns path of the installed unit file
+func (sm LinuxSystemdManager) unitPath(name string) string {
+	return filepath.Join(sm.unitDir, name)
+}
+
+// targetName returns name of the target grouping services of the instance
+func (sm LinuxSystemdManager) targetName() string {
+	if sm.instance != "" {
+		return fmt.Sprintf("%v-%v.target", constants.SystemdTargetName, sm.instance)
+	}
+	return constants.SystemdTargetName + ".target"
+}
+
+// systemdVersion returns version of the installed systemd or 0 if it is unknown
+func systemdVersion() int {
+	out, err := exec.Command("systemctl", "--version").Output()
+	if err != nil {
+		return 0
+	}
+	// systemd 252 (252.39-1~deb12u1)
+	fields := strings.Fields(string(out))
+	if len(fields) < 2 {
+		return 0
+	}
+	version, _ := strconv.Atoi(fields[1])
+	return version
+}
+
 // StartService starts the given service as long as it is registered
 func (sm LinuxSystemdManager) StartService(ctx context.Context, config *configs.Config, app constants.ToolType) (bool, error) {
 	isRegistered := sm.IsRegistered(ctx, config, app)
@@ -324,13 +595,16 @@ func (sm LinuxSystemdManager) RemoveService(ctx context.Context, config *configs
 	appServiceFileName := sm.ServiceName(app)
 	log.WithContext(ctx).Info("Removing service", appServiceFileName)
 
-	appServiceFilePath := filepath.Join(constants.SystemdSystemDir, appServiceFileName)
+	appServiceFilePath := sm.unitPath(appServiceFileName)
 
 	if out, err := RunSudoCMD(config, "rm", appServiceFilePath); err != nil {
 		log.WithContext(ctx).WithFields(log.Fields{"message": out}).
 			WithError(err).Error("unable to remove " + appServiceFileName + " service")
 		return fmt.Errorf("err removing "+appServiceFileName+" - err: %s", err)
 	}
+	if err := sm.updateTarget(ctx, config); err != nil {
+		log.WithContext(ctx).WithError(err).Warnf("unable to update %s", sm.targetName())
+	}
 
 	// reload systemctl daemon
 	_, err := RunSudoCMD(config, "systemctl", "daemon-reload")
@@ -359,6 +633,10 @@ func (sm LinuxSystemdManager) IsRegistered(ctx context.Context, config *configs.
 }
 
 // ServiceName returns the formatted service name given a tooltype
+// dd-service and imgserver are shared by all instances on the host
 func (sm LinuxSystemdManager) ServiceName(app constants.ToolType) string {
+	if sm.instance != "" && app != constants.DDService && app != constants.DDImgService {
+		return fmt.Sprintf("%v%v-%v.service", constants.SystemdServicePrefix, app, sm.instance)
+	}
 	return fmt.Sprintf("%v%v.service", constants.SystemdServicePrefix, app)
 }
@@ -0,0 +1,179 @@
+package cmd
+
+import (
+	"context"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pastelnetwork/pastelup/configs"
+	"github.com/pastelnetwork/pastelup/constants"
+	"github.com/pastelnetwork/pastelup/utils"
+	"github.com/tj/assert"
+)
+
+var updateGolden = flag.Bool("update", false, "update golden files in testdata")
+
+func TestSystemdUnits(t *testing.T) {
+	if utils.GetOS() != constants.Linux {
+		t.Skip("systemd units are registered on linux only")
+	}
+	ctx := context.Background()
+	const workDir = "/home/pastel/.pastel"
+	config := configs.InitConfig(nil)
+	config.PastelExecDir = "/home/pastel/pastel"
+	// pastel.conf gets the masternode private key, so the work dir is temporary and replaced in the units
+	config.WorkingDir = t.TempDir()
+	config.MasterNodeName = "mn1"
+	assert.NoError(t, os.WriteFile(getMasternodeConfPath(config, config.WorkingDir, "masternode.conf"),
+		[]byte(`{"mn1": {"mnAddress": "1.2.3.4:9933", "mnPrivKey": "privkey"}}`), 0600))
+	assert.NoError(t, os.WriteFile(filepath.Join(config.WorkingDir, constants.PastelConfName), nil, 0600))
+	sm := LinuxSystemdManager{homeDir: "/home/pastel", unitDir: t.TempDir()}
+
+	// services registered by "pastelup install-service --solution", bridge is registered with --tool bridge
+	solutions := map[string][]constants.ToolType{
+		"supernode": {constants.PastelD, constants.SuperNode, constants.DDService, constants.RQService,
+			constants.Hermes, constants.DDImgService},
+		"walletnode": {constants.PastelD, constants.WalletNode, constants.RQService, constants.Bridge},
+	}
+	for solution, tools := range solutions {
+		registered := func(app constants.ToolType) bool {
+			return utils.ContainsToolType(tools, app)
+		}
+		units := map[string]string{}
+		for _, app := range tools {
+			args, dir, _, err := serviceExecCommand(ctx, config, sm.homeDir, app, solution == "supernode", "1.2.3.4")
+			assert.NoError(t, err)
+			script := configs.NewSystemdServiceScript(app, sm.targetName(), sm.ServiceName, registered)
+			script.ExecCmd = configs.SystemdCommandLine(args)
+			script.WorkDir = dir
+			script.User = "pastel"
+			unit, err := utils.GetServiceConfig(string(app), configs.SystemdService, script)
+			assert.NoError(t, err)
+			units[sm.ServiceName(app)] = strings.ReplaceAll(unit, config.WorkingDir, workDir)
+		}
+		target, err := utils.GetServiceConfig(sm.targetName(), configs.SystemdTarget,
+			configs.NewSystemdTargetScript(sm.ServiceName, registered))
+		assert.NoError(t, err)
+		units[sm.targetName()] = target
+
+		for name, unit := range units {
+			path := filepath.Join("testdata", "systemd", solution, name)
+			if *updateGolden {
+				assert.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+				assert.NoError(t, os.WriteFile(path, []byte(unit), 0644))
+			}
+			golden, err := os.ReadFile(path)
+			assert.NoError(t, err, path)
+			assert.Equal(t, string(golden), unit, path)
+		}
+	}
+}
+
+func TestUnitArgs(t *testing.T) {
+	unit := "[Service]\nDescription=pasteld --masternode daemon\n" +
+		"ExecStart=/home/user/pastel/pasteld \"--datadir=/home/user/pastel data\" --externalip=1.2.3.4 --masternode --masternodeprivkey=key\n"
+	tests := []struct {
+		name     string
+		value    string
+		hasValue bool
+		hasArg   bool
+	}{
+		{name: "--externalip", value: "1.2.3.4", hasValue: true},
+		{name: "--masternodeprivkey", value: "key", hasValue: true},
+		{name: "--masternode", hasArg: true},
+		{name: "--datadir", value: "/home/user/pastel data", hasValue: true},
+		{name: "--txindex"},
+		// only ExecStart is searched
+		{name: "Description"},
+		// the prefix of the argument doesn't match
+		{name: "--master"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.value, unitArgValue(unit, test.name))
+			assert.Equal(t, test.hasArg, unitHasArg(unit, test.name))
+		})
+	}
+}
+
+func TestVerifyServices(t *testing.T) {
+	ctx := context.Background()
+	config := configs.InitConfig(nil)
+	config.WorkingDir = t.TempDir()
+	config.PastelExecDir = t.TempDir()
+	sm := LinuxSystemdManager{homeDir: t.TempDir(), unitDir: t.TempDir()}
+
+	install := func(app constants.ToolType, devMode bool, edit func(string) string) {
+		unitConfig := *config
+		unitConfig.DevMode = devMode
+		unit, _, err := sm.renderUnit(ctx, &unitConfig, app, false, "")
+		assert.NoError(t, err)
+		assert.NoError(t, os.WriteFile(sm.unitPath(sm.ServiceName(app)), []byte(edit(unit)), 0644))
+	}
+	verify := func() map[string]UnitCheck {
+		checks, err := sm.VerifyServices(ctx, config)
+		assert.NoError(t, err)
+		byUnit := make(map[string]UnitCheck)
+		for _, check := range checks {
+			byUnit[check.Unit] = check
+		}
+		return byUnit
+	}
+	unchanged := func(unit string) string { return unit }
+
+	// walletnode installed in dev mode keeps swagger
+	install(constants.WalletNode, true, unchanged)
+	install(constants.RQService, false, func(unit string) string {
+		return strings.Replace(unit, "--config-file=", "--config-file=/old", 1)
+	})
+	checks := verify()
+	assert.Len(t, checks, 3)
+	walletnode := checks[sm.ServiceName(constants.WalletNode)]
+	assert.False(t, walletnode.Drifted())
+	assert.True(t, unitHasArg(walletnode.Expected, "--swagger"))
+	assert.ElementsMatch(t, []string{
+		config.PastelExecDir + "/" + constants.WalletNodeExecName[constants.Linux],
+	}, walletnode.MissingBinaries)
+	rqService := checks[sm.ServiceName(constants.RQService)]
+	assert.True(t, rqService.Drifted())
+	assert.Contains(t, strings.Join(rqService.Diff, "\n"), "--config-file=/old")
+	// pastel.target is missing
+	assert.True(t, checks[sm.targetName()].Drifted())
+
+	// and the one installed without dev mode doesn't get it
+	install(constants.WalletNode, false, unchanged)
+	walletnode = verify()[sm.ServiceName(constants.WalletNode)]
+	assert.False(t, walletnode.Drifted())
+	assert.False(t, unitHasArg(walletnode.Expected, "--swagger"))
+}
+
+func TestRenderUnitMasternodePrivKey(t *testing.T) {
+	ctx := context.Background()
+	config := configs.InitConfig(nil)
+	config.WorkingDir = t.TempDir()
+	config.PastelExecDir = t.TempDir()
+	config.MasterNodeName = "mn1"
+	sm := LinuxSystemdManager{homeDir: t.TempDir(), unitDir: t.TempDir()}
+
+	const privKey = "5KaFHqGxtZM8tHwJ7Z2Fx6ZcMcLQEuR3hCdApKcBrV6Nt3Dm1wS"
+	mnConfPath := getMasternodeConfPath(config, config.WorkingDir, "masternode.conf")
+	assert.NoError(t, os.WriteFile(mnConfPath,
+		[]byte(`{"mn1": {"mnAddress": "1.2.3.4:9933", "mnPrivKey": "`+privKey+`"}}`), 0600))
+	pastelConfPath := filepath.Join(config.WorkingDir, constants.PastelConfName)
+	assert.NoError(t, os.WriteFile(pastelConfPath, []byte("rpcuser=user\n"), 0644))
+
+	unit, _, err := sm.renderUnit(ctx, config, constants.PastelD, true, "1.2.3.4")
+	assert.NoError(t, err)
+	// unit file is world-readable, the key is kept in pastel.conf
+	assert.NotContains(t, unit, privKey)
+	assert.True(t, unitHasArg(unit, "--masternode"))
+	data, err := os.ReadFile(pastelConfPath)
+	assert.NoError(t, err)
+	assert.Equal(t, "rpcuser=user\nmasternodeprivkey="+privKey+"\n", string(data))
+	info, err := os.Stat(pastelConfPath)
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+}
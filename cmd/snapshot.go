@@ -0,0 +1,238 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/pastelnetwork/pastelup/common/cli"
+	"github.com/pastelnetwork/pastelup/common/log"
+	"github.com/pastelnetwork/pastelup/common/sys"
+	"github.com/pastelnetwork/pastelup/configs"
+	"github.com/pastelnetwork/pastelup/constants"
+	"github.com/pastelnetwork/pastelup/services/pastelcore"
+	"github.com/pastelnetwork/pastelup/services/snapshot"
+	"github.com/pastelnetwork/pastelup/structure"
+	"github.com/pastelnetwork/pastelup/utils"
+)
+
+var (
+	flagSnapshotDir       string
+	flagSnapshotFormat    string
+	flagSnapshotKind      string
+	flagSnapshotNoRestart bool
+	flagSnapshotKeep      int
+	flagSnapshotListen    string
+)
+
+func setupSnapshotCommand(config *configs.Config) *cli.Command {
+	snapshotDirFlag := func() *cli.Flag {
+		return cli.NewFlag("snapshot-dir", &flagSnapshotDir).
+			SetUsage(green("Optional, directory of the snapshots, they are kept in its subdirectories per network")).
+			SetValue(filepath.Join(config.Configurer.DefaultHomeDir(), "pastel_snapshots"))
+	}
+
+	createSubCommand := cli.NewCommand("create")
+	createSubCommand.SetUsage(cyan("Stop pasteld, archive its chain data to the snapshot directory with checksum and height, and start it again"))
+	createSubCommand.AddFlags(
+		cli.NewFlag("dir", &config.PastelExecDir).SetAliases("d").
+			SetUsage(green("Optional, Location of the pastel node directory")).SetValue(config.Configurer.DefaultPastelExecutableDir()),
+		cli.NewFlag("work-dir", &config.WorkingDir).SetAliases("w").
+			SetUsage(green("Optional, Location of the working directory")).SetValue(config.Configurer.DefaultWorkingDir()),
+		snapshotDirFlag(),
+		cli.NewFlag("format", &flagSnapshotFormat).
+			SetUsage(green("Optional, archive format - \"tar.zst\" or \"tar.gz\"")).SetValue(snapshot.FormatZstd),
+		cli.NewFlag("kind", &flagSnapshotKind).
+			SetUsage(green("Optional, kind of the snapshot - \"node\", \"txindex\" (walletnode) or \"explorer\" (supernode), found by the indexes of pasteld if not set")),
+		cli.NewFlag("keep", &flagSnapshotKeep).
+			SetUsage(green("Optional, number of the latest snapshots of each kind to keep, the older ones are removed. All are kept if not set")),
+		cli.NewFlag("no-restart", &flagSnapshotNoRestart).
+			SetUsage(green("Optional, leave pasteld stopped after the snapshot is created")),
+		cli.NewFlag("stop-timeout", &config.StopTimeout).
+			SetUsage(green("Optional, how long to wait for pasteld to flush its database and exit before it is killed")).
+			SetValue(defaultStopTimeout),
+	)
+	addLogFlags(createSubCommand, config)
+	createSubCommand.SetActionFunc(func(ctx context.Context, _ []string) error {
+		ctx, err := configureLogging(ctx, "snapshot", config)
+		if err != nil {
+			return fmt.Errorf("failed to configure logging option - %v", err)
+		}
+		if err = ParsePastelConf(ctx, config); err != nil {
+			return err
+		}
+		return runSnapshotCreate(ctx, config)
+	})
+
+	serveSubCommand := cli.NewCommand("serve")
+	serveSubCommand.SetUsage(cyan("Serve the snapshots over HTTP, new nodes install them with 'pastelup install ... --snapshot-url http://<host>:<port>/'"))
+	serveSubCommand.AddFlags(
+		snapshotDirFlag(),
+		cli.NewFlag("listen", &flagSnapshotListen).
+			SetUsage(green("Optional, `address` to listen on")).SetValue(":8080"),
+	)
+	addLogFlags(serveSubCommand, config)
+	serveSubCommand.SetActionFunc(func(ctx context.Context, _ []string) error {
+		ctx, err := configureLogging(ctx, "snapshot", config)
+		if err != nil {
+			return fmt.Errorf("failed to configure logging option - %v", err)
+		}
+		return runSnapshotServe(ctx)
+	})
+
+	snapshotCommand := cli.NewCommand("snapshot")
+	snapshotCommand.SetUsage(blue("Create snapshots of the chain from the local node and serve them to bootstrap new nodes"))
+	snapshotCommand.AddSubcommands(createSubCommand, serveSubCommand)
+	return snapshotCommand
+}
+
+func runSnapshotCreate(ctx context.Context, config *configs.Config) error {
+	if _, err := snapshot.Format("snapshot." + flagSnapshotFormat); err != nil {
+		return err
+	}
+	if flagSnapshotKeep < 0 {
+		return fmt.Errorf("invalid --keep %d, it must be positive", flagSnapshotKeep)
+	}
+	entry := snapshot.Entry{Kind: flagSnapshotKind}
+	switch entry.Kind {
+	case "":
+	case snapshot.KindNode, snapshot.KindTxIndex, snapshot.KindExplorer:
+	default:
+		return fmt.Errorf("unknown snapshot kind %q, use node, txindex or explorer", entry.Kind)
+	}
+
+	pid, err := pasteldPid(config)
+	if err != nil {
+		return fmt.Errorf("unable to check if pasteld is running: %v", err)
+	}
+	var pasteldArgs []string
+	var chain structure.RPCBlockchainInfo
+	if pid != 0 {
+		_, pasteldArgs = GetProcessCmdInput(ctx, config, constants.PastelD)
+		if err = pastelcore.NewClient(config).RunCommand(pastelcore.GetBlockchainInfoCmd, &chain); err != nil {
+			return fmt.Errorf("unable to get the chain of pasteld: %v", err)
+		}
+		if chain.Result.Blocks < chain.Result.Headers {
+			log.WithContext(ctx).Warnf("pasteld is not synced - block %d of %d headers, the snapshot will be behind the network",
+				chain.Result.Blocks, chain.Result.Headers)
+		}
+	}
+	if entry.Kind == "" {
+		entry.Kind = snapshotKind(config, pasteldArgs)
+	}
+
+	// the database is consistent only when pasteld has flushed it and exited
+	if pid != 0 {
+		if err = stopServices(ctx, []constants.ToolType{constants.PastelD}, config); err != nil {
+			return err
+		}
+		if !flagSnapshotNoRestart {
+			defer func() {
+				if err := restartSnapshotPasteld(ctx, config, pasteldArgs); err != nil {
+					log.WithContext(ctx).WithError(err).Error("Failed to start pasteld, start it with 'pastelup start node'")
+				}
+			}()
+		}
+	}
+	if err = ensurePasteldExited(ctx, config); err != nil {
+		return err
+	}
+
+	dataDir := getMasternodeConfPath(config, config.WorkingDir, "")
+	tip, err := snapshot.ReadLogTip(filepath.Join(dataDir, "debug.log"))
+	switch {
+	case err == nil && !tip.CleanShutdown:
+		return fmt.Errorf("pasteld hasn't shut down cleanly, its database may be inconsistent - start it and let it sync before the snapshot")
+	case err == nil:
+		entry.Height, entry.BlockHash = tip.Height, tip.Hash
+	case pid != 0:
+		// debug.log may have been removed, the tip got before the stop is used then
+		log.WithContext(ctx).WithError(err).Warn("Unable to read the chain tip from debug.log")
+		entry.Height, entry.BlockHash = chain.Result.Blocks, chain.Result.BestBlockHash
+	default:
+		return fmt.Errorf("unable to find height of the chain: %v", err)
+	}
+	entry.Date = time.Now().UTC()
+
+	log.WithContext(ctx).Infof("Creating %s snapshot of %s at height %d...", entry.Kind, config.Network, entry.Height)
+	if entry, err = snapshot.Create(dataDir, flagSnapshotDir, config.Network, entry, flagSnapshotFormat); err != nil {
+		return fmt.Errorf("failed to create snapshot: %v", err)
+	}
+	log.WithContext(ctx).Infof("Snapshot %s created in %s, sha256 %s",
+		entry.Name, filepath.Join(flagSnapshotDir, config.Network), entry.SHA256)
+
+	if flagSnapshotKeep > 0 {
+		removed, err := snapshot.Prune(flagSnapshotDir, config.Network, flagSnapshotKeep)
+		if err != nil {
+			return fmt.Errorf("failed to remove old snapshots: %v", err)
+		}
+		for _, old := range removed {
+			log.WithContext(ctx).Infof("Removed old snapshot %s at height %d", old.Name, old.Height)
+		}
+	}
+	return nil
+}
+
+// snapshotKind returns the kind of the snapshot by the indexes pasteld keeps
+func snapshotKind(config *configs.Config, pasteldArgs []string) string {
+	conf, err := utils.LoadPastelConf(filepath.Join(config.WorkingDir, constants.PastelConfName))
+	if err == nil && conf.GetBool("insightexplorer") {
+		return snapshot.KindExplorer
+	}
+	if config.TxIndex == 1 {
+		return snapshot.KindTxIndex
+	}
+	for _, arg := range pasteldArgs {
+		if strings.TrimLeft(arg, "-") == "txindex=1" {
+			return snapshot.KindTxIndex
+		}
+	}
+	return snapshot.KindNode
+}
+
+func restartSnapshotPasteld(ctx context.Context, config *configs.Config, pasteldArgs []string) error {
+	if err := startPasteldWithArgs(ctx, config, pasteldArgs); err != nil {
+		return err
+	}
+	if !WaitingForPastelDToStart(ctx, config) {
+		return fmt.Errorf("pasteld didn't start")
+	}
+	return nil
+}
+
+func runSnapshotServe(ctx context.Context) error {
+	if _, err := os.Stat(flagSnapshotDir); err != nil {
+		return fmt.Errorf("no snapshots to serve: %v", err)
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	sys.RegisterSignalInterceptor(cancel, os.Interrupt, syscall.SIGTERM)
+
+	handler := snapshot.Handler(flagSnapshotDir)
+	server := &http.Server{
+		Addr: flagSnapshotListen,
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			log.WithContext(ctx).Infof("%s %s %s", r.RemoteAddr, r.Method, r.URL.Path)
+			handler.ServeHTTP(w, r)
+		}),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer shutdownCancel()
+		_ = server.Shutdown(shutdownCtx)
+	}()
+
+	log.WithContext(ctx).Infof("Serving snapshots of %s on %s", flagSnapshotDir, flagSnapshotListen)
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
@@ -87,6 +87,22 @@ func setupStartSubCommand(config *configs.Config,
 			SetUsage(green("Optional, WAN address of the host")),
 		cli.NewFlag("reindex", &config.ReIndex).
 			SetUsage(green("Optional, Start with reindex")),
+		cli.NewFlag("skip-config-validation", &config.SkipConfigValidation).
+			SetUsage(yellow("Optional, Start without validation of the config files")),
+		cli.NewFlag("auto-ports", &config.AutoPorts).
+			SetUsage(yellow("Optional, Move busy local ports (rpc, rq-service, walletnode API, bridge) to free ones and update all configs referring to them")),
+		cli.NewFlag("skip-port-check", &config.SkipPortCheck).
+			SetUsage(yellow("Optional, Start without checking that ports of the services are free")),
+		cli.NewFlag("start-timeout", &config.StartTimeout).
+			SetUsage(green("Optional, how long to wait for each component to become ready, pasteld loading blocks is waited for as long as it takes")).
+			SetValue(defaultStartTimeout),
+		cli.NewFlag("sync-timeout", &config.SyncTimeout).
+			SetUsage(green("Optional, how long to wait for pasteld to sync blocks and masternode lists, 0 means as long as it makes progress")),
+		cli.NewFlag("sync-stall-timeout", &config.SyncStallTimeout).
+			SetUsage(green("Optional, how long pasteld may sync without getting new blocks or headers before start fails")).
+			SetValue(defaultSyncStallTimeout),
+		cli.NewFlag("restart-on-stall", &config.RestartOnStall).
+			SetUsage(yellow("Optional, restart pasteld once, when its sync stalls, instead of failing")),
 	}
 
 	var dirsFlags []*cli.Flag
@@ -192,6 +208,14 @@ func setupStartSubCommand(config *configs.Config,
 				if err = ParsePastelConf(ctx, config); err != nil {
 					return err
 				}
+				if err = validateStartConfigs(ctx, config, startCommand); err != nil {
+					return err
+				}
+				if !config.SkipPortCheck {
+					if err = checkStartPorts(ctx, config, startCommand, config.AutoPorts); err != nil {
+						return err
+					}
+				}
 			}
 			log.WithContext(ctx).Info("Starting")
 			err = f(ctx, config)
@@ -349,7 +373,7 @@ func runStartSuperNode(ctx context.Context, config *configs.Config, justInit boo
 	log.WithContext(ctx).Info("Finished checking arguments!")
 
 	pastelDIsRunning := false
-	if CheckProcessRunning(constants.PastelD) {
+	if CheckProcessRunning(ctx, config, constants.PastelD) {
 		log.WithContext(ctx).Infof("pasteld is already running")
 		if yes, _ := AskUserToContinue(ctx,
 			"Do you want to stop it and continue? Y/N"); !yes {
@@ -365,7 +389,6 @@ func runStartSuperNode(ctx context.Context, config *configs.Config, justInit boo
 			log.WithContext(ctx).WithError(err).Error("Failed to validate and prepare masternode parameters")
 			return err
 		}
-		log.WithContext(ctx).Infof("CONFIG: %+v", config)
 		if err := createOrUpdateMasternodeConf(ctx, config); err != nil {
 			log.WithContext(ctx).WithError(err).Error("Failed to create or update masternode.conf")
 			return err
@@ -483,6 +506,18 @@ func runRemoteStart(ctx context.Context, config *configs.Config, tool string) er
 	if config.ReIndex {
 		startOptions = fmt.Sprintf("%s --reindex", startOptions)
 	}
+	if config.StartTimeout != defaultStartTimeout {
+		startOptions = fmt.Sprintf("%s --start-timeout=%s", startOptions, config.StartTimeout)
+	}
+	if config.SyncTimeout != 0 {
+		startOptions = fmt.Sprintf("%s --sync-timeout=%s", startOptions, config.SyncTimeout)
+	}
+	if config.SyncStallTimeout != defaultSyncStallTimeout {
+		startOptions = fmt.Sprintf("%s --sync-stall-timeout=%s", startOptions, config.SyncStallTimeout)
+	}
+	if config.RestartOnStall {
+		startOptions = fmt.Sprintf("%s --restart-on-stall", startOptions)
+	}
 	if config.DevMode {
 		startOptions = fmt.Sprintf("%s --development-mode", startOptions)
 	}
@@ -492,6 +527,7 @@ func runRemoteStart(ctx context.Context, config *configs.Config, tool string) er
 	if len(config.WorkingDir) > 0 {
 		startOptions = fmt.Sprintf("%s --work-dir=%s", startOptions, config.WorkingDir)
 	}
+	startOptions += instanceOptions(config, false)
 
 	startSuperNodeCmd := fmt.Sprintf("%s start %s", constants.RemotePastelupPath, startOptions)
 	if _, err := executeRemoteCommandsWithInventory(ctx, config, []string{startSuperNodeCmd}, false, false); err != nil {
@@ -555,7 +591,7 @@ func runStartMasternode(ctx context.Context, config *configs.Config) error {
 	}
 
 	// *************  Start Node as Masternode  *************
-	log.WithContext(ctx).Infof("Starting pasteld as masternode: nodeName: %s; mnPrivKey: %s", config.MasterNodeName, privKey)
+	log.WithContext(ctx).Infof("Starting pasteld as masternode: nodeName: %s", config.MasterNodeName)
 	if err := runPastelNode(ctx, config, true, config.ReIndex, config.NodeExtIP, privKey); err != nil { //in masternode mode pasteld MUST be started with reindex flag
 		log.WithContext(ctx).WithError(err).Error("pasteld failed to start as masternode")
 		return err
@@ -566,7 +602,7 @@ func runStartMasternode(ctx context.Context, config *configs.Config) error {
 // Sub Command
 func runRQService(ctx context.Context, config *configs.Config) error {
 	serviceEnabled := false
-	sm, err := NewServiceManager(utils.GetOS(), config.Configurer.DefaultHomeDir())
+	sm, err := NewServiceManager(utils.GetOS(), config.Configurer.DefaultHomeDir(), config.Instance)
 	if err != nil {
 		log.WithContext(ctx).Warn(err.Error())
 	} else {
@@ -580,11 +616,7 @@ func runRQService(ctx context.Context, config *configs.Config) error {
 			return err
 		}
 		if srvStarted {
-			log.WithContext(ctx).Infof("Check %s is running...", constants.RQService)
-			time.Sleep(10 * time.Second)
-			if CheckProcessRunning(constants.RQService) {
-				return nil
-			}
+			return waitForReady(ctx, config, constants.RQService)
 		}
 	}
 	rqExecName := constants.PastelRQServiceExecName[utils.GetOS()]
@@ -601,7 +633,7 @@ func runRQService(ctx context.Context, config *configs.Config) error {
 // Sub Command
 func runDDService(ctx context.Context, config *configs.Config) (err error) {
 	serviceEnabled := false
-	sm, err := NewServiceManager(utils.GetOS(), config.Configurer.DefaultHomeDir())
+	sm, err := NewServiceManager(utils.GetOS(), config.Configurer.DefaultHomeDir(), config.Instance)
 	if err != nil {
 		log.WithContext(ctx).Warn(err.Error())
 	} else {
@@ -632,27 +664,24 @@ func runDDService(ctx context.Context, config *configs.Config) (err error) {
 		if utils.GetOS() == constants.Windows {
 			python = "python"
 		}
-		venv := filepath.Join(config.PastelExecDir, constants.DupeDetectionSubFolder, "venv")
-		cmd := fmt.Sprintf("source %v/bin/activate && %v %v %v", venv, python, execPath, ddConfigFilePath)
-		go RunCMD("bash", "-c", cmd)
+		// python of the venv is run directly, as the system service does, so the pid file has the python process
+		venvPython := filepath.Join(config.PastelExecDir, constants.DupeDetectionSubFolder, "venv", "bin", python)
+		if err = startProcess(ctx, config, constants.DDService, venvPython, execPath, ddConfigFilePath); err != nil {
+			log.WithContext(ctx).WithError(err).Error("Failed to start dd-service")
+			return err
+		}
 	}
 
-	time.Sleep(10 * time.Second)
-
-	if output, err := FindRunningProcess(constants.DupeDetectionExecFileName); len(output) == 0 {
-		err = errors.Errorf("dd-service failed to start")
+	if err = waitForReady(ctx, config, constants.DDService); err != nil {
 		log.WithContext(ctx).WithError(err).Error("dd-service failed to start")
 		return err
-	} else if err != nil {
-		log.WithContext(ctx).WithError(err).Error("failed to test if dd-service is running")
-	} else {
-		log.WithContext(ctx).Info("dd-service is successfully started")
 	}
+	log.WithContext(ctx).Info("dd-service is successfully started")
 	return nil
 }
 
 func runDDImgServer(ctx context.Context, config *configs.Config) (err error) {
-	sm, err := NewServiceManager(utils.GetOS(), config.Configurer.DefaultHomeDir())
+	sm, err := NewServiceManager(utils.GetOS(), config.Configurer.DefaultHomeDir(), config.Instance)
 	if err != nil {
 		log.WithContext(ctx).Error(err.Error())
 		return err
@@ -671,7 +700,7 @@ func runDDImgServer(ctx context.Context, config *configs.Config) (err error) {
 // Sub Command
 func runWalletNodeService(ctx context.Context, config *configs.Config) error {
 	serviceEnabled := false
-	sm, err := NewServiceManager(utils.GetOS(), config.Configurer.DefaultHomeDir())
+	sm, err := NewServiceManager(utils.GetOS(), config.Configurer.DefaultHomeDir(), config.Instance)
 	if err != nil {
 		log.WithContext(ctx).Warn(err.Error())
 	} else {
@@ -685,11 +714,7 @@ func runWalletNodeService(ctx context.Context, config *configs.Config) error {
 			return err
 		}
 		if srvStarted {
-			log.WithContext(ctx).Infof("Check %s is running...", constants.WalletNode)
-			time.Sleep(10 * time.Second)
-			if CheckProcessRunning(constants.WalletNode) {
-				return nil
-			}
+			return waitForReady(ctx, config, constants.WalletNode)
 		}
 	}
 	walletnodeExecName := constants.WalletNodeExecName[utils.GetOS()]
@@ -719,7 +744,7 @@ func runBridgeService(ctx context.Context, config *configs.Config) error {
 	}
 
 	serviceEnabled := false
-	sm, err := NewServiceManager(utils.GetOS(), config.Configurer.DefaultHomeDir())
+	sm, err := NewServiceManager(utils.GetOS(), config.Configurer.DefaultHomeDir(), config.Instance)
 	if err != nil {
 		log.WithContext(ctx).Warn(err.Error())
 	} else {
@@ -733,11 +758,7 @@ func runBridgeService(ctx context.Context, config *configs.Config) error {
 			return err
 		}
 		if srvStarted {
-			log.WithContext(ctx).Infof("Check %s is running...", constants.Bridge)
-			time.Sleep(10 * time.Second)
-			if CheckProcessRunning(constants.Bridge) {
-				return nil
-			}
+			return waitForReady(ctx, config, constants.Bridge)
 		}
 	}
 	bridgeExecName := constants.BridgeExecName[utils.GetOS()]
@@ -761,7 +782,7 @@ func runBridgeService(ctx context.Context, config *configs.Config) error {
 // Sub Command
 func runSuperNodeService(ctx context.Context, config *configs.Config) error {
 	serviceEnabled := false
-	sm, err := NewServiceManager(utils.GetOS(), config.Configurer.DefaultHomeDir())
+	sm, err := NewServiceManager(utils.GetOS(), config.Configurer.DefaultHomeDir(), config.Instance)
 	if err != nil {
 		log.WithContext(ctx).Warn(err.Error())
 	} else {
@@ -774,15 +795,14 @@ func runSuperNodeService(ctx context.Context, config *configs.Config) error {
 			log.WithContext(ctx).Errorf("Failed to start service for %v: %v", constants.SuperNode, err)
 		}
 		if srvStarted {
-			log.WithContext(ctx).Infof("Check %s is running...", constants.SuperNode)
-			time.Sleep(10 * time.Second)
-			if CheckProcessRunning(constants.SuperNode) {
-				if err := runHermesService(ctx, config); err != nil {
-					log.WithContext(ctx).WithError(err).Error("sn-service started bu start hermes service failed")
-					return err
-				}
-				return nil
+			if err := waitForReady(ctx, config, constants.SuperNode); err != nil {
+				return err
 			}
+			if err := runHermesService(ctx, config); err != nil {
+				log.WithContext(ctx).WithError(err).Error("sn-service started bu start hermes service failed")
+				return err
+			}
+			return nil
 		}
 	}
 	supernodeConfigPath := config.Configurer.GetSuperNodeConfFile(config.WorkingDir)
@@ -817,7 +837,7 @@ func runHermesService(ctx context.Context, config *configs.Config) error {
 	}
 
 	serviceEnabled := false
-	sm, err := NewServiceManager(utils.GetOS(), config.Configurer.DefaultHomeDir())
+	sm, err := NewServiceManager(utils.GetOS(), config.Configurer.DefaultHomeDir(), config.Instance)
 	if err != nil {
 		log.WithContext(ctx).Warn(err.Error())
 	} else {
@@ -830,11 +850,7 @@ func runHermesService(ctx context.Context, config *configs.Config) error {
 			log.WithContext(ctx).Errorf("Failed to start service for %v: %v", constants.Hermes, err)
 		}
 		if srvStarted {
-			log.WithContext(ctx).Infof("Check %s is running...", constants.Hermes)
-			time.Sleep(10 * time.Second)
-			if CheckProcessRunning(constants.Hermes) {
-				return nil
-			}
+			return waitForReady(ctx, config, constants.Hermes)
 		}
 	}
 	hermesConfigPath := config.Configurer.GetHermesConfFile(config.WorkingDir)
@@ -902,7 +918,7 @@ func setPastelIDAndPassphraseInHermesConf(ctx context.Context, config *configs.C
 		return err
 	}
 
-	if os.WriteFile(hermesConfigPath, hermesConfFileUpdated, 0644) != nil {
+	if utils.WriteSecretFile(hermesConfigPath, hermesConfFileUpdated) != nil {
 		log.WithContext(ctx).WithError(err).Errorf("Failed to update hermes.yml file at - %s", hermesConfigPath)
 		return err
 	}
@@ -913,7 +929,7 @@ func setPastelIDAndPassphraseInHermesConf(ctx context.Context, config *configs.C
 // /// Run helpers
 func runPastelNode(ctx context.Context, config *configs.Config, txIndexOne bool, reindex bool, extIP string, mnPrivKey string) (err error) {
 	serviceEnabled := false
-	sm, err := NewServiceManager(utils.GetOS(), config.Configurer.DefaultHomeDir())
+	sm, err := NewServiceManager(utils.GetOS(), config.Configurer.DefaultHomeDir(), config.Instance)
 	if err != nil {
 		log.WithContext(ctx).Warn(err.Error())
 	} else {
@@ -974,11 +990,10 @@ func runPastelNode(ctx context.Context, config *configs.Config, txIndexOne bool,
 		go RunCMD(pastelDPath, pasteldArgs...)
 	}
 
-	if !WaitingForPastelDToStart(ctx, config) {
-		err = fmt.Errorf("pasteld was not started")
-		log.WithContext(ctx).WithError(err).Error("pasteld didn't start")
+	if err = waitForReady(ctx, config, constants.PastelD); err != nil {
 		return err
 	}
+	recordPasteldPid(ctx, config)
 	return nil
 }
 
@@ -992,19 +1007,16 @@ func runPastelService(ctx context.Context, config *configs.Config, toolType cons
 		return err
 	}
 
-	go RunCMD(execPath, args...)
-	time.Sleep(10 * time.Second)
+	if err = startProcess(ctx, config, toolType, execPath, args...); err != nil {
+		log.WithContext(ctx).WithError(err).Errorf("%s start failed!", toolType)
+		return err
+	}
 
-	log.WithContext(ctx).Infof("Check %s is running...", toolType)
-	isServiceRunning := CheckProcessRunning(toolType)
-	if isServiceRunning {
-		log.WithContext(ctx).Infof("The %s started successfully!", toolType)
-	} else {
-		if output, err := RunCMD(execPath, args...); err != nil {
-			log.WithContext(ctx).Errorf("%s start failed! : %s", toolType, output)
-			return err
-		}
+	if err = waitForReady(ctx, config, toolType); err != nil {
+		log.WithContext(ctx).WithError(err).Errorf("%s start failed!", toolType)
+		return err
 	}
+	log.WithContext(ctx).Infof("The %s started successfully!", toolType)
 
 	return nil
 }
@@ -1051,7 +1063,6 @@ func checkStartMasterNodeParams(ctx context.Context, config *configs.Config, col
 	}
 
 	portList := GetSNPortList(config)
-	log.WithContext(ctx).Infof("CONFIG: %+v", config)
 
 	if config.MasterNodePort == 0 {
 		config.MasterNodePort = portList[constants.NodePort]
@@ -1064,7 +1075,9 @@ func checkStartMasterNodeParams(ctx context.Context, config *configs.Config, col
 	if config.MasterNodeP2PPort == 0 {
 		config.MasterNodeP2PPort = portList[constants.P2PPort]
 	}
-	log.WithContext(ctx).Infof("CONFIG: %+v", config)
+	log.WithContext(ctx).Debugf("Masternode parameters: name %s, port %d, rpc %s:%d, p2p %s:%d, pastelid %s",
+		config.MasterNodeName, config.MasterNodePort, config.MasterNodeRPCIP, config.MasterNodeRPCPort,
+		config.MasterNodeP2PIP, config.MasterNodeP2PPort, config.MasterNodePastelID)
 
 	return nil
 }
@@ -1156,7 +1169,7 @@ func checkPastelID(ctx context.Context, config *configs.Config, client *utils.Cl
 		pastelid = res["pastelid"].(string)
 	} else { //client is not nil when called from ColdHot Init
 		pastelcliPath := filepath.Join(config.RemoteHotPastelExecDir, constants.PastelCliName[utils.GetOS()])
-		out, err := client.Cmd(fmt.Sprintf("%s %s %s", pastelcliPath, "pastelid newkey",
+		out, err := client.Script(remotePastelCliCommand(pastelcliPath, []string{"pastelid", "newkey"},
 			config.MasterNodePassPhrase)).Output()
 		if err != nil {
 			log.WithContext(ctx).WithError(err).Error("Failed to generate new pastelid key on Hot node")
@@ -1212,12 +1225,12 @@ func checkMasternodePrivKey(ctx context.Context, config *configs.Config, client
 			}
 
 			mnPrivKey = string(out)
-			fmt.Println("generated priv key on hotnode: ", mnPrivKey)
 		}
 
 		config.MasterNodePrivateKey = strings.TrimSuffix(mnPrivKey, "\n")
+		log.AddSecret(config.MasterNodePrivateKey)
+		log.WithContext(ctx).Info("New masternode private key generated")
 	}
-	log.WithContext(ctx).Infof("masternode private key = %s", config.MasterNodePrivateKey)
 	return nil
 }
 
@@ -1234,8 +1247,8 @@ func checkPassphrase(ctx context.Context, config *configs.Config) error {
 			log.WithContext(ctx).WithError(err).Error("User terminated - exiting")
 			return err
 		}
+		log.AddSecret(config.MasterNodePassPhrase)
 	}
-	log.WithContext(ctx).Infof(red(fmt.Sprintf("passphrase - %s", config.MasterNodePassPhrase)))
 	return nil
 }
 
@@ -1438,7 +1451,7 @@ func createOrUpdateSuperNodeConfig(ctx context.Context, config *configs.Config)
 			MDLPort:                         portList[constants.MDLPort],
 			RAFTPort:                        portList[constants.RAFTPort],
 			MDLDataDir:                      mdlDataPath,
-			RaptorqPort:                     constants.RQServiceDefaultPort,
+			RaptorqPort:                     rqServicePort(config),
 			DDServerPort:                    constants.DDServerDefaultPort,
 			NumberOfChallengeReplicas:       constants.NumberOfChallengeReplicas,
 			StorageChallengeExpiredDuration: constants.StorageChallengeExpiredDuration,
@@ -1447,7 +1460,7 @@ func createOrUpdateSuperNodeConfig(ctx context.Context, config *configs.Config)
 			log.WithContext(ctx).WithError(err).Error("Failed to get supernode config")
 			return err
 		}
-		if err = utils.WriteFile(supernodeConfigPath, toolConfig); err != nil {
+		if err = utils.WriteSecretFile(supernodeConfigPath, []byte(toolConfig)); err != nil {
 			log.WithContext(ctx).WithError(err).Errorf("Failed to update new supernode.yml file at - %s", supernodeConfigPath)
 			return err
 		}
@@ -1478,7 +1491,7 @@ func createOrUpdateSuperNodeConfig(ctx context.Context, config *configs.Config)
 			log.WithContext(ctx).WithError(err).Errorf("Failed to unparse yml for supernode.yml file at - %s", supernodeConfigPath)
 			return err
 		}
-		if os.WriteFile(supernodeConfigPath, snConfFileUpdated, 0644) != nil {
+		if utils.WriteSecretFile(supernodeConfigPath, snConfFileUpdated) != nil {
 			log.WithContext(ctx).WithError(err).Errorf("Failed to update supernode.yml file at - %s", supernodeConfigPath)
 			return err
 		}
@@ -619,7 +619,7 @@ func (r *ColdHotRunner) createAndCopyRemoteSuperNodeConfig(ctx context.Context)
 			MDLPort:                         portList[constants.MDLPort],
 			RAFTPort:                        portList[constants.RAFTPort],
 			MDLDataDir:                      mdlDataPath,
-			RaptorqPort:                     constants.RQServiceDefaultPort,
+			RaptorqPort:                     rqServicePort(r.config),
 			DDServerPort:                    constants.DDServerDefaultPort,
 			NumberOfChallengeReplicas:       constants.NumberOfChallengeReplicas,
 			StorageChallengeExpiredDuration: constants.StorageChallengeExpiredDuration,
@@ -630,7 +630,7 @@ func (r *ColdHotRunner) createAndCopyRemoteSuperNodeConfig(ctx context.Context)
 			log.WithContext(ctx).WithError(err).Error("Failed to get supernode config")
 			return err
 		}
-		if err = utils.WriteFile(supernodeConfigPath, toolConfig); err != nil {
+		if err = utils.WriteSecretFile(supernodeConfigPath, []byte(toolConfig)); err != nil {
 			log.WithContext(ctx).WithError(err).Errorf("Failed to update new supernode.yml file at - %s", supernodeConfigPath)
 			return err
 		}
@@ -661,7 +661,7 @@ func (r *ColdHotRunner) createAndCopyRemoteSuperNodeConfig(ctx context.Context)
 			log.WithContext(ctx).WithError(err).Errorf("Failed to unparse yml for supernode.yml file at - %s", supernodeConfigPath)
 			return err
 		}
-		if os.WriteFile(supernodeConfigPath, snConfFileUpdated, 0644) != nil {
+		if utils.WriteSecretFile(supernodeConfigPath, snConfFileUpdated) != nil {
 			log.WithContext(ctx).WithError(err).Errorf("Failed to update supernode.yml file at - %s", supernodeConfigPath)
 			return err
 		}
@@ -676,7 +676,7 @@ func (r *ColdHotRunner) createAndCopyRemoteSuperNodeConfig(ctx context.Context)
 	remoteSnConfigPath = strings.ReplaceAll(remoteSnConfigPath, "\\", "/")
 
 	log.WithContext(ctx).Info("copying supernode config..")
-	if err := r.sshClient.Scp(ctx, supernodeConfigPath, remoteSnConfigPath, "0644"); err != nil {
+	if err := r.sshClient.Scp(ctx, supernodeConfigPath, remoteSnConfigPath, "0600"); err != nil {
 		log.WithContext(ctx).WithError(err).Error("Failed to copy pastelup executable to remote host")
 		return err
 	}
@@ -714,13 +714,13 @@ func (r *ColdHotRunner) copyMasterNodeConToRemote(ctx context.Context) error {
 	}
 
 	tmpMNConfPath := "/tmp/mn.conf"
-	if err := os.WriteFile(tmpMNConfPath, confData, 0644); err != nil {
+	if err := utils.WriteSecretFile(tmpMNConfPath, confData); err != nil {
 		log.WithContext(ctx).WithError(err).Error("Failed to create and write temporary masternode.conf file at '/tmp/mn.conf'")
 		return err
 	}
 
 	hotMasternodeConfPath := getMasternodeConfPath(r.config, r.config.RemoteHotWorkingDir, "masternode.conf")
-	if err := r.sshClient.Scp(ctx, tmpMNConfPath, hotMasternodeConfPath, "0644"); err != nil {
+	if err := r.sshClient.Scp(ctx, tmpMNConfPath, hotMasternodeConfPath, "0600"); err != nil {
 		return fmt.Errorf("failed to copy masternode.conf to remote %s", err)
 	}
 	return nil
@@ -766,7 +766,7 @@ func (r *ColdHotRunner) createAndCopyRemoteHermesConfig(ctx context.Context) err
 			log.WithContext(ctx).WithError(err).Error("Failed to get hermes config")
 			return err
 		}
-		if err = utils.WriteFile(hermesConfigPath, toolConfig); err != nil {
+		if err = utils.WriteSecretFile(hermesConfigPath, []byte(toolConfig)); err != nil {
 			log.WithContext(ctx).WithError(err).Errorf("Failed to update new hermes.yml file at - %s", hermesConfigPath)
 			return err
 		}
@@ -793,7 +793,7 @@ func (r *ColdHotRunner) createAndCopyRemoteHermesConfig(ctx context.Context) err
 			log.WithContext(ctx).WithError(err).Errorf("Failed to unparse yml for hermes.yml file at - %s", hermesConfigPath)
 			return err
 		}
-		if os.WriteFile(hermesConfigPath, hermesConfFileUpdated, 0644) != nil {
+		if utils.WriteSecretFile(hermesConfigPath, hermesConfFileUpdated) != nil {
 			log.WithContext(ctx).WithError(err).Errorf("Failed to update hermes.yml file at - %s", hermesConfigPath)
 			return err
 		}
@@ -808,7 +808,7 @@ func (r *ColdHotRunner) createAndCopyRemoteHermesConfig(ctx context.Context) err
 	remoteHermesConfigPath = strings.ReplaceAll(remoteHermesConfigPath, "\\", "/")
 
 	log.WithContext(ctx).Info("copying hermes config..")
-	if err := r.sshClient.Scp(ctx, hermesConfigPath, remoteHermesConfigPath, "0644"); err != nil {
+	if err := r.sshClient.Scp(ctx, hermesConfigPath, remoteHermesConfigPath, "0600"); err != nil {
 		log.WithContext(ctx).WithError(err).Error("Failed to copy pastelup executable to remote host")
 		return err
 	}
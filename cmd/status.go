@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+
+	"github.com/pastelnetwork/pastelup/common/cli"
+	"github.com/pastelnetwork/pastelup/configs"
+	"github.com/pastelnetwork/pastelup/constants"
+	"github.com/pastelnetwork/pastelup/services/healthcheck"
+	"github.com/pastelnetwork/pastelup/services/pastelcore"
+	"github.com/pastelnetwork/pastelup/structure"
+	"github.com/pastelnetwork/pastelup/utils"
+)
+
+func setupStatusCommand(config *configs.Config) *cli.Command {
+	statusCommand := cli.NewCommand("status")
+	statusCommand.SetUsage(blue("Show status of the local node - running components, pasteld sync and supernode dependencies"))
+	statusCommand.AddFlags(
+		cli.NewFlag("dir", &config.PastelExecDir).SetAliases("d").
+			SetUsage(green("Optional, Location of the pastel node directory")).SetValue(config.Configurer.DefaultPastelExecutableDir()),
+		cli.NewFlag("work-dir", &config.WorkingDir).SetAliases("w").
+			SetUsage(green("Optional, Location of the working directory")).SetValue(config.Configurer.DefaultWorkingDir()),
+	)
+	addLogFlags(statusCommand, config)
+	statusCommand.SetActionFunc(func(ctx context.Context, _ []string) error {
+		ctx, err := configureLogging(ctx, "status", config)
+		if err != nil {
+			return fmt.Errorf("failed to configure logging option - %v", err)
+		}
+		if err = ParsePastelConf(ctx, config); err != nil {
+			return err
+		}
+		return runStatus(ctx, config)
+	})
+	return statusCommand
+}
+
+func runStatus(ctx context.Context, config *configs.Config) error {
+	components := watchedComponents(ctx, config)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "COMPONENT\tSTATE\tPID")
+	for _, tool := range components {
+		pid, err := servicePid(ctx, config, tool)
+		state, pidText := "stopped", "-"
+		if err != nil {
+			state = "unknown"
+		} else if pid != 0 {
+			state, pidText = "running", fmt.Sprint(pid)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", tool, state, pidText)
+	}
+	_ = w.Flush()
+	fmt.Println()
+
+	if info, err := GetPastelInfo(ctx, config); err != nil {
+		fmt.Printf("pasteld doesn't answer: %v\n", err)
+	} else {
+		fmt.Printf("pasteld %d on %s: block %d, %d connections\n", info.Result.Version, config.Network,
+			info.Result.Blocks, info.Result.Connections)
+		if conf, err := utils.LoadPastelConf(filepath.Join(config.WorkingDir, constants.PastelConfName)); err == nil && conf.GetBool("masternode") {
+			var mnStatus structure.RPCPastelMNStatus
+			if err = pastelcore.NewClient(config).RunCommandWithArgs(pastelcore.MasterNodeCmd, []string{"status"}, &mnStatus); err == nil {
+				fmt.Printf("masternode %s: %s\n", mnStatus.Result.Outpoint, mnStatus.Result.Status)
+			}
+		}
+	}
+
+	if !utils.ContainsToolType(components, constants.SuperNode) {
+		return nil
+	}
+	fmt.Println()
+	addr := fmt.Sprintf("localhost:%d", GetSNPortList(config)[constants.SNPort])
+	report, err := healthcheck.Check(ctx, addr, pingTimeout)
+	if err != nil {
+		fmt.Printf("supernode healthcheck failed: %v\n", err)
+		return nil
+	}
+	printHealthReport(report)
+	return nil
+}
@@ -4,14 +4,14 @@ import (
 	"context"
 	"fmt"
 	"os"
-
-	"github.com/pkg/errors"
+	"time"
 
 	"github.com/pastelnetwork/pastelup/common/cli"
 	"github.com/pastelnetwork/pastelup/common/log"
 	"github.com/pastelnetwork/pastelup/common/sys"
 	"github.com/pastelnetwork/pastelup/configs"
 	"github.com/pastelnetwork/pastelup/constants"
+	"github.com/pastelnetwork/pastelup/services/pastelcore"
 	"github.com/pastelnetwork/pastelup/utils"
 )
 
@@ -63,6 +63,11 @@ var (
 	}
 )
 
+const (
+	defaultStopTimeout = 3 * time.Minute
+	stopPollInterval   = time.Second
+)
+
 var serviceToProcessOverrides = map[string]string{
 	string(constants.DDService): constants.DupeDetectionExecFileName,
 }
@@ -90,6 +95,11 @@ func setupStopSubCommand(config *configs.Config,
 		}
 	}
 
+	commandFlags = append(commandFlags,
+		cli.NewFlag("stop-timeout", &config.StopTimeout).
+			SetUsage(green("Optional, how long to wait for each component to exit before it is killed")).
+			SetValue(defaultStopTimeout))
+
 	remoteStopFlags := []*cli.Flag{
 		cli.NewFlag("ssh-ip", &config.RemoteIP).
 			SetUsage(red("Required, SSH address of the remote host")),
@@ -266,6 +276,10 @@ func runRemoteStop(ctx context.Context, config *configs.Config, tool string) {
 	if len(config.WorkingDir) > 0 {
 		stopOptions = fmt.Sprintf("%s --work-dir %s", stopOptions, config.WorkingDir)
 	}
+	if config.StopTimeout != defaultStopTimeout {
+		stopOptions = fmt.Sprintf("%s --stop-timeout=%s", stopOptions, config.StopTimeout)
+	}
+	stopOptions += instanceOptions(config, false)
 
 	stopSuperNodeCmd := fmt.Sprintf("%s stop %s", constants.RemotePastelupPath, stopOptions)
 	if _, err := executeRemoteCommandsWithInventory(ctx, config, []string{stopSuperNodeCmd}, false, false); err != nil {
@@ -320,35 +334,133 @@ func stopBridgeService(ctx context.Context, config *configs.Config) {
 
 func stopPatelCLI(ctx context.Context, config *configs.Config) error {
 	log.WithContext(ctx).Info("Stopping Pasteld")
-	_, err := GetPastelInfo(ctx, config)
+	if _, err := stopPasteld(ctx, config); err != nil {
+		log.WithContext(ctx).WithError(err).Errorf("Failed to stop Pasteld")
+		return err
+	}
+	return nil
+}
+
+// stopTimeout returns how long the component is waited for to exit before it is killed
+func stopTimeout(config *configs.Config) time.Duration {
+	if config.StopTimeout == 0 {
+		return defaultStopTimeout
+	}
+	return config.StopTimeout
+}
+
+// pasteldLockPath returns path of the file, which pasteld keeps locked while it uses the data dir
+func pasteldLockPath(config *configs.Config) string {
+	return getMasternodeConfPath(config, config.WorkingDir, constants.PasteldLockFileName)
+}
+
+// pasteldPid returns pid of pasteld, which uses the work dir, or 0 if it isn't running. The lock of the data dir
+// tells instances apart, then the pid file is checked. pasteld is looked up by name only if there are no instances
+func pasteldPid(config *configs.Config) (int, error) {
+	if pid, err := utils.LockHolderPid(pasteldLockPath(config)); err != nil || pid != 0 {
+		return pid, err
+	}
+	if pid, err := utils.ReadPidFile(pidFilePath(config, constants.PastelD)); err != nil || pid != 0 {
+		return pid, err
+	}
+	if config.Instance != "" {
+		return 0, nil
+	}
+	return GetRunningProcessPid(constants.PastelD)
+}
+
+// stopPasteld asks pasteld to shut down with RPC stop call and waits until it exits. pasteld, which doesn't answer
+// RPC, is sent SIGTERM - it shuts down the same way. pasteld still running after the stop timeout is killed
+func stopPasteld(ctx context.Context, config *configs.Config) (killed bool, err error) {
+	pid, err := pasteldPid(config)
 	if err != nil {
+		return false, fmt.Errorf("unable to find pasteld process: %v", err)
+	}
+	if pid == 0 {
 		log.WithContext(ctx).Info("Pasteld is not running!")
-		return nil
+		return false, nil
 	}
-	err = StopPastelDAndWait(ctx, config)
-	if err != nil {
-		log.WithContext(ctx).WithError(err).Errorf("Failed to stop Pasteld")
+
+	timeout := stopTimeout(config)
+	var resp map[string]interface{}
+	if err = pastelcore.NewClient(config).RunCommand(pastelcore.StopCmd, &resp); err != nil {
+		log.WithContext(ctx).Warnf("unable to stop pasteld with RPC call: %v, sending SIGTERM to pid %d", err, pid)
+	} else {
+		log.WithContext(ctx).Infof("Waiting up to %v for pasteld (pid %d) to flush its database and exit...", timeout, pid)
+		if utils.WaitProcessExit(pid, timeout, stopPollInterval) {
+			log.WithContext(ctx).Info("Pasteld stopped")
+			return false, nil
+		}
+		// the timeout has already passed, pasteld is killed at once
+		timeout = 0
 	}
-	if CheckProcessRunning(constants.PastelD) {
-		log.WithContext(ctx).Warn("Failed to stop Pasteld")
-		return errors.Errorf("Failed to stop Pasteld")
+
+	killed, err = utils.TerminateProcess(pid, timeout, stopPollInterval)
+	if killed {
+		log.WithContext(ctx).Warnf("pasteld (pid %d) didn't exit in %v and was killed, it may need to be started with --reindex",
+			pid, stopTimeout(config))
+	}
+	if err != nil {
+		return killed, err
 	}
 	log.WithContext(ctx).Info("Pasteld stopped")
+	return killed, nil
+}
+
+// ensurePasteldExited refuses to go on while pasteld still uses the data dir, it waits up to the stop timeout
+// for pasteld, which is shutting down
+func ensurePasteldExited(ctx context.Context, config *configs.Config) error {
+	pid, err := pasteldPid(config)
+	if err != nil {
+		return fmt.Errorf("unable to check if pasteld is running: %v", err)
+	}
+	if pid == 0 {
+		return nil
+	}
+	log.WithContext(ctx).Infof("Waiting for pasteld (pid %d) to exit...", pid)
+	if !utils.WaitProcessExit(pid, stopTimeout(config), stopPollInterval) {
+		return fmt.Errorf("pasteld (pid %d) is still running and using %s, stop it with 'pastelup stop node' first",
+			pid, config.WorkingDir)
+	}
 	return nil
 }
 
+// terminateService stops the process of the component, which isn't a registered system service
+func terminateService(ctx context.Context, config *configs.Config, service constants.ToolType) (killed bool, err error) {
+	pid, err := servicePid(ctx, config, service)
+	if err != nil {
+		return false, fmt.Errorf("unable to find process: %v", err)
+	}
+	if pid == 0 {
+		if config.Instance != "" {
+			// processes are found by name, that can't tell one instance from another
+			log.WithContext(ctx).Warnf("%s of instance %s has no pid file, stop it manually if it is running", service, config.Instance)
+			return false, nil
+		}
+		log.WithContext(ctx).Infof("%s is not running", service)
+		return false, nil
+	}
+	log.WithContext(ctx).Infof("Terminating %s (pid %d)...", service, pid)
+	return utils.TerminateProcess(pid, stopTimeout(config), stopPollInterval)
+}
+
 func stopServicesWithConfirmation(ctx context.Context, config *configs.Config, services []constants.ToolType) error {
 	var servicesToStop []constants.ToolType
 	for _, service := range services {
 		log.WithContext(ctx).Infof("Stopping %s...", string(service))
 		if service == constants.PastelD {
-			_, err := GetPastelInfo(ctx, config)
-			if err == nil { // this means the pastel-cli is running
+			// pasteld, which is loading or shutting down, doesn't answer RPC, but it still uses the data dir
+			pid, err := pasteldPid(config)
+			if err != nil {
+				log.WithContext(ctx).Error(fmt.Sprintf("Failed validating if '%v' service is running: %v", service, err))
+				return err
+			}
+			if pid != 0 {
 				servicesToStop = append(servicesToStop, service)
 			}
 			continue
 		}
-		pid, err := GetRunningProcessPid(service)
+		pid, err := servicePid(ctx, config, service)
 		if err != nil {
 			log.WithContext(ctx).Error(fmt.Sprintf("Failed validating if '%v' service is running: %v", service, err))
 			return err
@@ -371,15 +483,18 @@ func stopServicesWithConfirmation(ctx context.Context, config *configs.Config, s
 	return stopServices(ctx, servicesToStop, config)
 }
 
+// stopServices stops the services in the reverse order of start and waits for each to exit. The service, which doesn't
+// exit in the stop timeout, is killed
 func stopServices(ctx context.Context, services []constants.ToolType, config *configs.Config) error {
 	servicesEnabled := false
-	sm, err := NewServiceManager(utils.GetOS(), config.Configurer.DefaultHomeDir())
+	sm, err := NewServiceManager(utils.GetOS(), config.Configurer.DefaultHomeDir(), config.Instance)
 	if err != nil {
 		log.WithContext(ctx).Warnf("services not enabled for your OS %v", utils.GetOS())
 	} else {
 		servicesEnabled = true
 	}
-	for _, service := range services {
+	var killed, failed []constants.ToolType
+	for _, service := range configs.StopOrder(services) {
 		log.WithContext(ctx).Infof("Stopping %s service...", string(service))
 
 		if servicesEnabled {
@@ -390,46 +505,38 @@ func stopServices(ctx context.Context, services []constants.ToolType, config *co
 				log.WithContext(ctx).Infof("Try to stop %s as system service...", string(service))
 				err := sm.StopService(ctx, config, service)
 				if err != nil {
-					log.WithContext(ctx).Errorf("unable to stop %s as system service: %v, will try to kill it", string(service), err)
-				} else {
+					log.WithContext(ctx).Errorf("unable to stop %s as system service: %v, will try to terminate it", string(service), err)
+				} else if service != constants.PastelD {
 					continue
 				}
+				// pasteld may have been started outside of its unit, so it is checked to be gone before its data is touched
 			}
 		}
 
+		var forced bool
 		switch service {
 		case constants.PastelD:
-			err = stopPatelCLI(ctx, config)
-			if err != nil {
-				log.WithContext(ctx).Errorf("unable to stop pasteld: %v", err)
-				continue
-			}
-		case constants.DDService:
-			searchTerm := constants.DupeDetectionExecFileName
-			pid, err := FindRunningProcessPid(ctx, searchTerm)
-			if err != nil {
-				log.WithContext(ctx).Errorf("unable to find service %v to stop it: %v", service, err)
-				continue
-			}
-			log.WithContext(ctx).Infof("Killing process: %v\n", pid)
-			err = KillProcessByPid(ctx, pid)
-			if err != nil {
-				log.WithContext(ctx).Errorf("unable to stop service %v: %v", service, err)
-				continue
-			}
+			forced, err = stopPasteld(ctx, config)
 		default:
-			process := service
-			override, ok := serviceToProcessOverrides[string(service)]
-			if ok {
-				process = constants.ToolType(override)
-			}
-			err := KillProcess(ctx, process) // kill process in case the service wasn't registered
-			if err != nil {
-				log.WithContext(ctx).Error(fmt.Sprintf("unable to kill process %v: %v", service, err))
-				continue
-			}
+			forced, err = terminateService(ctx, config, service)
+		}
+		if forced {
+			killed = append(killed, service)
+		}
+		if err != nil {
+			log.WithContext(ctx).Errorf("unable to stop %s: %v", service, err)
+			failed = append(failed, service)
+			continue
 		}
+		_ = utils.RemovePidFile(pidFilePath(config, service))
 		log.WithContext(ctx).Infof("%s service stopped", string(service))
 	}
+
+	if len(killed) > 0 {
+		log.WithContext(ctx).Warnf("%v didn't exit in %v after SIGTERM and were killed", killed, stopTimeout(config))
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("failed to stop %v", failed)
+	}
 	return nil
 }
@@ -0,0 +1,457 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/pastelnetwork/pastelup/common/cli"
+	"github.com/pastelnetwork/pastelup/common/log"
+	"github.com/pastelnetwork/pastelup/common/sys"
+	"github.com/pastelnetwork/pastelup/configs"
+	"github.com/pastelnetwork/pastelup/constants"
+	"github.com/pastelnetwork/pastelup/services/supervisor"
+	"github.com/pastelnetwork/pastelup/utils"
+)
+
+const (
+	supervisorDir         = "supervisor"
+	supervisorSocketName  = "supervisor.sock"
+	supervisorCallTimeout = 10 * time.Second
+)
+
+func setupSuperviseCommand(config *configs.Config) *cli.Command {
+	dirsFlags := func() []*cli.Flag {
+		return []*cli.Flag{
+			cli.NewFlag("dir", &config.PastelExecDir).SetAliases("d").
+				SetUsage(green("Optional, Location of the pastel node directory")).SetValue(config.Configurer.DefaultPastelExecutableDir()),
+			cli.NewFlag("work-dir", &config.WorkingDir).SetAliases("w").
+				SetUsage(green("Optional, Location of the working directory")).SetValue(config.Configurer.DefaultWorkingDir()),
+		}
+	}
+
+	statusSubCommand := cli.NewCommand("status")
+	statusSubCommand.SetUsage(cyan("Show state of the supervised services"))
+	statusSubCommand.AddFlags(dirsFlags()...)
+	addLogFlags(statusSubCommand, config)
+	statusSubCommand.SetActionFunc(func(ctx context.Context, _ []string) error {
+		if _, err := configureLogging(ctx, "supervise", config); err != nil {
+			return fmt.Errorf("failed to configure logging option - %v", err)
+		}
+		resp, err := supervisor.Call(supervisorPath(config, supervisorSocketName),
+			supervisor.Request{Command: supervisor.CommandStatus}, supervisorCallTimeout)
+		if err != nil {
+			return err
+		}
+		printSupervisorStatus(resp.Programs)
+		return nil
+	})
+
+	restartSubCommand := cli.NewCommand("restart")
+	restartSubCommand.SetUsage(cyan("Restart the supervised service, e.g. 'pastelup supervise restart supernode'"))
+	restartSubCommand.AddFlags(dirsFlags()...)
+	addLogFlags(restartSubCommand, config)
+	restartSubCommand.SetActionFunc(func(ctx context.Context, args []string) error {
+		ctx, err := configureLogging(ctx, "supervise", config)
+		if err != nil {
+			return fmt.Errorf("failed to configure logging option - %v", err)
+		}
+		if len(args) != 1 {
+			return fmt.Errorf("service to restart is required, e.g. 'pastelup supervise restart supernode'")
+		}
+		resp, err := supervisor.Call(supervisorPath(config, supervisorSocketName),
+			supervisor.Request{Command: supervisor.CommandRestart, Name: args[0]}, stopTimeout(config)+supervisorCallTimeout)
+		if err != nil {
+			return err
+		}
+		log.WithContext(ctx).Infof("%s restarted", args[0])
+		printSupervisorStatus(resp.Programs)
+		return nil
+	})
+
+	superviseCommand := cli.NewCommand("supervise")
+	superviseCommand.SetUsage(blue("Run registered services in foreground and restart them if they crash, for hosts without systemd"))
+	superviseCommand.AddFlags(dirsFlags()...)
+	superviseCommand.AddFlags(
+		cli.NewFlag("stop-timeout", &config.StopTimeout).
+			SetUsage(green("Optional, how long to wait for each service to exit before it is killed")).
+			SetValue(defaultStopTimeout),
+		cli.NewFlag("start-timeout", &config.StartTimeout).
+			SetUsage(green("Optional, how long to wait for each service to become ready before the next one is started")).
+			SetValue(defaultStartTimeout),
+	)
+	addLogFlags(superviseCommand, config)
+	superviseCommand.SetActionFunc(func(ctx context.Context, _ []string) error {
+		ctx, err := configureLogging(ctx, "supervise", config)
+		if err != nil {
+			return fmt.Errorf("failed to configure logging option - %v", err)
+		}
+		if err = ParsePastelConf(ctx, config); err != nil {
+			return err
+		}
+		return runSupervise(ctx, config)
+	})
+	superviseCommand.AddSubcommands(statusSubCommand, restartSubCommand)
+
+	return superviseCommand
+}
+
+// runSupervise starts the registered services in the dependency order and keeps them running until interrupted
+func runSupervise(ctx context.Context, config *configs.Config) error {
+	sm := SupervisorManager{homeDir: config.Configurer.DefaultHomeDir(), instance: config.Instance}
+	var programs []supervisor.Program
+	for _, app := range configs.SystemdTools {
+		if !sm.IsRegistered(ctx, config, app) {
+			continue
+		}
+		program, err := sm.loadProgram(config, app)
+		if err != nil {
+			return err
+		}
+		// services started outside of the supervisor would run twice
+		if running, err := isServiceRunning(ctx, config, app); err != nil {
+			return err
+		} else if running {
+			return fmt.Errorf("%s is already running, stop it with 'pastelup stop' before starting the supervisor", app)
+		}
+		tool := app
+		program.Ready = func(ctx context.Context) error {
+			return waitForReady(ctx, config, tool)
+		}
+		program.PidFile = pidFilePath(config, app)
+		programs = append(programs, program)
+	}
+	if len(programs) == 0 {
+		return fmt.Errorf("no services are registered, register them with 'pastelup update install-service'")
+	}
+
+	sup, err := supervisor.New(programs, supervisor.Options{
+		LogDir:      supervisorPath(config, "logs"),
+		StopTimeout: stopTimeout(config),
+	})
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	sys.RegisterSignalInterceptor(cancel, os.Interrupt, syscall.SIGTERM)
+
+	// the socket is taken before any service is started, so the second supervisor doesn't start them again
+	listener, err := supervisor.Listen(supervisorPath(config, supervisorSocketName))
+	if err != nil {
+		return err
+	}
+	served := make(chan error, 1)
+	go func() {
+		served <- sup.Serve(ctx, listener)
+		cancel()
+	}()
+
+	log.WithContext(ctx).Infof("Starting services, their output is written to %s", supervisorPath(config, "logs"))
+	sup.Start(ctx)
+	log.WithContext(ctx).Infof("Supervising %d service(s), check them with 'pastelup supervise status'", len(programs))
+
+	<-ctx.Done()
+	log.WithContext(ctx).Info("Stopping services...")
+	if killed := sup.Shutdown(); len(killed) > 0 {
+		log.WithContext(ctx).Warnf("%v didn't exit in %v after SIGTERM and were killed", killed, stopTimeout(config))
+	}
+	log.WithContext(ctx).Info("All services stopped")
+	return <-served
+}
+
+// isServiceRunning checks if the service process of the work dir is running
+func isServiceRunning(ctx context.Context, config *configs.Config, app constants.ToolType) (bool, error) {
+	pid, err := servicePid(ctx, config, app)
+	return pid != 0, err
+}
+
+func printSupervisorStatus(programs []supervisor.Status) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "SERVICE\tSTATE\tPID\tRESTARTS\tSINCE\tLAST EXIT")
+	for _, p := range programs {
+		pid := "-"
+		if p.Pid != 0 {
+			pid = fmt.Sprint(p.Pid)
+		}
+		since := "-"
+		if !p.Since.IsZero() {
+			since = time.Since(p.Since).Round(time.Second).String()
+		}
+		lastExit := p.LastExit
+		if lastExit == "" {
+			lastExit = "-"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%s\t%s\n", p.Name, p.State, pid, p.Restarts, since, lastExit)
+	}
+	_ = w.Flush()
+}
+
+func supervisorPath(config *configs.Config, elem ...string) string {
+	return filepath.Join(append([]string{config.WorkingDir, constants.PastelupStateDir, supervisorDir}, elem...)...)
+}
+
+// SupervisorManager runs services under 'pastelup supervise' on the hosts without systemd. Registered services are
+// stored as program files in the working directory, the running supervisor is controlled through its socket
+type SupervisorManager struct {
+	homeDir  string
+	instance string
+}
+
+// RegisterService writes the program file of the service, the service is started by the next 'pastelup supervise'
+func (sm SupervisorManager) RegisterService(ctx context.Context, config *configs.Config, app constants.ToolType, isMn bool) error {
+	log.WithContext(ctx).Infof("Registering %v for supervisor", app)
+
+	if sm.IsRegistered(ctx, config, app) {
+		return nil // already registered
+	}
+
+	var extIP string
+	var err error
+	if app == constants.PastelD {
+		if extIP, err = utils.GetExternalIPAddress(); err != nil {
+			log.WithContext(ctx).WithError(err).Error("Could not get external IP address")
+			return err
+		}
+	}
+	program, binaries, err := sm.renderProgram(ctx, config, app, isMn, extIP)
+	if err != nil {
+		return err
+	}
+	if program == "" {
+		return nil // not a service
+	}
+	if missing := missingFiles(binaries); len(missing) > 0 {
+		err = fmt.Errorf("could not find %v executable file %s", app, missing[0])
+		log.WithContext(ctx).WithError(err).Error("Failed to register service")
+		return err
+	}
+	if err = sm.writeProgram(config, app, program); err != nil {
+		return err
+	}
+	log.WithContext(ctx).Infof("%v is started by 'pastelup supervise'", app)
+	return nil
+}
+
+// renderProgram returns program file of the tool and the executables it runs, program is empty for tools, which are not services
+func (sm SupervisorManager) renderProgram(ctx context.Context, config *configs.Config, app constants.ToolType,
+	isMn bool, extIP string) (string, []string, error) {
+	args, workDir, binaries, err := serviceExecCommand(ctx, config, sm.homeDir, app, isMn, extIP)
+	if err != nil || len(args) == 0 {
+		return "", nil, err
+	}
+	data, err := json.MarshalIndent(supervisor.Program{
+		Name: string(app),
+		Path: args[0],
+		Args: args[1:],
+		Dir:  workDir,
+	}, "", "  ")
+	if err != nil {
+		return "", nil, err
+	}
+	return string(data) + "\n", binaries, nil
+}
+
+func (sm SupervisorManager) programPath(config *configs.Config, app constants.ToolType) string {
+	return supervisorPath(config, string(app)+".json")
+}
+
+// writeProgram writes the program file readable by the user only, as pasteld program has masternode private key
+func (sm SupervisorManager) writeProgram(config *configs.Config, app constants.ToolType, program string) error {
+	if err := os.MkdirAll(supervisorPath(config), 0700); err != nil {
+		return err
+	}
+	return os.WriteFile(sm.programPath(config, app), []byte(program), 0600)
+}
+
+func (sm SupervisorManager) loadProgram(config *configs.Config, app constants.ToolType) (supervisor.Program, error) {
+	var program supervisor.Program
+	data, err := os.ReadFile(sm.programPath(config, app))
+	if err != nil {
+		return program, err
+	}
+	if err = json.Unmarshal(data, &program); err != nil {
+		return program, fmt.Errorf("invalid program file %s: %v", sm.programPath(config, app), err)
+	}
+	return program, nil
+}
+
+// call sends the request to the running supervisor, running is false if the supervisor isn't running
+func (sm SupervisorManager) call(config *configs.Config, req supervisor.Request, timeout time.Duration) (supervisor.Response, bool, error) {
+	resp, err := supervisor.Call(supervisorPath(config, supervisorSocketName), req, timeout)
+	if errors.Is(err, supervisor.ErrNotRunning) {
+		return resp, false, nil
+	}
+	return resp, true, err
+}
+
+// StartService starts the registered service in the running supervisor
+func (sm SupervisorManager) StartService(ctx context.Context, config *configs.Config, app constants.ToolType) (bool, error) {
+	if !sm.IsRegistered(ctx, config, app) {
+		log.WithContext(ctx).Infof("skipping start service because %v is not a registered service", app)
+		return false, nil
+	}
+	_, running, err := sm.call(config, supervisor.Request{Command: supervisor.CommandStart, Name: string(app)}, supervisorCallTimeout)
+	if !running {
+		log.WithContext(ctx).Infof("%v is registered, but the supervisor is not running, start it with 'pastelup supervise'", app)
+		return false, nil
+	}
+	if err != nil {
+		// the service registered after the supervisor has started
+		log.WithContext(ctx).Warnf("supervisor can't start %v: %v, restart the supervisor to pick it up", app, err)
+		return false, nil
+	}
+	return true, nil
+}
+
+// StopService stops the service in the running supervisor, the supervisor doesn't restart it until it is started again
+func (sm SupervisorManager) StopService(ctx context.Context, config *configs.Config, app constants.ToolType) error {
+	if !sm.IsRegistered(ctx, config, app) {
+		return nil
+	}
+	resp, running, err := sm.call(config, supervisor.Request{Command: supervisor.CommandStop, Name: string(app)},
+		stopTimeout(config)+supervisorCallTimeout)
+	if !running {
+		log.WithContext(ctx).Infof("Service %s is not running", string(app))
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("unable to stop service (%v): %v", app, err)
+	}
+	if resp.Killed {
+		log.WithContext(ctx).Warnf("%s didn't exit after SIGTERM and was killed by the supervisor", app)
+	}
+	return nil
+}
+
+// EnableService is a noop, all registered services are started by 'pastelup supervise'
+func (sm SupervisorManager) EnableService(ctx context.Context, _ *configs.Config, app constants.ToolType) error {
+	log.WithContext(ctx).Infof("%v is started with 'pastelup supervise', run it at boot to start the services on boot", app)
+	return nil
+}
+
+// DisableService is a noop, services are disabled by removing them
+func (sm SupervisorManager) DisableService(context.Context, *configs.Config, constants.ToolType) error {
+	return nil
+}
+
+// RemoveService removes program file of the service, the running supervisor keeps running it until restarted
+func (sm SupervisorManager) RemoveService(ctx context.Context, config *configs.Config, app constants.ToolType) error {
+	if err := os.Remove(sm.programPath(config, app)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("unable to remove %s: %v", sm.programPath(config, app), err)
+	}
+	log.WithContext(ctx).Infof("%v removed from supervisor services", app)
+	return nil
+}
+
+// IsRunning checks if the supervisor runs the service
+func (sm SupervisorManager) IsRunning(_ context.Context, config *configs.Config, app constants.ToolType) bool {
+	resp, running, err := sm.call(config, supervisor.Request{Command: supervisor.CommandStatus}, supervisorCallTimeout)
+	if !running || err != nil {
+		return false
+	}
+	for _, program := range resp.Programs {
+		if program.Name == string(app) {
+			return program.State == supervisor.StateRunning
+		}
+	}
+	return false
+}
+
+// IsRegistered checks if the program file of the service exists
+func (sm SupervisorManager) IsRegistered(_ context.Context, config *configs.Config, app constants.ToolType) bool {
+	return utils.CheckFileExist(sm.programPath(config, app))
+}
+
+// ServiceName returns name of the service in the supervisor, the supervisor runs services of one instance only
+func (sm SupervisorManager) ServiceName(app constants.ToolType) string {
+	return string(app)
+}
+
+// VerifyServices renders program files of the registered services and compares them with the installed ones
+func (sm SupervisorManager) VerifyServices(ctx context.Context, config *configs.Config) ([]UnitCheck, error) {
+	var checks []UnitCheck
+	for _, app := range configs.SystemdTools {
+		if !sm.IsRegistered(ctx, config, app) {
+			continue
+		}
+		path := sm.programPath(config, app)
+		installed, err := sm.loadProgram(config, app)
+		if err != nil {
+			return nil, err
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		var isMn bool
+		var extIP string
+		if app == constants.PastelD {
+			if key := programArgValue(installed.Args, "--masternodeprivkey"); key != "" {
+				log.AddSecret(key)
+			}
+			for _, arg := range installed.Args {
+				isMn = isMn || arg == "--masternode"
+			}
+			// external IP is kept, so the program doesn't drift with every change of the dynamic IP address
+			if extIP = programArgValue(installed.Args, "--externalip"); extIP == "" {
+				if extIP, err = utils.GetExternalIPAddress(); err != nil {
+					return nil, fmt.Errorf("could not get external IP address: %v", err)
+				}
+			}
+		}
+		expected, binaries, err := sm.renderProgram(ctx, config, app, isMn, extIP)
+		if err != nil {
+			return nil, fmt.Errorf("unable to render %s: %v", path, err)
+		}
+		checks = append(checks, UnitCheck{
+			Unit:            sm.ServiceName(app),
+			Path:            path,
+			Diff:            utils.DiffLines(string(data), expected, 3),
+			MissingBinaries: missingFiles(binaries),
+			Expected:        expected,
+		})
+	}
+	return checks, nil
+}
+
+// RepairServices rewrites drifted program files, the running supervisor uses them after restart
+func (sm SupervisorManager) RepairServices(ctx context.Context, config *configs.Config, checks []UnitCheck) error {
+	repaired := false
+	for _, check := range checks {
+		if !check.Drifted() {
+			continue
+		}
+		if len(check.MissingBinaries) > 0 {
+			log.WithContext(ctx).Warnf("%s runs missing executables %v, install them with 'pastelup update'", check.Unit, check.MissingBinaries)
+		}
+		if err := sm.writeProgram(config, constants.ToolType(check.Unit), check.Expected); err != nil {
+			return fmt.Errorf("unable to write %s: %v", check.Path, err)
+		}
+		log.WithContext(ctx).Infof("%s rewritten", check.Path)
+		repaired = true
+	}
+	if _, running, _ := sm.call(config, supervisor.Request{Command: supervisor.CommandStatus}, supervisorCallTimeout); repaired && running {
+		log.WithContext(ctx).Warn("Running supervisor uses old programs until it is restarted")
+	}
+	return nil
+}
+
+// programArgValue returns value of the "--name=value" argument
+func programArgValue(args []string, name string) string {
+	for _, arg := range args {
+		if strings.HasPrefix(arg, name+"=") {
+			return strings.TrimPrefix(arg, name+"=")
+		}
+	}
+	return ""
+}
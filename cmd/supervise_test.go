@@ -0,0 +1,35 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+
+	"github.com/pastelnetwork/pastelup/configs"
+	"github.com/pastelnetwork/pastelup/constants"
+	"github.com/pastelnetwork/pastelup/services/supervisor"
+	"github.com/tj/assert"
+)
+
+func TestRenderProgram(t *testing.T) {
+	config := configs.InitConfig(nil)
+	// default work dir of macOS has a space
+	config.WorkingDir = filepath.Join(t.TempDir(), "Application Support", "Pastel")
+	config.PastelExecDir = t.TempDir()
+	sm := SupervisorManager{homeDir: t.TempDir()}
+
+	data, _, err := sm.renderProgram(context.Background(), config, constants.SuperNode, false, "")
+	assert.NoError(t, err)
+	var program supervisor.Program
+	assert.NoError(t, json.Unmarshal([]byte(data), &program))
+	assert.Equal(t, []string{
+		"--config-file=" + config.Configurer.GetSuperNodeConfFile(config.WorkingDir),
+		"--pastel-config-file=" + filepath.Join(config.WorkingDir, constants.PastelConfName),
+	}, program.Args)
+
+	// tools, which are not services, have no program
+	data, _, err = sm.renderProgram(context.Background(), config, constants.Pastelup, false, "")
+	assert.NoError(t, err)
+	assert.Empty(t, data)
+}
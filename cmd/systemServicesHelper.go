@@ -107,7 +107,7 @@ func installSystemService(ctx context.Context, config *configs.Config) error {
 		return err
 	}
 
-	sm, err := NewServiceManager(utils.GetOS(), config.Configurer.DefaultHomeDir())
+	sm, err := NewServiceManager(utils.GetOS(), config.Configurer.DefaultHomeDir(), config.Instance)
 	if err != nil {
 		return err // services feature not configured for users OS
 	}
@@ -153,7 +153,7 @@ func removeSystemService(ctx context.Context, config *configs.Config) error {
 		return err
 	}
 
-	sm, err := NewServiceManager(utils.GetOS(), config.Configurer.DefaultHomeDir())
+	sm, err := NewServiceManager(utils.GetOS(), config.Configurer.DefaultHomeDir(), config.Instance)
 	if err != nil {
 		return err // services feature not configured for users OS
 	}
@@ -0,0 +1,269 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	sigar "github.com/cloudfoundry/gosigar"
+	"golang.org/x/term"
+
+	"github.com/pastelnetwork/pastelup/common/cli"
+	"github.com/pastelnetwork/pastelup/common/sys"
+	"github.com/pastelnetwork/pastelup/configs"
+	"github.com/pastelnetwork/pastelup/constants"
+	"github.com/pastelnetwork/pastelup/services/dashboard"
+	"github.com/pastelnetwork/pastelup/services/pastelcore"
+	"github.com/pastelnetwork/pastelup/services/synctracker"
+	"github.com/pastelnetwork/pastelup/structure"
+	"github.com/pastelnetwork/pastelup/utils"
+)
+
+const (
+	// terminal escapes of the full screen mode
+	termAltScreen   = "\x1b[?1049h\x1b[?25l"
+	termMainScreen  = "\x1b[?25h\x1b[?1049l"
+	termClearScreen = "\x1b[H\x1b[2J"
+	// topHeadlessWidth is the width of the output, which isn't a terminal
+	topHeadlessWidth = 120
+)
+
+var (
+	flagTopInterval time.Duration
+	flagTopHeadless bool
+	flagTopLog      string
+	flagTopLogLines int
+	flagTopCount    int
+)
+
+func setupTopCommand(config *configs.Config) *cli.Command {
+	topCommand := cli.NewCommand("top")
+	topCommand.SetUsage(blue("Live dashboard of the local node - sync progress, masternode status, components and logs"))
+	topCommand.AddFlags(
+		cli.NewFlag("dir", &config.PastelExecDir).SetAliases("d").
+			SetUsage(green("Optional, Location of the pastel node directory")).SetValue(config.Configurer.DefaultPastelExecutableDir()),
+		cli.NewFlag("work-dir", &config.WorkingDir).SetAliases("w").
+			SetUsage(green("Optional, Location of the working directory")).SetValue(config.Configurer.DefaultWorkingDir()),
+		cli.NewFlag("interval", &flagTopInterval).
+			SetUsage(green("Optional, how often the dashboard is refreshed")).SetValue(2*time.Second),
+		cli.NewFlag("headless", &flagTopHeadless).
+			SetUsage(green("Optional, print the dashboard every interval instead of the full screen, it is on if the output isn't a terminal")),
+		cli.NewFlag("log", &flagTopLog).
+			SetUsage(green("Optional, component, whose log is shown - pasteld, supernode, walletnode, hermes or bridge")).SetValue(string(constants.PastelD)),
+		cli.NewFlag("log-lines", &flagTopLogLines).
+			SetUsage(green("Optional, how many recent log lines are shown, 0 hides the log")).SetValue(10),
+		cli.NewFlag("count", &flagTopCount).
+			SetUsage(green("Optional, exit after the dashboard is shown that many times, 0 means until interrupted")),
+	)
+	addLogFlags(topCommand, config)
+	topCommand.SetActionFunc(func(ctx context.Context, _ []string) error {
+		ctx, err := configureLogging(ctx, "top", config)
+		if err != nil {
+			return fmt.Errorf("failed to configure logging option - %v", err)
+		}
+		if err = ParsePastelConf(ctx, config); err != nil {
+			return err
+		}
+		return runTop(ctx, config)
+	})
+	return topCommand
+}
+
+func runTop(ctx context.Context, config *configs.Config) error {
+	if flagTopInterval <= 0 {
+		return fmt.Errorf("--interval must be positive")
+	}
+	logPath := ""
+	if flagTopLogLines > 0 {
+		var err error
+		if logPath, err = componentLogPath(config, constants.ToolType(flagTopLog)); err != nil {
+			return err
+		}
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	sys.RegisterSignalInterceptor(cancel, os.Interrupt, syscall.SIGTERM)
+
+	stdout := int(os.Stdout.Fd())
+	interactive := !flagTopHeadless && term.IsTerminal(stdout)
+	if interactive {
+		fmt.Print(termAltScreen)
+		defer fmt.Print(termMainScreen)
+		// raw mode delivers keys at once, so 'q' quits without Enter
+		if stdin := int(os.Stdin.Fd()); term.IsTerminal(stdin) {
+			if state, err := term.MakeRaw(stdin); err == nil {
+				defer func() { _ = term.Restore(stdin, state) }()
+				go readTopKeys(cancel)
+			}
+		}
+	}
+
+	collector := newTopCollector(config, logPath)
+	for shown := 1; ; shown++ {
+		snap := collector.collect(ctx)
+		var buf bytes.Buffer
+		if interactive {
+			width, height, err := term.GetSize(stdout)
+			if err != nil {
+				width, height = topHeadlessWidth, 0
+			}
+			collector.render(&buf, snap, width)
+			fmt.Print(termClearScreen + fitScreen(buf.String(), height))
+		} else {
+			collector.render(&buf, snap, topHeadlessWidth)
+			fmt.Println(buf.String())
+		}
+
+		if flagTopCount > 0 && shown >= flagTopCount {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(flagTopInterval):
+		}
+	}
+}
+
+// readTopKeys cancels the dashboard on 'q', Esc or Ctrl-C, the terminal in raw mode doesn't send SIGINT
+func readTopKeys(cancel context.CancelFunc) {
+	key := make([]byte, 1)
+	for {
+		if _, err := os.Stdin.Read(key); err != nil {
+			return
+		}
+		switch key[0] {
+		case 'q', 'Q', 0x1b, 0x03:
+			cancel()
+			return
+		}
+	}
+}
+
+// fitScreen cuts the text to the screen height and ends the lines with CR, which raw mode doesn't add
+func fitScreen(text string, height int) string {
+	lines := strings.Split(strings.TrimRight(text, "\n"), "\n")
+	if height > 0 && len(lines) > height {
+		lines = lines[:height]
+	}
+	return strings.Join(lines, "\r\n")
+}
+
+// componentLogPath returns path of the log file of the component
+func componentLogPath(config *configs.Config, tool constants.ToolType) (string, error) {
+	switch tool {
+	case constants.PastelD:
+		return getMasternodeConfPath(config, config.WorkingDir, "debug.log"), nil
+	case constants.SuperNode:
+		return config.Configurer.GetSuperNodeLogFile(config.WorkingDir), nil
+	case constants.WalletNode:
+		return config.Configurer.GetWalletNodeLogFile(config.WorkingDir), nil
+	case constants.Hermes:
+		return config.Configurer.GetHermesLogFile(config.WorkingDir), nil
+	case constants.Bridge:
+		return config.Configurer.GetBridgeLogFile(config.WorkingDir), nil
+	}
+	return "", fmt.Errorf("no log of %q, use pasteld, supernode, walletnode, hermes or bridge", tool)
+}
+
+// topCollector gets the snapshots of the node, it keeps the sync samples and CPU times between the refreshes
+type topCollector struct {
+	config       *configs.Config
+	node         string
+	isMasternode bool
+	logPath      string
+	estimator    *synctracker.Estimator
+	cpu          sigar.ProcCpu
+}
+
+func newTopCollector(config *configs.Config, logPath string) *topCollector {
+	node, _ := os.Hostname()
+	if config.Instance != "" {
+		node += "/" + config.Instance
+	}
+	c := &topCollector{
+		config:    config,
+		node:      node,
+		logPath:   logPath,
+		estimator: synctracker.NewEstimator(0),
+	}
+	if conf, err := utils.LoadPastelConf(filepath.Join(config.WorkingDir, constants.PastelConfName)); err == nil {
+		c.isMasternode = conf.GetBool("masternode")
+	}
+	return c
+}
+
+func (c *topCollector) collect(ctx context.Context) dashboard.Snapshot {
+	snap := dashboard.Snapshot{
+		Time:    time.Now(),
+		Node:    c.node,
+		Network: c.config.Network,
+	}
+	// components are found again, as they may be started while the dashboard is shown
+	for _, tool := range watchedComponents(ctx, c.config) {
+		component := dashboard.Component{Name: string(tool)}
+		if pid, err := servicePid(ctx, c.config, tool); err == nil && pid != 0 {
+			process := getProcessInfo(pid, &c.cpu)
+			component.Running = true
+			component.Pid = pid
+			component.CPUPercent = process.CPUPercent
+			component.Resident = process.Resident
+		}
+		snap.Components = append(snap.Components, component)
+	}
+	if c.logPath != "" {
+		snap.LogName = flagTopLog
+		if lines, err := dashboard.Tail(c.logPath, flagTopLogLines); err == nil {
+			snap.Logs = lines
+		} else {
+			snap.Logs = []string{err.Error()}
+		}
+	}
+
+	client := pastelcore.NewClient(c.config)
+	var info structure.RPCGetInfo
+	if err := client.RunCommand(pastelcore.GetInfoCmd, &info); err != nil {
+		snap.PasteldError = err.Error()
+		return snap
+	}
+	snap.Sync = synctracker.Sample{Time: snap.Time, Blocks: info.Result.Blocks}
+	snap.Peers = info.Result.Connections
+
+	var chain structure.RPCBlockchainInfo
+	if err := client.RunCommand(pastelcore.GetBlockchainInfoCmd, &chain); err == nil {
+		snap.Sync.Headers = chain.Result.Headers
+	}
+	var peers structure.RPCPeerInfo
+	if err := client.RunCommand(pastelcore.GetPeerInfoCmd, &peers); err == nil {
+		snap.Peers = len(peers.Result)
+		for _, peer := range peers.Result {
+			if peer.StartingHeight > snap.Sync.NetworkHeight {
+				snap.Sync.NetworkHeight = peer.StartingHeight
+			}
+		}
+	}
+	var mnsync structure.RPCPastelMNSyncStatus
+	if err := client.RunCommandWithArgs(pastelcore.MasterNodeSyncCmd, []string{"status"}, &mnsync); err == nil {
+		snap.MNSync = mnsync.Result.AssetName
+		snap.MNSynced = mnsync.Result.IsSynced
+	}
+	if c.isMasternode {
+		var mnStatus structure.RPCPastelMNStatus
+		if err := client.RunCommandWithArgs(pastelcore.MasterNodeCmd, []string{"status"}, &mnStatus); err == nil {
+			snap.MasternodeStatus = mnStatus.Result.Status
+		}
+	}
+	c.estimator.Add(snap.Sync)
+	return snap
+}
+
+func (c *topCollector) render(buf *bytes.Buffer, snap dashboard.Snapshot, width int) {
+	eta, etaKnown := c.estimator.ETA()
+	dashboard.Render(buf, snap, c.estimator.Rate(), eta, etaKnown, width)
+}
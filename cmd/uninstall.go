@@ -264,6 +264,10 @@ func runUninstallNodeSubCommand(ctx context.Context, config *configs.Config) err
 	}
 
 	runStopNodeSubCommand(ctx, config)
+	if err := ensurePasteldExited(ctx, config); err != nil {
+		log.WithContext(ctx).WithError(err).Error("Cannot uninstall while pasteld is running")
+		return err
+	}
 	removeFile(ctx, config.PastelExecDir, constants.PasteldName[utils.GetOS()])
 	removeFile(ctx, config.PastelExecDir, constants.PastelCliName[utils.GetOS()])
 
@@ -288,6 +292,10 @@ func runUninstallWalletNodeSubCommand(ctx context.Context, config *configs.Confi
 	}
 
 	runStopWalletSubCommand(ctx, config)
+	if err := ensurePasteldExited(ctx, config); err != nil {
+		log.WithContext(ctx).WithError(err).Error("Cannot uninstall while pasteld is running")
+		return err
+	}
 	removeFile(ctx, config.PastelExecDir, constants.PasteldName[utils.GetOS()])
 	removeFile(ctx, config.PastelExecDir, constants.PastelCliName[utils.GetOS()])
 	removeFile(ctx, config.PastelExecDir, constants.WalletNodeExecName[utils.GetOS()])
@@ -320,6 +328,10 @@ func runUninstallSuperNodeSubCommand(ctx context.Context, config *configs.Config
 	}
 
 	runStopSuperNodeSubCommand(ctx, config)
+	if err := ensurePasteldExited(ctx, config); err != nil {
+		log.WithContext(ctx).WithError(err).Error("Cannot uninstall while pasteld is running")
+		return err
+	}
 	removeFile(ctx, config.PastelExecDir, constants.PasteldName[utils.GetOS()])
 	removeFile(ctx, config.PastelExecDir, constants.PastelCliName[utils.GetOS()])
 	removeFile(ctx, config.PastelExecDir, constants.SuperNodeExecName[utils.GetOS()])
@@ -522,6 +534,7 @@ func runRemoteUninstall(ctx context.Context, config *configs.Config, tool string
 	if len(config.WorkingDir) > 0 {
 		uninstallOptions = fmt.Sprintf("%s --work-dir=%s", uninstallOptions, config.WorkingDir)
 	}
+	uninstallOptions += instanceOptions(config, false)
 
 	uninstallCmd := fmt.Sprintf("%s uninstall %s", constants.RemotePastelupPath, uninstallOptions)
 	if _, err := executeRemoteCommandsWithInventory(ctx, config, []string{uninstallCmd}, false, false); err != nil {
@@ -100,11 +100,16 @@ func setupUpdateSubCommand(config *configs.Config,
 			SetUsage(green("Optional, type of snapshot archive - can be \"tar.zst\" or \"tar.gz\"")).SetValue("tar.zst"),
 		cli.NewFlag("snapshot-name", &config.SnapshotName).SetAliases("sn").
 			SetUsage(green("Optional, Set the specific snapshot name to install with")),
+		cli.NewFlag("snapshot-height", &config.SnapshotHeight).
+			SetUsage(green("Optional, update with the snapshot at this block height from the snapshot index of the network")),
+		cli.NewFlag("snapshot-url", &config.SnapshotURL).
+			SetUsage(green("Optional, `URL` of the snapshot archive (.tar.zst or .tar.gz), index.json or the base of the snapshot indexes of the networks")),
 	}
 
 	userFlags := []*cli.Flag{
 		cli.NewFlag("user-pw", &config.UserPw).
 			SetUsage(green("Optional, password of current sudo user - so no sudo password request is prompted")),
+		secretFileFlag("user-pw", &config.UserPwFile),
 	}
 
 	var dirsFlags []*cli.Flag
@@ -147,6 +152,7 @@ func setupUpdateSubCommand(config *configs.Config,
 			SetUsage(yellow("Optional, Username of user at remote host")),
 		cli.NewFlag("ssh-user-pw", &config.UserPw).
 			SetUsage(yellow("Optional, password of remote user - so no sudo password request is prompted")),
+		secretFileFlag("ssh-user-pw", &config.UserPwFile),
 		cli.NewFlag("ssh-key", &config.RemoteSSHKey).
 			SetUsage(yellow("Optional, Path to SSH private key for SSH Key Authentication")),
 		cli.NewFlag("inventory", &config.InventoryFile).
@@ -319,8 +325,9 @@ func runRemoteUpdate(ctx context.Context, config *configs.Config, tool string) (
 		updateOptions = fmt.Sprintf("%s --force", updateOptions)
 	}
 
+	var secrets remoteSecrets
 	if len(config.UserPw) > 0 {
-		updateOptions = fmt.Sprintf("%s --user-pw %s", updateOptions, config.UserPw)
+		updateOptions += secrets.option("user-pw", config.UserPw)
 	}
 
 	if len(config.Version) > 0 {
@@ -339,8 +346,21 @@ func runRemoteUpdate(ctx context.Context, config *configs.Config, tool string) (
 	if config.SkipDDSupportingFilesUpdate {
 		updateOptions = fmt.Sprintf("%s --skip-dd-supporting-files-update", updateOptions)
 	}
+	if config.UseSnapshot {
+		updateOptions = fmt.Sprintf("%s --use-snapshot", updateOptions)
+	}
+	if len(config.SnapshotName) > 0 {
+		updateOptions = fmt.Sprintf("%s --snapshot-name=%s", updateOptions, config.SnapshotName)
+	}
+	if config.SnapshotHeight > 0 {
+		updateOptions = fmt.Sprintf("%s --snapshot-height=%d", updateOptions, config.SnapshotHeight)
+	}
+	if len(config.SnapshotURL) > 0 {
+		updateOptions = fmt.Sprintf("%s --snapshot-url=%s", updateOptions, config.SnapshotURL)
+	}
+	updateOptions += instanceOptions(config, false)
 
-	updateSuperNodeCmd := fmt.Sprintf("yes Y | %s update %s", constants.RemotePastelupPath, updateOptions)
+	updateSuperNodeCmd := secrets.wrap(fmt.Sprintf("yes Y | %s update %s", constants.RemotePastelupPath, updateOptions))
 	if _, err := executeRemoteCommandsWithInventory(ctx, config, []string{updateSuperNodeCmd}, false, false); err != nil {
 		log.WithContext(ctx).WithError(err).Errorf("Failed to update %s on remote host", tool)
 	}
@@ -401,11 +421,13 @@ func installOrRemoveSystemServiceRemote(ctx context.Context, config *configs.Con
 		serviceInstallOptions = fmt.Sprintf("%s --force", serviceInstallOptions)
 	}
 
+	var secrets remoteSecrets
 	if len(config.UserPw) > 0 {
-		serviceInstallOptions = fmt.Sprintf("%s --user-pw %s", serviceInstallOptions, config.UserPw)
+		serviceInstallOptions += secrets.option("user-pw", config.UserPw)
 	}
+	serviceInstallOptions += instanceOptions(config, false)
 
-	updateSuperNodeCmd := fmt.Sprintf("yes Y | %s update %s", constants.RemotePastelupPath, serviceInstallOptions)
+	updateSuperNodeCmd := secrets.wrap(fmt.Sprintf("yes Y | %s update %s", constants.RemotePastelupPath, serviceInstallOptions))
 	if _, err := executeRemoteCommandsWithInventory(ctx, config, []string{updateSuperNodeCmd}, false, false); err != nil {
 		log.WithContext(ctx).WithError(err).Errorf("Failed to %s systemd services on remote host", whatToDo)
 	}
@@ -514,12 +536,18 @@ func updateSolution(ctx context.Context, config *configs.Config, installCommand
 
 // backUpWorkDir runs archive dir on the users work dir (i.e. ~/.pastel if on linux)
 func backUpWorkDir(ctx context.Context, config *configs.Config) error {
+	// archive of the database, which pasteld is still writing, is broken
+	if err := ensurePasteldExited(ctx, config); err != nil {
+		log.WithContext(ctx).WithError(err).Error("Cannot back up working directory")
+		return err
+	}
 	archivePrefix := config.Configurer.WorkDir()
 
 	var whatToBackUp []string
 	if !config.BackupAll {
 		whatToBackUp = []string{"pastel.conf", "supernode.yml", "hermes.yml", "walletnode.yml", "bridge.yml",
-			"wallet.dat", "masternode.conf", "mncache.dat", "mnpayments.dat", "blocks", "chainstate", "pastelkeys"}
+			"wallet.dat", "masternode.conf", "mncache.dat", "mnpayments.dat", "blocks", "chainstate", "pastelkeys",
+			constants.PastelupStateDir}
 	}
 	if err := backUpDir(ctx, config, config.WorkingDir, archivePrefix, whatToBackUp); err != nil {
 		log.WithContext(ctx).Error(fmt.Sprintf("Failed to archive %v directory: %v", config.WorkingDir, err))
@@ -532,7 +560,7 @@ func backUpWorkDir(ctx context.Context, config *configs.Config) error {
 			"pastel.conf", "wallet.dat", "masternode.conf",
 			"supernode.yml", "hermes.yml",
 			"walletnode.yml", "bridge.yml"}
-		dirsToPreserve := []string{"pastelkeys"}
+		dirsToPreserve := []string{"pastelkeys", constants.PastelupStateDir}
 		if _, err := utils.ClearDir(ctx, config.WorkingDir, filesToPreserve, dirsToPreserve, config.IsTestnet, config.IsDevnet); err != nil {
 			log.WithContext(ctx).Error(fmt.Sprintf("Failed to clean directory:  %v", err))
 			return err
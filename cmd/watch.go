@@ -0,0 +1,292 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/pastelnetwork/pastelup/common/cli"
+	"github.com/pastelnetwork/pastelup/common/log"
+	"github.com/pastelnetwork/pastelup/common/sys"
+	commonutils "github.com/pastelnetwork/pastelup/common/utils"
+	"github.com/pastelnetwork/pastelup/configs"
+	"github.com/pastelnetwork/pastelup/constants"
+	"github.com/pastelnetwork/pastelup/services/alert"
+	"github.com/pastelnetwork/pastelup/services/pastelcore"
+	"github.com/pastelnetwork/pastelup/structure"
+	"github.com/pastelnetwork/pastelup/utils"
+)
+
+const (
+	watchStateFile     = "alerts.json"
+	watchRuleStateFile = "alert-rules.json"
+	// watchCertTimeout is how long the TLS endpoint is dialed to get its certificate
+	watchCertTimeout = 10 * time.Second
+)
+
+var (
+	flagWatchNotify   bool
+	flagWatchWebhooks string
+	flagWatchInterval time.Duration
+	flagWatchOnce     bool
+)
+
+// pastelIDConfKeys are the keys of PastelIDs in configs of the components
+var pastelIDConfKeys = map[constants.ToolType]string{
+	constants.SuperNode: "node.pastel_id",
+	constants.Hermes:    "pastel_id",
+	constants.Bridge:    "download.pastel_id",
+}
+
+func setupWatchCommand(config *configs.Config) *cli.Command {
+	watchCommand := cli.NewCommand("watch")
+	watchCommand.SetUsage(blue("Watch the node and alert about down components, stalled sync, masternode status, low balance, disk usage, certificate and PastelID issues"))
+	watchCommand.AddFlags(
+		cli.NewFlag("dir", &config.PastelExecDir).SetAliases("d").
+			SetUsage(green("Optional, Location of the pastel node directory")).SetValue(config.Configurer.DefaultPastelExecutableDir()),
+		cli.NewFlag("work-dir", &config.WorkingDir).SetAliases("w").
+			SetUsage(green("Optional, Location of the working directory")).SetValue(config.Configurer.DefaultWorkingDir()),
+		cli.NewFlag("notify", &flagWatchNotify).
+			SetUsage(green("Optional, send alerts to the receivers of the notify section of pastelup config and --webhook, otherwise alerts are only logged")),
+		cli.NewFlag("webhook", &flagWatchWebhooks).
+			SetUsage(green("Optional, comma separated `urls` of webhooks, which get alerts as JSON")),
+		cli.NewFlag("interval", &flagWatchInterval).
+			SetUsage(green("Optional, how often the node is checked, overrides interval of the notify section")),
+		cli.NewFlag("once", &flagWatchOnce).
+			SetUsage(green("Optional, check the node once and exit, e.g. to run from cron")),
+	)
+	addLogFlags(watchCommand, config)
+	watchCommand.SetActionFunc(func(ctx context.Context, _ []string) error {
+		ctx, err := configureLogging(ctx, "watch", config)
+		if err != nil {
+			return fmt.Errorf("failed to configure logging option - %v", err)
+		}
+		if err = ParsePastelConf(ctx, config); err != nil {
+			return err
+		}
+		return runWatch(ctx, config)
+	})
+	return watchCommand
+}
+
+func runWatch(ctx context.Context, config *configs.Config) error {
+	notifyConfig := configs.NotifyConfig{}
+	if notify := configs.LoadPastelupConfig().Notify(config.Profile); notify != nil {
+		notifyConfig = *notify
+	}
+	notifyConfig = notifyConfig.WithDefaults()
+	if flagWatchInterval > 0 {
+		notifyConfig.Interval = flagWatchInterval
+	}
+
+	var notifiers []alert.Notifier
+	if flagWatchNotify {
+		var err error
+		if notifiers, err = watchNotifiers(notifyConfig, flagWatchWebhooks); err != nil {
+			return err
+		}
+		if len(notifiers) == 0 {
+			return fmt.Errorf("no receivers, add them to the notify section of pastelup config or pass --webhook")
+		}
+	}
+
+	node, _ := os.Hostname()
+	if config.Instance != "" {
+		node += "/" + config.Instance
+	}
+	stateDir := filepath.Join(config.WorkingDir, constants.PastelupStateDir)
+	watcher, err := alert.New(watchRules(notifyConfig), notifiers, alert.Options{
+		Node:          node,
+		Repeat:        notifyConfig.Repeat,
+		StatePath:     filepath.Join(stateDir, watchStateFile),
+		RuleStatePath: filepath.Join(stateDir, watchRuleStateFile),
+	})
+	if err != nil {
+		return err
+	}
+
+	components := watchedComponents(ctx, config)
+	isMasternode := false
+	if conf, err := utils.LoadPastelConf(filepath.Join(config.WorkingDir, constants.PastelConfName)); err == nil {
+		isMasternode = conf.GetBool("masternode")
+	}
+	log.WithContext(ctx).Infof("Watching %v every %v, %d receiver(s)", components, notifyConfig.Interval, len(notifiers))
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	sys.RegisterSignalInterceptor(cancel, os.Interrupt, syscall.SIGTERM)
+
+	for {
+		state := observeNode(ctx, config, components, isMasternode, notifyConfig.Rules.Certificates)
+		sent, err := watcher.Evaluate(ctx, state)
+		for _, a := range sent {
+			if a.Status == alert.StatusFiring {
+				log.WithContext(ctx).Warn(alert.Text(a))
+			} else {
+				log.WithContext(ctx).Info(alert.Text(a))
+			}
+		}
+		if err != nil {
+			log.WithContext(ctx).WithError(err).Error("Failed to send alerts")
+		}
+		if flagWatchOnce {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(notifyConfig.Interval):
+		}
+	}
+}
+
+// watchNotifiers returns notifiers of the receivers and the generic webhooks
+func watchNotifiers(notifyConfig configs.NotifyConfig, webhooks string) ([]alert.Notifier, error) {
+	var notifiers []alert.Notifier
+	for _, receiver := range notifyConfig.Receivers {
+		switch receiver.Type {
+		case configs.NotifyWebhook:
+			notifiers = append(notifiers, alert.NewWebhook(receiver.URL))
+		case configs.NotifySlack:
+			notifiers = append(notifiers, alert.NewSlack(receiver.URL))
+		case configs.NotifyTelegram:
+			log.AddSecret(receiver.Token)
+			notifiers = append(notifiers, alert.NewTelegram(receiver.URL, receiver.Token, receiver.ChatID))
+		case configs.NotifyEmail:
+			log.AddSecret(receiver.Password)
+			notifiers = append(notifiers, &alert.Email{Addr: receiver.SMTP, Username: receiver.Username,
+				Password: receiver.Password, From: receiver.From, To: receiver.To})
+		default:
+			return nil, fmt.Errorf("unknown receiver type %q", receiver.Type)
+		}
+	}
+	for _, url := range strings.Split(webhooks, ",") {
+		if url = strings.TrimSpace(url); url != "" {
+			notifiers = append(notifiers, alert.NewWebhook(url))
+		}
+	}
+	return notifiers, nil
+}
+
+// watchRules returns the enabled rules, wallet balance is checked only if its minimum is set
+func watchRules(notifyConfig configs.NotifyConfig) []alert.Rule {
+	candidates := []alert.Rule{
+		alert.ComponentDown{},
+		&alert.HeightStall{For: notifyConfig.Rules.HeightStall},
+		&alert.MasternodeStatus{},
+		alert.DiskUsage{MaxPercent: notifyConfig.Rules.MaxDiskUsage},
+		alert.PastelID{},
+		alert.CertificateExpiry{Within: notifyConfig.Rules.CertExpiry},
+	}
+	if notifyConfig.Rules.MinBalance > 0 {
+		candidates = append(candidates, alert.LowBalance{Min: notifyConfig.Rules.MinBalance})
+	}
+	var rules []alert.Rule
+	for _, rule := range candidates {
+		if !notifyConfig.IsRuleDisabled(rule.Name()) {
+			rules = append(rules, rule)
+		}
+	}
+	return rules
+}
+
+// watchedComponents returns pasteld and the components, which are registered as services or are running now
+func watchedComponents(ctx context.Context, config *configs.Config) []constants.ToolType {
+	components := []constants.ToolType{constants.PastelD}
+	sm, err := NewServiceManager(utils.GetOS(), config.Configurer.DefaultHomeDir(), config.Instance)
+	for _, tool := range configs.SystemdTools {
+		if tool == constants.PastelD {
+			continue
+		}
+		if (err == nil && sm.IsRegistered(ctx, config, tool)) || CheckProcessRunning(ctx, config, tool) {
+			components = append(components, tool)
+		}
+	}
+	return components
+}
+
+// observeNode collects the state of the node checked by the rules, values, which can't be got, are left unknown
+func observeNode(ctx context.Context, config *configs.Config, components []constants.ToolType, isMasternode bool, certificates []string) alert.State {
+	state := alert.State{
+		Time:         time.Now(),
+		Components:   make(map[string]bool),
+		DiskUsage:    make(map[string]float64),
+		Certificates: make(map[string]alert.Certificate),
+	}
+	for _, tool := range components {
+		state.Components[string(tool)] = CheckProcessRunning(ctx, config, tool)
+	}
+	if disk, err := commonutils.DiskUsage(config.WorkingDir); err == nil && disk.All > 0 {
+		state.DiskUsage[config.WorkingDir] = disk.Used / disk.All * 100
+	}
+	for _, target := range certificates {
+		state.Certificates[target] = alert.ReadCertificate(target, watchCertTimeout)
+	}
+
+	info, err := GetPastelInfo(ctx, config)
+	if err != nil {
+		return state
+	}
+	state.Height = info.Result.Blocks
+	balance := info.Result.Balance
+	state.Balance = &balance
+
+	if isMasternode {
+		var mnStatus structure.RPCPastelMNStatus
+		err = pastelcore.NewClient(config).RunCommandWithArgs(pastelcore.MasterNodeCmd, []string{"status"}, &mnStatus)
+		if err == nil && mnStatus.Result.Status != "" {
+			state.MasternodeStatus = mnStatus.Result.Status
+		}
+	}
+	state.PastelIDs = pastelIDIssues(ctx, config, isMasternode)
+	return state
+}
+
+// pastelIDIssues checks PastelIDs in configs of the components: their keys must be in pastelkeys and mnid ticket
+// of supernode's PastelID must be registered
+func pastelIDIssues(ctx context.Context, config *configs.Config, isMasternode bool) map[string]string {
+	var list structure.RPCPastelIDList
+	err := pastelcore.NewClient(config).RunCommandWithArgs(pastelcore.PastelIDCmd, []string{"list"}, &list)
+	if err != nil || list.Error != nil {
+		return nil
+	}
+	keys := make(map[string]bool)
+	for _, key := range list.Result {
+		keys[key.PastelID] = true
+	}
+
+	issues := make(map[string]string)
+	for tool, confKey := range pastelIDConfKeys {
+		component := configComponents[tool]
+		conf, err := component.load(component.path(config))
+		if err != nil {
+			continue
+		}
+		pastelID, ok, err := conf.Lookup(confKey)
+		if err != nil || !ok || pastelID == "" {
+			continue
+		}
+		issue := ""
+		if !keys[pastelID] {
+			issue = fmt.Sprintf("key used by %s is not in pastelkeys of pasteld", tool)
+		} else if tool == constants.SuperNode && isMasternode {
+			registered, err := isMNIDTicketRegistered(ctx, pastelcore.NewClient(config), pastelID)
+			if err != nil {
+				continue
+			}
+			if !registered {
+				issue = "mnid ticket is not registered"
+			}
+		}
+		// the PastelID may be used by several components, any issue wins
+		if issue != "" || issues[pastelID] == "" {
+			issues[pastelID] = issue
+		}
+	}
+	return issues
+}
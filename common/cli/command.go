@@ -33,7 +33,10 @@ func (cmd *Command) SetBeforeFunc(before func() error) {
 // SetActionFunc sets the Action function for the cli.Command
 func (cmd *Command) SetActionFunc(actionFn ActionFn) {
 	cmd.Action = func(c *cli.Context) error {
-		args := []string(c.Args().Tail())
+		if err := applyFlagValueSource(c); err != nil {
+			return err
+		}
+		args := c.Args().Slice()
 		return actionFn(c.Context, args)
 	}
 }
@@ -0,0 +1,158 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/urfave/cli/v2"
+)
+
+// ProfileFlagName is the name of the flag which selects named profile of the FlagValueSource
+const ProfileFlagName = "profile"
+
+// FlagValueSource provides values of the flags which are not set on the command line,
+// e.g. from the config files or environment variables
+type FlagValueSource interface {
+	// Lookup returns value of the flag and description of where it came from.
+	// command is the path of the command, e.g. ["install", "supernode"]
+	Lookup(command []string, profile string, flag string) (value string, origin string, ok bool)
+	// Validate returns error if the source is broken or the profile is not defined
+	Validate(profile string) error
+}
+
+// FlagValue is the effective value of the command flag
+type FlagValue struct {
+	Name   string
+	Value  string
+	Origin string
+}
+
+var flagValueSource FlagValueSource
+
+// SetFlagValueSource sets source of the flag values used by all commands
+func SetFlagValueSource(source FlagValueSource) {
+	flagValueSource = source
+}
+
+// commandPath returns names of the commands from the top level one to the current one
+func commandPath(c *cli.Context) []string {
+	var path []string
+	lineage := c.Lineage()
+	for i, ctx := range lineage {
+		// skip the app's root command, its parent context has no command
+		if ctx.Command == nil || i+1 >= len(lineage) || lineage[i+1].Command == nil {
+			continue
+		}
+		if len(path) == 0 || path[0] != ctx.Command.Name {
+			path = append([]string{ctx.Command.Name}, path...)
+		}
+	}
+	return path
+}
+
+// lookupFlagValue looks up value of the flag by any of its names except single letter aliases
+func lookupFlagValue(command []string, profile string, flag cli.Flag) (string, string, bool) {
+	for i, name := range flag.Names() {
+		if i > 0 && len(name) == 1 {
+			continue
+		}
+		if value, origin, ok := flagValueSource.Lookup(command, profile, name); ok {
+			return value, origin, true
+		}
+	}
+	return "", "", false
+}
+
+// applyFlagValueSource sets flags which are not set on the command line from the flagValueSource
+func applyFlagValueSource(c *cli.Context) error {
+	if flagValueSource == nil || c.Command == nil {
+		return nil
+	}
+
+	command := commandPath(c)
+	profile := ""
+	if c.IsSet(ProfileFlagName) {
+		profile = c.String(ProfileFlagName)
+	}
+	if err := flagValueSource.Validate(profile); err != nil {
+		return err
+	}
+
+	for _, flag := range c.Command.Flags {
+		name := flag.Names()[0]
+		if name == ProfileFlagName || c.IsSet(name) {
+			continue
+		}
+		value, origin, ok := lookupFlagValue(command, profile, flag)
+		if !ok {
+			continue
+		}
+		if err := c.Set(name, value); err != nil {
+			return fmt.Errorf("invalid value %q of --%s from %s: %v", value, name, origin, err)
+		}
+	}
+	return nil
+}
+
+// EffectiveFlagValues returns values the flags of the command would have without command line flags
+func (app *App) EffectiveFlagValues(command []string, profile string) ([]FlagValue, error) {
+	if flagValueSource != nil {
+		if err := flagValueSource.Validate(profile); err != nil {
+			return nil, err
+		}
+	}
+
+	commands := app.Commands
+	var cmd *cli.Command
+	for _, name := range command {
+		cmd = nil
+		for _, c := range commands {
+			if c.HasName(name) {
+				cmd = c
+				break
+			}
+		}
+		if cmd == nil {
+			return nil, fmt.Errorf("unknown command %q", name)
+		}
+		commands = cmd.Subcommands
+	}
+	if cmd == nil {
+		return nil, fmt.Errorf("command is not specified")
+	}
+
+	// resolve aliases of the commands
+	path := make([]string, 0, len(command))
+	commands = app.Commands
+	for _, name := range command {
+		for _, c := range commands {
+			if c.HasName(name) {
+				path = append(path, c.Name)
+				commands = c.Subcommands
+				break
+			}
+		}
+	}
+
+	var values []FlagValue
+	for _, flag := range cmd.Flags {
+		name := flag.Names()[0]
+		if name == ProfileFlagName {
+			continue
+		}
+		if flagValueSource != nil {
+			if value, origin, ok := lookupFlagValue(path, profile, flag); ok {
+				values = append(values, FlagValue{Name: name, Value: value, Origin: origin})
+				continue
+			}
+		}
+		value := ""
+		if f, ok := flag.(cli.DocGenerationFlag); ok {
+			value = f.GetValue()
+			if !f.TakesValue() {
+				value = f.GetDefaultText()
+			}
+		}
+		values = append(values, FlagValue{Name: name, Value: value, Origin: "default"})
+	}
+	return values, nil
+}
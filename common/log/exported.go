@@ -149,7 +149,7 @@ func FatalAndExit(err error) {
 
 	Fatal(err)
 
-	fmt.Fprintf(os.Stderr, "ERROR: %s\n", err)
+	fmt.Fprintf(os.Stderr, "ERROR: %s\n", Redact(err.Error()))
 }
 
 // WithSub return a log entry - with log level of given sub domain - using SetSubLevelName() to set sub level
@@ -2,6 +2,7 @@ package hooks
 
 import (
 	"fmt"
+	"io"
 	"math"
 	"time"
 
@@ -94,3 +95,9 @@ func NewFileHook(filename string) *FileHook {
 
 	return hook
 }
+
+// NewRotatingFile returns writer to the file, which is rotated the same way as the log file of FileHook.
+// It is used to capture output of the processes
+func NewRotatingFile(filename string) io.WriteCloser {
+	return NewFileHook(filename).fileLogger
+}
@@ -0,0 +1,96 @@
+package log
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Redacted replaces secrets in the log records
+const Redacted = "[REDACTED]"
+
+// minSecretLen is the minimal length of the registered secret, shorter values would garble unrelated text
+const minSecretLen = 4
+
+var (
+	secretsMtx sync.RWMutex
+	secrets    []string
+
+	// secretFieldRe matches names of the log fields, which values are always redacted
+	secretFieldRe = regexp.MustCompile(`(?i)(pass|pwd|(^|[-_])pw$|privkey|pkey|private[-_]?key|secret|token)`)
+
+	// secretOptionRe matches secrets passed as options in the command lines and config files
+	secretOptionRe = regexp.MustCompile(`(?i)(-{0,2}\b(?:pkey|passphrase|pass_phrase|user-pw|ssh-user-pw|rpcpassword|rpcpwd|masternodeprivkey|mnprivkey)(?:=|:\s*|\s+))("[^"]*"|'[^']*'|[^\s"',;]+)`)
+)
+
+// AddSecret registers the value, which is replaced by [REDACTED] in all log records
+func AddSecret(value string) {
+	value = strings.TrimSpace(value)
+	if len(value) < minSecretLen {
+		return
+	}
+	secretsMtx.Lock()
+	defer secretsMtx.Unlock()
+	for _, s := range secrets {
+		if s == value {
+			return
+		}
+	}
+	secrets = append(secrets, value)
+}
+
+// Redact replaces registered secrets and values of the secret options in the text
+func Redact(text string) string {
+	secretsMtx.RLock()
+	for _, s := range secrets {
+		text = strings.ReplaceAll(text, s, Redacted)
+	}
+	secretsMtx.RUnlock()
+	return secretOptionRe.ReplaceAllStringFunc(text, func(match string) string {
+		sub := secretOptionRe.FindStringSubmatch(match)
+		if sub[2] == Redacted {
+			return match
+		}
+		return sub[1] + Redacted
+	})
+}
+
+func redactValue(key string, value interface{}) interface{} {
+	if secretFieldRe.MatchString(key) {
+		return Redacted
+	}
+	switch v := value.(type) {
+	case string:
+		return Redact(v)
+	case error:
+		if s := Redact(v.Error()); s != v.Error() {
+			return errors.New(s)
+		}
+	case fmt.Stringer:
+		return Redact(v.String())
+	}
+	return value
+}
+
+// redactHook scrubs secrets from the message and fields before the record is written by the logger or other hooks
+type redactHook struct{}
+
+func (redactHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (redactHook) Fire(entry *logrus.Entry) error {
+	entry.Message = Redact(entry.Message)
+	for key, value := range entry.Data {
+		entry.Data[key] = redactValue(key, value)
+	}
+	return nil
+}
+
+func init() {
+	AddHook(redactHook{})
+}
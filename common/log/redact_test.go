@@ -0,0 +1,62 @@
+package log
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pastelnetwork/pastelup/common/log/hooks"
+	"github.com/sirupsen/logrus"
+	"github.com/tj/assert"
+)
+
+func TestRedact(t *testing.T) {
+	AddSecret("s3cr3t-passw0rd")
+	AddSecret("abc") // too short to be registered
+
+	assert.Equal(t, "password is [REDACTED]", Redact("password is s3cr3t-passw0rd"))
+	assert.Equal(t, "abc", Redact("abc"))
+	assert.Equal(t, "pastelup install --user-pw=[REDACTED] --force", Redact("pastelup install --user-pw=qwerty --force"))
+	assert.Equal(t, "init --pkey [REDACTED] --passphrase=[REDACTED]", Redact(`init --pkey 5Kxyz --passphrase="my pass"`))
+	assert.Equal(t, "rpcpassword=[REDACTED]\nrpcport=9932", Redact("rpcpassword=qwe123\nrpcport=9932"))
+	assert.Equal(t, "--passphrase-file=/tmp/pass", Redact("--passphrase-file=/tmp/pass"))
+}
+
+func TestNoSecretsInLogOutput(t *testing.T) {
+	secrets := []string{"5KZrGn7y1Q8bY3mK9vWZ", "pastelid-passphrase-42", "sudo-user-pw", "explicit-field-value"}
+	for _, s := range secrets[:3] {
+		AddSecret(s)
+	}
+
+	var out bytes.Buffer
+	SetOutput(&out)
+	defer SetOutput(os.Stderr)
+
+	saved := logrus.LevelHooks{}
+	for level, levelHooks := range DefaultLogger.Hooks {
+		saved[level] = append([]logrus.Hook(nil), levelHooks...)
+	}
+	defer DefaultLogger.ReplaceHooks(saved)
+	logFile := filepath.Join(t.TempDir(), "pastelup.log")
+	AddHook(hooks.NewFileHook(logFile))
+
+	ctx := ContextWithServer(context.Background(), "")
+	WithContext(ctx).Infof("Starting pasteld as masternode: mnPrivKey: %s", secrets[0])
+	WithContext(ctx).WithField("passphrase", secrets[1]).Info("pastelid created")
+	WithContext(ctx).WithField("password", secrets[3]).Warn("field with secret name")
+	WithContext(ctx).WithError(errors.New("sudo -S failed for " + secrets[2])).Error("failed")
+	WithContext(ctx).Infof("Remote Command: /tmp/pastelup install supernode --user-pw=%s started", secrets[2])
+
+	fileOut, err := os.ReadFile(logFile)
+	assert.NoError(t, err)
+
+	for _, output := range []string{out.String(), string(fileOut)} {
+		assert.Contains(t, output, Redacted)
+		for _, s := range secrets {
+			assert.NotContains(t, output, s)
+		}
+	}
+}
@@ -26,7 +26,7 @@ rq-files-dir: {{.RQDir}}
 node:
   api:
     hostname: "localhost"
-    port: 8080
+    port: {{.APIPort}}
   burn_address: {{.BurnAddress}} 
 raptorq:
   host: "localhost"
@@ -156,20 +156,56 @@ python3 -m  http.server 80`
 	// SystemdService - /etc/systemd/sysstem/rq-service.service
 	SystemdService = `[Unit]
 Description={{.Desc}}
-    
+After=network-online.target{{range .After}} {{.}}{{end}}
+Wants=network-online.target{{range .Wants}} {{.}}{{end}}
+{{- if .Requires}}
+Requires={{range $i, $unit := .Requires}}{{if $i}} {{end}}{{$unit}}{{end}}
+{{- end}}
+PartOf={{.Target}}
+StartLimitIntervalSec=600
+StartLimitBurst=5
+
 [Service]
 Type=simple
-Restart=always
+Restart=on-failure
 RestartSec=10
+{{- if .Backoff}}
+RestartSteps=5
+RestartMaxDelaySec=300
+{{- end}}
 WorkingDirectory={{.WorkDir}}
 ExecStart={{.ExecCmd}}
 User={{.User}}
+LimitNOFILE={{.LimitNOFILE}}
+ProtectSystem=full
+PrivateTmp=true
+NoNewPrivileges=true
+
+[Install]
+WantedBy=multi-user.target
+`
+
+	// SystemdTarget - /etc/systemd/system/pastel.target
+	SystemdTarget = `[Unit]
+Description={{.Desc}}
+Wants={{range $i, $unit := .Wants}}{{if $i}} {{end}}{{$unit}}{{end}}
+After={{range $i, $unit := .Wants}}{{if $i}} {{end}}{{$unit}}{{end}}
 
 [Install]
 WantedBy=multi-user.target
 `
 )
 
+// ConfigTemplateVersions are versions of the default config templates. Version must be increased
+// with every change of the template, so "update" merges new defaults into the existing configs
+var ConfigTemplateVersions = map[string]int{
+	"supernode":  1,
+	"walletnode": 1,
+	"hermes":     1,
+	"bridge":     1,
+	"rq-service": 1,
+}
+
 // BridgeConfig defines configurations for bridge
 type BridgeConfig struct {
 	LogLevel           string
@@ -204,6 +240,7 @@ type WalletNodeConfig struct {
 	BurnAddress   string
 	BridgePort    int
 	BridgeOn      bool
+	APIPort       int
 }
 
 // SuperNodeConfig defines configurations for supernode
@@ -277,6 +314,21 @@ type SystemdServiceScript struct {
 	Desc    string
 	WorkDir string
 	User    string
+	// After, Wants and Requires are the units of the pastel services the service depends on
+	After    []string
+	Wants    []string
+	Requires []string
+	// Target is pastel.target unit the service is part of
+	Target      string
+	LimitNOFILE int
+	// Backoff enables increasing restart delay, it is supported by systemd 254 and newer
+	Backoff bool
+}
+
+// SystemdTargetScript defines pastel.target file for /etc/systemd/system, which groups all pastel services
+type SystemdTargetScript struct {
+	Desc  string
+	Wants []string
 }
 
 // ZksnarkParamsNamesV2 - slice of zksnark parameters
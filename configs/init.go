@@ -1,5 +1,7 @@
 package configs
 
+import "time"
+
 // Init contains config of the Init command
 type Init struct {
 	WorkingDir                  string `json:"workdir,omitempty"`
@@ -7,6 +9,9 @@ type Init struct {
 	RPCPort                     int    `json:"rpc-port,omitempty"`
 	RPCUser                     string `json:"rpc-user,omitempty"`
 	RPCPwd                      string `json:"rpc-pwd,omitempty"`
+	RPCCookie                   bool   `json:"rpc-cookie,omitempty"`
+	RPCCookieFile               string `json:"rpc-cookie-file,omitempty"`
+	NoRestart                   bool   `json:"no-restart,omitempty"`
 	Force                       bool   `json:"force,omitempty"`
 	SkipSystemUpdate            bool   `json:"skip-system-update,omitempty"`
 	SkipDDPackagesUpdate        bool   `json:"skip-dd-packages-update,omitempty"`
@@ -17,6 +22,7 @@ type Init struct {
 	Version                     string `json:"nodeversion,omitempty"`
 	StartedRemote               bool   `json:"started-remote,omitempty"`
 	UserPw                      string `json:"user-pw,omitempty"`
+	UserPwFile                  string `json:"user-pw-file,omitempty"`
 	OpMode                      string `json:"opmode,omitempty"`
 	ArchiveDir                  string `json:"archivedir,omitempty"`
 	Legacy                      bool   `json:"legacy,omitempty"`
@@ -37,25 +43,52 @@ type Init struct {
 	UseSnapshot                 bool   `json:"use-snapshot,omitempty"`
 	SnapshotName                string `json:"snapshot-name,omitempty"`
 	SnapshotType                string `json:"snapshot-type,omitempty"`
+	SnapshotHeight              int    `json:"snapshot-height,omitempty"`
+	SnapshotURL                 string `json:"snapshot-url,omitempty"`
+	SkipConfigValidation        bool   `json:"skip-config-validation,omitempty"`
+	SpecFile                    string `json:"spec-file,omitempty"`
+	Instance                    string `json:"instance,omitempty"`
+	AutoPorts                   bool   `json:"auto-ports,omitempty"`
+	SkipPortCheck               bool   `json:"skip-port-check,omitempty"`
+	Ports                       Ports  `json:"ports,omitempty"`
 
 	NodeExtIP string `json:"nodeextip,omitempty"`
 
-	ActivateMasterNode      bool   `json:"activatemasternode,omitempty"`
-	MasterNodeName          string `json:"masternodename,omitempty"`
-	CreateNewMasterNodeConf bool   `json:"createnewmasternodeconf,omitempty"`
-	AddToMasterNodeConf     bool   `json:"addtomasternodeconf,omitempty"`
-	MasterNodeTxID          string `json:"masternodetxid,omitempty"`
-	MasterNodeTxInd         string `json:"masternodetxind,omitempty"`
-	DontCheckCollateral     bool   `json:"dontcheckcollateral,omitempty"`
-	DontUseReindex          bool   `json:"dontusereindex,omitempty"`
-	MasterNodePort          int    `json:"masternodeport,omitempty"`
-	MasterNodePrivateKey    string `json:"masternodeprivatekey,omitempty"`
-	MasterNodePastelID      string `json:"masternodepastelid,omitempty"`
-	MasterNodePassPhrase    string `json:"masternodepassphrase,omitempty"`
-	MasterNodeRPCIP         string `json:"masternoderpcip,omitempty"`
-	MasterNodeRPCPort       int    `json:"masternoderpcport,omitempty"`
-	MasterNodeP2PIP         string `json:"masternodep2pip,omitempty"`
-	MasterNodeP2PPort       int    `json:"masternodep2pport,omitempty"`
+	ActivateMasterNode       bool   `json:"activatemasternode,omitempty"`
+	MasterNodeName           string `json:"masternodename,omitempty"`
+	CreateNewMasterNodeConf  bool   `json:"createnewmasternodeconf,omitempty"`
+	AddToMasterNodeConf      bool   `json:"addtomasternodeconf,omitempty"`
+	MasterNodeTxID           string `json:"masternodetxid,omitempty"`
+	MasterNodeTxInd          string `json:"masternodetxind,omitempty"`
+	DontCheckCollateral      bool   `json:"dontcheckcollateral,omitempty"`
+	DontUseReindex           bool   `json:"dontusereindex,omitempty"`
+	MasterNodePort           int    `json:"masternodeport,omitempty"`
+	MasterNodePrivateKey     string `json:"masternodeprivatekey,omitempty"`
+	MasterNodePrivateKeyFile string `json:"masternodeprivatekey-file,omitempty"`
+	MasterNodePastelID       string `json:"masternodepastelid,omitempty"`
+	MasterNodePassPhrase     string `json:"masternodepassphrase,omitempty"`
+	MasterNodeRPCIP          string `json:"masternoderpcip,omitempty"`
+	MasterNodeRPCPort        int    `json:"masternoderpcport,omitempty"`
+	MasterNodeP2PIP          string `json:"masternodep2pip,omitempty"`
+	MasterNodeP2PPort        int    `json:"masternodep2pport,omitempty"`
+
+	// Configs for ticket registration
+	PassphraseFile   string        `json:"passphrase-file,omitempty"`
+	TicketAddress    string        `json:"ticket-address,omitempty"`
+	TicketAutoFund   bool          `json:"ticket-auto-fund,omitempty"`
+	Confirmations    int           `json:"confirmations,omitempty"`
+	TicketRegTimeout time.Duration `json:"ticket-reg-timeout,omitempty"`
+
+	// StartTimeout is how long start waits for each component to become ready
+	StartTimeout time.Duration `json:"start-timeout,omitempty"`
+	// StopTimeout is how long stop waits for each component to exit before it is killed
+	StopTimeout time.Duration `json:"stop-timeout,omitempty"`
+	// SyncTimeout is how long start waits for pasteld to sync, zero means as long as it makes progress
+	SyncTimeout time.Duration `json:"sync-timeout,omitempty"`
+	// SyncStallTimeout is how long pasteld may sync without any progress
+	SyncStallTimeout time.Duration `json:"sync-stall-timeout,omitempty"`
+	// RestartOnStall restarts pasteld once, when its sync stalls, instead of failing at once
+	RestartOnStall bool `json:"restart-on-stall,omitempty"`
 
 	// Configs for remote session
 	RemoteHotHomeDir       string `json:"remotehomedir,omitempty"`
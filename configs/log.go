@@ -9,6 +9,7 @@ type Main struct {
 	LogLevel string `json:"log-level,omitempty"`
 	LogFile  string `json:"log-file,omitempty"`
 	Quiet    bool   `json:"quiet"`
+	Profile  string `json:"profile,omitempty"`
 }
 
 // NewMain returns a new Main instance.
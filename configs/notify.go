@@ -0,0 +1,160 @@
+package configs
+
+import (
+	"fmt"
+	"time"
+)
+
+// Types of the notification receivers
+const (
+	NotifyWebhook  = "webhook"
+	NotifySlack    = "slack"
+	NotifyTelegram = "telegram"
+	NotifyEmail    = "email"
+)
+
+const (
+	defaultNotifyInterval    = time.Minute
+	defaultNotifyHeightStall = 30 * time.Minute
+	defaultNotifyDiskUsage   = 90
+	defaultNotifyCertExpiry  = 14 * 24 * time.Hour
+)
+
+// NotifyConfig is the notify section of pastelup config with receivers and rules of 'pastelup watch' alerts, e.g.
+//
+//	notify:
+//	  interval: 1m
+//	  repeat: 4h
+//	  receivers:
+//	    - type: slack
+//	      url: https://hooks.slack.com/services/...
+//	    - type: telegram
+//	      token: 123456:ABC...
+//	      chat-id: "-100123456"
+//	    - type: email
+//	      smtp: smtp.example.com:587
+//	      username: alerts@example.com
+//	      password: secret
+//	      from: alerts@example.com
+//	      to: [ops@example.com]
+//	  rules:
+//	    height-stall: 1h
+//	    min-balance: 1000
+//	    certificates: [/etc/ssl/node.crt, node.example.com:443]
+//	    disabled: [pastelid]
+type NotifyConfig struct {
+	// Interval is how often the rules are evaluated
+	Interval time.Duration `yaml:"interval,omitempty"`
+	// Repeat is how often the firing alert is sent again, it is sent once if not set
+	Repeat    time.Duration    `yaml:"repeat,omitempty"`
+	Receivers []NotifyReceiver `yaml:"receivers,omitempty"`
+	Rules     NotifyRules      `yaml:"rules,omitempty"`
+}
+
+// NotifyReceiver is where the alerts are sent to
+type NotifyReceiver struct {
+	Type string `yaml:"type"`
+	// URL of the webhook, Slack incoming webhook or Telegram Bot API server
+	URL string `yaml:"url,omitempty"`
+	// Token and ChatID of the Telegram bot
+	Token  string `yaml:"token,omitempty"`
+	ChatID string `yaml:"chat-id,omitempty"`
+	// SMTP is host:port of the mail server
+	SMTP     string   `yaml:"smtp,omitempty"`
+	Username string   `yaml:"username,omitempty"`
+	Password string   `yaml:"password,omitempty"`
+	From     string   `yaml:"from,omitempty"`
+	To       []string `yaml:"to,omitempty"`
+}
+
+// NotifyRules are thresholds of the rules, unset thresholds have default values
+type NotifyRules struct {
+	// HeightStall is how long the block height may not grow
+	HeightStall time.Duration `yaml:"height-stall,omitempty"`
+	// MinBalance is the wallet balance, below which the alert fires, the balance isn't checked if not set
+	MinBalance float64 `yaml:"min-balance,omitempty"`
+	// MaxDiskUsage is the percent of used disk space of the working dir, above which the alert fires
+	MaxDiskUsage float64 `yaml:"max-disk-usage,omitempty"`
+	// Certificates are the PEM files and host:port of TLS endpoints, whose certificates must not expire
+	Certificates []string `yaml:"certificates,omitempty"`
+	// CertExpiry is how long before the expiry of the certificate the alert fires
+	CertExpiry time.Duration `yaml:"cert-expiry,omitempty"`
+	// Disabled are names of the rules, which are not evaluated
+	Disabled []string `yaml:"disabled,omitempty"`
+}
+
+// WithDefaults returns the config with default values of the unset options
+func (c NotifyConfig) WithDefaults() NotifyConfig {
+	if c.Interval == 0 {
+		c.Interval = defaultNotifyInterval
+	}
+	if c.Rules.HeightStall == 0 {
+		c.Rules.HeightStall = defaultNotifyHeightStall
+	}
+	if c.Rules.MaxDiskUsage == 0 {
+		c.Rules.MaxDiskUsage = defaultNotifyDiskUsage
+	}
+	if c.Rules.CertExpiry == 0 {
+		c.Rules.CertExpiry = defaultNotifyCertExpiry
+	}
+	return c
+}
+
+// IsRuleDisabled checks if the rule is disabled
+func (c NotifyConfig) IsRuleDisabled(rule string) bool {
+	for _, name := range c.Rules.Disabled {
+		if name == rule {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate checks that the receivers have all required options
+func (c NotifyConfig) Validate() error {
+	if c.Interval < 0 || c.Repeat < 0 || c.Rules.HeightStall < 0 || c.Rules.CertExpiry < 0 {
+		return fmt.Errorf("durations can't be negative")
+	}
+	if c.Rules.MaxDiskUsage < 0 || c.Rules.MaxDiskUsage > 100 {
+		return fmt.Errorf("rules.max-disk-usage must be a percent, got %v", c.Rules.MaxDiskUsage)
+	}
+	for i, receiver := range c.Receivers {
+		if err := receiver.validate(); err != nil {
+			return fmt.Errorf("receivers[%d]: %v", i, err)
+		}
+	}
+	return nil
+}
+
+func (r NotifyReceiver) validate() error {
+	var missing []string
+	switch r.Type {
+	case NotifyWebhook, NotifySlack:
+		if r.URL == "" {
+			missing = append(missing, "url")
+		}
+	case NotifyTelegram:
+		if r.Token == "" {
+			missing = append(missing, "token")
+		}
+		if r.ChatID == "" {
+			missing = append(missing, "chat-id")
+		}
+	case NotifyEmail:
+		if r.SMTP == "" {
+			missing = append(missing, "smtp")
+		}
+		if r.From == "" {
+			missing = append(missing, "from")
+		}
+		if len(r.To) == 0 {
+			missing = append(missing, "to")
+		}
+	default:
+		return fmt.Errorf("unknown type %q, valid types: %s, %s, %s, %s", r.Type, NotifyWebhook, NotifySlack, NotifyTelegram, NotifyEmail)
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("%s receiver requires %v", r.Type, missing)
+	}
+	return nil
+}
@@ -0,0 +1,247 @@
+package configs
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	// SystemPastelupConfigFile is the system wide config of pastelup flags
+	SystemPastelupConfigFile = "/etc/pastelup/config.yaml"
+	// PastelupEnvPrefix is the prefix of environment variables with values of pastelup flags
+	PastelupEnvPrefix = "PASTELUP_"
+	// PastelupProfileEnv is the environment variable which selects the profile if --profile is not set
+	PastelupProfileEnv = "PASTELUP_PROFILE"
+)
+
+// pastelupConfigSection is a set of flag values, e.g.
+//
+//	network: testnet
+//	commands:
+//	  install supernode:
+//	    peers: 10.0.0.2,10.0.0.3
+//	profiles:
+//	  testnet-lab:
+//	    inventory: ~/lab.yml
+type pastelupConfigSection struct {
+	Commands map[string]map[string]interface{} `yaml:"commands,omitempty"`
+	Profiles map[string]*pastelupConfigSection `yaml:"profiles,omitempty"`
+	Notify   *NotifyConfig                     `yaml:"notify,omitempty"`
+	Flags    map[string]interface{}            `yaml:",inline"`
+}
+
+type pastelupConfigFile struct {
+	path    string
+	section *pastelupConfigSection
+}
+
+// PastelupConfig is the layered source of pastelup flag values: config files in order of
+// increasing priority, then PASTELUP_* environment variables
+type PastelupConfig struct {
+	files  []pastelupConfigFile
+	lookup func(string) (string, bool)
+	err    error
+}
+
+// UserPastelupConfigFile returns path of the user's config of pastelup flags
+func UserPastelupConfigFile() string {
+	if dir := os.Getenv("XDG_CONFIG_HOME"); dir != "" {
+		return filepath.Join(dir, "pastelup", "config.yaml")
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".config", "pastelup", "config.yaml")
+}
+
+// LoadPastelupConfig loads system and user configs of pastelup flags
+func LoadPastelupConfig() *PastelupConfig {
+	return NewPastelupConfig(os.LookupEnv, SystemPastelupConfigFile, UserPastelupConfigFile())
+}
+
+// NewPastelupConfig loads configs of pastelup flags from the files, missing files are skipped.
+// Errors are reported by Validate
+func NewPastelupConfig(lookupEnv func(string) (string, bool), paths ...string) *PastelupConfig {
+	config := &PastelupConfig{lookup: lookupEnv}
+	for _, path := range paths {
+		if path == "" {
+			continue
+		}
+		data, err := os.ReadFile(path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			config.err = fmt.Errorf("failed to read %s: %v", path, err)
+			return config
+		}
+		section := &pastelupConfigSection{}
+		if err = yaml.Unmarshal(data, section); err != nil {
+			config.err = fmt.Errorf("failed to parse %s: %v", path, err)
+			return config
+		}
+		if err = section.check(true); err != nil {
+			config.err = fmt.Errorf("invalid %s: %v", path, err)
+			return config
+		}
+		config.files = append(config.files, pastelupConfigFile{path: path, section: section})
+	}
+	return config
+}
+
+func (s *pastelupConfigSection) check(allowProfiles bool) error {
+	if !allowProfiles && len(s.Profiles) > 0 {
+		return fmt.Errorf("profiles can't be nested")
+	}
+	if s.Notify != nil {
+		if err := s.Notify.Validate(); err != nil {
+			return fmt.Errorf("notify: %v", err)
+		}
+	}
+	for name, value := range s.Flags {
+		if _, err := flagValueString(value); err != nil {
+			return fmt.Errorf("%s: %v", name, err)
+		}
+	}
+	for command, flags := range s.Commands {
+		for name, value := range flags {
+			if _, err := flagValueString(value); err != nil {
+				return fmt.Errorf("commands.%s.%s: %v", command, name, err)
+			}
+		}
+	}
+	for name, profile := range s.Profiles {
+		if profile == nil {
+			continue
+		}
+		if err := profile.check(false); err != nil {
+			return fmt.Errorf("profiles.%s: %v", name, err)
+		}
+	}
+	return nil
+}
+
+func flagValueString(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case int, int64, uint64, float64:
+		return fmt.Sprint(v), nil
+	case []interface{}:
+		items := make([]string, 0, len(v))
+		for _, item := range v {
+			s, err := flagValueString(item)
+			if err != nil {
+				return "", err
+			}
+			items = append(items, s)
+		}
+		return strings.Join(items, ","), nil
+	}
+	return "", fmt.Errorf("unsupported value %v", value)
+}
+
+// lookup returns value of the flag, values of the commands sections override top level values,
+// more specific commands override less specific ones
+func (s *pastelupConfigSection) lookup(command []string, flag string) (string, string, bool) {
+	value, origin, found := "", "", false
+	if v, ok := s.Flags[flag]; ok {
+		value, _ = flagValueString(v)
+		found = true
+	}
+
+	var sections []string
+	for name := range s.Commands {
+		path := strings.Fields(name)
+		if len(path) > 0 && len(path) <= len(command) && strings.Join(path, " ") == strings.Join(command[:len(path)], " ") {
+			sections = append(sections, name)
+		}
+	}
+	sort.Slice(sections, func(i, j int) bool {
+		return len(strings.Fields(sections[i])) < len(strings.Fields(sections[j]))
+	})
+	for _, name := range sections {
+		if v, ok := s.Commands[name][flag]; ok {
+			value, _ = flagValueString(v)
+			origin, found = fmt.Sprintf("commands.%q", strings.Join(strings.Fields(name), " ")), true
+		}
+	}
+	return value, origin, found
+}
+
+func (c *PastelupConfig) profile(profile string) string {
+	if profile == "" && c.lookup != nil {
+		profile, _ = c.lookup(PastelupProfileEnv)
+	}
+	return profile
+}
+
+// Validate returns error if config files are invalid or the profile is not defined in any of them
+func (c *PastelupConfig) Validate(profile string) error {
+	if c.err != nil {
+		return c.err
+	}
+	profile = c.profile(profile)
+	if profile == "" {
+		return nil
+	}
+	for _, file := range c.files {
+		if _, ok := file.section.Profiles[profile]; ok {
+			return nil
+		}
+	}
+	return fmt.Errorf("profile %q is not defined", profile)
+}
+
+// Lookup returns value of the flag of the command and description of where it came from
+func (c *PastelupConfig) Lookup(command []string, profile string, flag string) (string, string, bool) {
+	profile = c.profile(profile)
+	value, origin, found := "", "", false
+	for _, file := range c.files {
+		if v, o, ok := file.section.lookup(command, flag); ok {
+			value, origin, found = v, strings.TrimSpace(file.path+" "+o), true
+		}
+		if p := file.section.Profiles[profile]; profile != "" && p != nil {
+			if v, o, ok := p.lookup(command, flag); ok {
+				value, origin, found = v, strings.TrimSpace(fmt.Sprintf("%s profiles.%s %s", file.path, profile, o)), true
+			}
+		}
+	}
+
+	if c.lookup != nil {
+		env := PastelupEnvPrefix + strings.ToUpper(strings.ReplaceAll(flag, "-", "_"))
+		if env != PastelupProfileEnv {
+			if v, ok := c.lookup(env); ok {
+				value, origin, found = v, "env "+env, true
+			}
+		}
+	}
+	return value, origin, found
+}
+
+// Notify returns the notify section, the section of the profile overrides the top level one and the later file
+// overrides the earlier ones. It returns nil if no file has the section
+func (c *PastelupConfig) Notify(profile string) *NotifyConfig {
+	profile = c.profile(profile)
+	var notify *NotifyConfig
+	for _, file := range c.files {
+		if file.section.Notify != nil {
+			notify = file.section.Notify
+		}
+		if p := file.section.Profiles[profile]; profile != "" && p != nil && p.Notify != nil {
+			notify = p.Notify
+		}
+	}
+	return notify
+}
@@ -0,0 +1,132 @@
+package configs
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/tj/assert"
+)
+
+func TestPastelupConfigLookup(t *testing.T) {
+	dir := t.TempDir()
+	system := filepath.Join(dir, "system.yaml")
+	user := filepath.Join(dir, "user.yaml")
+	assert.NoError(t, os.WriteFile(system, []byte(`
+network: mainnet
+work-dir: /var/lib/pastel
+peers: [10.0.0.1, 10.0.0.2]
+`), 0644))
+	assert.NoError(t, os.WriteFile(user, []byte(`
+network: testnet
+commands:
+  install:
+    force: true
+  install supernode:
+    force: false
+profiles:
+  lab:
+    network: devnet
+    commands:
+      install:
+        work-dir: /lab
+`), 0644))
+	env := map[string]string{"PASTELUP_LOG_LEVEL": "debug"}
+	config := NewPastelupConfig(func(name string) (string, bool) {
+		v, ok := env[name]
+		return v, ok
+	}, system, user, filepath.Join(dir, "missing.yaml"))
+	assert.NoError(t, config.Validate(""))
+	assert.NoError(t, config.Validate("lab"))
+	assert.Error(t, config.Validate("unknown"))
+
+	lookup := func(command []string, profile, flag string) []string {
+		value, origin, ok := config.Lookup(command, profile, flag)
+		if !ok {
+			return nil
+		}
+		return []string{value, origin}
+	}
+	assert.Equal(t, []string{"testnet", user}, lookup([]string{"install", "node"}, "", "network"))
+	assert.Equal(t, []string{"10.0.0.1,10.0.0.2", system}, lookup([]string{"install", "node"}, "", "peers"))
+	assert.Equal(t, []string{"true", user + ` commands."install"`}, lookup([]string{"install", "node"}, "", "force"))
+	assert.Equal(t, []string{"false", user + ` commands."install supernode"`}, lookup([]string{"install", "supernode"}, "", "force"))
+	assert.Nil(t, lookup([]string{"start", "node"}, "", "force"))
+	assert.Equal(t, []string{"debug", "env PASTELUP_LOG_LEVEL"}, lookup([]string{"start", "node"}, "", "log-level"))
+
+	assert.Equal(t, []string{"devnet", user + " profiles.lab"}, lookup([]string{"install", "node"}, "lab", "network"))
+	assert.Equal(t, []string{"/lab", user + ` profiles.lab commands."install"`}, lookup([]string{"install", "node"}, "lab", "work-dir"))
+	assert.Equal(t, []string{"/var/lib/pastel", system}, lookup([]string{"start", "node"}, "lab", "work-dir"))
+
+	env[PastelupProfileEnv] = "lab"
+	assert.Equal(t, []string{"devnet", user + " profiles.lab"}, lookup([]string{"install", "node"}, "", "network"))
+}
+
+func TestPastelupConfigInvalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	assert.NoError(t, os.WriteFile(path, []byte("network:\n  name: testnet\n"), 0644))
+	assert.Error(t, NewPastelupConfig(nil, path).Validate(""))
+
+	assert.NoError(t, os.WriteFile(path, []byte("profiles:\n  a:\n    profiles:\n      b: {}\n"), 0644))
+	assert.Error(t, NewPastelupConfig(nil, path).Validate(""))
+}
+
+func TestPastelupConfigNotify(t *testing.T) {
+	dir := t.TempDir()
+	system := filepath.Join(dir, "system.yaml")
+	user := filepath.Join(dir, "user.yaml")
+	assert.NoError(t, os.WriteFile(system, []byte(`
+network: testnet
+notify:
+  interval: 30s
+  receivers:
+    - type: webhook
+      url: http://localhost:9000/alerts
+  rules:
+    height-stall: 1h
+    disabled: [pastelid]
+`), 0644))
+	assert.NoError(t, os.WriteFile(user, []byte(`
+profiles:
+  lab:
+    notify:
+      receivers:
+        - type: telegram
+          token: "123:abc"
+          chat-id: "-100"
+`), 0644))
+	config := NewPastelupConfig(nil, system, user)
+	assert.NoError(t, config.Validate(""))
+	value, _, _ := config.Lookup([]string{"watch"}, "", "network")
+	assert.Equal(t, "testnet", value)
+	_, _, ok := config.Lookup([]string{"watch"}, "", "notify")
+	assert.False(t, ok)
+
+	notify := config.Notify("")
+	assert.NotNil(t, notify)
+	assert.Equal(t, 30*time.Second, notify.Interval)
+	assert.Equal(t, "http://localhost:9000/alerts", notify.Receivers[0].URL)
+	assert.True(t, notify.IsRuleDisabled("pastelid"))
+	withDefaults := notify.WithDefaults()
+	assert.Equal(t, time.Hour, withDefaults.Rules.HeightStall)
+	assert.Equal(t, 90.0, withDefaults.Rules.MaxDiskUsage)
+	assert.Equal(t, 14*24*time.Hour, withDefaults.Rules.CertExpiry)
+
+	notify = config.Notify("lab")
+	assert.Equal(t, []NotifyReceiver{{Type: NotifyTelegram, Token: "123:abc", ChatID: "-100"}}, notify.Receivers)
+	assert.Nil(t, NewPastelupConfig(nil, user).Notify(""))
+}
+
+func TestPastelupConfigNotifyInvalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	for _, notify := range []string{
+		"notify:\n  receivers:\n    - type: pager\n",
+		"notify:\n  receivers:\n    - type: slack\n",
+		"notify:\n  receivers:\n    - type: email\n      smtp: localhost:25\n",
+		"notify:\n  rules:\n    max-disk-usage: 120\n",
+	} {
+		assert.NoError(t, os.WriteFile(path, []byte(notify), 0644))
+		assert.Error(t, NewPastelupConfig(nil, path).Validate(""), notify)
+	}
+}
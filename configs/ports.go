@@ -0,0 +1,42 @@
+package configs
+
+// Ports contains custom ports of the services, zero value means the default port of the network
+type Ports struct {
+	Offset    int `json:"port-offset,omitempty" yaml:"port-offset,omitempty"`
+	Node      int `json:"node-port,omitempty" yaml:"node-port,omitempty"`
+	NodeRPC   int `json:"node-rpc-port,omitempty" yaml:"node-rpc-port,omitempty"`
+	SuperNode int `json:"sn-port,omitempty" yaml:"sn-port,omitempty"`
+	P2P       int `json:"p2p-port,omitempty" yaml:"p2p-port,omitempty"`
+	MDL       int `json:"mdl-port,omitempty" yaml:"mdl-port,omitempty"`
+	RAFT      int `json:"raft-port,omitempty" yaml:"raft-port,omitempty"`
+	RQService int `json:"rq-port,omitempty" yaml:"rq-port,omitempty"`
+	Bridge    int `json:"bridge-port,omitempty" yaml:"bridge-port,omitempty"`
+	WNAPI     int `json:"wn-api-port,omitempty" yaml:"wn-api-port,omitempty"`
+}
+
+// IsDefault checks if no custom ports are set
+func (p Ports) IsDefault() bool {
+	return p == Ports{}
+}
+
+// Merge returns ports with values of other set where they are not set in p
+func (p Ports) Merge(other Ports) Ports {
+	pick := func(a, b int) int {
+		if a != 0 {
+			return a
+		}
+		return b
+	}
+	return Ports{
+		Offset:    pick(p.Offset, other.Offset),
+		Node:      pick(p.Node, other.Node),
+		NodeRPC:   pick(p.NodeRPC, other.NodeRPC),
+		SuperNode: pick(p.SuperNode, other.SuperNode),
+		P2P:       pick(p.P2P, other.P2P),
+		MDL:       pick(p.MDL, other.MDL),
+		RAFT:      pick(p.RAFT, other.RAFT),
+		RQService: pick(p.RQService, other.RQService),
+		Bridge:    pick(p.Bridge, other.Bridge),
+		WNAPI:     pick(p.WNAPI, other.WNAPI),
+	}
+}
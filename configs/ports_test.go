@@ -0,0 +1,16 @@
+package configs
+
+import (
+	"testing"
+
+	"github.com/tj/assert"
+)
+
+func TestPortsMerge(t *testing.T) {
+	assert.True(t, Ports{}.IsDefault())
+
+	flags := Ports{SuperNode: 5000}
+	stored := Ports{Offset: 100, SuperNode: 4000, RQService: 6000}
+	assert.Equal(t, Ports{Offset: 100, SuperNode: 5000, RQService: 6000}, flags.Merge(stored))
+	assert.False(t, flags.Merge(stored).IsDefault())
+}
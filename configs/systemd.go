@@ -0,0 +1,130 @@
+package configs
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pastelnetwork/pastelup/constants"
+)
+
+// SystemdLimitNOFILE is the open files limit of pastel services, it matches DefaultLimitNOFILE set by install
+const SystemdLimitNOFILE = 65536
+
+// SystemdTools are the tools, which can be registered as systemd services, in the order they are started
+var SystemdTools = []constants.ToolType{
+	constants.PastelD,
+	constants.RQService,
+	constants.DDService,
+	constants.DDImgService,
+	constants.SuperNode,
+	constants.Hermes,
+	constants.WalletNode,
+	constants.Bridge,
+}
+
+// StopOrder returns the tools in the order they are stopped - the reverse of the start order, so the services are
+// stopped before pasteld they depend on. Unknown tools are stopped first
+func StopOrder(tools []constants.ToolType) []constants.ToolType {
+	rank := make(map[constants.ToolType]int, len(SystemdTools))
+	for i, tool := range SystemdTools {
+		rank[tool] = len(SystemdTools) - i
+	}
+	ordered := append([]constants.ToolType(nil), tools...)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return rank[ordered[i]] < rank[ordered[j]]
+	})
+	return ordered
+}
+
+// systemdDependencies are the services the tool talks to. pasteld is required - the service is stopped and restarted
+// together with it, the other dependencies are only wanted, so they can be restarted on their own
+var systemdDependencies = map[constants.ToolType][]constants.ToolType{
+	constants.SuperNode:  {constants.PastelD, constants.RQService, constants.DDService},
+	constants.Hermes:     {constants.PastelD, constants.SuperNode, constants.DDService},
+	constants.WalletNode: {constants.PastelD, constants.RQService},
+	constants.Bridge:     {constants.PastelD, constants.WalletNode},
+}
+
+// NewSystemdServiceScript returns service file of the tool, which is part of the target. unitName returns unit names
+// of the services and registered reports if the unit file of the service exists. Only registered pasteld is required,
+// as pasteld may be started without systemd
+func NewSystemdServiceScript(app constants.ToolType, target string,
+	unitName func(constants.ToolType) string, registered func(constants.ToolType) bool,
+) *SystemdServiceScript {
+	script := &SystemdServiceScript{
+		Desc:        fmt.Sprintf("%v daemon", app),
+		Target:      target,
+		LimitNOFILE: SystemdLimitNOFILE,
+	}
+	for _, dep := range systemdDependencies[app] {
+		unit := unitName(dep)
+		script.After = append(script.After, unit)
+		if dep == constants.PastelD && registered(dep) {
+			script.Requires = append(script.Requires, unit)
+		} else {
+			script.Wants = append(script.Wants, unit)
+		}
+	}
+	return script
+}
+
+// NewSystemdTargetScript returns pastel.target file, which starts and stops the registered services together
+func NewSystemdTargetScript(unitName func(constants.ToolType) string, registered func(constants.ToolType) bool) *SystemdTargetScript {
+	script := &SystemdTargetScript{Desc: "Pastel services"}
+	for _, app := range SystemdTools {
+		if registered(app) {
+			script.Wants = append(script.Wants, unitName(app))
+		}
+	}
+	return script
+}
+
+// SystemdCommandLine returns ExecStart value, which runs the command with the args. Arguments with spaces, quotes or
+// backslashes are double-quoted, "%" specifiers and "$" variables are escaped, so every argument is passed as is
+func SystemdCommandLine(args []string) string {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		arg = strings.NewReplacer("%", "%%", "$", "$$").Replace(arg)
+		if arg == "" || strings.ContainsAny(arg, " \t\"'\\;") {
+			arg = `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(arg) + `"`
+		}
+		quoted[i] = arg
+	}
+	return strings.Join(quoted, " ")
+}
+
+// SplitSystemdCommandLine returns the args of ExecStart value written by SystemdCommandLine
+func SplitSystemdCommandLine(line string) []string {
+	var args []string
+	var arg strings.Builder
+	inArg, inQuotes := false, false
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case c == '\\' && inQuotes && i+1 < len(line):
+			i++
+			arg.WriteByte(line[i])
+		case c == '"':
+			inQuotes = !inQuotes
+			inArg = true
+		case (c == '%' || c == '$') && i+1 < len(line) && line[i+1] == c:
+			i++
+			arg.WriteByte(c)
+			inArg = true
+		case (c == ' ' || c == '\t') && !inQuotes:
+			if inArg {
+				args = append(args, arg.String())
+				arg.Reset()
+				inArg = false
+			}
+		default:
+			arg.WriteByte(c)
+			inArg = true
+		}
+	}
+	if inArg {
+		args = append(args, arg.String())
+	}
+	return args
+}
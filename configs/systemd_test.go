@@ -0,0 +1,64 @@
+package configs
+
+import (
+	"testing"
+
+	"github.com/pastelnetwork/pastelup/constants"
+	"github.com/pastelnetwork/pastelup/utils"
+	"github.com/tj/assert"
+)
+
+func TestSystemdUnitBackoff(t *testing.T) {
+	unitName := func(app constants.ToolType) string { return string(app) + ".service" }
+	script := NewSystemdServiceScript(constants.SuperNode, "pastel.target", unitName,
+		func(constants.ToolType) bool { return false })
+	// pasteld is not required if it is not registered, it may be started without systemd
+	assert.Empty(t, script.Requires)
+	assert.Equal(t, []string{"pasteld.service", "rq-service.service", "dd-service.service"}, script.Wants)
+
+	script.Backoff = true
+	unit, err := utils.GetServiceConfig("supernode", SystemdService, script)
+	assert.NoError(t, err)
+	assert.Contains(t, unit, "RestartSteps=5\nRestartMaxDelaySec=300\n")
+}
+
+func TestSystemdCommandLine(t *testing.T) {
+	args := []string{
+		"/Users/pastel/Library/Application Support/Pastel/pasteld",
+		"--datadir=/Users/pastel/Library/Application Support/Pastel",
+		`--quote="\\"`,
+		"--percent=100%",
+		"--var=$HOME",
+		"",
+		"--txindex=1",
+	}
+	line := SystemdCommandLine(args)
+	assert.Equal(t, `"/Users/pastel/Library/Application Support/Pastel/pasteld" `+
+		`"--datadir=/Users/pastel/Library/Application Support/Pastel" "--quote=\"\\\\\"" `+
+		`--percent=100%% --var=$$HOME "" --txindex=1`, line)
+	assert.Equal(t, args, SplitSystemdCommandLine(line))
+}
+
+func TestStopOrder(t *testing.T) {
+	services := []constants.ToolType{
+		constants.SuperNode,
+		constants.RQService,
+		constants.DDImgService,
+		constants.DDService,
+		constants.PastelD,
+		constants.Hermes,
+	}
+	assert.Equal(t, []constants.ToolType{
+		constants.Hermes,
+		constants.SuperNode,
+		constants.DDImgService,
+		constants.DDService,
+		constants.RQService,
+		constants.PastelD,
+	}, StopOrder(services))
+	// the caller's slice is not reordered
+	assert.Equal(t, constants.SuperNode, services[0])
+
+	assert.Equal(t, []constants.ToolType{constants.Pastelup, constants.WalletNode, constants.PastelD},
+		StopOrder([]constants.ToolType{constants.PastelD, constants.WalletNode, constants.Pastelup}))
+}
@@ -21,6 +21,12 @@ const (
 	// PastelConfName - pastel config file name
 	PastelConfName string = "pastel.conf"
 
+	// PastelRPCCookieName - file with RPC credentials written by pasteld at start, when rpcuser is not set in pastel.conf
+	PastelRPCCookieName string = ".cookie"
+
+	// PasteldLockFileName - file in the data dir, which pasteld keeps locked while it runs
+	PasteldLockFileName string = ".lock"
+
 	// PastelUtilityConfigFilePath - The path of the config of pastelup
 	PastelUtilityConfigFilePath string = "./pastelup.conf"
 
@@ -92,8 +98,12 @@ const (
 
 	// SystemdServicePrefix prefix of all pastel services
 	SystemdServicePrefix = "pastel-"
+	// SystemdTargetName name of the systemd target grouping all pastel services
+	SystemdTargetName = "pastel"
 	// SystemdSystemDir location of systemd folder in Linux system
 	SystemdSystemDir = "/etc/systemd/system"
+	// SystemdRuntimeDir exists only if the system is booted with systemd
+	SystemdRuntimeDir = "/run/systemd/system"
 
 	// RQServiceDir defines location for rq-service file exchange dir
 	RQServiceDir = "rqfiles"
@@ -126,6 +136,9 @@ const (
 	// RQServiceDefaultPort defines rqservice port
 	RQServiceDefaultPort = 50051
 
+	// WalletNodeDefaultAPIPort defines walletnode API port
+	WalletNodeDefaultAPIPort = 8080
+
 	// BridgeServiceDefaultPort defines bridge service port
 	BridgeServiceDefaultPort = 60061
 
@@ -141,6 +154,12 @@ const (
 	// TempDir defines temporary directory
 	TempDir = "tmp"
 
+	// PastelupStateDir defines directory in the working dir where pastelup keeps its own state
+	PastelupStateDir = ".pastelup"
+
+	// ConfigTemplatesDir defines directory in the PastelupStateDir with default configs the component configs were created from
+	ConfigTemplatesDir = "templates"
+
 	// RemotePastelupPath - Remote pastelup path
 	RemotePastelupPath = "/tmp/pastelup"
 )
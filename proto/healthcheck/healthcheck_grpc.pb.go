@@ -18,8 +18,11 @@ const _ = grpc.SupportPackageIsVersion7
 //
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
 type HealthCheckClient interface {
-	// ConnectTo requests to connect to the primary supernode.
+	// Ping checks that the supernode answers.
 	Ping(ctx context.Context, in *PingRequest, opts ...grpc.CallOption) (*PingReply, error)
+	// Status reports the supernode and its dependencies like pasteld, p2p, rq-service and dd-service.
+	// Older supernodes don't implement it and answer with UNIMPLEMENTED.
+	Status(ctx context.Context, in *StatusRequest, opts ...grpc.CallOption) (*StatusReply, error)
 }
 
 type healthCheckClient struct {
@@ -39,12 +42,24 @@ func (c *healthCheckClient) Ping(ctx context.Context, in *PingRequest, opts ...g
 	return out, nil
 }
 
+func (c *healthCheckClient) Status(ctx context.Context, in *StatusRequest, opts ...grpc.CallOption) (*StatusReply, error) {
+	out := new(StatusReply)
+	err := c.cc.Invoke(ctx, "/healthcheck.HealthCheck/Status", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // HealthCheckServer is the server API for HealthCheck service.
 // All implementations must embed UnimplementedHealthCheckServer
 // for forward compatibility
 type HealthCheckServer interface {
-	// ConnectTo requests to connect to the primary supernode.
+	// Ping checks that the supernode answers.
 	Ping(context.Context, *PingRequest) (*PingReply, error)
+	// Status reports the supernode and its dependencies like pasteld, p2p, rq-service and dd-service.
+	// Older supernodes don't implement it and answer with UNIMPLEMENTED.
+	Status(context.Context, *StatusRequest) (*StatusReply, error)
 	mustEmbedUnimplementedHealthCheckServer()
 }
 
@@ -55,6 +70,9 @@ type UnimplementedHealthCheckServer struct {
 func (UnimplementedHealthCheckServer) Ping(context.Context, *PingRequest) (*PingReply, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Ping not implemented")
 }
+func (UnimplementedHealthCheckServer) Status(context.Context, *StatusRequest) (*StatusReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Status not implemented")
+}
 func (UnimplementedHealthCheckServer) mustEmbedUnimplementedHealthCheckServer() {}
 
 // UnsafeHealthCheckServer may be embedded to opt out of forward compatibility for this service.
@@ -86,6 +104,24 @@ func _HealthCheck_Ping_Handler(srv interface{}, ctx context.Context, dec func(in
 	return interceptor(ctx, in, info, handler)
 }
 
+func _HealthCheck_Status_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(StatusRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(HealthCheckServer).Status(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/healthcheck.HealthCheck/Status",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(HealthCheckServer).Status(ctx, req.(*StatusRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // HealthCheck_ServiceDesc is the grpc.ServiceDesc for HealthCheck service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -97,6 +133,10 @@ var HealthCheck_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "Ping",
 			Handler:    _HealthCheck_Ping_Handler,
 		},
+		{
+			MethodName: "Status",
+			Handler:    _HealthCheck_Status_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "healthcheck/healthcheck.proto",
@@ -0,0 +1,287 @@
+package alert
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// Status of the alert
+type Status string
+
+const (
+	// StatusFiring - the rule check fails
+	StatusFiring Status = "firing"
+	// StatusResolved - the rule check, which has failed, passes again
+	StatusResolved Status = "resolved"
+)
+
+// Alert is sent to the receivers when the rule check starts failing and when it recovers
+type Alert struct {
+	Rule    string `json:"rule"`
+	Subject string `json:"subject"`
+	Status  Status `json:"status"`
+	Message string `json:"message"`
+	Node    string `json:"node"`
+	// Since is when the check started failing
+	Since time.Time `json:"since"`
+	Time  time.Time `json:"time"`
+}
+
+// Key identifies the alert, only one alert with the key is firing at a time
+func (a Alert) Key() string {
+	return a.Rule + "/" + a.Subject
+}
+
+// Options configure the watcher
+type Options struct {
+	// Node is the name of the node in the alerts
+	Node string
+	// Repeat is how often the firing alert is sent again, it is sent once if 0
+	Repeat time.Duration
+	// StatePath is the file, where firing alerts are kept, so they aren't sent again after restart
+	StatePath string
+	// RuleStatePath is the file, where the state of StatefulRules is kept, so the rules compare with the previous
+	// run, e.g. of 'pastelup watch --once' from cron
+	RuleStatePath string
+}
+
+// firing is the firing alert and when it was sent last time. Alert is the resolved one, if some receivers failed
+// to get the recovery
+type firing struct {
+	Alert Alert     `json:"alert"`
+	Sent  time.Time `json:"sent"`
+	// Pending are the receivers, which failed to get the alert, it is sent again to them by the next evaluation
+	Pending []string `json:"pending,omitempty"`
+}
+
+// Watcher evaluates the rules against the observed state of the node and notifies the receivers
+// about the changes of the checks
+type Watcher struct {
+	rules     []Rule
+	notifiers []Notifier
+	// receivers are the names of the notifiers in the state file, the same notifiers are numbered, e.g. "webhook#2"
+	receivers []string
+	opts      Options
+	firing    map[string]firing
+}
+
+// New returns the watcher, firing alerts are loaded from the state file
+func New(rules []Rule, notifiers []Notifier, opts Options) (*Watcher, error) {
+	w := &Watcher{rules: rules, notifiers: notifiers, opts: opts, firing: make(map[string]firing)}
+	seen := make(map[string]int)
+	for _, notifier := range notifiers {
+		name := notifier.String()
+		if seen[name]++; seen[name] > 1 {
+			name = fmt.Sprintf("%s#%d", name, seen[name])
+		}
+		w.receivers = append(w.receivers, name)
+	}
+	if err := readState(opts.StatePath, &w.firing); err != nil {
+		return nil, err
+	}
+
+	ruleStates := make(map[string]json.RawMessage)
+	if err := readState(opts.RuleStatePath, &ruleStates); err != nil {
+		return nil, err
+	}
+	for _, rule := range rules {
+		stateful, ok := rule.(StatefulRule)
+		if !ok || ruleStates[rule.Name()] == nil {
+			continue
+		}
+		if err := stateful.UnmarshalState(ruleStates[rule.Name()]); err != nil {
+			return nil, fmt.Errorf("invalid state of rule %s in %s: %v", rule.Name(), opts.RuleStatePath, err)
+		}
+	}
+	return w, nil
+}
+
+// readState reads the JSON state file into v, missing file leaves v as it is
+func readState(path string, v interface{}) error {
+	if path == "" {
+		return nil
+	}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	if err = json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("invalid alert state %s: %v", path, err)
+	}
+	return nil
+}
+
+// Firing returns the firing alerts ordered by key
+func (w *Watcher) Firing() []Alert {
+	var alerts []Alert
+	for _, f := range w.firing {
+		if f.Alert.Status == StatusFiring {
+			alerts = append(alerts, f.Alert)
+		}
+	}
+	sort.Slice(alerts, func(i, j int) bool { return alerts[i].Key() < alerts[j].Key() })
+	return alerts
+}
+
+// Evaluate checks the state and sends the alerts, which have started firing, are resolved or are due to be repeated.
+// It returns the sent alerts, the error reports the receivers, which failed. The alert is sent again by the next
+// evaluation only to the receivers, which failed to get it
+func (w *Watcher) Evaluate(ctx context.Context, state State) ([]Alert, error) {
+	now := state.Time
+	if now.IsZero() {
+		now = time.Now()
+	}
+	var sent []Alert
+	var errs []error
+	for _, rule := range w.rules {
+		for _, check := range rule.Evaluate(state) {
+			alert := Alert{
+				Rule:    rule.Name(),
+				Subject: check.Subject,
+				Message: check.Message,
+				Node:    w.opts.Node,
+				Since:   now,
+				Time:    now,
+			}
+			prev, isFiring := w.firing[alert.Key()]
+			if isFiring {
+				alert.Since = prev.Alert.Since
+			}
+			resolving := isFiring && prev.Alert.Status == StatusResolved
+
+			receivers := w.receivers
+			switch {
+			case check.Failing && !isFiring:
+				alert.Status = StatusFiring
+			case check.Failing && resolving:
+				// fails again before all receivers got the recovery, the ones, which didn't, still see it firing
+				alert.Status = StatusFiring
+				receivers = without(w.receivers, prev.Pending)
+			case check.Failing && w.opts.Repeat > 0 && now.Sub(prev.Sent) >= w.opts.Repeat:
+				alert.Status = StatusFiring
+			case check.Failing && len(prev.Pending) > 0:
+				alert.Status = StatusFiring
+				receivers = prev.Pending
+			case check.Failing:
+				// already sent, the message is kept up to date for the state file
+				prev.Alert.Message = check.Message
+				w.firing[alert.Key()] = prev
+				continue
+			case resolving:
+				alert = prev.Alert
+				alert.Time = now
+				receivers = prev.Pending
+			case isFiring:
+				alert.Status = StatusResolved
+				// the recovery is sent to the receivers, which got the alert
+				if receivers = without(w.receivers, prev.Pending); len(receivers) == 0 {
+					delete(w.firing, alert.Key())
+					continue
+				}
+			default:
+				continue
+			}
+
+			sent = append(sent, alert)
+			failed, err := w.notify(ctx, alert, receivers)
+			if err != nil {
+				errs = append(errs, err)
+			}
+			switch {
+			case len(failed) > 0:
+				// the alert is sent again to the failed receivers by the next evaluation
+				next := firing{Alert: alert, Sent: prev.Sent, Pending: failed}
+				if alert.Status == StatusFiring && len(failed) < len(receivers) {
+					next.Sent = now
+				}
+				w.firing[alert.Key()] = next
+			case alert.Status == StatusFiring:
+				w.firing[alert.Key()] = firing{Alert: alert, Sent: now}
+			default:
+				delete(w.firing, alert.Key())
+			}
+		}
+	}
+	if err := w.save(); err != nil {
+		errs = append(errs, err)
+	}
+	return sent, errors.Join(errs...)
+}
+
+// notify sends the alert to the receivers and returns the ones, which failed to get it
+func (w *Watcher) notify(ctx context.Context, alert Alert, receivers []string) ([]string, error) {
+	var failed []string
+	var errs []error
+	for i, notifier := range w.notifiers {
+		if !contains(receivers, w.receivers[i]) {
+			continue
+		}
+		if err := notifier.Notify(ctx, alert); err != nil {
+			failed = append(failed, w.receivers[i])
+			errs = append(errs, fmt.Errorf("%s: %v", w.receivers[i], err))
+		}
+	}
+	return failed, errors.Join(errs...)
+}
+
+// without returns the receivers, which aren't excluded
+func without(receivers []string, excluded []string) []string {
+	var result []string
+	for _, receiver := range receivers {
+		if !contains(excluded, receiver) {
+			result = append(result, receiver)
+		}
+	}
+	return result
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func (w *Watcher) save() error {
+	if err := writeState(w.opts.StatePath, w.firing); err != nil {
+		return err
+	}
+	if w.opts.RuleStatePath == "" {
+		return nil
+	}
+	ruleStates := make(map[string]json.RawMessage)
+	for _, rule := range w.rules {
+		if stateful, ok := rule.(StatefulRule); ok {
+			data, err := stateful.MarshalState()
+			if err != nil {
+				return fmt.Errorf("failed to save state of rule %s: %v", rule.Name(), err)
+			}
+			ruleStates[rule.Name()] = data
+		}
+	}
+	return writeState(w.opts.RuleStatePath, ruleStates)
+}
+
+func writeState(path string, v interface{}) error {
+	if path == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
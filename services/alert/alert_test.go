@@ -0,0 +1,368 @@
+package alert
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/tj/assert"
+)
+
+// receiver is the local webhook, which records the posted payloads
+type receiver struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	paths    []string
+	payloads []map[string]interface{}
+	status   int
+}
+
+func newReceiver(t *testing.T) *receiver {
+	r := &receiver{status: http.StatusOK}
+	r.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		var payload map[string]interface{}
+		if err := json.NewDecoder(req.Body).Decode(&payload); err != nil || req.Header.Get("Content-Type") != "application/json" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.paths = append(r.paths, req.URL.Path)
+		r.payloads = append(r.payloads, payload)
+		w.WriteHeader(r.status)
+	}))
+	t.Cleanup(r.Close)
+	return r
+}
+
+func (r *receiver) received() []map[string]interface{} {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	payloads := r.payloads
+	r.payloads = nil
+	return payloads
+}
+
+func TestWatcherDeduplicatesAndResolves(t *testing.T) {
+	r := newReceiver(t)
+	w, err := New([]Rule{ComponentDown{}}, []Notifier{NewWebhook(r.URL)}, Options{Node: "sn1"})
+	assert.NoError(t, err)
+	ctx := context.Background()
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	state := State{Time: start, Components: map[string]bool{"pasteld": true, "supernode": false}}
+	sent, err := w.Evaluate(ctx, state)
+	assert.NoError(t, err)
+	assert.Len(t, sent, 1)
+	payloads := r.received()
+	assert.Len(t, payloads, 1)
+	assert.Equal(t, "component-down", payloads[0]["rule"])
+	assert.Equal(t, "supernode", payloads[0]["subject"])
+	assert.Equal(t, "firing", payloads[0]["status"])
+	assert.Equal(t, "sn1", payloads[0]["node"])
+
+	// still down - nothing is sent again
+	state.Time = start.Add(time.Minute)
+	sent, err = w.Evaluate(ctx, state)
+	assert.NoError(t, err)
+	assert.Empty(t, sent)
+	assert.Empty(t, r.received())
+	assert.Len(t, w.Firing(), 1)
+
+	state.Time = start.Add(5 * time.Minute)
+	state.Components["supernode"] = true
+	sent, err = w.Evaluate(ctx, state)
+	assert.NoError(t, err)
+	assert.Len(t, sent, 1)
+	assert.Equal(t, StatusResolved, sent[0].Status)
+	assert.Equal(t, start, sent[0].Since)
+	payloads = r.received()
+	assert.Len(t, payloads, 1)
+	assert.Equal(t, "resolved", payloads[0]["status"])
+	assert.Empty(t, w.Firing())
+}
+
+func TestWatcherRepeat(t *testing.T) {
+	r := newReceiver(t)
+	w, err := New([]Rule{LowBalance{Min: 100}}, []Notifier{NewWebhook(r.URL)}, Options{Repeat: time.Hour})
+	assert.NoError(t, err)
+	start := time.Now()
+	balance := 10.0
+	for _, minutes := range []int{0, 30, 59, 60, 90, 120} {
+		_, err = w.Evaluate(context.Background(), State{Time: start.Add(time.Duration(minutes) * time.Minute), Balance: &balance})
+		assert.NoError(t, err)
+	}
+	assert.Len(t, r.received(), 3)
+}
+
+func TestWatcherState(t *testing.T) {
+	r := newReceiver(t)
+	path := filepath.Join(t.TempDir(), "alerts.json")
+	state := State{Time: time.Now(), DiskUsage: map[string]float64{"/var/lib/pastel": 95}}
+
+	w, err := New([]Rule{DiskUsage{MaxPercent: 90}}, []Notifier{NewWebhook(r.URL)}, Options{StatePath: path})
+	assert.NoError(t, err)
+	_, err = w.Evaluate(context.Background(), state)
+	assert.NoError(t, err)
+	assert.Len(t, r.received(), 1)
+
+	// restarted watcher knows the alert has been sent
+	w, err = New([]Rule{DiskUsage{MaxPercent: 90}}, []Notifier{NewWebhook(r.URL)}, Options{StatePath: path})
+	assert.NoError(t, err)
+	assert.Len(t, w.Firing(), 1)
+	_, err = w.Evaluate(context.Background(), state)
+	assert.NoError(t, err)
+	assert.Empty(t, r.received())
+
+	state.DiskUsage["/var/lib/pastel"] = 50
+	sent, err := w.Evaluate(context.Background(), state)
+	assert.NoError(t, err)
+	assert.Len(t, sent, 1)
+	assert.Equal(t, StatusResolved, sent[0].Status)
+}
+
+func TestWatcherReceiverError(t *testing.T) {
+	failing := newReceiver(t)
+	failing.status = http.StatusInternalServerError
+	ok := newReceiver(t)
+	path := filepath.Join(t.TempDir(), "alerts.json")
+	w, err := New([]Rule{ComponentDown{}}, []Notifier{NewWebhook(failing.URL), NewSlack(ok.URL)}, Options{StatePath: path})
+	assert.NoError(t, err)
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	state := State{Time: start, Components: map[string]bool{"pasteld": false}}
+
+	_, err = w.Evaluate(context.Background(), state)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "webhook: receiver returned 500")
+	assert.Len(t, ok.received(), 1)
+	assert.Len(t, failing.received(), 1)
+	assert.Len(t, w.Firing(), 1)
+
+	// the failed alert is pending after restart and is sent again only to the receiver, which didn't get it
+	w, err = New([]Rule{ComponentDown{}}, []Notifier{NewWebhook(failing.URL), NewSlack(ok.URL)}, Options{StatePath: path})
+	assert.NoError(t, err)
+	state.Time = start.Add(time.Minute)
+	_, err = w.Evaluate(context.Background(), state)
+	assert.Error(t, err)
+	assert.Len(t, failing.received(), 1)
+	assert.Empty(t, ok.received())
+
+	failing.status = http.StatusOK
+	state.Time = start.Add(2 * time.Minute)
+	sent, err := w.Evaluate(context.Background(), state)
+	assert.NoError(t, err)
+	assert.Len(t, sent, 1)
+	assert.Equal(t, start, sent[0].Since)
+	assert.Len(t, failing.received(), 1)
+	assert.Empty(t, ok.received())
+
+	// and isn't sent again once it is delivered
+	state.Time = start.Add(3 * time.Minute)
+	sent, err = w.Evaluate(context.Background(), state)
+	assert.NoError(t, err)
+	assert.Empty(t, sent)
+
+	// the failed recovery is sent again to the failed receiver too
+	failing.status = http.StatusInternalServerError
+	state.Components["pasteld"] = true
+	_, err = w.Evaluate(context.Background(), state)
+	assert.Error(t, err)
+	assert.Len(t, failing.received(), 1)
+	assert.Len(t, ok.received(), 1)
+	assert.Empty(t, w.Firing())
+	failing.status = http.StatusOK
+	sent, err = w.Evaluate(context.Background(), state)
+	assert.NoError(t, err)
+	assert.Len(t, sent, 1)
+	assert.Equal(t, StatusResolved, sent[0].Status)
+	assert.Len(t, failing.received(), 1)
+	assert.Empty(t, ok.received())
+	assert.Empty(t, w.Firing())
+
+	// nothing is pending once all receivers got the recovery
+	sent, err = w.Evaluate(context.Background(), state)
+	assert.NoError(t, err)
+	assert.Empty(t, sent)
+}
+
+func TestWatcherFailsAgainWhileResolving(t *testing.T) {
+	failing := newReceiver(t)
+	ok := newReceiver(t)
+	// the same notifiers are told apart by their number
+	w, err := New([]Rule{ComponentDown{}}, []Notifier{NewWebhook(ok.URL), NewWebhook(failing.URL)}, Options{})
+	assert.NoError(t, err)
+	down := State{Components: map[string]bool{"pasteld": false}}
+	up := State{Components: map[string]bool{"pasteld": true}}
+
+	_, err = w.Evaluate(context.Background(), down)
+	assert.NoError(t, err)
+	failing.status = http.StatusInternalServerError
+	_, err = w.Evaluate(context.Background(), up)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "webhook#2: receiver returned 500")
+	assert.Len(t, ok.received(), 2)
+	assert.Len(t, failing.received(), 2)
+
+	// the receiver, which didn't get the recovery, still sees the alert firing, only the other one gets it again
+	failing.status = http.StatusOK
+	sent, err := w.Evaluate(context.Background(), down)
+	assert.NoError(t, err)
+	assert.Len(t, sent, 1)
+	assert.Equal(t, StatusFiring, sent[0].Status)
+	assert.Len(t, ok.received(), 1)
+	assert.Empty(t, failing.received())
+	assert.Len(t, w.Firing(), 1)
+}
+
+func TestWatcherPendingNeverSent(t *testing.T) {
+	failing := newReceiver(t)
+	failing.status = http.StatusInternalServerError
+	w, err := New([]Rule{ComponentDown{}}, []Notifier{NewWebhook(failing.URL)}, Options{})
+	assert.NoError(t, err)
+
+	_, err = w.Evaluate(context.Background(), State{Components: map[string]bool{"pasteld": false}})
+	assert.Error(t, err)
+	assert.Len(t, failing.received(), 1)
+
+	// the receiver has never got the alert, so its recovery isn't sent
+	sent, err := w.Evaluate(context.Background(), State{Components: map[string]bool{"pasteld": true}})
+	assert.NoError(t, err)
+	assert.Empty(t, sent)
+	assert.Empty(t, failing.received())
+	assert.Empty(t, w.Firing())
+}
+
+func TestWatcherRuleState(t *testing.T) {
+	r := newReceiver(t)
+	dir := t.TempDir()
+	opts := Options{StatePath: filepath.Join(dir, "alerts.json"), RuleStatePath: filepath.Join(dir, "alert-rules.json")}
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	// every evaluation is a new watcher, as 'watch --once' run from cron
+	evaluate := func(minutes, height int, mnStatus string) []Alert {
+		w, err := New([]Rule{&HeightStall{For: 30 * time.Minute}, &MasternodeStatus{}}, []Notifier{NewWebhook(r.URL)}, opts)
+		assert.NoError(t, err)
+		sent, err := w.Evaluate(context.Background(), State{Time: start.Add(time.Duration(minutes) * time.Minute),
+			Height: height, MasternodeStatus: mnStatus})
+		assert.NoError(t, err)
+		return sent
+	}
+
+	assert.Empty(t, evaluate(0, 100, MasternodeEnabled))
+	assert.Empty(t, evaluate(10, 100, MasternodeEnabled))
+	sent := evaluate(40, 100, "NEW_START_REQUIRED")
+	assert.Len(t, sent, 2)
+	assert.Equal(t, RuleHeightStall, sent[0].Rule)
+	assert.Equal(t, "block height is stuck at 100 for 40m0s", sent[0].Message)
+	assert.Equal(t, RuleMasternodeStatus, sent[1].Rule)
+
+	sent = evaluate(41, 101, MasternodeEnabled)
+	assert.Len(t, sent, 2)
+	assert.Equal(t, StatusResolved, sent[0].Status)
+	assert.Equal(t, StatusResolved, sent[1].Status)
+}
+
+func TestFormatters(t *testing.T) {
+	r := newReceiver(t)
+	since := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	alert := Alert{Rule: RuleComponentDown, Subject: "supernode", Status: StatusResolved, Node: "sn1",
+		Message: "supernode is running", Since: since, Time: since.Add(90 * time.Second)}
+	text := "[RESOLVED] sn1: supernode is running (failed for 1m30s)"
+	assert.Equal(t, text, Text(alert))
+
+	assert.NoError(t, NewSlack(r.URL).Notify(context.Background(), alert))
+	assert.NoError(t, NewTelegram(r.URL+"/", "123:secret", "-100").Notify(context.Background(), alert))
+	payloads := r.received()
+	assert.Equal(t, []map[string]interface{}{
+		{"text": text},
+		{"chat_id": "-100", "text": text},
+	}, payloads)
+	assert.Equal(t, "/bot123:secret/sendMessage", r.paths[1])
+
+	msg := string(EmailMessage("alerts@example.com", []string{"ops@example.com", "dev@example.com"}, alert))
+	assert.True(t, strings.HasPrefix(msg, "From: alerts@example.com\r\nTo: ops@example.com, dev@example.com\r\nSubject: "+text+"\r\n"))
+	assert.Contains(t, msg, "Alert: component-down/supernode\r\n")
+}
+
+func TestHeightStall(t *testing.T) {
+	rule := &HeightStall{For: 30 * time.Minute}
+	start := time.Now()
+	evaluate := func(minutes, height int) []Check {
+		return rule.Evaluate(State{Time: start.Add(time.Duration(minutes) * time.Minute), Height: height})
+	}
+	assert.Empty(t, evaluate(0, 100))
+	assert.Empty(t, evaluate(29, 100))
+	// pasteld doesn't answer
+	assert.Empty(t, evaluate(31, 0))
+	checks := evaluate(31, 100)
+	assert.Len(t, checks, 1)
+	assert.True(t, checks[0].Failing)
+	assert.Equal(t, "block height is stuck at 100 for 31m0s", checks[0].Message)
+	checks = evaluate(32, 101)
+	assert.Len(t, checks, 1)
+	assert.False(t, checks[0].Failing)
+}
+
+func TestMasternodeStatus(t *testing.T) {
+	rule := &MasternodeStatus{}
+	// not activated yet
+	assert.Empty(t, rule.Evaluate(State{MasternodeStatus: "PRE_ENABLED"}))
+	assert.Empty(t, rule.Evaluate(State{}))
+	checks := rule.Evaluate(State{MasternodeStatus: MasternodeEnabled})
+	assert.Len(t, checks, 1)
+	assert.False(t, checks[0].Failing)
+	checks = rule.Evaluate(State{MasternodeStatus: "NEW_START_REQUIRED"})
+	assert.Len(t, checks, 1)
+	assert.True(t, checks[0].Failing)
+}
+
+func TestPastelIDRule(t *testing.T) {
+	checks := PastelID{}.Evaluate(State{PastelIDs: map[string]string{"jXb": "", "jXa": "key is missing"}})
+	assert.Equal(t, []Check{
+		{Subject: "jXa", Failing: true, Message: "PastelID jXa: key is missing"},
+		{Subject: "jXb", Message: "PastelID jXb is fine"},
+	}, checks)
+}
+
+func TestCertificateExpiry(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	checks := CertificateExpiry{Within: 14 * 24 * time.Hour}.Evaluate(State{Time: now, Certificates: map[string]Certificate{
+		"a.crt":    {NotAfter: now.Add(90 * 24 * time.Hour)},
+		"b:443":    {NotAfter: now.Add(48 * time.Hour)},
+		"c.crt":    {NotAfter: now.Add(-time.Hour)},
+		"d.pastel": {Error: "connection refused"},
+	}})
+	assert.Equal(t, []Check{
+		{Subject: "a.crt", Message: "certificate of a.crt is valid until 2026-04-01T00:00:00Z"},
+		{Subject: "b:443", Failing: true, Message: "certificate of b:443 expires in 48h0m0s, on 2026-01-03T00:00:00Z"},
+		{Subject: "c.crt", Failing: true, Message: "certificate of c.crt has expired on 2025-12-31T23:00:00Z"},
+		{Subject: "d.pastel", Failing: true, Message: "certificate of d.pastel: connection refused"},
+	}, checks)
+}
+
+func TestReadCertificate(t *testing.T) {
+	server := httptest.NewTLSServer(http.NotFoundHandler())
+	t.Cleanup(server.Close)
+	leaf := server.Certificate()
+
+	cert := ReadCertificate(strings.TrimPrefix(server.URL, "https://"), time.Second)
+	assert.Empty(t, cert.Error)
+	assert.Equal(t, leaf.NotAfter, cert.NotAfter)
+
+	path := filepath.Join(t.TempDir(), "node.crt")
+	assert.NoError(t, os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: leaf.Raw}), 0644))
+	cert = ReadCertificate(path, time.Second)
+	assert.Empty(t, cert.Error)
+	assert.Equal(t, leaf.NotAfter, cert.NotAfter)
+
+	assert.NoError(t, os.WriteFile(path, []byte("not a certificate"), 0644))
+	assert.Equal(t, "no certificate in "+path, ReadCertificate(path, time.Second).Error)
+}
@@ -0,0 +1,64 @@
+package alert
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"net"
+	"os"
+	"time"
+)
+
+// Certificate is the certificate of the file or TLS endpoint checked by CertificateExpiry
+type Certificate struct {
+	NotAfter time.Time
+	// Error is why the certificate can't be read
+	Error string
+}
+
+// ReadCertificate returns the certificate of the target, which is the path of PEM file or host:port of TLS endpoint.
+// The certificate of the endpoint isn't verified, only its expiry is checked, so self-signed ones are fine
+func ReadCertificate(target string, timeout time.Duration) Certificate {
+	var cert *x509.Certificate
+	var err error
+	if _, statErr := os.Stat(target); statErr == nil {
+		cert, err = readCertificateFile(target)
+	} else {
+		cert, err = dialCertificate(target, timeout)
+	}
+	if err != nil {
+		return Certificate{Error: err.Error()}
+	}
+	return Certificate{NotAfter: cert.NotAfter}
+}
+
+func readCertificateFile(path string) (*x509.Certificate, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		var block *pem.Block
+		if block, data = pem.Decode(data); block == nil {
+			return nil, fmt.Errorf("no certificate in %s", path)
+		}
+		if block.Type == "CERTIFICATE" {
+			return x509.ParseCertificate(block.Bytes)
+		}
+	}
+}
+
+func dialCertificate(addr string, timeout time.Duration) (*x509.Certificate, error) {
+	dialer := &net.Dialer{Timeout: timeout}
+	conn, err := tls.DialWithDialer(dialer, "tcp", addr, &tls.Config{InsecureSkipVerify: true}) // #nosec G402 - only expiry is checked
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	certs := conn.ConnectionState().PeerCertificates
+	if len(certs) == 0 {
+		return nil, fmt.Errorf("%s has no certificate", addr)
+	}
+	return certs[0], nil
+}
@@ -0,0 +1,159 @@
+package alert
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/smtp"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultTelegramAPI is the Telegram Bot API server
+	DefaultTelegramAPI = "https://api.telegram.org"
+
+	webhookTimeout = 30 * time.Second
+	// maxErrorBody is how much of the receiver's error response is reported
+	maxErrorBody = 512
+)
+
+// Notifier sends the alert to the receiver, String names the receiver in errors
+type Notifier interface {
+	Notify(ctx context.Context, alert Alert) error
+	String() string
+}
+
+// Text formats the alert as a one line message for chats and email subject
+func Text(alert Alert) string {
+	text := fmt.Sprintf("[%s] %s: %s", strings.ToUpper(string(alert.Status)), alert.Node, alert.Message)
+	if alert.Status == StatusResolved {
+		text += fmt.Sprintf(" (failed for %v)", alert.Time.Sub(alert.Since).Round(time.Second))
+	}
+	return text
+}
+
+// Webhook posts the alert as JSON to the URL, Payload formats the alert for the receiver
+type Webhook struct {
+	Name    string
+	URL     string
+	Payload func(alert Alert) interface{}
+	Client  *http.Client
+}
+
+// NewWebhook returns the generic webhook, which gets the alert as is
+func NewWebhook(url string) *Webhook {
+	return &Webhook{Name: "webhook", URL: url, Payload: func(alert Alert) interface{} { return alert }}
+}
+
+// NewSlack returns the Slack incoming webhook
+func NewSlack(url string) *Webhook {
+	return &Webhook{Name: "slack", URL: url, Payload: SlackPayload}
+}
+
+// NewTelegram returns the notifier, which sends the alerts to the Telegram chat with the bot. api is the Bot API server,
+// DefaultTelegramAPI if empty
+func NewTelegram(api, token, chatID string) *Webhook {
+	if api == "" {
+		api = DefaultTelegramAPI
+	}
+	return &Webhook{
+		Name:    "telegram",
+		URL:     strings.TrimSuffix(api, "/") + "/bot" + token + "/sendMessage",
+		Payload: func(alert Alert) interface{} { return TelegramPayload(chatID, alert) },
+	}
+}
+
+// SlackPayload formats the alert as Slack message
+func SlackPayload(alert Alert) interface{} {
+	return map[string]string{"text": Text(alert)}
+}
+
+// TelegramPayload formats the alert as sendMessage request of Telegram Bot API
+func TelegramPayload(chatID string, alert Alert) interface{} {
+	return map[string]string{"chat_id": chatID, "text": Text(alert)}
+}
+
+// Notify implements Notifier
+func (w *Webhook) Notify(ctx context.Context, alert Alert) error {
+	body, err := json.Marshal(w.Payload(alert))
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, webhookTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	client := w.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		// the url of telegram has the bot token, the error of the client has the url
+		if urlErr, ok := err.(*url.Error); ok {
+			return urlErr.Err
+		}
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+		return fmt.Errorf("receiver returned %s: %s", resp.Status, strings.TrimSpace(string(data)))
+	}
+	return nil
+}
+
+func (w *Webhook) String() string {
+	return w.Name
+}
+
+// Email sends the alert with SMTP server at Addr, host:port. Username and Password are used for PLAIN auth if set
+type Email struct {
+	Addr     string
+	Username string
+	Password string
+	From     string
+	To       []string
+}
+
+// Notify implements Notifier
+func (e *Email) Notify(_ context.Context, alert Alert) error {
+	var auth smtp.Auth
+	if e.Username != "" {
+		host := e.Addr
+		if i := strings.LastIndex(host, ":"); i >= 0 {
+			host = host[:i]
+		}
+		auth = smtp.PlainAuth("", e.Username, e.Password, host)
+	}
+	return smtp.SendMail(e.Addr, auth, e.From, e.To, EmailMessage(e.From, e.To, alert))
+}
+
+func (e *Email) String() string {
+	return "email"
+}
+
+// EmailMessage formats the alert as email message
+func EmailMessage(from string, to []string, alert Alert) []byte {
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", from)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(to, ", "))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", Text(alert))
+	fmt.Fprintf(&msg, "Date: %s\r\n", alert.Time.Format(time.RFC1123Z))
+	msg.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	fmt.Fprintf(&msg, "Node: %s\r\n", alert.Node)
+	fmt.Fprintf(&msg, "Alert: %s\r\n", alert.Key())
+	fmt.Fprintf(&msg, "Status: %s\r\n", alert.Status)
+	fmt.Fprintf(&msg, "Since: %s\r\n\r\n", alert.Since.Format(time.RFC3339))
+	msg.WriteString(alert.Message + "\r\n")
+	return msg.Bytes()
+}
@@ -0,0 +1,292 @@
+package alert
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Names of the rules
+const (
+	RuleComponentDown    = "component-down"
+	RuleHeightStall      = "height-stall"
+	RuleMasternodeStatus = "masternode-status"
+	RuleLowBalance       = "low-balance"
+	RuleDiskUsage        = "disk-usage"
+	RulePastelID         = "pastelid"
+	RuleCertificate      = "certificate"
+)
+
+// MasternodeEnabled is the status of the masternode, which is in the list and gets paid
+const MasternodeEnabled = "ENABLED"
+
+// State is the state of the node observed by the watcher, unknown values are left empty and aren't checked
+type State struct {
+	Time time.Time
+	// Components maps the components, which must be running, to whether they are running
+	Components map[string]bool
+	// Height is the block height of pasteld, 0 if pasteld doesn't answer
+	Height int
+	// MasternodeStatus is the status of the masternode, empty for the node, which isn't a masternode
+	MasternodeStatus string
+	// Balance is the wallet balance
+	Balance *float64
+	// DiskUsage maps the directories to the percent of used space of their disks
+	DiskUsage map[string]float64
+	// PastelIDs maps PastelIDs used by the components to their issue, empty if the PastelID is fine
+	PastelIDs map[string]string
+	// Certificates maps the certificate files and TLS endpoints to their certificates
+	Certificates map[string]Certificate
+}
+
+// Check is the result of the rule for one subject, e.g. one component
+type Check struct {
+	Subject string
+	Failing bool
+	Message string
+}
+
+// Rule checks the state of the node. The subject, which can't be checked in the state, is omitted, so its alert
+// is neither fired nor resolved
+type Rule interface {
+	Name() string
+	Evaluate(state State) []Check
+}
+
+// StatefulRule compares the state with the previous evaluations. Its state is saved by the watcher, so the rule
+// works when the watcher runs once at a time, e.g. from cron
+type StatefulRule interface {
+	Rule
+	MarshalState() (json.RawMessage, error)
+	UnmarshalState(data json.RawMessage) error
+}
+
+// ComponentDown fires when the component, which must be running, isn't
+type ComponentDown struct{}
+
+// Name implements Rule
+func (ComponentDown) Name() string { return RuleComponentDown }
+
+// Evaluate implements Rule
+func (ComponentDown) Evaluate(state State) []Check {
+	var names []string
+	for name := range state.Components {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var checks []Check
+	for _, name := range names {
+		check := Check{Subject: name, Message: name + " is running"}
+		if !state.Components[name] {
+			check.Failing, check.Message = true, name+" is not running"
+		}
+		checks = append(checks, check)
+	}
+	return checks
+}
+
+// HeightStall fires when the block height of pasteld doesn't grow for the given time, it is resolved by the next block
+type HeightStall struct {
+	For time.Duration
+
+	height  int
+	changed time.Time
+}
+
+// Name implements Rule
+func (r *HeightStall) Name() string { return RuleHeightStall }
+
+// Evaluate implements Rule
+func (r *HeightStall) Evaluate(state State) []Check {
+	if state.Height == 0 {
+		return nil
+	}
+	if state.Height != r.height {
+		first := r.height == 0
+		r.height, r.changed = state.Height, state.Time
+		if first {
+			return nil
+		}
+		return []Check{{Subject: "pasteld", Message: fmt.Sprintf("block height grows again, it is %d", state.Height)}}
+	}
+	if stalled := state.Time.Sub(r.changed); stalled >= r.For {
+		return []Check{{Subject: "pasteld", Failing: true,
+			Message: fmt.Sprintf("block height is stuck at %d for %v", state.Height, stalled.Round(time.Minute))}}
+	}
+	return nil
+}
+
+type heightStallState struct {
+	Height  int       `json:"height"`
+	Changed time.Time `json:"changed"`
+}
+
+// MarshalState implements StatefulRule
+func (r *HeightStall) MarshalState() (json.RawMessage, error) {
+	return json.Marshal(heightStallState{Height: r.height, Changed: r.changed})
+}
+
+// UnmarshalState implements StatefulRule
+func (r *HeightStall) UnmarshalState(data json.RawMessage) error {
+	var state heightStallState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return err
+	}
+	r.height, r.changed = state.Height, state.Changed
+	return nil
+}
+
+// MasternodeStatus fires when the masternode, which has been ENABLED, leaves that status
+type MasternodeStatus struct {
+	enabled bool
+}
+
+// Name implements Rule
+func (r *MasternodeStatus) Name() string { return RuleMasternodeStatus }
+
+// Evaluate implements Rule
+func (r *MasternodeStatus) Evaluate(state State) []Check {
+	switch {
+	case state.MasternodeStatus == "":
+		return nil
+	case state.MasternodeStatus == MasternodeEnabled:
+		r.enabled = true
+		return []Check{{Subject: "masternode", Message: "masternode is " + MasternodeEnabled}}
+	case r.enabled:
+		return []Check{{Subject: "masternode", Failing: true,
+			Message: fmt.Sprintf("masternode status is %s, it was %s", state.MasternodeStatus, MasternodeEnabled)}}
+	}
+	// the masternode isn't activated yet
+	return nil
+}
+
+type masternodeStatusState struct {
+	Enabled bool `json:"enabled"`
+}
+
+// MarshalState implements StatefulRule
+func (r *MasternodeStatus) MarshalState() (json.RawMessage, error) {
+	return json.Marshal(masternodeStatusState{Enabled: r.enabled})
+}
+
+// UnmarshalState implements StatefulRule
+func (r *MasternodeStatus) UnmarshalState(data json.RawMessage) error {
+	var state masternodeStatusState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return err
+	}
+	r.enabled = state.Enabled
+	return nil
+}
+
+// LowBalance fires when the wallet balance is below the minimum
+type LowBalance struct {
+	Min float64
+}
+
+// Name implements Rule
+func (LowBalance) Name() string { return RuleLowBalance }
+
+// Evaluate implements Rule
+func (r LowBalance) Evaluate(state State) []Check {
+	if state.Balance == nil {
+		return nil
+	}
+	check := Check{Subject: "wallet", Message: fmt.Sprintf("wallet balance is %.5f", *state.Balance)}
+	if *state.Balance < r.Min {
+		check.Failing = true
+		check.Message = fmt.Sprintf("wallet balance %.5f is below %.5f", *state.Balance, r.Min)
+	}
+	return []Check{check}
+}
+
+// DiskUsage fires when the used space of the disk exceeds the percent
+type DiskUsage struct {
+	MaxPercent float64
+}
+
+// Name implements Rule
+func (DiskUsage) Name() string { return RuleDiskUsage }
+
+// Evaluate implements Rule
+func (r DiskUsage) Evaluate(state State) []Check {
+	var dirs []string
+	for dir := range state.DiskUsage {
+		dirs = append(dirs, dir)
+	}
+	sort.Strings(dirs)
+	var checks []Check
+	for _, dir := range dirs {
+		used := state.DiskUsage[dir]
+		check := Check{Subject: dir, Message: fmt.Sprintf("disk of %s is %.1f%% used", dir, used)}
+		if used > r.MaxPercent {
+			check.Failing = true
+			check.Message = fmt.Sprintf("disk of %s is %.1f%% used, above %.0f%%", dir, used, r.MaxPercent)
+		}
+		checks = append(checks, check)
+	}
+	return checks
+}
+
+// PastelID fires when the PastelID used by the components has an issue, e.g. its key is missing
+type PastelID struct{}
+
+// Name implements Rule
+func (PastelID) Name() string { return RulePastelID }
+
+// Evaluate implements Rule
+func (PastelID) Evaluate(state State) []Check {
+	var ids []string
+	for id := range state.PastelIDs {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	var checks []Check
+	for _, id := range ids {
+		check := Check{Subject: id, Message: fmt.Sprintf("PastelID %s is fine", id)}
+		if issue := state.PastelIDs[id]; issue != "" {
+			check.Failing = true
+			check.Message = fmt.Sprintf("PastelID %s: %s", id, issue)
+		}
+		checks = append(checks, check)
+	}
+	return checks
+}
+
+// CertificateExpiry fires when the certificate expires within the given time or can't be read
+type CertificateExpiry struct {
+	Within time.Duration
+}
+
+// Name implements Rule
+func (CertificateExpiry) Name() string { return RuleCertificate }
+
+// Evaluate implements Rule
+func (r CertificateExpiry) Evaluate(state State) []Check {
+	var targets []string
+	for target := range state.Certificates {
+		targets = append(targets, target)
+	}
+	sort.Strings(targets)
+	var checks []Check
+	for _, target := range targets {
+		cert := state.Certificates[target]
+		left := cert.NotAfter.Sub(state.Time)
+		check := Check{Subject: target,
+			Message: fmt.Sprintf("certificate of %s is valid until %s", target, cert.NotAfter.Format(time.RFC3339))}
+		switch {
+		case cert.Error != "":
+			check.Failing = true
+			check.Message = fmt.Sprintf("certificate of %s: %s", target, cert.Error)
+		case left <= 0:
+			check.Failing = true
+			check.Message = fmt.Sprintf("certificate of %s has expired on %s", target, cert.NotAfter.Format(time.RFC3339))
+		case left < r.Within:
+			check.Failing = true
+			check.Message = fmt.Sprintf("certificate of %s expires in %v, on %s", target, left.Round(time.Hour), cert.NotAfter.Format(time.RFC3339))
+		}
+		checks = append(checks, check)
+	}
+	return checks
+}
@@ -0,0 +1,119 @@
+package dashboard
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/dustin/go-humanize"
+
+	"github.com/pastelnetwork/pastelup/services/synctracker"
+)
+
+// Snapshot is the state of the node shown by the dashboard, values, which can't be got, are left zero
+type Snapshot struct {
+	Time    time.Time
+	Node    string
+	Network string
+	// PasteldError is set if pasteld doesn't answer, the chain fields are unknown then
+	PasteldError string
+	Sync         synctracker.Sample
+	Peers        int
+	// MNSync is the current phase of "mnsync status", e.g. MASTERNODE_SYNC_LIST
+	MNSync           string
+	MNSynced         bool
+	MasternodeStatus string
+	Components       []Component
+	LogName          string
+	Logs             []string
+}
+
+// Component is the process of the pastel component
+type Component struct {
+	Name       string
+	Running    bool
+	Pid        int
+	CPUPercent float64
+	Resident   uint64
+}
+
+// Render writes the snapshot in width columns, the log lines are cut to fit.
+// rate and eta are the sync estimates, eta is shown only if it is known
+func Render(w io.Writer, snap Snapshot, rate float64, eta time.Duration, etaKnown bool, width int) {
+	fmt.Fprintf(w, "%s  %s  %s\n", snap.Node, snap.Network, snap.Time.Format(time.DateTime))
+	fmt.Fprintln(w, strings.Repeat("─", clamp(width, 10, 120)))
+
+	if snap.PasteldError != "" {
+		fmt.Fprintf(w, "pasteld     doesn't answer: %s\n", snap.PasteldError)
+	} else {
+		target := snap.Sync.Target()
+		percent := 100.0
+		if target > 0 {
+			percent = float64(snap.Sync.Blocks) / float64(target) * 100
+		}
+		fmt.Fprintf(w, "blocks      %d / %d (%.2f%%)  headers %d  peers %d\n", snap.Sync.Blocks, target, percent,
+			snap.Sync.Headers, snap.Peers)
+		fmt.Fprintf(w, "sync        %s\n", syncLine(snap.Sync, rate, eta, etaKnown))
+		mnsync := snap.MNSync
+		if snap.MNSynced {
+			mnsync += " (synced)"
+		}
+		fmt.Fprintf(w, "mnsync      %s\n", orDash(mnsync))
+		if snap.MasternodeStatus != "" {
+			fmt.Fprintf(w, "masternode  %s\n", snap.MasternodeStatus)
+		}
+	}
+	fmt.Fprintln(w)
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "COMPONENT\tSTATE\tPID\tCPU%\tRSS")
+	for _, c := range snap.Components {
+		if !c.Running {
+			fmt.Fprintf(tw, "%s\tstopped\t-\t-\t-\n", c.Name)
+			continue
+		}
+		fmt.Fprintf(tw, "%s\trunning\t%d\t%.1f\t%s\n", c.Name, c.Pid, c.CPUPercent, humanize.IBytes(c.Resident))
+	}
+	_ = tw.Flush()
+
+	if snap.LogName == "" {
+		return
+	}
+	fmt.Fprintf(w, "\n%s log:\n", snap.LogName)
+	for _, line := range snap.Logs {
+		if runes := []rune(line); width > 0 && len(runes) > width {
+			line = string(runes[:width])
+		}
+		fmt.Fprintln(w, line)
+	}
+}
+
+func syncLine(sample synctracker.Sample, rate float64, eta time.Duration, etaKnown bool) string {
+	if sample.Blocks >= sample.Target() {
+		return "synced"
+	}
+	line := fmt.Sprintf("%.1f blocks/s", rate)
+	if etaKnown {
+		return line + ", ETA " + eta.Round(time.Second).String()
+	}
+	return line + ", ETA unknown"
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+func clamp(v, min, max int) int {
+	if v < min {
+		return min
+	}
+	if v > max {
+		return max
+	}
+	return v
+}
@@ -0,0 +1,99 @@
+package dashboard
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tj/assert"
+
+	"github.com/pastelnetwork/pastelup/services/synctracker"
+)
+
+func TestRender(t *testing.T) {
+	snap := Snapshot{
+		Time:             time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC),
+		Node:             "sn1",
+		Network:          "mainnet",
+		Sync:             synctracker.Sample{Blocks: 500, Headers: 900, NetworkHeight: 1000},
+		Peers:            8,
+		MNSync:           "MASTERNODE_SYNC_LIST",
+		MasternodeStatus: "PRE_ENABLED",
+		Components: []Component{
+			{Name: "pasteld", Running: true, Pid: 42, CPUPercent: 12.5, Resident: 512 << 20},
+			{Name: "supernode"},
+		},
+		LogName: "pasteld",
+		Logs:    []string{"UpdateTip: new best=00ab height=500", strings.Repeat("x", 100)},
+	}
+	var buf bytes.Buffer
+	Render(&buf, snap, 2.5, 200*time.Second, true, 80)
+	out := buf.String()
+
+	assert.Contains(t, out, "sn1  mainnet  2024-03-01 12:00:00")
+	assert.Contains(t, out, "blocks      500 / 1000 (50.00%)  headers 900  peers 8")
+	assert.Contains(t, out, "sync        2.5 blocks/s, ETA 3m20s")
+	assert.Contains(t, out, "mnsync      MASTERNODE_SYNC_LIST\n")
+	assert.Contains(t, out, "masternode  PRE_ENABLED")
+	assert.Contains(t, out, "pasteld    running  42   12.5  512 MiB")
+	assert.Contains(t, out, "supernode  stopped  -    -     -")
+	assert.Contains(t, out, "pasteld log:\nUpdateTip: new best=00ab height=500\n"+strings.Repeat("x", 80)+"\n")
+}
+
+func TestRenderSyncedAndDown(t *testing.T) {
+	var buf bytes.Buffer
+	Render(&buf, Snapshot{Sync: synctracker.Sample{Blocks: 1000, Headers: 1000}, MNSync: "MASTERNODE_SYNC_FINISHED", MNSynced: true},
+		0, 0, false, 80)
+	assert.Contains(t, buf.String(), "sync        synced")
+	assert.Contains(t, buf.String(), "mnsync      MASTERNODE_SYNC_FINISHED (synced)")
+
+	buf.Reset()
+	Render(&buf, Snapshot{Sync: synctracker.Sample{Blocks: 10, Headers: 1000}}, 0, 0, false, 80)
+	assert.Contains(t, buf.String(), "sync        0.0 blocks/s, ETA unknown")
+
+	buf.Reset()
+	Render(&buf, Snapshot{PasteldError: "connection refused"}, 0, 0, false, 80)
+	assert.Contains(t, buf.String(), "pasteld     doesn't answer: connection refused")
+	assert.NotContains(t, buf.String(), "blocks")
+}
+
+func TestTail(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "debug.log")
+
+	var lines []string
+	for i := 0; i < 5000; i++ {
+		lines = append(lines, fmt.Sprintf("line %d", i))
+	}
+	assert.Nil(t, os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0644))
+
+	got, err := Tail(path, 3)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"line 4997", "line 4998", "line 4999"}, got)
+
+	// more lines than the file has
+	small := filepath.Join(dir, "small.log")
+	assert.Nil(t, os.WriteFile(small, []byte("first\nsecond"), 0644))
+	got, err = Tail(small, 10)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"first", "second"}, got)
+
+	// more lines than fit in a chunk
+	got, err = Tail(path, 4000)
+	assert.Nil(t, err)
+	assert.Len(t, got, 4000)
+	assert.Equal(t, "line 1000", got[0])
+
+	empty := filepath.Join(dir, "empty.log")
+	assert.Nil(t, os.WriteFile(empty, nil, 0644))
+	got, err = Tail(empty, 10)
+	assert.Nil(t, err)
+	assert.Empty(t, got)
+
+	_, err = Tail(filepath.Join(dir, "missing.log"), 10)
+	assert.NotNil(t, err)
+}
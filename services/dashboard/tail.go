@@ -0,0 +1,55 @@
+package dashboard
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"strings"
+)
+
+const tailChunk = 16 * 1024
+
+// Tail returns up to n last lines of the file, it reads the file from the end, so it is cheap for large logs
+func Tail(path string, n int) ([]string, error) {
+	if n <= 0 {
+		return nil, nil
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	stat, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	var data []byte
+	offset := stat.Size()
+	// n lines need n+1 newlines, unless the file starts within the data
+	for offset > 0 && bytes.Count(data, []byte{'\n'}) <= n {
+		size := int64(tailChunk)
+		if size > offset {
+			size = offset
+		}
+		offset -= size
+		chunk := make([]byte, size)
+		if _, err := file.ReadAt(chunk, offset); err != nil && err != io.EOF {
+			return nil, err
+		}
+		data = append(chunk, data...)
+	}
+
+	lines := strings.Split(strings.TrimRight(string(data), "\n"), "\n")
+	if offset > 0 {
+		// the first line is cut
+		lines = lines[1:]
+	}
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	if len(lines) == 1 && lines[0] == "" {
+		return nil, nil
+	}
+	return lines, nil
+}
@@ -0,0 +1,206 @@
+package fleet
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pastelnetwork/pastelup/services/healthcheck"
+	"github.com/pastelnetwork/pastelup/utils"
+)
+
+const (
+	defaultTimeout     = 5 * time.Second
+	defaultConcurrency = 32
+	// masternodeEnabled is the status of the masternode, which is expected to serve requests
+	masternodeEnabled = "ENABLED"
+)
+
+// Target is the host to probe, zero ports are not probed
+type Target struct {
+	Name string `json:"name"`
+	Host string `json:"host"`
+	// Outpoint and Advertised are the collateral and status of the masternode in the masternode list
+	Outpoint   string `json:"outpoint,omitempty"`
+	Advertised string `json:"advertised,omitempty"`
+
+	NodePort       int `json:"node-port,omitempty"`
+	SuperNodePort  int `json:"sn-port,omitempty"`
+	WalletNodePort int `json:"wn-api-port,omitempty"`
+}
+
+// Probe is the result of the probe of one service
+type Probe struct {
+	Reachable bool          `json:"reachable"`
+	Latency   time.Duration `json:"latency-ns,omitempty"`
+	Detail    string        `json:"detail,omitempty"`
+	Error     string        `json:"error,omitempty"`
+}
+
+// Result of the probes of the target, Mismatches are differences between advertised and actual state
+type Result struct {
+	Target
+	Node       *Probe   `json:"node,omitempty"`
+	SuperNode  *Probe   `json:"supernode,omitempty"`
+	WalletNode *Probe   `json:"walletnode,omitempty"`
+	Mismatches []string `json:"mismatches,omitempty"`
+}
+
+// Options of the scan, zero values are replaced with the defaults
+type Options struct {
+	Timeout     time.Duration
+	Concurrency int
+}
+
+// Scan probes the targets in parallel and returns the results in the order of the targets
+func Scan(ctx context.Context, targets []Target, opts Options) []Result {
+	if opts.Timeout == 0 {
+		opts.Timeout = defaultTimeout
+	}
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = defaultConcurrency
+	}
+
+	results := make([]Result, len(targets))
+	sem := make(chan struct{}, opts.Concurrency)
+	var wg sync.WaitGroup
+	for i, target := range targets {
+		wg.Add(1)
+		go func(i int, target Target) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			results[i] = probeTarget(ctx, target, opts.Timeout)
+		}(i, target)
+	}
+	wg.Wait()
+	return results
+}
+
+// MasternodeTargets returns targets of the masternodes, pasteld is probed on the advertised port and supernode on snPort
+func MasternodeTargets(entries []utils.MasternodeListEntry, snPort int) []Target {
+	var targets []Target
+	for _, entry := range entries {
+		target := Target{
+			Name:          entry.Outpoint,
+			Host:          utils.PeerHost(entry.Address),
+			Outpoint:      entry.Outpoint,
+			Advertised:    entry.Status,
+			SuperNodePort: snPort,
+		}
+		if _, port, err := net.SplitHostPort(entry.Address); err == nil {
+			target.NodePort, _ = strconv.Atoi(port)
+		}
+		targets = append(targets, target)
+	}
+	return targets
+}
+
+// Advertise sets the outpoint and status of the targets, whose hosts are in the masternode list
+func Advertise(targets []Target, entries []utils.MasternodeListEntry) {
+	byHost := make(map[string]utils.MasternodeListEntry)
+	for _, entry := range entries {
+		byHost[utils.PeerHost(entry.Address)] = entry
+	}
+	for i := range targets {
+		if entry, ok := byHost[targets[i].Host]; ok {
+			targets[i].Outpoint = entry.Outpoint
+			targets[i].Advertised = entry.Status
+		}
+	}
+}
+
+// probeTarget probes the services of the target at once
+func probeTarget(ctx context.Context, target Target, timeout time.Duration) Result {
+	result := Result{Target: target}
+	var wg sync.WaitGroup
+	probe := func(dst **Probe, port int, f func(ctx context.Context, addr string, timeout time.Duration) Probe) {
+		if port == 0 {
+			return
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			p := f(ctx, net.JoinHostPort(target.Host, strconv.Itoa(port)), timeout)
+			*dst = &p
+		}()
+	}
+	probe(&result.Node, target.NodePort, probeTCP)
+	probe(&result.SuperNode, target.SuperNodePort, probeSuperNode)
+	probe(&result.WalletNode, target.WalletNodePort, probeHTTP)
+	wg.Wait()
+
+	result.Mismatches = mismatches(result)
+	return result
+}
+
+func probeTCP(ctx context.Context, addr string, timeout time.Duration) Probe {
+	dialer := net.Dialer{Timeout: timeout}
+	started := time.Now()
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return Probe{Error: err.Error()}
+	}
+	_ = conn.Close()
+	return Probe{Reachable: true, Latency: time.Since(started)}
+}
+
+// probeHTTP checks that the API answers, any HTTP response means it is up
+func probeHTTP(ctx context.Context, addr string, timeout time.Duration) Probe {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://"+addr+"/", nil)
+	if err != nil {
+		return Probe{Error: err.Error()}
+	}
+	started := time.Now()
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return Probe{Error: err.Error()}
+	}
+	_ = resp.Body.Close()
+	return Probe{Reachable: true, Latency: time.Since(started), Detail: resp.Status}
+}
+
+// probeSuperNode gets status of the supernode and its dependencies, the dependency problems are reported in Error
+func probeSuperNode(ctx context.Context, addr string, timeout time.Duration) Probe {
+	started := time.Now()
+	report, err := healthcheck.Check(ctx, addr, timeout)
+	if err != nil {
+		return Probe{Error: err.Error()}
+	}
+	probe := Probe{Reachable: true, Latency: time.Since(started)}
+	if report.Legacy {
+		probe.Detail = "ping only"
+	} else {
+		probe.Detail = report.Status.Version
+	}
+	probe.Error = strings.Join(report.Problems(), "; ")
+	return probe
+}
+
+// mismatches compares the advertised status of the masternode with the probes
+func mismatches(result Result) []string {
+	if result.Advertised == "" {
+		return nil
+	}
+	var found []string
+	if result.Advertised == masternodeEnabled {
+		if result.Node != nil && !result.Node.Reachable {
+			found = append(found, fmt.Sprintf("%s, but pasteld port is unreachable", masternodeEnabled))
+		}
+		if result.SuperNode != nil && !result.SuperNode.Reachable {
+			found = append(found, fmt.Sprintf("%s, but supernode doesn't answer", masternodeEnabled))
+		} else if result.SuperNode != nil && result.SuperNode.Error != "" {
+			found = append(found, fmt.Sprintf("%s, but supernode is unhealthy", masternodeEnabled))
+		}
+	} else if result.SuperNode != nil && result.SuperNode.Reachable {
+		found = append(found, fmt.Sprintf("supernode answers, but masternode is %s", result.Advertised))
+	}
+	return found
+}
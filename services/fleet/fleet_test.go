@@ -0,0 +1,139 @@
+package fleet
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/tj/assert"
+	"google.golang.org/grpc"
+
+	pb "github.com/pastelnetwork/pastelup/proto/healthcheck"
+	"github.com/pastelnetwork/pastelup/utils"
+)
+
+type server struct {
+	pb.UnimplementedHealthCheckServer
+	reply *pb.StatusReply
+}
+
+func (s server) Status(context.Context, *pb.StatusRequest) (*pb.StatusReply, error) {
+	return s.reply, nil
+}
+
+func listenPort(t *testing.T, listener net.Listener) int {
+	_, port, err := net.SplitHostPort(listener.Addr().String())
+	assert.Nil(t, err)
+	n, err := strconv.Atoi(port)
+	assert.Nil(t, err)
+	return n
+}
+
+// startNode runs pasteld port, supernode healthcheck and walletnode API on localhost and returns their ports
+func startNode(t *testing.T, reply *pb.StatusReply) (nodePort, snPort, wnPort int) {
+	node, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	t.Cleanup(func() { _ = node.Close() })
+	go func() {
+		for {
+			conn, err := node.Accept()
+			if err != nil {
+				return
+			}
+			_ = conn.Close()
+		}
+	}()
+
+	sn, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	s := grpc.NewServer()
+	pb.RegisterHealthCheckServer(s, server{reply: reply})
+	go func() { _ = s.Serve(sn) }()
+	t.Cleanup(s.Stop)
+
+	wn := httptest.NewServer(http.NotFoundHandler())
+	t.Cleanup(wn.Close)
+	wnPort, err = strconv.Atoi(wn.URL[len("http://127.0.0.1:"):])
+	assert.Nil(t, err)
+
+	return listenPort(t, node), listenPort(t, sn), wnPort
+}
+
+// closedPort returns the port, which nobody listens on
+func closedPort(t *testing.T) int {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	port := listenPort(t, listener)
+	_ = listener.Close()
+	return port
+}
+
+func TestScan(t *testing.T) {
+	nodePort, snPort, wnPort := startNode(t, &pb.StatusReply{Version: "v2.1.0",
+		Dependencies: []*pb.DependencyStatus{{Name: "pasteld", State: pb.DependencyStatus_OK}}})
+
+	targets := []Target{
+		{Name: "up", Host: "127.0.0.1", Advertised: "ENABLED", NodePort: nodePort, SuperNodePort: snPort, WalletNodePort: wnPort},
+		{Name: "down", Host: "127.0.0.1", Advertised: "ENABLED", NodePort: closedPort(t), SuperNodePort: closedPort(t)},
+	}
+	results := Scan(context.Background(), targets, Options{Timeout: 2 * time.Second})
+	assert.Len(t, results, 2)
+
+	up := results[0]
+	assert.Equal(t, "up", up.Name)
+	assert.True(t, up.Node.Reachable)
+	assert.True(t, up.SuperNode.Reachable)
+	assert.Equal(t, "v2.1.0", up.SuperNode.Detail)
+	assert.Empty(t, up.SuperNode.Error)
+	assert.True(t, up.WalletNode.Reachable)
+	assert.Equal(t, "404 Not Found", up.WalletNode.Detail)
+	assert.Empty(t, up.Mismatches)
+
+	down := results[1]
+	assert.False(t, down.Node.Reachable)
+	assert.NotEmpty(t, down.Node.Error)
+	assert.False(t, down.SuperNode.Reachable)
+	assert.Nil(t, down.WalletNode)
+	assert.Len(t, down.Mismatches, 2)
+}
+
+func TestScanMismatches(t *testing.T) {
+	nodePort, snPort, _ := startNode(t, &pb.StatusReply{Version: "v2.1.0",
+		Dependencies: []*pb.DependencyStatus{{Name: "rq-service", State: pb.DependencyStatus_DOWN}}})
+
+	targets := []Target{
+		{Name: "unhealthy", Host: "127.0.0.1", Advertised: "ENABLED", NodePort: nodePort, SuperNodePort: snPort},
+		{Name: "expired", Host: "127.0.0.1", Advertised: "EXPIRED", NodePort: nodePort, SuperNodePort: snPort},
+		{Name: "inventory", Host: "127.0.0.1", NodePort: closedPort(t)},
+	}
+	results := Scan(context.Background(), targets, Options{Timeout: 2 * time.Second, Concurrency: 1})
+
+	assert.True(t, results[0].SuperNode.Reachable)
+	assert.NotEmpty(t, results[0].SuperNode.Error)
+	assert.Equal(t, []string{"ENABLED, but supernode is unhealthy"}, results[0].Mismatches)
+	assert.Equal(t, []string{"supernode answers, but masternode is EXPIRED"}, results[1].Mismatches)
+	// the host, which isn't in the masternode list, has nothing to compare with
+	assert.Empty(t, results[2].Mismatches)
+}
+
+func TestMasternodeTargets(t *testing.T) {
+	entries := []utils.MasternodeListEntry{
+		{Outpoint: "a-0", Status: "ENABLED", Address: "1.2.3.4:9933"},
+		{Outpoint: "b-1", Status: "EXPIRED", Address: "[2001:db8::1]:19933"},
+	}
+	targets := MasternodeTargets(entries, 4444)
+	assert.Equal(t, []Target{
+		{Name: "a-0", Host: "1.2.3.4", Outpoint: "a-0", Advertised: "ENABLED", NodePort: 9933, SuperNodePort: 4444},
+		{Name: "b-1", Host: "2001:db8::1", Outpoint: "b-1", Advertised: "EXPIRED", NodePort: 19933, SuperNodePort: 4444},
+	}, targets)
+
+	inventory := []Target{{Name: "sn1", Host: "1.2.3.4"}, {Name: "sn2", Host: "5.6.7.8"}}
+	Advertise(inventory, entries)
+	assert.Equal(t, "ENABLED", inventory[0].Advertised)
+	assert.Equal(t, "a-0", inventory[0].Outpoint)
+	assert.Empty(t, inventory[1].Advertised)
+}
@@ -0,0 +1,107 @@
+package healthcheck
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/pastelnetwork/pastelup/proto/healthcheck"
+)
+
+const pingMessage = "hello"
+
+// Report is the health of the supernode
+type Report struct {
+	Addr string
+	// Legacy is set for the supernode, which doesn't implement Status, only its Ping reply is known
+	Legacy  bool
+	Reply   string
+	Status  *pb.StatusReply
+	Latency time.Duration
+}
+
+// Problems returns the dependencies, which are not OK, with their last errors
+func (r *Report) Problems() []string {
+	if r.Status == nil {
+		return nil
+	}
+	var problems []string
+	for _, dep := range r.Status.Dependencies {
+		if dep.State == pb.DependencyStatus_OK {
+			continue
+		}
+		problem := fmt.Sprintf("%s is %s", dep.Name, dep.State)
+		if dep.LastError != "" {
+			problem += ": " + dep.LastError
+		}
+		problems = append(problems, problem)
+	}
+	return problems
+}
+
+func dial(ctx context.Context, addr string, timeout time.Duration, opts []grpc.DialOption) (*grpc.ClientConn, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	opts = append([]grpc.DialOption{grpc.WithTransportCredentials(insecure.NewCredentials()), grpc.WithBlock()}, opts...)
+	conn, err := grpc.DialContext(ctx, addr, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to %s: %v", addr, err)
+	}
+	return conn, nil
+}
+
+// Ping sends ping to the healthcheck service of the supernode at addr and returns the reply
+func Ping(ctx context.Context, addr string, timeout time.Duration, opts ...grpc.DialOption) (string, error) {
+	conn, err := dial(ctx, addr, timeout, opts)
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	res, err := pb.NewHealthCheckClient(conn).Ping(ctx, &pb.PingRequest{Msg: pingMessage})
+	if err != nil {
+		return "", fmt.Errorf("failed to send ping command: %v", err)
+	}
+	return res.Reply, nil
+}
+
+// Check gets status of the supernode at addr and its dependencies. The supernode, which doesn't implement Status,
+// is pinged, the report is marked as Legacy then
+func Check(ctx context.Context, addr string, timeout time.Duration, opts ...grpc.DialOption) (*Report, error) {
+	conn, err := dial(ctx, addr, timeout, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	client := pb.NewHealthCheckClient(conn)
+	report := &Report{Addr: addr}
+
+	callCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	started := time.Now()
+	report.Status, err = client.Status(callCtx, &pb.StatusRequest{})
+	report.Latency = time.Since(started)
+	if err == nil {
+		return report, nil
+	}
+	if status.Code(err) != codes.Unimplemented {
+		return nil, fmt.Errorf("failed to get status: %v", err)
+	}
+
+	report.Legacy = true
+	started = time.Now()
+	res, err := client.Ping(callCtx, &pb.PingRequest{Msg: pingMessage})
+	report.Latency = time.Since(started)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send ping command: %v", err)
+	}
+	report.Reply = res.Reply
+	return report, nil
+}
@@ -0,0 +1,92 @@
+package healthcheck
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/tj/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	pb "github.com/pastelnetwork/pastelup/proto/healthcheck"
+)
+
+// legacyServer is the supernode, which implements Ping only
+type legacyServer struct {
+	pb.UnimplementedHealthCheckServer
+}
+
+func (legacyServer) Ping(_ context.Context, req *pb.PingRequest) (*pb.PingReply, error) {
+	return &pb.PingReply{Reply: "pong: " + req.Msg}, nil
+}
+
+type server struct {
+	legacyServer
+	reply *pb.StatusReply
+	err   error
+}
+
+func (s server) Status(context.Context, *pb.StatusRequest) (*pb.StatusReply, error) {
+	return s.reply, s.err
+}
+
+// startServer runs the healthcheck service in process and returns the dial option, which connects to it
+func startServer(t *testing.T, srv pb.HealthCheckServer) grpc.DialOption {
+	listener := bufconn.Listen(1 << 20)
+	s := grpc.NewServer()
+	pb.RegisterHealthCheckServer(s, srv)
+	go func() { _ = s.Serve(listener) }()
+	t.Cleanup(s.Stop)
+	return grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+		return listener.DialContext(ctx)
+	})
+}
+
+func TestCheck(t *testing.T) {
+	reply := &pb.StatusReply{
+		Version:       "v2.1.0",
+		UptimeSeconds: 3600,
+		PastelId:      "jXYZ",
+		Dependencies: []*pb.DependencyStatus{
+			{Name: "pasteld", State: pb.DependencyStatus_OK, Version: "v1.2.3", UptimeSeconds: 7200},
+			{Name: "p2p", State: pb.DependencyStatus_DEGRADED, LastError: "3 of 10 peers"},
+			{Name: "rq-service", State: pb.DependencyStatus_DOWN, LastError: "connection refused", LastErrorTime: 1700000000},
+		},
+	}
+	dialer := startServer(t, server{reply: reply})
+
+	report, err := Check(context.Background(), "bufnet", time.Second, dialer)
+	assert.NoError(t, err)
+	assert.False(t, report.Legacy)
+	assert.Equal(t, "bufnet", report.Addr)
+	assert.Equal(t, "v2.1.0", report.Status.Version)
+	assert.Len(t, report.Status.Dependencies, 3)
+	assert.Equal(t, []string{"p2p is DEGRADED: 3 of 10 peers", "rq-service is DOWN: connection refused"}, report.Problems())
+
+	pong, err := Ping(context.Background(), "bufnet", time.Second, dialer)
+	assert.NoError(t, err)
+	assert.Equal(t, "pong: hello", pong)
+}
+
+func TestCheckLegacy(t *testing.T) {
+	dialer := startServer(t, legacyServer{})
+
+	report, err := Check(context.Background(), "bufnet", time.Second, dialer)
+	assert.NoError(t, err)
+	assert.True(t, report.Legacy)
+	assert.Equal(t, "pong: hello", report.Reply)
+	assert.Nil(t, report.Status)
+	assert.Empty(t, report.Problems())
+}
+
+func TestCheckError(t *testing.T) {
+	dialer := startServer(t, server{err: status.Error(codes.Internal, "pasteld is not ready")})
+
+	_, err := Check(context.Background(), "bufnet", time.Second, dialer)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "pasteld is not ready")
+}
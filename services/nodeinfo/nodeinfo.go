@@ -0,0 +1,195 @@
+// Package nodeinfo defines the report printed by "pastelup info --output json|yaml".
+//
+// The report is a stable schema described by SchemaVersion. Fields, which can't be got on the host, are omitted.
+package nodeinfo
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"debug/buildinfo"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// SchemaVersion is the version of the report schema.
+//
+// Keys are kebab-case as in the other files of pastelup (inventory, host spec, snapshot index), so the update
+// availability of the component is "update-available", not "update_available". Schema version 1:
+//
+//	schema-version      int, SchemaVersion of the report
+//	generated-at        time the report was made, RFC 3339 in UTC
+//	host                hostname, os, arch, cpus; memory and swap: total, used, free, available in bytes;
+//	                    filesystems: device, mount-point, total, used, available in bytes, used-percent 0-100
+//	components[]        name; binary path; version from "--version" or the go build info; sha256 of the binary
+//	                    in hex; configs paths; running; process: pid, started-at, cpu-percent (100 is one busy core),
+//	                    resident-memory and virtual-memory in bytes, args with secrets redacted
+//	  latest            release on the download server: url, size in bytes, last-modified, sha256
+//	  update-available  true if sha256 of the binary differs from the latest release, omitted if either is unknown
+//	  error             the problem, which prevented getting some of the fields of the component
+//	pasteld             network, working-dir, exec-dir; chain: version, protocol-version, blocks, connections,
+//	                    difficulty, balance in PSL; masternode: alias, outpoint, address, ext-address, ext-p2p, status
+//
+// Fields may be added within the version, readers must ignore unknown ones. Renaming or removing a field, changing
+// its type, unit or meaning increments the version.
+const SchemaVersion = 1
+
+const versionTimeout = 5 * time.Second
+
+var versionRegexp = regexp.MustCompile(`v?\d+\.\d+(\.\d+)?([-+.][0-9A-Za-z.-]+)?`)
+
+// Report is the information about the host and the pastel components installed on it
+type Report struct {
+	SchemaVersion int         `json:"schema-version" yaml:"schema-version"`
+	GeneratedAt   time.Time   `json:"generated-at" yaml:"generated-at"`
+	Host          *Host       `json:"host,omitempty" yaml:"host,omitempty"`
+	Components    []Component `json:"components,omitempty" yaml:"components,omitempty"`
+	Pasteld       *Pasteld    `json:"pasteld,omitempty" yaml:"pasteld,omitempty"`
+}
+
+// Host describes the machine
+type Host struct {
+	Hostname    string       `json:"hostname" yaml:"hostname"`
+	OS          string       `json:"os" yaml:"os"`
+	Arch        string       `json:"arch" yaml:"arch"`
+	CPUs        int          `json:"cpus" yaml:"cpus"`
+	Memory      Memory       `json:"memory" yaml:"memory"`
+	Swap        Memory       `json:"swap" yaml:"swap"`
+	Filesystems []Filesystem `json:"filesystems,omitempty" yaml:"filesystems,omitempty"`
+}
+
+// Memory is the usage of RAM or swap, Available counts buffers and cache as free
+type Memory struct {
+	Total     uint64 `json:"total" yaml:"total"`
+	Used      uint64 `json:"used" yaml:"used"`
+	Free      uint64 `json:"free" yaml:"free"`
+	Available uint64 `json:"available,omitempty" yaml:"available,omitempty"`
+}
+
+// Filesystem is the usage of the mounted device
+type Filesystem struct {
+	Device      string  `json:"device" yaml:"device"`
+	MountPoint  string  `json:"mount-point" yaml:"mount-point"`
+	Total       uint64  `json:"total" yaml:"total"`
+	Used        uint64  `json:"used" yaml:"used"`
+	Available   uint64  `json:"available" yaml:"available"`
+	UsedPercent float64 `json:"used-percent" yaml:"used-percent"`
+}
+
+// Component is the installed pastel component. UpdateAvailable is set only when the latest release is checked
+// and can be compared with the binary
+type Component struct {
+	Name            string   `json:"name" yaml:"name"`
+	Binary          string   `json:"binary,omitempty" yaml:"binary,omitempty"`
+	Version         string   `json:"version,omitempty" yaml:"version,omitempty"`
+	SHA256          string   `json:"sha256,omitempty" yaml:"sha256,omitempty"`
+	Configs         []string `json:"configs,omitempty" yaml:"configs,omitempty"`
+	Running         bool     `json:"running" yaml:"running"`
+	Process         *Process `json:"process,omitempty" yaml:"process,omitempty"`
+	Latest          *Release `json:"latest,omitempty" yaml:"latest,omitempty"`
+	UpdateAvailable *bool    `json:"update-available,omitempty" yaml:"update-available,omitempty"`
+	// Error is the problem, which prevented getting some of the fields
+	Error string `json:"error,omitempty" yaml:"error,omitempty"`
+}
+
+// Process is the running process of the component
+type Process struct {
+	Pid        int       `json:"pid" yaml:"pid"`
+	StartedAt  time.Time `json:"started-at,omitempty" yaml:"started-at,omitempty"`
+	CPUPercent float64   `json:"cpu-percent" yaml:"cpu-percent"`
+	Resident   uint64    `json:"resident-memory" yaml:"resident-memory"`
+	Virtual    uint64    `json:"virtual-memory" yaml:"virtual-memory"`
+	Args       []string  `json:"args,omitempty" yaml:"args,omitempty"`
+}
+
+// Pasteld is the node and its chain as reported by pasteld RPC, Chain is omitted if pasteld doesn't answer
+type Pasteld struct {
+	Network    string      `json:"network" yaml:"network"`
+	WorkingDir string      `json:"working-dir" yaml:"working-dir"`
+	ExecDir    string      `json:"exec-dir" yaml:"exec-dir"`
+	Chain      *Chain      `json:"chain,omitempty" yaml:"chain,omitempty"`
+	Masternode *Masternode `json:"masternode,omitempty" yaml:"masternode,omitempty"`
+}
+
+// Chain is the result of getinfo
+type Chain struct {
+	Version         int     `json:"version" yaml:"version"`
+	ProtocolVersion int     `json:"protocol-version" yaml:"protocol-version"`
+	Blocks          int     `json:"blocks" yaml:"blocks"`
+	Connections     int     `json:"connections" yaml:"connections"`
+	Difficulty      float64 `json:"difficulty" yaml:"difficulty"`
+	Balance         float64 `json:"balance" yaml:"balance"`
+}
+
+// Masternode is the status of the masternode and its entry of masternode.conf, the private key is never reported
+type Masternode struct {
+	Alias      string `json:"alias,omitempty" yaml:"alias,omitempty"`
+	Outpoint   string `json:"outpoint,omitempty" yaml:"outpoint,omitempty"`
+	Address    string `json:"address,omitempty" yaml:"address,omitempty"`
+	ExtAddress string `json:"ext-address,omitempty" yaml:"ext-address,omitempty"`
+	ExtP2P     string `json:"ext-p2p,omitempty" yaml:"ext-p2p,omitempty"`
+	Status     string `json:"status,omitempty" yaml:"status,omitempty"`
+}
+
+// FileHash returns sha256 of the file as hex
+func FileHash(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// BinaryVersion returns version printed by "<binary> --version", if the binary doesn't print it,
+// the version is read from the build info of go binaries
+func BinaryVersion(ctx context.Context, path string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, versionTimeout)
+	defer cancel()
+	out, runErr := exec.CommandContext(ctx, path, "--version").CombinedOutput()
+	if runErr == nil {
+		if version := ParseVersion(string(out)); version != "" {
+			return version, nil
+		}
+	}
+
+	info, err := buildinfo.ReadFile(path)
+	if err != nil {
+		if runErr != nil {
+			return "", fmt.Errorf("unable to run %s --version: %v", path, runErr)
+		}
+		return "", fmt.Errorf("%s --version doesn't print version", path)
+	}
+	if info.Main.Version != "" && info.Main.Version != "(devel)" {
+		return info.Main.Version, nil
+	}
+	for _, setting := range info.Settings {
+		if setting.Key == "vcs.revision" && setting.Value != "" {
+			return setting.Value, nil
+		}
+	}
+	return "", fmt.Errorf("%s has no version in its build info", path)
+}
+
+// ParseVersion returns the first version found in the output of --version, e.g. "v2.1.0-rc1" of
+// "Pastel Daemon version v2.1.0-rc1"
+func ParseVersion(out string) string {
+	scanner := bufio.NewScanner(strings.NewReader(out))
+	for scanner.Scan() {
+		if version := versionRegexp.FindString(scanner.Text()); version != "" {
+			return version
+		}
+	}
+	return ""
+}
@@ -0,0 +1,131 @@
+package nodeinfo
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tj/assert"
+	"gopkg.in/yaml.v2"
+)
+
+func TestParseVersion(t *testing.T) {
+	tests := map[string]string{
+		"Pastel Daemon version v2.1.0-rc1\nCopyright (C) 2023": "v2.1.0-rc1",
+		"supernode version v2.1.4":                             "v2.1.4",
+		"\n1.2.3\n":                                            "1.2.3",
+		"rq-service 0.4":                                       "0.4",
+		"usage: dd-service [options]":                          "",
+	}
+	for out, want := range tests {
+		assert.Equal(t, want, ParseVersion(out), out)
+	}
+}
+
+func TestFileHash(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "supernode")
+	assert.Nil(t, os.WriteFile(path, []byte("pastel"), 0644))
+
+	hash, err := FileHash(path)
+	assert.Nil(t, err)
+	assert.Equal(t, "1a3a22660f71a429e265aed59f839aaeba8332f2d01c2a2c0bce20d41ab6288c", hash)
+
+	_, err = FileHash(path + ".missing")
+	assert.NotNil(t, err)
+}
+
+func TestLatestRelease(t *testing.T) {
+	modified := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodHead, r.Method)
+		if r.URL.Path != "/latest-release/mainnet/supernode/supernode-linux-amd64" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Last-Modified", modified.Format(http.TimeFormat))
+		w.Header().Set("Content-Length", "6")
+	}))
+	defer server.Close()
+
+	release, err := LatestRelease(context.Background(), server.Client(), server.URL+"/latest-release/mainnet/supernode/supernode-linux-amd64")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(6), release.Size)
+	assert.Equal(t, modified, release.LastModified)
+
+	_, err = LatestRelease(context.Background(), server.Client(), server.URL+"/latest-release/mainnet/supernode/missing")
+	assert.NotNil(t, err)
+}
+
+func TestReleaseHash(t *testing.T) {
+	const pastelHash = "1a3a22660f71a429e265aed59f839aaeba8332f2d01c2a2c0bce20d41ab6288c"
+	files := map[string]string{
+		"/published/supernode":        "pastel",
+		"/published/supernode.sha256": strings.ToUpper(pastelHash) + "  supernode\n",
+		"/unpublished/supernode":      "pastel",
+		"/invalid/supernode.sha256":   "not a checksum",
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		content, ok := files[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte(content))
+	}))
+	defer server.Close()
+	ctx := context.Background()
+
+	for _, dir := range []string{"published", "unpublished"} {
+		hash, err := ReleaseHash(ctx, server.Client(), server.URL+"/"+dir+"/supernode")
+		assert.Nil(t, err, dir)
+		assert.Equal(t, pastelHash, hash, dir)
+	}
+	_, err := ReleaseHash(ctx, server.Client(), server.URL+"/invalid/supernode")
+	assert.NotNil(t, err)
+	_, err = ReleaseHash(ctx, server.Client(), server.URL+"/missing/supernode")
+	assert.NotNil(t, err)
+
+	release := &Release{URL: server.URL + "/published/supernode", SHA256: pastelHash}
+	assert.False(t, *UpdateAvailable(pastelHash, release))
+	// the build of the same size is told apart by hash
+	assert.True(t, *UpdateAvailable(strings.Repeat("0", 64), release))
+	assert.Nil(t, UpdateAvailable("", release))
+	assert.Nil(t, UpdateAvailable(pastelHash, nil))
+	assert.Nil(t, UpdateAvailable(pastelHash, &Release{URL: release.URL}))
+}
+
+// TestReportSchema guards the field names of the schema, changing them requires a new SchemaVersion
+func TestReportSchema(t *testing.T) {
+	available := true
+	report := Report{
+		SchemaVersion: SchemaVersion,
+		GeneratedAt:   time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC),
+		Host:          &Host{Hostname: "sn1", OS: "Linux", Arch: "amd64", CPUs: 8},
+		Components: []Component{{Name: "supernode", Binary: "/root/pastel/supernode-linux-amd64", Version: "v2.1.4",
+			Running: true, Process: &Process{Pid: 42}, UpdateAvailable: &available}},
+		Pasteld: &Pasteld{Network: "mainnet", Chain: &Chain{Blocks: 100}, Masternode: &Masternode{Status: "ENABLED"}},
+	}
+
+	data, err := json.Marshal(report)
+	assert.Nil(t, err)
+	var fields map[string]interface{}
+	assert.Nil(t, json.Unmarshal(data, &fields))
+	assert.Equal(t, float64(1), fields["schema-version"])
+	assert.Equal(t, "2024-03-01T12:00:00Z", fields["generated-at"])
+	component := fields["components"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, true, component["update-available"])
+	assert.Equal(t, float64(42), component["process"].(map[string]interface{})["pid"])
+	assert.Equal(t, float64(100), fields["pasteld"].(map[string]interface{})["chain"].(map[string]interface{})["blocks"])
+
+	out, err := yaml.Marshal(report)
+	assert.Nil(t, err)
+	for _, key := range []string{"schema-version: 1", "update-available: true", "status: ENABLED"} {
+		assert.True(t, strings.Contains(string(out), key), key)
+	}
+}
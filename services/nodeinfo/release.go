@@ -0,0 +1,108 @@
+package nodeinfo
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Release is the file of the latest release on the download server
+type Release struct {
+	URL          string    `json:"url" yaml:"url"`
+	Size         int64     `json:"size,omitempty" yaml:"size,omitempty"`
+	LastModified time.Time `json:"last-modified,omitempty" yaml:"last-modified,omitempty"`
+	// SHA256 is the hash of the release file, it is empty if the file isn't hashed
+	SHA256 string `json:"sha256,omitempty" yaml:"sha256,omitempty"`
+}
+
+// LatestRelease gets size and modification time of the release file without downloading it
+func LatestRelease(ctx context.Context, client *http.Client, url string) (*Release, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s: %s", url, resp.Status)
+	}
+
+	release := &Release{URL: url}
+	if resp.ContentLength > 0 {
+		release.Size = resp.ContentLength
+	}
+	if modified, err := http.ParseTime(resp.Header.Get("Last-Modified")); err == nil {
+		release.LastModified = modified.UTC()
+	}
+	return release, nil
+}
+
+// ReleaseHash returns sha256 of the release file. The checksum published next to the file as "<url>.sha256" in
+// sha256sum format is taken if the server has it, otherwise the file is downloaded and hashed without saving it
+func ReleaseHash(ctx context.Context, client *http.Client, url string) (string, error) {
+	body, status, err := get(ctx, client, url+".sha256")
+	if err != nil {
+		return "", err
+	}
+	if status == http.StatusOK {
+		data, err := io.ReadAll(io.LimitReader(body, 1024))
+		body.Close()
+		if err != nil {
+			return "", err
+		}
+		fields := strings.Fields(string(data))
+		if len(fields) == 0 || len(fields[0]) != 64 {
+			return "", fmt.Errorf("invalid checksum file %s.sha256", url)
+		}
+		return strings.ToLower(fields[0]), nil
+	}
+
+	body, status, err = get(ctx, client, url)
+	if err != nil {
+		return "", err
+	}
+	defer body.Close()
+	if status != http.StatusOK {
+		return "", fmt.Errorf("%s: %s", url, http.StatusText(status))
+	}
+	hash := sha256.New()
+	if _, err = io.Copy(hash, body); err != nil {
+		return "", fmt.Errorf("failed to download %s: %v", url, err)
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// get returns the body of the response, it is closed if the status isn't OK
+func get(ctx context.Context, client *http.Client, url string) (io.ReadCloser, int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		_ = resp.Body.Close()
+	}
+	return resp.Body, resp.StatusCode, nil
+}
+
+// UpdateAvailable compares hash of the binary with the release file, which is the binary itself, not an archive.
+// The download server publishes no versions, so the builds are told apart by their content. It returns nil if
+// they can't be compared
+func UpdateAvailable(binaryHash string, release *Release) *bool {
+	if binaryHash == "" || release == nil || release.SHA256 == "" {
+		return nil
+	}
+	available := !strings.EqualFold(binaryHash, release.SHA256)
+	return &available
+}
@@ -3,8 +3,11 @@ package pastelcore
 import (
 	"bytes"
 	"encoding/json"
+	"fmt"
 	"net/http"
+	"os"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/pastelnetwork/pastelup/configs"
@@ -21,6 +24,8 @@ var (
 const (
 	// GetInfoCmd is an RPC command
 	GetInfoCmd = "getinfo"
+	// GetBlockchainInfoCmd is an RPC command
+	GetBlockchainInfoCmd = "getblockchaininfo"
 	// GetBalanceCmd is an RPC command
 	GetBalanceCmd = "getbalance"
 	// SendToAddressCmd is an R`PC command
@@ -39,6 +44,16 @@ const (
 	TicketsCmd = "tickets"
 	// AddNode is an RPC command
 	AddNode = "addnode"
+	// DisconnectNodeCmd is an RPC command
+	DisconnectNodeCmd = "disconnectnode"
+	// GetPeerInfoCmd is an RPC command
+	GetPeerInfoCmd = "getpeerinfo"
+	// SetBanCmd is an RPC command
+	SetBanCmd = "setban"
+	// ZGetBalanceCmd is an RPC command
+	ZGetBalanceCmd = "z_getbalance"
+	// GetTransactionCmd is an RPC command
+	GetTransactionCmd = "gettransaction"
 )
 
 // RPCRequest represents a jsonrpc request object.
@@ -60,20 +75,43 @@ type RPCCommunicator interface {
 // Client represents an rpc client that satisifies the RPCCommunicator interface
 type Client struct {
 	username, password string
-	port               int
-	network            string
+	// cookieFile is used when username is not set, pasteld rewrites it at every start
+	cookieFile string
+	port       int
+	network    string
 }
 
 // NewClient returns a new client
 func NewClient(config *configs.Config) *Client {
 	return &Client{
-		username: config.RPCUser,
-		password: config.RPCPwd,
-		port:     config.RPCPort,
-		network:  config.Network,
+		username:   config.RPCUser,
+		password:   config.RPCPwd,
+		cookieFile: config.RPCCookieFile,
+		port:       config.RPCPort,
+		network:    config.Network,
 	}
 }
 
+// ReadCookie reads RPC credentials from the cookie file of pasteld
+func ReadCookie(path string) (username, password string, err error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to read RPC cookie: %v", err)
+	}
+	username, password, ok := strings.Cut(strings.TrimSpace(string(data)), ":")
+	if !ok {
+		return "", "", fmt.Errorf("invalid RPC cookie %s", path)
+	}
+	return username, password, nil
+}
+
+func (client Client) credentials() (string, string, error) {
+	if client.username == "" && client.cookieFile != "" {
+		return ReadCookie(client.cookieFile)
+	}
+	return client.username, client.password, nil
+}
+
 // Addr returns the address of the pasteld rpc server
 func (client Client) Addr() string {
 	p := client.port
@@ -119,7 +157,11 @@ func (client *Client) do(cmd string, args, response interface{}) error {
 	if err != nil {
 		return err
 	}
-	request.SetBasicAuth(client.username, client.password)
+	username, password, err := client.credentials()
+	if err != nil {
+		return err
+	}
+	request.SetBasicAuth(username, password)
 	request.Header.Set("Content-Type", "text/plain;")
 	result, err := DefaultClient.Do(request)
 	if err != nil {
@@ -0,0 +1,68 @@
+package pastelcore
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/pastelnetwork/pastelup/configs"
+	"github.com/tj/assert"
+)
+
+func testServer(t *testing.T, username, password string) int {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if u, p, ok := r.BasicAuth(); !ok || u != username || p != password {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte(`{"result":{"version":1},"error":null,"id":"pastelapi"}`))
+	}))
+	t.Cleanup(server.Close)
+
+	u, err := url.Parse(server.URL)
+	assert.NoError(t, err)
+	port, err := strconv.Atoi(u.Port())
+	assert.NoError(t, err)
+	return port
+}
+
+func TestClientCredentials(t *testing.T) {
+	port := testServer(t, "user", "secret")
+	var resp map[string]interface{}
+
+	config := &configs.Config{Init: configs.Init{RPCUser: "user", RPCPwd: "secret", RPCPort: port}}
+	assert.NoError(t, NewClient(config).RunCommand(GetInfoCmd, &resp))
+
+	config.RPCPwd = "wrong"
+	assert.Error(t, NewClient(config).RunCommand(GetInfoCmd, &resp))
+}
+
+func TestClientCookie(t *testing.T) {
+	port := testServer(t, "__cookie__", "abc123")
+	cookie := filepath.Join(t.TempDir(), ".cookie")
+	config := &configs.Config{Init: configs.Init{RPCCookieFile: cookie, RPCPort: port}}
+	var resp map[string]interface{}
+
+	assert.Error(t, NewClient(config).RunCommand(GetInfoCmd, &resp), "missing cookie")
+
+	assert.NoError(t, os.WriteFile(cookie, []byte("__cookie__:abc123"), 0600))
+	assert.NoError(t, NewClient(config).RunCommand(GetInfoCmd, &resp))
+	assert.Equal(t, map[string]interface{}{"version": float64(1)}, resp["result"])
+
+	// explicit credentials take precedence over the cookie
+	config.RPCUser, config.RPCPwd = "user", "secret"
+	assert.Error(t, NewClient(config).RunCommand(GetInfoCmd, &resp))
+
+	user, password, err := ReadCookie(cookie)
+	assert.NoError(t, err)
+	assert.Equal(t, "__cookie__", user)
+	assert.Equal(t, "abc123", password)
+
+	assert.NoError(t, os.WriteFile(cookie, []byte("garbage"), 0600))
+	_, _, err = ReadCookie(cookie)
+	assert.Error(t, err)
+}
@@ -0,0 +1,368 @@
+package snapshot
+
+import (
+	"archive/tar"
+	"bufio"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// archivedFiles are the chain files put into the snapshot, the caches and peers of the node are left out
+var archivedFiles = []string{"blocks", "chainstate", "tickets"}
+
+// logTailSize is how much of the end of debug.log is searched for the chain tip
+const logTailSize = 4 << 20
+
+var updateTipRegexp = regexp.MustCompile(`UpdateTip: new best=([0-9a-f]{64})\s+height=(\d+)`)
+
+// Name returns the archive name of the snapshot
+func Name(network, kind string, height int, format string) string {
+	return fmt.Sprintf("snapshot-%s%s-%d.%s", network, kindSuffix(kind), height, format)
+}
+
+// Create archives the chain files of dataDir, which must not be written while it runs, into the network directory
+// of snapshotDir. The archive is published with its "<name>.sha256" and the entry in the index of the network, the
+// latest snapshot of the kind is also published by its legacy name for pastelup without the index support
+func Create(dataDir, snapshotDir, network string, entry Entry, format string) (Entry, error) {
+	dir := filepath.Join(snapshotDir, network)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return entry, err
+	}
+	entry.Name = Name(network, entry.Kind, entry.Height, format)
+	path := filepath.Join(dir, entry.Name)
+	// files starting with dot aren't served, so the partial archive is never downloaded
+	tmpPath := filepath.Join(dir, "."+entry.Name+".tmp")
+	defer os.Remove(tmpPath)
+
+	file, err := os.Create(tmpPath)
+	if err != nil {
+		return entry, err
+	}
+	hash := sha256.New()
+	counter := &countWriter{}
+	err = writeArchive(io.MultiWriter(file, hash, counter), dataDir, format)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return entry, err
+	}
+	entry.SHA256 = hex.EncodeToString(hash.Sum(nil))
+	entry.Size = counter.n
+
+	if err = os.Rename(tmpPath, path); err != nil {
+		return entry, err
+	}
+	if err = writeChecksum(dir, entry.Name, entry.SHA256); err != nil {
+		return entry, err
+	}
+	if err = AddToIndex(dir, network, entry); err != nil {
+		return entry, err
+	}
+	index, err := readIndex(dir, network)
+	if err != nil {
+		return entry, err
+	}
+	return entry, linkLatest(dir, index)
+}
+
+// AddToIndex adds the entry to the index in dir, the entry with the same name is replaced
+func AddToIndex(dir, network string, entry Entry) error {
+	index, err := readIndex(dir, network)
+	if err != nil {
+		return err
+	}
+	snapshots := []Entry{entry}
+	for _, existing := range index.Snapshots {
+		if existing.Name != entry.Name {
+			snapshots = append(snapshots, existing)
+		}
+	}
+	sort.SliceStable(snapshots, func(i, j int) bool { return snapshots[i].Height > snapshots[j].Height })
+	index.Snapshots = snapshots
+	return writeIndex(dir, index)
+}
+
+// Prune removes all but keep latest snapshots of each kind from the network directory of snapshotDir, the archives
+// of the same height in different formats count as one. Entries pointing to other servers are left as they are
+func Prune(snapshotDir, network string, keep int) ([]Entry, error) {
+	if keep < 1 {
+		return nil, fmt.Errorf("at least one snapshot must be kept")
+	}
+	dir := filepath.Join(snapshotDir, network)
+	index, err := readIndex(dir, network)
+	if err != nil {
+		return nil, err
+	}
+
+	heights := map[string][]int{}
+	for _, entry := range index.Snapshots {
+		if isLocal(entry) && !containsInt(heights[entry.Kind], entry.Height) {
+			heights[entry.Kind] = append(heights[entry.Kind], entry.Height)
+		}
+	}
+	// the lowest height kept of each kind
+	oldest := map[string]int{}
+	for kind, kindHeights := range heights {
+		sort.Sort(sort.Reverse(sort.IntSlice(kindHeights)))
+		if len(kindHeights) > keep {
+			oldest[kind] = kindHeights[keep-1]
+		}
+	}
+	var kept, removed []Entry
+	for _, entry := range index.Snapshots {
+		if isLocal(entry) && entry.Height < oldest[entry.Kind] {
+			removed = append(removed, entry)
+		} else {
+			kept = append(kept, entry)
+		}
+	}
+	if len(removed) == 0 {
+		return nil, nil
+	}
+
+	// the index and the legacy names stop pointing to the archives before they are removed
+	index.Snapshots = kept
+	if err = writeIndex(dir, index); err != nil {
+		return nil, err
+	}
+	if err = linkLatest(dir, index); err != nil {
+		return nil, err
+	}
+	for _, entry := range removed {
+		for _, name := range []string{entry.Name, entry.Name + ".sha256"} {
+			if err = os.Remove(filepath.Join(dir, name)); err != nil && !os.IsNotExist(err) {
+				return removed, err
+			}
+		}
+	}
+	return removed, nil
+}
+
+// linkLatest points the legacy names of each kind and format to the latest local archive, the names of the kinds
+// without snapshots are removed
+func linkLatest(dir string, index Index) error {
+	for _, kind := range []string{KindNode, KindTxIndex, KindExplorer} {
+		for _, format := range []string{FormatZstd, FormatGzip} {
+			legacy := LegacyName(index.Network, kind, format)
+			var latest *Entry
+			for i, entry := range index.Snapshots {
+				if entry.Kind == kind && isLocal(entry) && strings.HasSuffix(entry.Name, "."+format) &&
+					(latest == nil || entry.Height > latest.Height) {
+					latest = &index.Snapshots[i]
+				}
+			}
+			if latest == nil {
+				for _, name := range []string{legacy, legacy + ".sha256"} {
+					if err := os.Remove(filepath.Join(dir, name)); err != nil && !os.IsNotExist(err) {
+						return err
+					}
+				}
+				continue
+			}
+
+			// the link is replaced at once, so the legacy name is always served
+			tmpPath := filepath.Join(dir, "."+legacy+".tmp")
+			_ = os.Remove(tmpPath)
+			if err := os.Symlink(latest.Name, tmpPath); err != nil {
+				return err
+			}
+			if err := os.Rename(tmpPath, filepath.Join(dir, legacy)); err != nil {
+				return err
+			}
+			if err := writeChecksum(dir, legacy, latest.SHA256); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// writeChecksum publishes the sha256 of the archive as "<name>.sha256" in sha256sum format
+func writeChecksum(dir, name, sum string) error {
+	tmpPath := filepath.Join(dir, "."+name+".sha256.tmp")
+	if err := os.WriteFile(tmpPath, []byte(sum+"  "+name+"\n"), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, filepath.Join(dir, name+".sha256"))
+}
+
+func readIndex(dir, network string) (Index, error) {
+	path := filepath.Join(dir, IndexName)
+	index := Index{Network: network}
+	data, err := os.ReadFile(path)
+	switch {
+	case err == nil:
+		if err = json.Unmarshal(data, &index); err != nil {
+			return index, fmt.Errorf("invalid snapshot index %s: %v", path, err)
+		}
+	case !os.IsNotExist(err):
+		return index, err
+	}
+	return index, nil
+}
+
+func writeIndex(dir string, index Index) error {
+	data, err := json.MarshalIndent(index, "", "  ")
+	if err != nil {
+		return err
+	}
+	// the index is replaced at once, so it is never served half written
+	tmpPath := filepath.Join(dir, "."+IndexName+".tmp")
+	if err = os.WriteFile(tmpPath, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, filepath.Join(dir, IndexName))
+}
+
+// isLocal checks if the archive of the entry is in the directory of the index
+func isLocal(entry Entry) bool {
+	return !strings.Contains(entry.Name, "/")
+}
+
+func containsInt(values []int, value int) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func writeArchive(w io.Writer, dataDir, format string) error {
+	var compressor io.WriteCloser
+	switch format {
+	case FormatGzip:
+		compressor = gzip.NewWriter(w)
+	case FormatZstd:
+		zw, err := zstd.NewWriter(w)
+		if err != nil {
+			return err
+		}
+		compressor = zw
+	default:
+		return fmt.Errorf("unsupported archive format: %s", format)
+	}
+
+	tw := tar.NewWriter(compressor)
+	found := 0
+	for _, name := range archivedFiles {
+		root := filepath.Join(dataDir, name)
+		if _, err := os.Stat(root); os.IsNotExist(err) {
+			continue
+		}
+		found++
+		if err := addToTar(tw, dataDir, root); err != nil {
+			return err
+		}
+	}
+	if found == 0 {
+		return fmt.Errorf("no chain data in %s", dataDir)
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return compressor.Close()
+}
+
+func addToTar(tw *tar.Writer, dataDir, root string) error {
+	return filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() && !info.Mode().IsRegular() {
+			return fmt.Errorf("%s is not a regular file", path)
+		}
+		rel, err := filepath.Rel(dataDir, path)
+		if err != nil {
+			return err
+		}
+		header, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return err
+		}
+		header.Name = filepath.ToSlash(rel)
+		if info.IsDir() {
+			header.Name += "/"
+		}
+		if err = tw.WriteHeader(header); err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		_, err = io.Copy(tw, file)
+		return err
+	})
+}
+
+// Tip is the chain tip of the node found in its debug.log
+type Tip struct {
+	Height int
+	Hash   string
+	// CleanShutdown tells that pasteld has flushed its database and exited, the log ends with "Shutdown: done"
+	CleanShutdown bool
+}
+
+// ReadLogTip returns the chain tip last written to debug.log
+func ReadLogTip(path string) (Tip, error) {
+	var tip Tip
+	file, err := os.Open(path)
+	if err != nil {
+		return tip, err
+	}
+	defer file.Close()
+	if info, err := file.Stat(); err == nil && info.Size() > logTailSize {
+		if _, err = file.Seek(-logTailSize, io.SeekEnd); err != nil {
+			return tip, err
+		}
+	}
+
+	var last string
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if match := updateTipRegexp.FindStringSubmatch(line); match != nil {
+			tip.Hash = match[1]
+			tip.Height, _ = strconv.Atoi(match[2])
+		}
+		if strings.TrimSpace(line) != "" {
+			last = line
+		}
+	}
+	if err = scanner.Err(); err != nil {
+		return tip, err
+	}
+	if tip.Height == 0 {
+		return tip, fmt.Errorf("no chain tip in the end of %s", path)
+	}
+	tip.CleanShutdown = strings.Contains(last, "Shutdown: done")
+	return tip, nil
+}
+
+type countWriter struct {
+	n int64
+}
+
+func (c *countWriter) Write(p []byte) (int, error) {
+	c.n += int64(len(p))
+	return len(p), nil
+}
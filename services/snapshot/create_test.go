@@ -0,0 +1,156 @@
+package snapshot
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/tj/assert"
+)
+
+func TestCreateAndServe(t *testing.T) {
+	dataDir := oldChain(t)
+	snapshotDir := t.TempDir()
+
+	entry, err := Create(dataDir, snapshotDir, "testnet", Entry{Kind: KindNode, Height: 100}, FormatZstd)
+	assert.Nil(t, err)
+	assert.Equal(t, "snapshot-testnet-100.tar.zst", entry.Name)
+	data, err := os.ReadFile(filepath.Join(snapshotDir, "testnet", entry.Name))
+	assert.Nil(t, err)
+	assert.Equal(t, sha(data), entry.SHA256)
+	assert.Equal(t, int64(len(data)), entry.Size)
+
+	second, err := Create(dataDir, snapshotDir, "testnet", Entry{Kind: KindTxIndex, Height: 200}, FormatGzip)
+	assert.Nil(t, err)
+	// the same snapshot created again replaces its entry
+	_, err = Create(dataDir, snapshotDir, "testnet", Entry{Kind: KindNode, Height: 100}, FormatZstd)
+	assert.Nil(t, err)
+
+	var index Index
+	data, err = os.ReadFile(filepath.Join(snapshotDir, "testnet", IndexName))
+	assert.Nil(t, err)
+	assert.Nil(t, json.Unmarshal(data, &index))
+	assert.Equal(t, "testnet", index.Network)
+	assert.Len(t, index.Snapshots, 2)
+	assert.Equal(t, second, index.Snapshots[0])
+
+	server := httptest.NewServer(Handler(snapshotDir))
+	defer server.Close()
+	ctx := context.Background()
+
+	source, err := Resolve(ctx, server.Client(), Request{URL: server.URL, Network: "testnet", Kind: KindNode, Format: FormatZstd})
+	assert.Nil(t, err)
+	assert.Equal(t, server.URL+"/testnet/snapshot-testnet-100.tar.zst", source.URL)
+	sum, err := FetchChecksum(ctx, server.Client(), source.URL)
+	assert.Nil(t, err)
+	assert.Equal(t, source.SHA256, sum)
+
+	body, _, err := Open(ctx, server.Client(), source.URL)
+	assert.Nil(t, err)
+	defer body.Close()
+	target := t.TempDir()
+	assert.Nil(t, Install(body, target, source.Format, source.SHA256))
+	assert.Equal(t, "old blocks", readFile(t, filepath.Join(target, "blocks", "blk00000.dat")))
+	assert.Equal(t, "old chainstate", readFile(t, filepath.Join(target, "chainstate", "CURRENT")))
+	// peers and configs of the node aren't in the snapshot
+	assert.NoFileExists(t, filepath.Join(target, "peers.dat"))
+	assert.NoFileExists(t, filepath.Join(target, "pastel.conf"))
+
+	assert.Nil(t, os.WriteFile(filepath.Join(snapshotDir, "testnet", ".partial.tmp"), []byte("x"), 0644))
+	for path, code := range map[string]int{"/testnet/.partial.tmp": http.StatusNotFound, "/testnet/index.json": http.StatusOK} {
+		resp, err := server.Client().Get(server.URL + path)
+		assert.Nil(t, err)
+		resp.Body.Close()
+		assert.Equal(t, code, resp.StatusCode, path)
+	}
+	resp, err := server.Client().Post(server.URL+"/testnet/index.json", "text/plain", strings.NewReader(""))
+	assert.Nil(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusMethodNotAllowed, resp.StatusCode)
+
+	_, err = Create(t.TempDir(), snapshotDir, "devnet", Entry{Kind: KindNode, Height: 1}, FormatZstd)
+	assert.Contains(t, err.Error(), "no chain data")
+	_, err = os.Stat(filepath.Join(snapshotDir, "devnet", "snapshot-devnet-1.tar.zst"))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestLatestAliasAndPrune(t *testing.T) {
+	dataDir := oldChain(t)
+	snapshotDir := t.TempDir()
+	dir := filepath.Join(snapshotDir, "testnet")
+	entries := map[int]Entry{}
+	for _, height := range []int{300, 100, 200} {
+		entry, err := Create(dataDir, snapshotDir, "testnet", Entry{Kind: KindNode, Height: height}, FormatZstd)
+		assert.Nil(t, err)
+		entries[height] = entry
+	}
+	txind, err := Create(dataDir, snapshotDir, "testnet", Entry{Kind: KindTxIndex, Height: 150}, FormatGzip)
+	assert.Nil(t, err)
+
+	// the latest snapshot of the kind is published by its legacy name, the older one created later doesn't replace it
+	alias := LegacyName("testnet", KindNode, FormatZstd)
+	target, err := os.Readlink(filepath.Join(dir, alias))
+	assert.Nil(t, err)
+	assert.Equal(t, entries[300].Name, target)
+	assert.Equal(t, entries[300].SHA256+"  "+alias+"\n", readFile(t, filepath.Join(dir, alias+".sha256")))
+	assert.FileExists(t, filepath.Join(dir, LegacyName("testnet", KindTxIndex, FormatGzip)))
+	assert.NoFileExists(t, filepath.Join(dir, LegacyName("testnet", KindTxIndex, FormatZstd)))
+
+	removed, err := Prune(snapshotDir, "testnet", 2)
+	assert.Nil(t, err)
+	assert.Equal(t, []Entry{entries[100]}, removed)
+	assert.NoFileExists(t, filepath.Join(dir, entries[100].Name))
+	assert.NoFileExists(t, filepath.Join(dir, entries[100].Name+".sha256"))
+	index, err := readIndex(dir, "testnet")
+	assert.Nil(t, err)
+	assert.Equal(t, []Entry{entries[300], entries[200], txind}, index.Snapshots)
+
+	removed, err = Prune(snapshotDir, "testnet", 2)
+	assert.Nil(t, err)
+	assert.Empty(t, removed)
+	_, err = Prune(snapshotDir, "testnet", 0)
+	assert.Error(t, err)
+
+	// pastelup without the index support installs the latest snapshot by its legacy name
+	assert.Nil(t, os.Remove(filepath.Join(dir, IndexName)))
+	server := httptest.NewServer(Handler(snapshotDir))
+	defer server.Close()
+	ctx := context.Background()
+	source, err := Resolve(ctx, server.Client(), Request{URL: server.URL, Network: "testnet", Kind: KindNode, Format: FormatZstd})
+	assert.Nil(t, err)
+	assert.Equal(t, server.URL+"/testnet/"+alias, source.URL)
+	assert.Equal(t, entries[300].SHA256, source.SHA256)
+	body, _, err := Open(ctx, server.Client(), source.URL)
+	assert.Nil(t, err)
+	defer body.Close()
+	assert.Nil(t, Install(body, t.TempDir(), source.Format, source.SHA256))
+}
+
+func TestReadLogTip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "debug.log")
+	hash := strings.Repeat("ab", 32)
+	log := fmt.Sprintf("2024-03-01 12:00:00 UpdateTip: new best=%s  height=41 bits=1 log2_work=2 tx=3 date=x progress=1\n"+
+		"2024-03-01 12:00:01 Leaving InvalidChainFound\n"+
+		"2024-03-01 12:00:02 UpdateTip: new best=%s  height=42 bits=1 log2_work=2 tx=3 date=x progress=1\n"+
+		"2024-03-01 12:00:03 Shutdown: done\n", strings.Repeat("cd", 32), hash)
+	assert.Nil(t, os.WriteFile(path, []byte(log), 0644))
+
+	tip, err := ReadLogTip(path)
+	assert.Nil(t, err)
+	assert.Equal(t, Tip{Height: 42, Hash: hash, CleanShutdown: true}, tip)
+
+	assert.Nil(t, os.WriteFile(path, []byte(log+"2024-03-01 12:10:00 Pastel version v2.1.0\n"), 0644))
+	tip, err = ReadLogTip(path)
+	assert.Nil(t, err)
+	assert.False(t, tip.CleanShutdown)
+
+	assert.Nil(t, os.WriteFile(path, []byte("2024-03-01 Pastel version v2.1.0\n"), 0644))
+	_, err = ReadLogTip(path)
+	assert.Contains(t, err.Error(), "no chain tip")
+}
@@ -0,0 +1,186 @@
+package snapshot
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// ChainFiles are the files of the data directory, which belong to the chain. They are replaced by the snapshot
+// together, so the node never has blocks of the snapshot with the indexes of the old chain
+var ChainFiles = []string{"blocks", "chainstate", "db.log", "debug.log", "fee_estimates.dat", "messages.dat",
+	"mncache.dat", "mnpayments.dat", "netfulfilled.dat", "peers.dat", "tickets"}
+
+// requiredDirs must be in the snapshot, the archive without them isn't the chain data
+var requiredDirs = []string{"blocks", "chainstate"}
+
+// Extract unpacks the archive stream into dir and checks it. sha256 of the archive is verified after the whole
+// stream is read, if it is empty the archive isn't verified
+func Extract(r io.Reader, dir, format, checksum string) error {
+	hash := sha256.New()
+	stream := io.TeeReader(r, hash)
+
+	var reader io.Reader
+	switch format {
+	case FormatGzip:
+		gzr, err := gzip.NewReader(stream)
+		if err != nil {
+			return err
+		}
+		defer gzr.Close()
+		reader = gzr
+	case FormatZstd:
+		zr, err := zstd.NewReader(stream)
+		if err != nil {
+			return err
+		}
+		defer zr.Close()
+		reader = zr
+	default:
+		return fmt.Errorf("unsupported archive format: %s", format)
+	}
+
+	if err := extractTar(tar.NewReader(reader), dir); err != nil {
+		return err
+	}
+	// the rest of the stream, e.g. the padding after the tar end, is hashed too
+	if _, err := io.Copy(io.Discard, stream); err != nil {
+		return err
+	}
+	if checksum != "" {
+		if sum := hex.EncodeToString(hash.Sum(nil)); !strings.EqualFold(sum, checksum) {
+			return fmt.Errorf("snapshot checksum mismatch: got %s, expected %s", sum, checksum)
+		}
+	}
+	return Validate(dir)
+}
+
+func extractTar(tarReader *tar.Reader, dest string) error {
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		path := filepath.Join(dest, header.Name)
+		// the archive must not write outside of dest
+		if rel, err := filepath.Rel(dest, path); err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return fmt.Errorf("invalid path %q in tar archive", header.Name)
+		}
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(path, 0755); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+				return err
+			}
+			outFile, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+			if err != nil {
+				return err
+			}
+			if _, err := io.Copy(outFile, tarReader); err != nil {
+				outFile.Close()
+				return err
+			}
+			if err := outFile.Close(); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("unsupported file type %v in tar archive", header.Typeflag)
+		}
+	}
+}
+
+// Validate checks that the extracted snapshot has the chain data
+func Validate(dir string) error {
+	for _, name := range requiredDirs {
+		entries, err := os.ReadDir(filepath.Join(dir, name))
+		if err != nil {
+			return fmt.Errorf("snapshot has no %s: %v", name, err)
+		}
+		if len(entries) == 0 {
+			return fmt.Errorf("snapshot has empty %s", name)
+		}
+	}
+	return nil
+}
+
+// Replace moves the chain files of the snapshot extracted to staging into dataDir. The old chain files are moved
+// aside first and restored if any of the new ones can't be moved, they are removed when all are in place.
+// staging must be on the same filesystem as dataDir
+func Replace(dataDir, staging string) error {
+	backup := filepath.Join(dataDir, fmt.Sprintf(".snapshot-old-%d", time.Now().Unix()))
+	if err := os.MkdirAll(backup, 0700); err != nil {
+		return err
+	}
+	var moved []string
+	restore := func() {
+		for _, name := range moved {
+			_ = os.RemoveAll(filepath.Join(dataDir, name))
+			_ = os.Rename(filepath.Join(backup, name), filepath.Join(dataDir, name))
+		}
+		_ = os.RemoveAll(backup)
+	}
+	for _, name := range ChainFiles {
+		err := os.Rename(filepath.Join(dataDir, name), filepath.Join(backup, name))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			restore()
+			return fmt.Errorf("unable to move aside %s: %v", name, err)
+		}
+		moved = append(moved, name)
+	}
+
+	// other files of the archive, e.g. its metadata, stay in staging
+	var installed []string
+	for _, name := range ChainFiles {
+		err := os.Rename(filepath.Join(staging, name), filepath.Join(dataDir, name))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			for _, done := range installed {
+				_ = os.Rename(filepath.Join(dataDir, done), filepath.Join(staging, done))
+			}
+			restore()
+			return fmt.Errorf("unable to move %s of the snapshot: %v", name, err)
+		}
+		installed = append(installed, name)
+	}
+	return os.RemoveAll(backup)
+}
+
+// Install extracts the archive stream into a staging directory in dataDir and replaces the chain files with it
+// only when the whole archive is extracted and verified, so the failed download keeps the old chain
+func Install(r io.Reader, dataDir, format, checksum string) error {
+	if err := os.MkdirAll(dataDir, 0700); err != nil {
+		return err
+	}
+	staging := filepath.Join(dataDir, ".snapshot-staging")
+	// leftovers of the interrupted install
+	if err := os.RemoveAll(staging); err != nil {
+		return err
+	}
+	defer os.RemoveAll(staging)
+
+	if err := Extract(r, staging, format, checksum); err != nil {
+		return err
+	}
+	return Replace(dataDir, staging)
+}
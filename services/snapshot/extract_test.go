@@ -0,0 +1,120 @@
+package snapshot
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/tj/assert"
+)
+
+// archive returns the compressed tar of the files, names ending with "/" are directories
+func archive(t *testing.T, format string, files map[string]string) []byte {
+	var buf bytes.Buffer
+	var compressor io.WriteCloser
+	if format == FormatGzip {
+		compressor = gzip.NewWriter(&buf)
+	} else {
+		zw, err := zstd.NewWriter(&buf)
+		assert.Nil(t, err)
+		compressor = zw
+	}
+	tw := tar.NewWriter(compressor)
+	for name, content := range files {
+		header := &tar.Header{Name: name, Mode: 0644, Size: int64(len(content)), Typeflag: tar.TypeReg}
+		if name[len(name)-1] == '/' {
+			header = &tar.Header{Name: name, Mode: 0755, Typeflag: tar.TypeDir}
+		}
+		assert.Nil(t, tw.WriteHeader(header))
+		_, err := tw.Write([]byte(content))
+		assert.Nil(t, err)
+	}
+	assert.Nil(t, tw.Close())
+	assert.Nil(t, compressor.Close())
+	return buf.Bytes()
+}
+
+func sha(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+var chainFiles = map[string]string{
+	"blocks/":             "",
+	"blocks/blk00000.dat": "new blocks",
+	"chainstate/CURRENT":  "new chainstate",
+	"tickets/000001.ldb":  "new tickets",
+	"snapshot.json":       `{"height":100}`,
+}
+
+func readFile(t *testing.T, path string) string {
+	data, err := os.ReadFile(path)
+	assert.Nil(t, err)
+	return string(data)
+}
+
+func oldChain(t *testing.T) string {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"blocks/blk00000.dat": "old blocks", "chainstate/CURRENT": "old chainstate", "peers.dat": "old peers",
+		"pastel.conf": "testnet=1", "wallet.dat": "wallet",
+	} {
+		assert.Nil(t, os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0755))
+		assert.Nil(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0644))
+	}
+	return dir
+}
+
+func TestInstall(t *testing.T) {
+	for _, format := range []string{FormatZstd, FormatGzip} {
+		data := archive(t, format, chainFiles)
+		dir := oldChain(t)
+
+		assert.Nil(t, Install(bytes.NewReader(data), dir, format, sha(data)))
+		assert.Equal(t, "new blocks", readFile(t, filepath.Join(dir, "blocks", "blk00000.dat")))
+		assert.Equal(t, "new chainstate", readFile(t, filepath.Join(dir, "chainstate", "CURRENT")))
+		assert.Equal(t, "new tickets", readFile(t, filepath.Join(dir, "tickets", "000001.ldb")))
+		// stale chain files are removed, the configs and wallet are kept
+		assert.NoFileExists(t, filepath.Join(dir, "peers.dat"))
+		assert.NoFileExists(t, filepath.Join(dir, "snapshot.json"))
+		assert.Equal(t, "wallet", readFile(t, filepath.Join(dir, "wallet.dat")))
+		assert.Equal(t, "testnet=1", readFile(t, filepath.Join(dir, "pastel.conf")))
+
+		entries, err := os.ReadDir(dir)
+		assert.Nil(t, err)
+		assert.Len(t, entries, 5)
+	}
+}
+
+func TestInstallKeepsOldChain(t *testing.T) {
+	data := archive(t, FormatZstd, chainFiles)
+	noChainstate := archive(t, FormatZstd, map[string]string{"blocks/blk00000.dat": "new blocks"})
+	escape := archive(t, FormatZstd, map[string]string{"../evil": "x"})
+
+	for name, install := range map[string]func(dir string) error{
+		"checksum mismatch": func(dir string) error { return Install(bytes.NewReader(data), dir, FormatZstd, sha([]byte("other"))) },
+		"truncated":         func(dir string) error { return Install(bytes.NewReader(data[:len(data)/2]), dir, FormatZstd, "") },
+		"snapshot has no chainstate": func(dir string) error {
+			return Install(bytes.NewReader(noChainstate), dir, FormatZstd, sha(noChainstate))
+		},
+		"invalid path": func(dir string) error { return Install(bytes.NewReader(escape), dir, FormatZstd, "") },
+		"unsupported":  func(dir string) error { return Install(bytes.NewReader(data), dir, "zip", "") },
+	} {
+		dir := oldChain(t)
+		err := install(dir)
+		assert.Error(t, err, name)
+		if name != "truncated" {
+			assert.Contains(t, err.Error(), name)
+		}
+		assert.Equal(t, "old blocks", readFile(t, filepath.Join(dir, "blocks", "blk00000.dat")), name)
+		assert.Equal(t, "old peers", readFile(t, filepath.Join(dir, "peers.dat")), name)
+		assert.NoDirExists(t, filepath.Join(dir, ".snapshot-staging"), name)
+	}
+}
@@ -0,0 +1,207 @@
+// Package snapshot downloads and installs archives of the pasteld chain data.
+//
+// Snapshots of a network are listed in the index "<base>/<network>/index.json" with their height, date and
+// sha256, the archive names in the index are relative to it. The archive is a tar.zst or tar.gz of blocks,
+// chainstate and the other chain files at its root, which are extracted into the data directory of the network.
+// Create builds the archives from the stopped node in this layout and Handler serves them. The latest snapshot of each
+// kind is also linked as "snapshot-latest-<network>[-txind|-explorer].<format>" for pastelup without the index support.
+package snapshot
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// IndexName is the file name of the index in the directory of the network
+const IndexName = "index.json"
+
+// Kinds of the snapshots, they differ in the indexes pasteld was run with
+const (
+	KindNode     = "node"
+	KindTxIndex  = "txindex"
+	KindExplorer = "explorer"
+)
+
+// Archive formats
+const (
+	FormatZstd = "tar.zst"
+	FormatGzip = "tar.gz"
+)
+
+// Timeouts of the client returned by NewClient. The download itself isn't limited, snapshots of mainnet take hours on
+// slow links
+const (
+	ResponseHeaderTimeout = 30 * time.Second
+	IdleConnTimeout       = 90 * time.Second
+)
+
+// ErrNotFound is returned when the server has no index or archive at the URL
+var ErrNotFound = errors.New("not found")
+
+// Index lists the snapshots of the network
+type Index struct {
+	Network   string  `json:"network"`
+	Snapshots []Entry `json:"snapshots"`
+}
+
+// Entry is the snapshot in the index
+type Entry struct {
+	// Name is the archive URL relative to the index
+	Name      string    `json:"name"`
+	Kind      string    `json:"kind"`
+	Height    int       `json:"height"`
+	BlockHash string    `json:"block-hash,omitempty"`
+	Date      time.Time `json:"date"`
+	SHA256    string    `json:"sha256"`
+	Size      int64     `json:"size,omitempty"`
+}
+
+// NewClient returns HTTP client for the index and archive downloads, it doesn't hang on the server which accepts
+// the connection but never responds
+func NewClient() *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.ResponseHeaderTimeout = ResponseHeaderTimeout
+	transport.IdleConnTimeout = IdleConnTimeout
+	return &http.Client{Transport: transport}
+}
+
+// IndexURL returns the URL of the index of the network under the base URL
+func IndexURL(base, network string) string {
+	return strings.TrimSuffix(base, "/") + "/" + network + "/" + IndexName
+}
+
+// FetchIndex downloads the index, ErrNotFound is returned if the server doesn't have it
+func FetchIndex(ctx context.Context, client *http.Client, indexURL string) (*Index, error) {
+	body, _, err := Open(ctx, client, indexURL)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	var index Index
+	if err = json.NewDecoder(body).Decode(&index); err != nil {
+		return nil, fmt.Errorf("invalid snapshot index %s: %v", indexURL, err)
+	}
+	return &index, nil
+}
+
+// Select returns the snapshot of the kind at the height, or the highest one if height is 0. Of the snapshots
+// at the same height the one in the preferred format is taken
+func (idx *Index) Select(kind string, height int, format string) (Entry, error) {
+	var found []Entry
+	for _, entry := range idx.Snapshots {
+		if entry.Kind == kind && (height == 0 || entry.Height == height) {
+			found = append(found, entry)
+		}
+	}
+	if len(found) == 0 {
+		if height != 0 {
+			return Entry{}, fmt.Errorf("no %s snapshot of %s at height %d", kind, idx.Network, height)
+		}
+		return Entry{}, fmt.Errorf("no %s snapshot of %s", kind, idx.Network)
+	}
+	sort.SliceStable(found, func(i, j int) bool {
+		if found[i].Height != found[j].Height {
+			return found[i].Height > found[j].Height
+		}
+		return strings.HasSuffix(found[i].Name, "."+format) && !strings.HasSuffix(found[j].Name, "."+format)
+	})
+	return found[0], nil
+}
+
+// Find returns the snapshot with the name
+func (idx *Index) Find(name string) (Entry, bool) {
+	for _, entry := range idx.Snapshots {
+		if entry.Name == name {
+			return entry, true
+		}
+	}
+	return Entry{}, false
+}
+
+// URL returns the URL of the archive of the entry in the index at indexURL
+func (e Entry) URL(indexURL string) (string, error) {
+	base, err := url.Parse(indexURL)
+	if err != nil {
+		return "", err
+	}
+	ref, err := url.Parse(e.Name)
+	if err != nil {
+		return "", fmt.Errorf("invalid snapshot name %q: %v", e.Name, err)
+	}
+	return base.ResolveReference(ref).String(), nil
+}
+
+// LegacyName returns the name of the latest snapshot on servers without the index
+func LegacyName(network, kind, format string) string {
+	return "snapshot-latest-" + network + kindSuffix(kind) + "." + format
+}
+
+func kindSuffix(kind string) string {
+	switch kind {
+	case KindTxIndex:
+		return "-txind"
+	case KindExplorer:
+		return "-explorer"
+	}
+	return ""
+}
+
+// Format returns the format of the archive by its name
+func Format(name string) (string, error) {
+	switch {
+	case strings.HasSuffix(name, "."+FormatZstd):
+		return FormatZstd, nil
+	case strings.HasSuffix(name, "."+FormatGzip):
+		return FormatGzip, nil
+	}
+	return "", fmt.Errorf("unsupported snapshot archive %q, it must be %s or %s", name, FormatZstd, FormatGzip)
+}
+
+// Open starts the download, the body is read as the archive is extracted. The size is -1 if it is unknown
+func Open(ctx context.Context, client *http.Client, rawURL string) (io.ReadCloser, int64, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, 0, fmt.Errorf("http request failed: %v", err)
+	}
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		resp.Body.Close()
+		return nil, 0, fmt.Errorf("%s: %w", rawURL, ErrNotFound)
+	case resp.StatusCode != http.StatusOK:
+		resp.Body.Close()
+		return nil, 0, fmt.Errorf("%s: %s", rawURL, resp.Status)
+	}
+	return resp.Body, resp.ContentLength, nil
+}
+
+// FetchChecksum returns the sha256 published next to the archive as "<url>.sha256" in sha256sum format
+func FetchChecksum(ctx context.Context, client *http.Client, archiveURL string) (string, error) {
+	body, _, err := Open(ctx, client, archiveURL+".sha256")
+	if err != nil {
+		return "", err
+	}
+	defer body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(body, 1024))
+	if err != nil {
+		return "", err
+	}
+	fields := strings.Fields(string(data))
+	if len(fields) == 0 || len(fields[0]) != 64 {
+		return "", fmt.Errorf("invalid checksum file %s.sha256", archiveURL)
+	}
+	return strings.ToLower(fields[0]), nil
+}
@@ -0,0 +1,112 @@
+package snapshot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/tj/assert"
+)
+
+var testIndex = Index{
+	Network: "testnet",
+	Snapshots: []Entry{
+		{Name: "snapshot-testnet-100.tar.gz", Kind: KindNode, Height: 100},
+		{Name: "snapshot-testnet-200.tar.gz", Kind: KindNode, Height: 200},
+		{Name: "snapshot-testnet-200.tar.zst", Kind: KindNode, Height: 200},
+		{Name: "snapshot-testnet-txind-300.tar.zst", Kind: KindTxIndex, Height: 300},
+		{Name: "https://mirror.example.com/explorer-150.tar.zst", Kind: KindExplorer, Height: 150},
+	},
+}
+
+func TestSelect(t *testing.T) {
+	entry, err := testIndex.Select(KindNode, 0, FormatZstd)
+	assert.Nil(t, err)
+	assert.Equal(t, "snapshot-testnet-200.tar.zst", entry.Name)
+
+	entry, err = testIndex.Select(KindNode, 0, FormatGzip)
+	assert.Nil(t, err)
+	assert.Equal(t, "snapshot-testnet-200.tar.gz", entry.Name)
+
+	entry, err = testIndex.Select(KindNode, 100, FormatZstd)
+	assert.Nil(t, err)
+	assert.Equal(t, "snapshot-testnet-100.tar.gz", entry.Name)
+
+	_, err = testIndex.Select(KindNode, 300, FormatZstd)
+	assert.EqualError(t, err, "no node snapshot of testnet at height 300")
+	_, err = (&Index{Network: "devnet"}).Select(KindTxIndex, 0, FormatZstd)
+	assert.EqualError(t, err, "no txindex snapshot of devnet")
+}
+
+func TestEntryURL(t *testing.T) {
+	indexURL := IndexURL("https://download.pastel.network/snapshots/", "testnet")
+	assert.Equal(t, "https://download.pastel.network/snapshots/testnet/index.json", indexURL)
+
+	archiveURL, err := testIndex.Snapshots[0].URL(indexURL)
+	assert.Nil(t, err)
+	assert.Equal(t, "https://download.pastel.network/snapshots/testnet/snapshot-testnet-100.tar.gz", archiveURL)
+
+	archiveURL, err = testIndex.Snapshots[4].URL(indexURL)
+	assert.Nil(t, err)
+	assert.Equal(t, "https://mirror.example.com/explorer-150.tar.zst", archiveURL)
+}
+
+func TestNames(t *testing.T) {
+	assert.Equal(t, "snapshot-latest-mainnet.tar.zst", LegacyName("mainnet", KindNode, FormatZstd))
+	assert.Equal(t, "snapshot-latest-testnet-txind.tar.gz", LegacyName("testnet", KindTxIndex, FormatGzip))
+	assert.Equal(t, "snapshot-latest-devnet-explorer.tar.zst", LegacyName("devnet", KindExplorer, FormatZstd))
+
+	format, err := Format("snapshot-latest-mainnet.tar.gz")
+	assert.Nil(t, err)
+	assert.Equal(t, FormatGzip, format)
+	_, err = Format("snapshot.zip")
+	assert.Error(t, err)
+}
+
+func TestFetch(t *testing.T) {
+	checksum := "5d41402abc4b2a76b9719d911017c592aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/testnet/index.json":
+			fmt.Fprint(w, `{"network":"testnet","snapshots":[{"name":"s-1.tar.zst","kind":"node","height":1,`+
+				`"date":"2024-03-01T12:00:00Z","sha256":"abc","size":42}]}`)
+		case "/testnet/s-1.tar.zst.sha256":
+			fmt.Fprintf(w, "%s  s-1.tar.zst\n", checksum)
+		case "/broken/index.json":
+			fmt.Fprint(w, "<html>")
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	index, err := FetchIndex(context.Background(), server.Client(), IndexURL(server.URL, "testnet"))
+	assert.Nil(t, err)
+	assert.Equal(t, "testnet", index.Network)
+	assert.Len(t, index.Snapshots, 1)
+	assert.Equal(t, int64(42), index.Snapshots[0].Size)
+	assert.Equal(t, 2024, index.Snapshots[0].Date.Year())
+
+	_, err = FetchIndex(context.Background(), server.Client(), IndexURL(server.URL, "mainnet"))
+	assert.True(t, errors.Is(err, ErrNotFound))
+	_, err = FetchIndex(context.Background(), server.Client(), IndexURL(server.URL, "broken"))
+	assert.Contains(t, err.Error(), "invalid snapshot index")
+
+	sum, err := FetchChecksum(context.Background(), server.Client(), server.URL+"/testnet/s-1.tar.zst")
+	assert.Nil(t, err)
+	assert.Equal(t, checksum, sum)
+	_, err = FetchChecksum(context.Background(), server.Client(), server.URL+"/testnet/s-2.tar.zst")
+	assert.True(t, errors.Is(err, ErrNotFound))
+}
+
+func TestNewClient(t *testing.T) {
+	transport, ok := NewClient().Transport.(*http.Transport)
+	assert.True(t, ok)
+	assert.Equal(t, ResponseHeaderTimeout, transport.ResponseHeaderTimeout)
+	assert.Equal(t, IdleConnTimeout, transport.IdleConnTimeout)
+	// the default transport is not modified
+	assert.Zero(t, http.DefaultTransport.(*http.Transport).ResponseHeaderTimeout)
+}
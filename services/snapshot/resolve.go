@@ -0,0 +1,102 @@
+package snapshot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Request tells which snapshot to install
+type Request struct {
+	// BaseURL has the indexes of the networks
+	BaseURL string
+	Network string
+	Kind    string
+	// Format is the preferred archive format
+	Format string
+	// Height picks the snapshot at the height instead of the latest one
+	Height int
+	// Name picks the snapshot by its name in the index
+	Name string
+	// URL overrides BaseURL, it is the archive, the index or the base of the indexes
+	URL string
+}
+
+// Source is the resolved snapshot archive, SHA256 is empty if the server doesn't publish it
+type Source struct {
+	URL    string
+	Format string
+	SHA256 string
+	Height int
+}
+
+// Resolve finds the archive of the request. The index of the network is used if the server has it, otherwise the
+// latest snapshot is downloaded by its legacy name, which can't be verified unless "<archive>.sha256" is published
+func Resolve(ctx context.Context, client *http.Client, req Request) (Source, error) {
+	if _, err := Format(req.URL); err == nil {
+		if req.Height != 0 {
+			return Source{}, fmt.Errorf("snapshot height can't be used with the archive URL %s", req.URL)
+		}
+		return archiveSource(ctx, client, req.URL)
+	}
+
+	indexURL := IndexURL(req.BaseURL, req.Network)
+	if req.URL != "" {
+		indexURL = IndexURL(req.URL, req.Network)
+		if strings.HasSuffix(req.URL, ".json") {
+			indexURL = req.URL
+		}
+	}
+	index, err := FetchIndex(ctx, client, indexURL)
+	if errors.Is(err, ErrNotFound) && req.Height == 0 {
+		name := req.Name
+		if name == "" {
+			name = LegacyName(req.Network, req.Kind, req.Format)
+		}
+		return archiveSource(ctx, client, strings.TrimSuffix(indexURL, IndexName)+name)
+	}
+	if err != nil {
+		return Source{}, err
+	}
+	if index.Network != "" && index.Network != req.Network {
+		return Source{}, fmt.Errorf("snapshot index %s is of %s, not %s", indexURL, index.Network, req.Network)
+	}
+	if index.Network == "" {
+		index.Network = req.Network
+	}
+
+	var entry Entry
+	if req.Name != "" {
+		var ok bool
+		if entry, ok = index.Find(req.Name); !ok {
+			return Source{}, fmt.Errorf("snapshot %s is not in the index %s", req.Name, indexURL)
+		}
+	} else if entry, err = index.Select(req.Kind, req.Height, req.Format); err != nil {
+		return Source{}, err
+	}
+	if entry.SHA256 == "" {
+		return Source{}, fmt.Errorf("snapshot %s has no checksum in the index %s", entry.Name, indexURL)
+	}
+	source := Source{SHA256: entry.SHA256, Height: entry.Height}
+	if source.URL, err = entry.URL(indexURL); err != nil {
+		return Source{}, err
+	}
+	if source.Format, err = Format(entry.Name); err != nil {
+		return Source{}, err
+	}
+	return source, nil
+}
+
+func archiveSource(ctx context.Context, client *http.Client, archiveURL string) (Source, error) {
+	format, err := Format(archiveURL)
+	if err != nil {
+		return Source{}, err
+	}
+	checksum, err := FetchChecksum(ctx, client, archiveURL)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return Source{}, err
+	}
+	return Source{URL: archiveURL, Format: format, SHA256: checksum}, nil
+}
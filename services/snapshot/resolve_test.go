@@ -0,0 +1,64 @@
+package snapshot
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/tj/assert"
+)
+
+func TestResolve(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/snapshots/testnet/index.json":
+			fmt.Fprint(w, `{"network":"testnet","snapshots":[`+
+				`{"name":"node-100.tar.gz","kind":"node","height":100,"sha256":"aa"},`+
+				`{"name":"node-200.tar.zst","kind":"node","height":200,"sha256":"bb"},`+
+				`{"name":"txind-200.tar.zst","kind":"txindex","height":200}]}`)
+		case "/snapshots/devnet/snapshot-latest-devnet-explorer.tar.zst.sha256":
+			fmt.Fprint(w, "cccccccccccccccccccccccccccccccccccccccccccccccccccccccccccccccc  snapshot-latest-devnet-explorer.tar.zst")
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	base := server.URL + "/snapshots/"
+	ctx := context.Background()
+
+	source, err := Resolve(ctx, server.Client(), Request{BaseURL: base, Network: "testnet", Kind: KindNode, Format: FormatZstd})
+	assert.Nil(t, err)
+	assert.Equal(t, Source{URL: base + "testnet/node-200.tar.zst", Format: FormatZstd, SHA256: "bb", Height: 200}, source)
+
+	source, err = Resolve(ctx, server.Client(), Request{BaseURL: base, Network: "testnet", Kind: KindNode, Height: 100})
+	assert.Nil(t, err)
+	assert.Equal(t, Source{URL: base + "testnet/node-100.tar.gz", Format: FormatGzip, SHA256: "aa", Height: 100}, source)
+
+	// the index is found by the base URL or its own URL
+	for _, url := range []string{base, base + "testnet/index.json"} {
+		source, err = Resolve(ctx, server.Client(), Request{BaseURL: "http://unused", URL: url, Network: "testnet", Name: "node-100.tar.gz"})
+		assert.Nil(t, err)
+		assert.Equal(t, base+"testnet/node-100.tar.gz", source.URL)
+	}
+
+	_, err = Resolve(ctx, server.Client(), Request{BaseURL: base, Network: "testnet", Kind: KindTxIndex})
+	assert.EqualError(t, err, "snapshot txind-200.tar.zst has no checksum in the index "+base+"testnet/index.json")
+	_, err = Resolve(ctx, server.Client(), Request{BaseURL: base, Network: "testnet", Kind: KindNode, Height: 150})
+	assert.EqualError(t, err, "no node snapshot of testnet at height 150")
+	_, err = Resolve(ctx, server.Client(), Request{URL: base + "testnet/index.json", Network: "mainnet", Kind: KindNode})
+	assert.EqualError(t, err, "snapshot index "+base+"testnet/index.json is of testnet, not mainnet")
+
+	// servers without the index have the latest snapshots only
+	source, err = Resolve(ctx, server.Client(), Request{BaseURL: base, Network: "devnet", Kind: KindExplorer, Format: FormatZstd})
+	assert.Nil(t, err)
+	assert.Equal(t, base+"devnet/snapshot-latest-devnet-explorer.tar.zst", source.URL)
+	assert.Equal(t, "cccccccccccccccccccccccccccccccccccccccccccccccccccccccccccccccc", source.SHA256)
+	_, err = Resolve(ctx, server.Client(), Request{BaseURL: base, Network: "devnet", Kind: KindNode, Height: 100})
+	assert.Contains(t, err.Error(), "not found")
+
+	source, err = Resolve(ctx, server.Client(), Request{URL: base + "mainnet/custom.tar.gz", Network: "mainnet"})
+	assert.Nil(t, err)
+	assert.Equal(t, Source{URL: base + "mainnet/custom.tar.gz", Format: FormatGzip}, source)
+}
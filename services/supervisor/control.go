@@ -0,0 +1,132 @@
+package supervisor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Commands of the control socket
+const (
+	CommandStatus  = "status"
+	CommandStart   = "start"
+	CommandStop    = "stop"
+	CommandRestart = "restart"
+)
+
+// ErrNotRunning is returned by Call, when no supervisor serves the socket
+var ErrNotRunning = errors.New("supervisor is not running")
+
+// Request is sent to the control socket of the supervisor, Name is the program, which start, stop and restart act on
+type Request struct {
+	Command string `json:"command"`
+	Name    string `json:"name,omitempty"`
+}
+
+// Response of the supervisor returns state of all programs after the command is done
+type Response struct {
+	Programs []Status `json:"programs,omitempty"`
+	Killed   bool     `json:"killed,omitempty"`
+	Error    string   `json:"error,omitempty"`
+}
+
+// Listen listens on the control socket. It fails if another supervisor serves the socket
+func Listen(socketPath string) (net.Listener, error) {
+	if conn, err := net.DialTimeout("unix", socketPath, time.Second); err == nil {
+		_ = conn.Close()
+		return nil, fmt.Errorf("supervisor is already running, its control socket is %s", socketPath)
+	}
+	// the socket is left by the supervisor, which has crashed
+	if err := os.Remove(socketPath); err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("unable to remove stale control socket: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(socketPath), 0700); err != nil {
+		return nil, err
+	}
+	listener, err := net.Listen("unix", socketPath)
+	if err != nil {
+		return nil, fmt.Errorf("unable to listen on control socket: %v", err)
+	}
+	if err = os.Chmod(socketPath, 0600); err != nil {
+		_ = listener.Close()
+		return nil, err
+	}
+	return listener, nil
+}
+
+// Serve answers the requests until ctx is done, then it closes the listener, which removes the socket
+func (s *Supervisor) Serve(ctx context.Context, listener net.Listener) error {
+	go func() {
+		<-ctx.Done()
+		_ = listener.Close()
+	}()
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("control socket: %v", err)
+		}
+		go s.handle(conn)
+	}
+}
+
+func (s *Supervisor) handle(conn net.Conn) {
+	defer conn.Close()
+
+	var req Request
+	var resp Response
+	if err := json.NewDecoder(conn).Decode(&req); err != nil {
+		resp.Error = fmt.Sprintf("invalid request: %v", err)
+		_ = json.NewEncoder(conn).Encode(resp)
+		return
+	}
+
+	var err error
+	switch req.Command {
+	case CommandStatus:
+	case CommandStart:
+		err = s.StartProgram(req.Name)
+	case CommandStop:
+		resp.Killed, err = s.StopProgram(req.Name)
+	case CommandRestart:
+		err = s.RestartProgram(req.Name)
+	default:
+		err = fmt.Errorf("unknown command %q", req.Command)
+	}
+	if err != nil {
+		resp.Error = err.Error()
+	}
+	resp.Programs = s.Status()
+	_ = json.NewEncoder(conn).Encode(resp)
+}
+
+// Call sends the request to the supervisor, which serves the socket, and waits up to timeout for the response
+func Call(socketPath string, req Request, timeout time.Duration) (Response, error) {
+	var resp Response
+	conn, err := net.DialTimeout("unix", socketPath, time.Second)
+	if err != nil {
+		return resp, fmt.Errorf("%w: %v", ErrNotRunning, err)
+	}
+	defer conn.Close()
+	if err = conn.SetDeadline(time.Now().Add(timeout)); err != nil {
+		return resp, err
+	}
+
+	if err = json.NewEncoder(conn).Encode(req); err != nil {
+		return resp, fmt.Errorf("unable to send request: %v", err)
+	}
+	if err = json.NewDecoder(conn).Decode(&resp); err != nil {
+		return resp, fmt.Errorf("unable to read response: %v", err)
+	}
+	if resp.Error != "" {
+		return resp, fmt.Errorf("%s", resp.Error)
+	}
+	return resp, nil
+}
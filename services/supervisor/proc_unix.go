@@ -0,0 +1,13 @@
+//go:build !windows
+
+package supervisor
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// setProcAttr starts the program in its own process group, so Ctrl-C in the terminal reaches the supervisor only
+func setProcAttr(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+}
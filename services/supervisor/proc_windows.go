@@ -0,0 +1,11 @@
+package supervisor
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// setProcAttr starts the program in its own process group, so Ctrl-C in the console reaches the supervisor only
+func setProcAttr(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{CreationFlags: syscall.CREATE_NEW_PROCESS_GROUP}
+}
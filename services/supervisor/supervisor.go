@@ -0,0 +1,366 @@
+package supervisor
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/pastelnetwork/pastelup/common/log"
+	"github.com/pastelnetwork/pastelup/common/log/hooks"
+	"github.com/pastelnetwork/pastelup/utils"
+)
+
+// State is the state of the supervised program
+type State string
+
+const (
+	// StateRunning - the program is running
+	StateRunning State = "running"
+	// StateBackoff - the program has exited and waits to be restarted
+	StateBackoff State = "backoff"
+	// StateStopping - the program is asked to exit
+	StateStopping State = "stopping"
+	// StateStopped - the program is not running and won't be restarted
+	StateStopped State = "stopped"
+)
+
+const (
+	defaultMinBackoff  = time.Second
+	defaultMaxBackoff  = 5 * time.Minute
+	defaultStableTime  = time.Minute
+	defaultStopTimeout = 3 * time.Minute
+)
+
+// Program is the process run by the supervisor
+type Program struct {
+	Name string   `json:"name"`
+	Path string   `json:"path"`
+	Args []string `json:"args,omitempty"`
+	Dir  string   `json:"dir,omitempty"`
+	// Ready waits until the started program serves requests, the next program is started after it returns
+	Ready func(ctx context.Context) error `json:"-"`
+	// PidFile is written when the program is started and removed when it exits
+	PidFile string `json:"-"`
+}
+
+// Options configure the supervisor, zero values are replaced with the defaults
+type Options struct {
+	// LogDir is the directory, where output of the program is written to <name>.log
+	LogDir string
+	// MinBackoff is the delay of the first restart, it is doubled with every next restart up to MaxBackoff
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+	// StableTime is how long the program must run for its restart delay to be reset
+	StableTime time.Duration
+	// StopTimeout is how long the program is waited for to exit before it is killed
+	StopTimeout time.Duration
+}
+
+// Status is the state of the supervised program
+type Status struct {
+	Name     string    `json:"name"`
+	State    State     `json:"state"`
+	Pid      int       `json:"pid,omitempty"`
+	Restarts int       `json:"restarts"`
+	Since    time.Time `json:"since"`
+	LastExit string    `json:"last-exit,omitempty"`
+}
+
+type process struct {
+	program Program
+	log     io.WriteCloser
+
+	mu       sync.Mutex
+	cmd      *exec.Cmd
+	done     chan struct{}
+	state    State
+	wanted   bool
+	restarts int
+	backoff  time.Duration
+	since    time.Time
+	lastExit string
+	timer    *time.Timer
+}
+
+// Supervisor starts the programs, restarts them when they crash and stops them in the reverse order
+type Supervisor struct {
+	opts  Options
+	procs []*process
+}
+
+// New returns the supervisor of the programs, which are started in the given order
+func New(programs []Program, opts Options) (*Supervisor, error) {
+	if opts.MinBackoff == 0 {
+		opts.MinBackoff = defaultMinBackoff
+	}
+	if opts.MaxBackoff == 0 {
+		opts.MaxBackoff = defaultMaxBackoff
+	}
+	if opts.StableTime == 0 {
+		opts.StableTime = defaultStableTime
+	}
+	if opts.StopTimeout == 0 {
+		opts.StopTimeout = defaultStopTimeout
+	}
+	if err := os.MkdirAll(opts.LogDir, 0755); err != nil {
+		return nil, fmt.Errorf("unable to create log dir %s: %v", opts.LogDir, err)
+	}
+
+	s := &Supervisor{opts: opts}
+	seen := make(map[string]bool)
+	for _, program := range programs {
+		if program.Name == "" || program.Path == "" {
+			return nil, fmt.Errorf("program must have name and path: %+v", program)
+		}
+		if seen[program.Name] {
+			return nil, fmt.Errorf("program %s is given twice", program.Name)
+		}
+		seen[program.Name] = true
+		s.procs = append(s.procs, &process{
+			program: program,
+			log:     hooks.NewRotatingFile(s.LogPath(program.Name)),
+			state:   StateStopped,
+		})
+	}
+	return s, nil
+}
+
+// LogPath returns path of the file with output of the program
+func (s *Supervisor) LogPath(name string) string {
+	return filepath.Join(s.opts.LogDir, name+".log")
+}
+
+// Start starts the programs one by one, the next program is started once the previous one is ready.
+// The program, which fails to start or to get ready, is restarted as if it crashed
+func (s *Supervisor) Start(ctx context.Context) {
+	for _, p := range s.procs {
+		p.mu.Lock()
+		p.wanted = true
+		if p.cmd == nil && p.timer == nil {
+			s.launch(p)
+		}
+		p.mu.Unlock()
+
+		if p.program.Ready == nil {
+			continue
+		}
+		if err := p.program.Ready(ctx); err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			log.WithContext(ctx).WithError(err).Warnf("%s is not ready, starting the next program anyway", p.program.Name)
+		}
+	}
+}
+
+// Shutdown stops the programs in the reverse order and closes their logs. It returns the programs, which had to be killed
+func (s *Supervisor) Shutdown() []string {
+	var killed []string
+	for i := len(s.procs) - 1; i >= 0; i-- {
+		if s.stop(s.procs[i]) {
+			killed = append(killed, s.procs[i].program.Name)
+		}
+	}
+	for _, p := range s.procs {
+		_ = p.log.Close()
+	}
+	return killed
+}
+
+// Status returns state of the programs in the start order
+func (s *Supervisor) Status() []Status {
+	var statuses []Status
+	for _, p := range s.procs {
+		p.mu.Lock()
+		status := Status{
+			Name:     p.program.Name,
+			State:    p.state,
+			Restarts: p.restarts,
+			Since:    p.since,
+			LastExit: p.lastExit,
+		}
+		if p.cmd != nil {
+			status.Pid = p.cmd.Process.Pid
+		}
+		p.mu.Unlock()
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
+
+// StartProgram starts the stopped program, it is a noop for the running one
+func (s *Supervisor) StartProgram(name string) error {
+	p, err := s.find(name)
+	if err != nil {
+		return err
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.wanted = true
+	if p.timer != nil {
+		p.timer.Stop()
+		p.timer = nil
+	}
+	if p.cmd == nil {
+		p.backoff = 0
+		s.launch(p)
+	}
+	return nil
+}
+
+// StopProgram stops the program, so it isn't restarted until it is started again. killed reports that it had to be killed
+func (s *Supervisor) StopProgram(name string) (killed bool, err error) {
+	p, err := s.find(name)
+	if err != nil {
+		return false, err
+	}
+	return s.stop(p), nil
+}
+
+// RestartProgram stops the program and starts it again
+func (s *Supervisor) RestartProgram(name string) error {
+	if _, err := s.StopProgram(name); err != nil {
+		return err
+	}
+	return s.StartProgram(name)
+}
+
+func (s *Supervisor) find(name string) (*process, error) {
+	for _, p := range s.procs {
+		if p.program.Name == name {
+			return p, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown program %s", name)
+}
+
+// launch starts the program, the program, which fails to start, is scheduled for restart. p.mu must be held
+func (s *Supervisor) launch(p *process) {
+	cmd := exec.Command(p.program.Path, p.program.Args...)
+	cmd.Dir = p.program.Dir
+	cmd.Stdout = p.log
+	cmd.Stderr = p.log
+	// the programs don't get terminal signals, so they are stopped by the supervisor in order
+	setProcAttr(cmd)
+
+	p.since = time.Now()
+	if err := cmd.Start(); err != nil {
+		p.lastExit = err.Error()
+		p.event("failed to start: %v", err)
+		s.scheduleRestart(p)
+		return
+	}
+	p.cmd = cmd
+	p.done = make(chan struct{})
+	p.state = StateRunning
+	p.event("started %s, pid %d", p.program.Path, cmd.Process.Pid)
+	if p.program.PidFile != "" {
+		if err := utils.WritePidFile(p.program.PidFile, cmd.Process.Pid); err != nil {
+			p.event("failed to write pid file: %v", err)
+		}
+	}
+	go s.wait(p, cmd, p.done)
+}
+
+func (s *Supervisor) wait(p *process, cmd *exec.Cmd, done chan struct{}) {
+	err := cmd.Wait()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	close(done)
+	p.cmd = nil
+	if p.program.PidFile != "" {
+		_ = utils.RemovePidFile(p.program.PidFile)
+	}
+	ran := time.Since(p.since)
+	p.since = time.Now()
+	if err != nil {
+		p.lastExit = err.Error()
+	} else {
+		p.lastExit = "exit status 0"
+	}
+	p.event("exited after %v: %s", ran.Round(time.Millisecond), p.lastExit)
+
+	if !p.wanted {
+		p.state = StateStopped
+		return
+	}
+	if ran >= s.opts.StableTime {
+		p.backoff = 0
+	}
+	s.scheduleRestart(p)
+}
+
+// scheduleRestart restarts the program after the next backoff delay. p.mu must be held
+func (s *Supervisor) scheduleRestart(p *process) {
+	p.backoff = nextBackoff(p.backoff, s.opts.MinBackoff, s.opts.MaxBackoff)
+	p.state = StateBackoff
+	p.event("restarting in %v", p.backoff)
+	p.timer = time.AfterFunc(p.backoff, func() {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		p.timer = nil
+		if !p.wanted || p.cmd != nil {
+			return
+		}
+		p.restarts++
+		s.launch(p)
+	})
+}
+
+// stop asks the program to exit with SIGTERM and kills it after the stop timeout, it returns true if it was killed
+func (s *Supervisor) stop(p *process) bool {
+	p.mu.Lock()
+	p.wanted = false
+	if p.timer != nil {
+		p.timer.Stop()
+		p.timer = nil
+	}
+	cmd, done := p.cmd, p.done
+	if cmd == nil {
+		p.state = StateStopped
+		p.mu.Unlock()
+		return false
+	}
+	p.state = StateStopping
+	p.event("stopping")
+	p.mu.Unlock()
+
+	// windows can't send SIGTERM, the program is killed at once
+	if err := cmd.Process.Signal(syscall.SIGTERM); err == nil {
+		select {
+		case <-done:
+			return false
+		case <-time.After(s.opts.StopTimeout):
+		}
+	}
+	_ = cmd.Process.Kill()
+	<-done
+
+	p.mu.Lock()
+	p.event("killed, it didn't exit in %v", s.opts.StopTimeout)
+	p.mu.Unlock()
+	return true
+}
+
+// event writes the supervisor event to the program log. p.mu must be held
+func (p *process) event(format string, args ...interface{}) {
+	_, _ = fmt.Fprintf(p.log, "[%s] supervisor: %s\n", time.Now().Format(time.RFC3339), fmt.Sprintf(format, args...))
+}
+
+// nextBackoff doubles the restart delay from min up to max
+func nextBackoff(backoff, min, max time.Duration) time.Duration {
+	if backoff < min {
+		return min
+	}
+	if backoff *= 2; backoff > max {
+		return max
+	}
+	return backoff
+}
@@ -0,0 +1,194 @@
+//go:build !windows
+
+package supervisor
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/tj/assert"
+
+	"github.com/pastelnetwork/pastelup/utils"
+)
+
+// TestHelperProcess isn't a real test, it is the program run by the supervisor in the tests below
+func TestHelperProcess(t *testing.T) {
+	switch os.Getenv("PASTELUP_HELPER_PROCESS") {
+	case "":
+		return
+	case "crash":
+		os.Stdout.WriteString("crashing\n")
+		os.Exit(1)
+	case "ignore-term":
+		signal.Ignore(syscall.SIGTERM)
+	}
+	os.Stdout.WriteString("running\n")
+	time.Sleep(time.Minute)
+	os.Exit(0)
+}
+
+func helperProgram(t *testing.T, name, mode string) Program {
+	t.Setenv("PASTELUP_HELPER_PROCESS", mode)
+	return Program{Name: name, Path: os.Args[0], Args: []string{"-test.run=^TestHelperProcess$"}}
+}
+
+func newTestSupervisor(t *testing.T, programs ...Program) *Supervisor {
+	s, err := New(programs, Options{
+		LogDir:      t.TempDir(),
+		MinBackoff:  10 * time.Millisecond,
+		MaxBackoff:  40 * time.Millisecond,
+		StopTimeout: 5 * time.Second,
+	})
+	assert.NoError(t, err)
+	t.Cleanup(func() { s.Shutdown() })
+	return s
+}
+
+func waitStatus(t *testing.T, s *Supervisor, index int, check func(Status) bool) Status {
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		status := s.Status()[index]
+		if check(status) {
+			return status
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("unexpected status: %+v", status)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestSupervisorRestartsCrashed(t *testing.T) {
+	s := newTestSupervisor(t, helperProgram(t, "crasher", "crash"))
+	s.Start(context.Background())
+
+	status := waitStatus(t, s, 0, func(status Status) bool { return status.Restarts >= 3 })
+	assert.Equal(t, "exit status 1", status.LastExit)
+
+	s.Shutdown()
+	data, err := os.ReadFile(s.LogPath("crasher"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), "crashing\n")
+	assert.Contains(t, string(data), "supervisor: restarting in 40ms")
+}
+
+func TestSupervisorStartOrder(t *testing.T) {
+	first := helperProgram(t, "first", "sleep")
+	second := helperProgram(t, "second", "sleep")
+	var s *Supervisor
+	var secondState State
+	first.Ready = func(context.Context) error {
+		secondState = s.Status()[1].State
+		return nil
+	}
+	s = newTestSupervisor(t, first, second)
+	s.Start(context.Background())
+
+	assert.Equal(t, StateStopped, secondState)
+	for i := range s.Status() {
+		waitStatus(t, s, i, func(status Status) bool { return status.State == StateRunning })
+	}
+	assert.Empty(t, s.Shutdown())
+	for _, status := range s.Status() {
+		assert.Equal(t, StateStopped, status.State)
+		assert.Zero(t, status.Pid)
+	}
+}
+
+func TestSupervisorPidFile(t *testing.T) {
+	program := helperProgram(t, "sleeper", "sleep")
+	program.PidFile = filepath.Join(t.TempDir(), "run", "sleeper.pid")
+	s := newTestSupervisor(t, program)
+	s.Start(context.Background())
+
+	status := waitStatus(t, s, 0, func(status Status) bool { return status.State == StateRunning })
+	pid, err := utils.ReadPidFile(program.PidFile)
+	assert.NoError(t, err)
+	assert.Equal(t, status.Pid, pid)
+
+	s.Shutdown()
+	_, err = os.Stat(program.PidFile)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestSupervisorStopEscalates(t *testing.T) {
+	s, err := New([]Program{helperProgram(t, "stubborn", "ignore-term")}, Options{
+		LogDir:      t.TempDir(),
+		StopTimeout: 50 * time.Millisecond,
+	})
+	assert.NoError(t, err)
+	s.Start(context.Background())
+	// the program writes to its log once it ignores SIGTERM
+	for i := 0; i < 1000; i++ {
+		if data, _ := os.ReadFile(s.LogPath("stubborn")); strings.Contains(string(data), "running\n") {
+			break
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	killed, err := s.StopProgram("stubborn")
+	assert.NoError(t, err)
+	assert.True(t, killed)
+	assert.Equal(t, StateStopped, s.Status()[0].State)
+	assert.Equal(t, []string(nil), s.Shutdown())
+
+	_, err = s.StopProgram("unknown")
+	assert.EqualError(t, err, "unknown program unknown")
+}
+
+func TestControlSocket(t *testing.T) {
+	s := newTestSupervisor(t, helperProgram(t, "worker", "sleep"))
+	socketPath := filepath.Join(t.TempDir(), "supervisor.sock")
+	listener, err := Listen(socketPath)
+	assert.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan error, 1)
+	go func() { served <- s.Serve(ctx, listener) }()
+	s.Start(ctx)
+
+	resp, err := Call(socketPath, Request{Command: CommandStatus}, time.Second)
+	assert.NoError(t, err)
+	assert.Len(t, resp.Programs, 1)
+	assert.Equal(t, StateRunning, resp.Programs[0].State)
+	pid := resp.Programs[0].Pid
+
+	resp, err = Call(socketPath, Request{Command: CommandRestart, Name: "worker"}, 10*time.Second)
+	assert.NoError(t, err)
+	assert.Equal(t, StateRunning, resp.Programs[0].State)
+	assert.NotEqual(t, pid, resp.Programs[0].Pid)
+
+	resp, err = Call(socketPath, Request{Command: CommandStop, Name: "worker"}, 10*time.Second)
+	assert.NoError(t, err)
+	assert.False(t, resp.Killed)
+	assert.Equal(t, StateStopped, resp.Programs[0].State)
+
+	_, err = Call(socketPath, Request{Command: CommandStart, Name: "nope"}, time.Second)
+	assert.EqualError(t, err, "unknown program nope")
+
+	// the socket is served by one supervisor only
+	_, err = Listen(socketPath)
+	assert.EqualError(t, err, "supervisor is already running, its control socket is "+socketPath)
+
+	cancel()
+	assert.NoError(t, <-served)
+	assert.NoFileExists(t, socketPath)
+	_, err = Call(socketPath, Request{Command: CommandStatus}, time.Second)
+	assert.True(t, errors.Is(err, ErrNotRunning))
+}
+
+func TestNextBackoff(t *testing.T) {
+	backoff := time.Duration(0)
+	var delays []time.Duration
+	for i := 0; i < 5; i++ {
+		backoff = nextBackoff(backoff, time.Second, 5*time.Second)
+		delays = append(delays, backoff)
+	}
+	assert.Equal(t, []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second}, delays)
+}
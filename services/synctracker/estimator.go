@@ -0,0 +1,97 @@
+package synctracker
+
+import (
+	"time"
+)
+
+const defaultWindow = 5 * time.Minute
+
+// Sample is the height of the chain seen at the time. Headers is the best known header, NetworkHeight is the best
+// height reported by the peers, both are 0 if unknown
+type Sample struct {
+	Time          time.Time
+	Blocks        int
+	Headers       int
+	NetworkHeight int
+}
+
+// Target returns the height the node syncs to
+func (s Sample) Target() int {
+	target := s.Blocks
+	if s.Headers > target {
+		target = s.Headers
+	}
+	if s.NetworkHeight > target {
+		target = s.NetworkHeight
+	}
+	return target
+}
+
+// Estimator computes the sync rate over the samples of the last window
+type Estimator struct {
+	Window  time.Duration
+	samples []Sample
+}
+
+// NewEstimator returns the estimator with the window, zero window means the default
+func NewEstimator(window time.Duration) *Estimator {
+	if window == 0 {
+		window = defaultWindow
+	}
+	return &Estimator{Window: window}
+}
+
+// Add adds the sample and drops the ones out of the window, the latest sample older than the window is kept
+// so the rate is computed over the whole window
+func (e *Estimator) Add(sample Sample) {
+	// pasteld restarted with the reindex or the chain was replaced, the old samples make no sense
+	if n := len(e.samples); n > 0 && sample.Blocks < e.samples[n-1].Blocks {
+		e.samples = nil
+	}
+	e.samples = append(e.samples, sample)
+	cutoff := sample.Time.Add(-e.Window)
+	drop := 0
+	for drop+1 < len(e.samples) && !e.samples[drop+1].Time.After(cutoff) {
+		drop++
+	}
+	e.samples = e.samples[drop:]
+}
+
+// Last returns the latest sample, ok is false if there are none
+func (e *Estimator) Last() (sample Sample, ok bool) {
+	if len(e.samples) == 0 {
+		return Sample{}, false
+	}
+	return e.samples[len(e.samples)-1], true
+}
+
+// Rate returns blocks per second over the window, it is 0 until there are two samples
+func (e *Estimator) Rate() float64 {
+	if len(e.samples) < 2 {
+		return 0
+	}
+	first, last := e.samples[0], e.samples[len(e.samples)-1]
+	elapsed := last.Time.Sub(first.Time).Seconds()
+	if elapsed <= 0 {
+		return 0
+	}
+	return float64(last.Blocks-first.Blocks) / elapsed
+}
+
+// ETA returns the time left until the node reaches its target height, ok is false while the rate is unknown.
+// The synced node has zero ETA
+func (e *Estimator) ETA() (eta time.Duration, ok bool) {
+	last, found := e.Last()
+	if !found {
+		return 0, false
+	}
+	left := last.Target() - last.Blocks
+	if left <= 0 {
+		return 0, true
+	}
+	rate := e.Rate()
+	if rate <= 0 {
+		return 0, false
+	}
+	return time.Duration(float64(left) / rate * float64(time.Second)), true
+}
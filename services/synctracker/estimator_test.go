@@ -0,0 +1,58 @@
+package synctracker
+
+import (
+	"testing"
+	"time"
+
+	"github.com/tj/assert"
+)
+
+func TestEstimator(t *testing.T) {
+	start := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	e := NewEstimator(time.Minute)
+
+	_, ok := e.ETA()
+	assert.False(t, ok)
+
+	e.Add(Sample{Time: start, Blocks: 1000, Headers: 5000})
+	assert.Equal(t, float64(0), e.Rate())
+	_, ok = e.ETA()
+	assert.False(t, ok)
+
+	e.Add(Sample{Time: start.Add(10 * time.Second), Blocks: 1100, Headers: 5000, NetworkHeight: 6100})
+	assert.Equal(t, float64(10), e.Rate())
+	eta, ok := e.ETA()
+	assert.True(t, ok)
+	// 5000 blocks to the network height at 10 blocks/s
+	assert.Equal(t, 500*time.Second, eta)
+
+	// the samples out of the window are dropped, but the one at its start is kept
+	e.Add(Sample{Time: start.Add(70 * time.Second), Blocks: 1400, Headers: 6100})
+	assert.Equal(t, float64(5), e.Rate())
+	e.Add(Sample{Time: start.Add(80 * time.Second), Blocks: 1500, Headers: 6100})
+	assert.Equal(t, float64(400)/70, e.Rate())
+
+	last, ok := e.Last()
+	assert.True(t, ok)
+	assert.Equal(t, 1500, last.Blocks)
+}
+
+func TestEstimatorSyncedAndReset(t *testing.T) {
+	start := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	e := NewEstimator(0)
+	assert.Equal(t, defaultWindow, e.Window)
+
+	e.Add(Sample{Time: start, Blocks: 6100, Headers: 6100})
+	eta, ok := e.ETA()
+	assert.True(t, ok)
+	assert.Equal(t, time.Duration(0), eta)
+
+	e.Add(Sample{Time: start.Add(time.Minute), Blocks: 6110, Headers: 6200})
+	assert.True(t, e.Rate() > 0)
+
+	// the height went down, e.g. pasteld is reindexing, so the rate starts over
+	e.Add(Sample{Time: start.Add(2 * time.Minute), Blocks: 10, Headers: 6200})
+	assert.Equal(t, float64(0), e.Rate())
+	_, ok = e.ETA()
+	assert.False(t, ok)
+}
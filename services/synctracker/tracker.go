@@ -0,0 +1,180 @@
+package synctracker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// PhaseInitial is the masternode sync phase, which pasteld may get stuck in, it is left by resetting the sync
+const PhaseInitial = "Initial"
+
+const (
+	defaultInterval     = 10 * time.Second
+	defaultStallTimeout = 30 * time.Minute
+)
+
+var (
+	// ErrTimeout is returned when the node isn't synced within the overall timeout
+	ErrTimeout = errors.New("sync timed out")
+	// ErrStalled is returned when the node makes no progress for the stall timeout and can't be restarted
+	ErrStalled = errors.New("sync stalled")
+)
+
+// Status is the sync state reported by pasteld. Phase is the masternode sync asset, e.g. MASTERNODE_SYNC_LIST
+type Status struct {
+	Sample
+	Connections int
+	Phase       string
+	Synced      bool
+	Failed      bool
+}
+
+// RPC is the part of pasteld RPC the tracker uses
+type RPC interface {
+	// Status returns blocks, headers and the masternode sync phase
+	Status(ctx context.Context) (Status, error)
+	// ResetMNSync restarts the masternode sync, which is stuck in the initial phase
+	ResetMNSync(ctx context.Context) error
+}
+
+// Progress is reported to Options.Report after every poll
+type Progress struct {
+	Status
+	Rate     float64
+	ETA      time.Duration
+	ETAKnown bool
+	Elapsed  time.Duration
+	// Idle is how long the node has made no progress
+	Idle time.Duration
+}
+
+// Options of the tracker, zero values are replaced with the defaults
+type Options struct {
+	// Interval between the polls
+	Interval time.Duration
+	// Timeout is the limit of the whole sync, zero means no limit
+	Timeout time.Duration
+	// StallTimeout is how long the node may make no progress - neither blocks, headers nor sync phase change
+	StallTimeout time.Duration
+	// Restart restarts pasteld, when the sync stalls. The tracker fails at once if it is nil
+	Restart func(ctx context.Context) error
+	// MaxRestarts is how many times pasteld is restarted before the tracker fails
+	MaxRestarts int
+	// Report gets the progress after every poll
+	Report func(Progress)
+}
+
+// Tracker waits for pasteld to sync the chain and masternode lists
+type Tracker struct {
+	rpc  RPC
+	opts Options
+
+	// now and sleep are replaced by tests
+	now   func() time.Time
+	sleep func(ctx context.Context, d time.Duration) error
+}
+
+// New returns the tracker of the node
+func New(rpc RPC, opts Options) *Tracker {
+	if opts.Interval == 0 {
+		opts.Interval = defaultInterval
+	}
+	if opts.StallTimeout == 0 {
+		opts.StallTimeout = defaultStallTimeout
+	}
+	return &Tracker{
+		rpc:   rpc,
+		opts:  opts,
+		now:   time.Now,
+		sleep: sleepContext,
+	}
+}
+
+// Wait polls pasteld until it is synced and returns its last status. It fails on RPC errors, when the masternode
+// sync fails, on the overall timeout and when the sync stalls and pasteld can't be restarted any more
+func (t *Tracker) Wait(ctx context.Context) (Status, error) {
+	started := t.now()
+	estimator := NewEstimator(0)
+	var last Status
+	lastProgress := started
+	restarts := 0
+
+	for first := true; ; first = false {
+		status, err := t.rpc.Status(ctx)
+		if err != nil {
+			return last, err
+		}
+		now := t.now()
+		status.Time = now
+		estimator.Add(status.Sample)
+		if first || madeProgress(last, status) {
+			lastProgress = now
+		}
+		last = status
+
+		progress := Progress{Status: status, Rate: estimator.Rate(), Elapsed: now.Sub(started), Idle: now.Sub(lastProgress)}
+		progress.ETA, progress.ETAKnown = estimator.ETA()
+		if t.opts.Report != nil {
+			t.opts.Report(progress)
+		}
+
+		switch {
+		case status.Synced:
+			return status, nil
+		case status.Failed:
+			return status, fmt.Errorf("masternode sync failed in phase %s", status.Phase)
+		case t.opts.Timeout > 0 && progress.Elapsed >= t.opts.Timeout:
+			return status, fmt.Errorf("%w after %v at block %d of %d, phase %s", ErrTimeout, t.opts.Timeout,
+				status.Blocks, status.Target(), status.Phase)
+		case progress.Idle >= t.opts.StallTimeout:
+			if t.opts.Restart == nil || restarts >= t.opts.MaxRestarts {
+				return status, stallError(status, progress.Idle, restarts)
+			}
+			restarts++
+			if err = t.opts.Restart(ctx); err != nil {
+				return status, fmt.Errorf("%v, restart of pasteld failed: %w", stallError(status, progress.Idle, restarts-1), err)
+			}
+			// pasteld starts over, e.g. with reloaded peers, so it gets the whole stall timeout again
+			lastProgress = t.now()
+			estimator = NewEstimator(0)
+		case status.Phase == PhaseInitial:
+			if err = t.rpc.ResetMNSync(ctx); err != nil {
+				return status, fmt.Errorf("unable to reset masternode sync: %w", err)
+			}
+		}
+
+		if err = t.sleep(ctx, t.opts.Interval); err != nil {
+			return last, err
+		}
+	}
+}
+
+// madeProgress reports that the node has got new blocks or headers or moved to the next sync phase
+func madeProgress(prev, cur Status) bool {
+	return cur.Blocks > prev.Blocks || cur.Headers > prev.Headers || cur.Phase != prev.Phase
+}
+
+// stallError tells where the sync has stalled and what to check
+func stallError(status Status, idle time.Duration, restarts int) error {
+	guidance := "check that the node has peers ('pastelup peers list', add them with 'pastelup peers add') and see debug.log"
+	if status.Connections == 0 {
+		guidance = "the node has no peers, add them with 'pastelup peers add' and check that the p2p port is reachable"
+	}
+	restarted := ""
+	if restarts > 0 {
+		restarted = fmt.Sprintf(" and %d restart(s) of pasteld", restarts)
+	}
+	return fmt.Errorf("%w: no progress for %v%s at block %d of %d, headers %d, phase %s: %s", ErrStalled,
+		idle.Round(time.Second), restarted, status.Blocks, status.Target(), status.Headers, status.Phase, guidance)
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(d):
+		return nil
+	}
+}
@@ -0,0 +1,176 @@
+package synctracker
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tj/assert"
+)
+
+// scriptedRPC returns the statuses one by one, the last one is repeated
+type scriptedRPC struct {
+	statuses []Status
+	calls    int
+	resets   int
+	err      error
+}
+
+func (r *scriptedRPC) Status(context.Context) (Status, error) {
+	if r.err != nil {
+		return Status{}, r.err
+	}
+	i := r.calls
+	if i >= len(r.statuses) {
+		i = len(r.statuses) - 1
+	}
+	r.calls++
+	return r.statuses[i], nil
+}
+
+func (r *scriptedRPC) ResetMNSync(context.Context) error {
+	r.resets++
+	return nil
+}
+
+// newTestTracker returns the tracker, whose clock moves only when it sleeps
+func newTestTracker(rpc RPC, opts Options) *Tracker {
+	tracker := New(rpc, opts)
+	clock := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	tracker.now = func() time.Time { return clock }
+	tracker.sleep = func(_ context.Context, d time.Duration) error {
+		clock = clock.Add(d)
+		return nil
+	}
+	return tracker
+}
+
+func status(blocks, headers int, phase string) Status {
+	return Status{Sample: Sample{Blocks: blocks, Headers: headers}, Connections: 8, Phase: phase}
+}
+
+func TestTrackerSynced(t *testing.T) {
+	synced := status(300, 300, "MASTERNODE_SYNC_FINISHED")
+	synced.Synced = true
+	rpc := &scriptedRPC{statuses: []Status{
+		status(100, 300, "MASTERNODE_SYNC_INITIAL"),
+		status(200, 300, "MASTERNODE_SYNC_INITIAL"),
+		status(300, 300, "MASTERNODE_SYNC_LIST"),
+		synced,
+	}}
+	var reports []Progress
+	tracker := newTestTracker(rpc, Options{Interval: 10 * time.Second, Report: func(p Progress) { reports = append(reports, p) }})
+
+	last, err := tracker.Wait(context.Background())
+	assert.Nil(t, err)
+	assert.True(t, last.Synced)
+	assert.Len(t, reports, 4)
+
+	assert.False(t, reports[0].ETAKnown)
+	assert.Equal(t, float64(10), reports[1].Rate)
+	assert.True(t, reports[1].ETAKnown)
+	assert.Equal(t, 10*time.Second, reports[1].ETA)
+	assert.Equal(t, 30*time.Second, reports[3].Elapsed)
+	assert.Equal(t, 0, rpc.resets)
+}
+
+func TestTrackerResetsInitialPhase(t *testing.T) {
+	synced := status(300, 300, "MASTERNODE_SYNC_FINISHED")
+	synced.Synced = true
+	rpc := &scriptedRPC{statuses: []Status{status(300, 300, PhaseInitial), status(300, 300, PhaseInitial), synced}}
+
+	_, err := newTestTracker(rpc, Options{}).Wait(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, 2, rpc.resets)
+}
+
+func TestTrackerTimeout(t *testing.T) {
+	var rpc scriptedRPC
+	for i := 1; i <= 100; i++ {
+		rpc.statuses = append(rpc.statuses, status(i, 1000, "MASTERNODE_SYNC_INITIAL"))
+	}
+
+	last, err := newTestTracker(&rpc, Options{Interval: time.Minute, Timeout: 10 * time.Minute}).Wait(context.Background())
+	assert.True(t, errors.Is(err, ErrTimeout))
+	assert.Equal(t, 11, last.Blocks)
+	assert.Contains(t, err.Error(), "at block 11 of 1000")
+}
+
+func TestTrackerStalled(t *testing.T) {
+	stuck := status(500, 1000, "MASTERNODE_SYNC_INITIAL")
+	stuck.Connections = 0
+	rpc := &scriptedRPC{statuses: []Status{status(400, 1000, "MASTERNODE_SYNC_INITIAL"), stuck}}
+	var idle time.Duration
+	tracker := newTestTracker(rpc, Options{Interval: time.Minute, StallTimeout: 5 * time.Minute,
+		Report: func(p Progress) { idle = p.Idle }})
+
+	_, err := tracker.Wait(context.Background())
+	assert.True(t, errors.Is(err, ErrStalled))
+	assert.Equal(t, 5*time.Minute, idle)
+	assert.Contains(t, err.Error(), "no progress for 5m0s at block 500 of 1000")
+	assert.Contains(t, err.Error(), "the node has no peers")
+	// 500 is reached at minute 1, stall is detected at minute 6
+	assert.Equal(t, 7, rpc.calls)
+}
+
+func TestTrackerRestartsStalled(t *testing.T) {
+	synced := status(1000, 1000, "MASTERNODE_SYNC_FINISHED")
+	synced.Synced = true
+	script := []Status{status(500, 1000, "MASTERNODE_SYNC_INITIAL")}
+	// stalled for 3 polls, then restarted pasteld syncs
+	script = append(script, script[0], script[0], script[0], status(800, 1000, "MASTERNODE_SYNC_INITIAL"), synced)
+	rpc := &scriptedRPC{statuses: script}
+
+	restarts := 0
+	tracker := newTestTracker(rpc, Options{Interval: time.Minute, StallTimeout: 3 * time.Minute, MaxRestarts: 1,
+		Restart: func(context.Context) error {
+			restarts++
+			return nil
+		}})
+	last, err := tracker.Wait(context.Background())
+	assert.Nil(t, err)
+	assert.True(t, last.Synced)
+	assert.Equal(t, 1, restarts)
+}
+
+func TestTrackerGivesUpAfterRestarts(t *testing.T) {
+	rpc := &scriptedRPC{statuses: []Status{status(500, 1000, "MASTERNODE_SYNC_INITIAL")}}
+	restarts := 0
+	tracker := newTestTracker(rpc, Options{Interval: time.Minute, StallTimeout: 3 * time.Minute, MaxRestarts: 2,
+		Restart: func(context.Context) error {
+			restarts++
+			return nil
+		}})
+
+	_, err := tracker.Wait(context.Background())
+	assert.True(t, errors.Is(err, ErrStalled))
+	assert.Equal(t, 2, restarts)
+	assert.Contains(t, err.Error(), "and 2 restart(s) of pasteld")
+	assert.Contains(t, err.Error(), "pastelup peers list")
+
+	restartErr := errors.New("pasteld didn't start")
+	tracker = newTestTracker(rpc, Options{Interval: time.Minute, StallTimeout: 3 * time.Minute, MaxRestarts: 1,
+		Restart: func(context.Context) error { return restartErr }})
+	_, err = tracker.Wait(context.Background())
+	assert.True(t, errors.Is(err, restartErr))
+	assert.True(t, strings.HasPrefix(err.Error(), "sync stalled"))
+}
+
+func TestTrackerErrors(t *testing.T) {
+	rpcErr := errors.New("connection refused")
+	_, err := newTestTracker(&scriptedRPC{err: rpcErr}, Options{}).Wait(context.Background())
+	assert.True(t, errors.Is(err, rpcErr))
+
+	failed := status(500, 500, "MASTERNODE_SYNC_FAILED")
+	failed.Failed = true
+	_, err = newTestTracker(&scriptedRPC{statuses: []Status{failed}}, Options{}).Wait(context.Background())
+	assert.EqualError(t, err, "masternode sync failed in phase MASTERNODE_SYNC_FAILED")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	tracker := New(&scriptedRPC{statuses: []Status{status(1, 2, "MASTERNODE_SYNC_INITIAL")}}, Options{})
+	_, err = tracker.Wait(ctx)
+	assert.True(t, errors.Is(err, context.Canceled))
+}
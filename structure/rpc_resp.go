@@ -102,6 +102,21 @@ func (s GetInfoResult) String() string {
 	return toString(s)
 }
 
+// RPCBlockchainInfo RPC result structure from getblockchaininfo
+type RPCBlockchainInfo struct {
+	Result BlockchainInfoResult `json:"result"`
+	Error  *RPCError            `json:"error,omitempty"`
+}
+
+// BlockchainInfoResult is the result field for the RPC command response
+type BlockchainInfoResult struct {
+	Chain                string  `json:"chain"`
+	Blocks               int     `json:"blocks"`
+	Headers              int     `json:"headers"`
+	BestBlockHash        string  `json:"bestblockhash"`
+	VerificationProgress float64 `json:"verificationprogress"`
+}
+
 // RPCMasternodeConf RPC result structure from masterode list-conf
 type RPCMasternodeConf struct {
 	Result MasternodeConfResult `json:"result"`
@@ -134,6 +149,93 @@ func (s MasternodeConfResult) String() string {
 	return toString(s)
 }
 
+// RPCError is the error field of an RPC command response
+type RPCError struct {
+	Code    int    `json:"code,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+// RPCBalance RPC result structure from getbalance and z_getbalance
+type RPCBalance struct {
+	Result float64   `json:"result"`
+	Error  *RPCError `json:"error,omitempty"`
+}
+
+// RPCStringResult RPC result structure for commands returning a single string, like getnewaddress or sendtoaddress
+type RPCStringResult struct {
+	Result string    `json:"result"`
+	Error  *RPCError `json:"error,omitempty"`
+}
+
+// RPCGetTransaction RPC result structure from gettransaction
+type RPCGetTransaction struct {
+	Result struct {
+		TxID          string `json:"txid"`
+		Confirmations int    `json:"confirmations"`
+	} `json:"result"`
+	Error *RPCError `json:"error,omitempty"`
+}
+
+// RPCTicketRegister RPC result structure from tickets register
+type RPCTicketRegister struct {
+	Result struct {
+		TxID string `json:"txid"`
+	} `json:"result"`
+	Error *RPCError `json:"error,omitempty"`
+}
+
+// RPCTicketFind RPC result structure from tickets find, the result is either a ticket object
+// or a plain "not found" string
+type RPCTicketFind struct {
+	Result json.RawMessage `json:"result"`
+	Error  *RPCError       `json:"error,omitempty"`
+}
+
+// Found returns true if tickets find has returned a ticket
+func (s RPCTicketFind) Found() bool {
+	for _, c := range s.Result {
+		switch c {
+		case ' ', '\t', '\n', '\r':
+			continue
+		case '{', '[':
+			return true
+		}
+		return false
+	}
+	return false
+}
+
+// RPCNullResult RPC result structure for commands returning nothing, like addnode or setban
+type RPCNullResult struct {
+	Result interface{} `json:"result"`
+	Error  *RPCError   `json:"error,omitempty"`
+}
+
+// RPCPeerInfo RPC result structure from getpeerinfo
+type RPCPeerInfo struct {
+	Result []PeerInfo `json:"result"`
+	Error  *RPCError  `json:"error,omitempty"`
+}
+
+// PeerInfo is the connected peer from getpeerinfo
+type PeerInfo struct {
+	ID             int     `json:"id"`
+	Addr           string  `json:"addr"`
+	SubVer         string  `json:"subver"`
+	Inbound        bool    `json:"inbound"`
+	ConnTime       int64   `json:"conntime"`
+	PingTime       float64 `json:"pingtime"`
+	StartingHeight int     `json:"startingheight"`
+	SyncedBlocks   int     `json:"synced_blocks"`
+	BanScore       int     `json:"banscore"`
+}
+
+// RPCMasternodeList RPC result structure from masternode list full, outpoint is mapped to masternode info
+type RPCMasternodeList struct {
+	Result map[string]string `json:"result"`
+	Error  *RPCError         `json:"error,omitempty"`
+}
+
 // TxInfo Transaction information
 type TxInfo struct {
 	Account         string
@@ -161,3 +263,14 @@ func toString(s interface{}) string {
 	}
 	return string(b)
 }
+
+// RPCPastelIDList RPC result structure from pastelid list
+type RPCPastelIDList struct {
+	Result []PastelIDKey `json:"result"`
+	Error  *RPCError     `json:"error,omitempty"`
+}
+
+// PastelIDKey is the PastelID, which key is stored in pastelkeys of pasteld
+type PastelIDKey struct {
+	PastelID string `json:"PastelID"`
+}
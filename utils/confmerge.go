@@ -0,0 +1,195 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ConfReader is a read only key/value view of the config file
+type ConfReader interface {
+	Keys() []string
+	Lookup(key string) (string, bool, error)
+}
+
+// ConfWriter is an editable key/value view of the config file
+type ConfWriter interface {
+	ConfReader
+	Set(key, value string) error
+	Unset(key string) bool
+}
+
+// ConfMergeAction is a result of the merge for the single key
+type ConfMergeAction string
+
+const (
+	// ConfMergeAdded - new default option added to the config
+	ConfMergeAdded ConfMergeAction = "added"
+	// ConfMergeUpdated - changed default value replaced value which wasn't changed by operator
+	ConfMergeUpdated ConfMergeAction = "updated"
+	// ConfMergeRemoved - option removed from defaults, and it wasn't changed by operator
+	ConfMergeRemoved ConfMergeAction = "removed"
+	// ConfMergeConflict - both operator and new defaults changed the option, operator's value is kept
+	ConfMergeConflict ConfMergeAction = "conflict"
+)
+
+// ConfMergeChange describes change of the single key done (or not done, for conflicts) by MergeConf
+type ConfMergeChange struct {
+	Key     string
+	Action  ConfMergeAction
+	Current string
+	Default string
+	Reason  string
+}
+
+func (c ConfMergeChange) String() string {
+	switch c.Action {
+	case ConfMergeAdded:
+		return fmt.Sprintf("%s %s = %q", c.Action, c.Key, c.Default)
+	case ConfMergeUpdated:
+		return fmt.Sprintf("%s %s: %q -> %q", c.Action, c.Key, c.Current, c.Default)
+	case ConfMergeRemoved:
+		return fmt.Sprintf("%s %s", c.Action, c.Key)
+	}
+	return fmt.Sprintf("%s %s: %s, keeping %q (new default %q)", c.Action, c.Key, c.Reason, c.Current, c.Default)
+}
+
+// MergeConf does three-way merge of the new defaults into the current config. base is the default config
+// the current config was created from, it may be nil if it is not known - then only missing options are added.
+// Options changed by operator are never overwritten, they are reported as conflicts if defaults changed too
+func MergeConf(base ConfReader, defaults ConfReader, current ConfWriter) ([]ConfMergeChange, error) {
+	lookupBase := func(key string) (string, bool) {
+		if base == nil {
+			return "", false
+		}
+		value, ok, err := base.Lookup(key)
+		return value, ok && err == nil
+	}
+
+	var changes []ConfMergeChange
+	for _, key := range defaults.Keys() {
+		def, _, err := defaults.Lookup(key)
+		if err != nil {
+			// nested lists are not merged
+			continue
+		}
+		cur, curOK, err := current.Lookup(key)
+		if err != nil {
+			changes = append(changes, ConfMergeChange{Key: key, Action: ConfMergeConflict, Default: def, Reason: err.Error()})
+			continue
+		}
+		old, oldOK := lookupBase(key)
+
+		switch {
+		case !curOK && (base == nil || !oldOK):
+			if err := current.Set(key, def); err != nil {
+				return nil, fmt.Errorf("failed to add %s: %v", key, err)
+			}
+			changes = append(changes, ConfMergeChange{Key: key, Action: ConfMergeAdded, Default: def})
+		case !curOK:
+			if def != old {
+				changes = append(changes, ConfMergeChange{Key: key, Action: ConfMergeConflict, Default: def,
+					Reason: "removed by operator, but its default changed"})
+			}
+		case cur == def || base == nil:
+		case oldOK && cur == old:
+			if err := current.Set(key, def); err != nil {
+				return nil, fmt.Errorf("failed to update %s: %v", key, err)
+			}
+			changes = append(changes, ConfMergeChange{Key: key, Action: ConfMergeUpdated, Current: cur, Default: def})
+		case oldOK && def == old:
+			// operator's override of unchanged default
+		default:
+			changes = append(changes, ConfMergeChange{Key: key, Action: ConfMergeConflict, Current: cur, Default: def,
+				Reason: "changed by operator and in new defaults"})
+		}
+	}
+
+	if base == nil {
+		return changes, nil
+	}
+	for _, key := range base.Keys() {
+		if Contains(defaults.Keys(), key) {
+			continue
+		}
+		old, oldOK := lookupBase(key)
+		cur, curOK, err := current.Lookup(key)
+		if !oldOK || !curOK || err != nil {
+			continue
+		}
+		if cur == old {
+			current.Unset(key)
+			changes = append(changes, ConfMergeChange{Key: key, Action: ConfMergeRemoved, Current: cur})
+		} else {
+			changes = append(changes, ConfMergeChange{Key: key, Action: ConfMergeConflict, Current: cur,
+				Reason: "removed from defaults, but changed by operator"})
+		}
+	}
+	return changes, nil
+}
+
+// DiffLines returns line diff of two texts in unified format, unchanged lines farther than
+// context lines from the changes are omitted. Empty result means texts are equal
+func DiffLines(a, b string, context int) []string {
+	x := strings.Split(strings.TrimSuffix(a, "\n"), "\n")
+	y := strings.Split(strings.TrimSuffix(b, "\n"), "\n")
+
+	// longest common subsequence table
+	lcs := make([][]int, len(x)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(y)+1)
+	}
+	for i := len(x) - 1; i >= 0; i-- {
+		for j := len(y) - 1; j >= 0; j-- {
+			if x[i] == y[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	var all []string
+	changed := false
+	i, j := 0, 0
+	for i < len(x) || j < len(y) {
+		switch {
+		case i < len(x) && j < len(y) && x[i] == y[j]:
+			all = append(all, " "+x[i])
+			i++
+			j++
+		case i < len(x) && (j == len(y) || lcs[i+1][j] >= lcs[i][j+1]):
+			all = append(all, "-"+x[i])
+			changed = true
+			i++
+		default:
+			all = append(all, "+"+y[j])
+			changed = true
+			j++
+		}
+	}
+	if !changed {
+		return nil
+	}
+
+	var out []string
+	skipped := false
+	for n, line := range all {
+		near := false
+		for k := n - context; k <= n+context; k++ {
+			if k >= 0 && k < len(all) && all[k][0] != ' ' {
+				near = true
+				break
+			}
+		}
+		if near {
+			out = append(out, line)
+			skipped = false
+		} else if !skipped {
+			out = append(out, "@@")
+			skipped = true
+		}
+	}
+	return out
+}
@@ -0,0 +1,93 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/tj/assert"
+)
+
+func TestMergeConf(t *testing.T) {
+	base, err := ParseYAMLConf([]byte(`
+node:
+  port: 4444
+  replicas: 1
+  timeout: 3m
+raptorq:
+  host: localhost
+old-option: 1
+customized-old-option: 1
+`))
+	assert.NoError(t, err)
+	defaults, err := ParseYAMLConf([]byte(`
+node:
+  port: 4444
+  replicas: 2
+  timeout: 5m
+raptorq:
+  host: localhost
+dd-server:
+  port: 50052
+`))
+	assert.NoError(t, err)
+	current, err := ParseYAMLConf([]byte(`# operator's config
+node:
+  port: 14444 # custom port
+  replicas: 1
+  timeout: 10m
+old-option: 1
+customized-old-option: 2
+extra: yes
+`))
+	assert.NoError(t, err)
+
+	changes, err := MergeConf(base, defaults, current)
+	assert.NoError(t, err)
+
+	actions := map[string]ConfMergeAction{}
+	for _, change := range changes {
+		actions[change.Key] = change.Action
+	}
+	assert.Equal(t, map[string]ConfMergeAction{
+		"node.replicas":         ConfMergeUpdated,
+		"node.timeout":          ConfMergeConflict,
+		"dd-server.port":        ConfMergeAdded,
+		"old-option":            ConfMergeRemoved,
+		"customized-old-option": ConfMergeConflict,
+	}, actions)
+
+	data, err := current.Bytes()
+	assert.NoError(t, err)
+	assert.Equal(t, `# operator's config
+node:
+  port: 14444 # custom port
+  replicas: 2
+  timeout: 10m
+customized-old-option: 2
+extra: yes
+dd-server:
+  port: 50052
+`, string(data))
+}
+
+func TestMergeConfWithoutBase(t *testing.T) {
+	defaults, err := ParseTOMLConf([]byte(`grpc-service = "127.0.0.1:50051"
+threads = 4
+`))
+	assert.NoError(t, err)
+	current, err := ParseTOMLConf([]byte(`grpc-service = "0.0.0.0:50051"
+`))
+	assert.NoError(t, err)
+
+	changes, err := MergeConf(nil, defaults, current)
+	assert.NoError(t, err)
+	assert.Equal(t, []ConfMergeChange{{Key: "threads", Action: ConfMergeAdded, Default: "4"}}, changes)
+	assert.Equal(t, `grpc-service = "0.0.0.0:50051"
+threads = 4
+`, string(current.Bytes()))
+}
+
+func TestDiffLines(t *testing.T) {
+	assert.Nil(t, DiffLines("a\nb\n", "a\nb\n", 1))
+	assert.Equal(t, []string{"@@", " c", "-d", "+x", " e", "@@"},
+		DiffLines("a\nb\nc\nd\ne\nf\ng\n", "a\nb\nc\nx\ne\nf\ng\n", 1))
+}
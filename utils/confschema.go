@@ -0,0 +1,139 @@
+package utils
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+)
+
+// ConfValueType is a type of the config value
+type ConfValueType int
+
+const (
+	// ConfString is any string value
+	ConfString ConfValueType = iota
+	// ConfInt is an integer value
+	ConfInt
+	// ConfBool is a boolean value - true/false or 1/0
+	ConfBool
+	// ConfPort is a TCP port in range 1-65535
+	ConfPort
+	// ConfHostPort is "host:port" address
+	ConfHostPort
+	// ConfFilePath is a path to the file, its directory must exist
+	ConfFilePath
+	// ConfExistingDir is a path to the existing directory
+	ConfExistingDir
+	// ConfPastelID is a PastelID - base58 encoded key starting with "jX"
+	ConfPastelID
+)
+
+var pastelIDRegexp = regexp.MustCompile(`^jX[1-9A-HJ-NP-Za-km-z]{84}$`)
+
+// ConfKeySchema describes single key of the config file
+type ConfKeySchema struct {
+	Key      string
+	Type     ConfValueType
+	Required bool
+}
+
+// ConfSchema describes keys of the config file
+type ConfSchema []ConfKeySchema
+
+// ConfIssue is a problem found in the config file
+type ConfIssue struct {
+	Key     string
+	Message string
+	// Warning issues don't prevent the component from starting
+	Warning bool
+}
+
+func (i ConfIssue) String() string {
+	if i.Key == "" {
+		return i.Message
+	}
+	return fmt.Sprintf("%s: %s", i.Key, i.Message)
+}
+
+// ConfLookupFunc returns scalar value of the key and whether it is set;
+// error is returned if the key is set, but it is not a scalar value
+type ConfLookupFunc func(key string) (string, bool, error)
+
+// Validate checks config values against the schema
+func (s ConfSchema) Validate(lookup ConfLookupFunc) []ConfIssue {
+	var issues []ConfIssue
+	for _, ks := range s {
+		value, found, err := lookup(ks.Key)
+		if err != nil {
+			issues = append(issues, ConfIssue{Key: ks.Key, Message: err.Error()})
+			continue
+		}
+		if !found || value == "" {
+			if ks.Required {
+				issues = append(issues, ConfIssue{Key: ks.Key, Message: "required value is missing"})
+			}
+			continue
+		}
+		if err := CheckConfValue(ks.Type, value); err != nil {
+			issues = append(issues, ConfIssue{Key: ks.Key, Message: err.Error()})
+		}
+	}
+	return issues
+}
+
+// Has returns true if the key is described by the schema
+func (s ConfSchema) Has(key string) bool {
+	for _, ks := range s {
+		if ks.Key == key {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckConfValue checks the value is of the given type
+func CheckConfValue(t ConfValueType, value string) error {
+	switch t {
+	case ConfInt:
+		if _, err := strconv.Atoi(value); err != nil {
+			return fmt.Errorf("%q is not an integer", value)
+		}
+	case ConfBool:
+		if _, err := strconv.ParseBool(value); err != nil {
+			return fmt.Errorf("%q is not a boolean", value)
+		}
+	case ConfPort:
+		return checkPort(value)
+	case ConfHostPort:
+		host, port, err := net.SplitHostPort(value)
+		if err != nil || host == "" {
+			return fmt.Errorf("%q is not a host:port address", value)
+		}
+		return checkPort(port)
+	case ConfFilePath:
+		dir := filepath.Dir(value)
+		if fi, err := os.Stat(dir); err != nil || !fi.IsDir() {
+			return fmt.Errorf("directory %s does not exist", dir)
+		}
+	case ConfExistingDir:
+		if fi, err := os.Stat(value); err != nil || !fi.IsDir() {
+			return fmt.Errorf("directory %s does not exist", value)
+		}
+	case ConfPastelID:
+		if !pastelIDRegexp.MatchString(value) {
+			return fmt.Errorf("%q is not a valid PastelID", value)
+		}
+	}
+	return nil
+}
+
+func checkPort(value string) error {
+	port, err := strconv.Atoi(value)
+	if err != nil || port < 1 || port > 65535 {
+		return fmt.Errorf("%q is not a valid port (1-65535)", value)
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package utils
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/tj/assert"
+)
+
+func TestConfSchemaValidate(t *testing.T) {
+	dir := t.TempDir()
+	conf, err := ParseYAMLConf([]byte(`
+log-config:
+  log-file: ` + filepath.Join(dir, "sn.log") + `
+work-dir: ` + filepath.Join(dir, "missing") + `
+node:
+  pastel_id: jXabc
+  server:
+    port: 70000
+p2p:
+  port:
+    value: 4445
+raptorq:
+  address: localhost:50051
+`))
+	assert.NoError(t, err)
+
+	schema := ConfSchema{
+		{Key: "log-config.log-file", Type: ConfFilePath},
+		{Key: "work-dir", Type: ConfExistingDir},
+		{Key: "node.pastel_id", Type: ConfPastelID},
+		{Key: "node.pass_phrase", Type: ConfString},
+		{Key: "node.server.port", Type: ConfPort, Required: true},
+		{Key: "p2p.port", Type: ConfPort, Required: true},
+		{Key: "raptorq.address", Type: ConfHostPort},
+		{Key: "dd-server.port", Type: ConfPort, Required: true},
+	}
+	var keys []string
+	for _, issue := range schema.Validate(conf.Lookup) {
+		keys = append(keys, issue.Key)
+	}
+	assert.Equal(t, []string{"work-dir", "node.pastel_id", "node.server.port", "p2p.port", "dd-server.port"}, keys)
+}
+
+func TestCheckConfValue(t *testing.T) {
+	assert.NoError(t, CheckConfValue(ConfPastelID, "jXYwVLikSSJfoX7s4VpX3osfMWnBk3Eahtv5p1bYQchaMiMVzAmPU57HMA7fz59ffxjd2Y57b9f7oGqfN5bYou"))
+	assert.Error(t, CheckConfValue(ConfPastelID, "jXYwVLikSSJfoX7s4VpX3osfMWnBk3Eahtv5p1bYQchaMiMVzAmPU57HMA7fz59ffxjd2Y57b9f7oGqfN5bYo0"))
+	assert.NoError(t, CheckConfValue(ConfBool, "1"))
+	assert.Error(t, CheckConfValue(ConfBool, "yes"))
+	assert.NoError(t, CheckConfValue(ConfHostPort, "127.0.0.1:50051"))
+	assert.Error(t, CheckConfValue(ConfHostPort, ":50051"))
+	assert.Error(t, CheckConfValue(ConfPort, "0"))
+	assert.NoError(t, CheckConfValue(ConfString, ""))
+}
@@ -0,0 +1,333 @@
+package utils
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pastelnetwork/pastelup/constants"
+	"github.com/pkg/errors"
+)
+
+// pastelConfMultiValueKeys are pastel.conf options which may be set more than once
+var pastelConfMultiValueKeys = map[string]bool{
+	"addnode":    true,
+	"bind":       true,
+	"connect":    true,
+	"debug":      true,
+	"externalip": true,
+	"onlynet":    true,
+	"rpcallowip": true,
+	"rpcbind":    true,
+	"seednode":   true,
+	"whitebind":  true,
+	"whitelist":  true,
+}
+
+// pastelConfKnownKeys are pastel.conf options recognized by pasteld
+var pastelConfKnownKeys = map[string]bool{
+	"addnode": true, "alertnotify": true, "bind": true, "blocknotify": true, "checkblocks": true,
+	"checklevel": true, "connect": true, "daemon": true, "datadir": true, "dbcache": true,
+	"debug": true, "devnet": true, "discover": true, "dns": true, "dnsseed": true,
+	"experimentalfeatures": true, "exportdir": true, "externalip": true, "gen": true, "genproclimit": true,
+	"insightexplorer": true, "keypool": true, "listen": true, "listenonion": true, "logips": true,
+	"masternode": true, "masternodeprivkey": true, "maxconnections": true, "maxmempool": true, "mempooltxinputlimit": true,
+	"minetolocalwallet": true, "onlynet": true, "par": true, "paytxfee": true, "pid": true,
+	"port": true, "printtoconsole": true, "proxy": true, "regtest": true, "reindex": true,
+	"rescan": true, "rpcallowip": true, "rpcbind": true, "rpcclienttimeout": true, "rpcconnect": true,
+	"rpccookiefile": true, "rpcpassword": true, "rpcport": true, "rpcthreads": true, "rpcuser": true,
+	"rpcworkqueue": true, "seednode": true, "server": true, "showmetrics": true, "spentindex": true,
+	"testnet": true, "timeout": true, "txexpirydelta": true, "txindex": true, "upnp": true,
+	"wallet": true, "walletnotify": true, "whitebind": true, "whitelist": true, "zapwallettxes": true,
+}
+
+// pastelConfNetworkKeys maps network mode to pastel.conf option which enables it, mainnet has none
+var pastelConfNetworkKeys = map[string]string{
+	constants.NetworkTestnet: "testnet",
+	constants.NetworkDevnet:  "devnet",
+	constants.NetworkRegTest: "regtest",
+}
+
+// IsPastelConfMultiValueKey returns true if the pastel.conf option can be set more than once
+func IsPastelConfMultiValueKey(key string) bool {
+	return pastelConfMultiValueKeys[key]
+}
+
+// IsPastelConfKnownKey returns true if the option is recognized by pasteld
+func IsPastelConfKnownKey(key string) bool {
+	return pastelConfKnownKeys[key]
+}
+
+// pastelConfLine is a single line of pastel.conf, lines without key (comments, blanks, sections)
+// are kept verbatim in raw
+type pastelConfLine struct {
+	key     string
+	value   string
+	comment string
+	raw     string
+}
+
+func (l pastelConfLine) String() string {
+	if l.key == "" {
+		return l.raw
+	}
+	s := l.key + "=" + l.value
+	if l.comment != "" {
+		s += " " + l.comment
+	}
+	return s
+}
+
+// PastelConf is an editable model of pastel.conf, which preserves comments and order of the options
+type PastelConf struct {
+	lines []pastelConfLine
+}
+
+// NewPastelConf returns an empty pastel.conf model
+func NewPastelConf() *PastelConf {
+	return &PastelConf{}
+}
+
+// ParsePastelConf parses content of pastel.conf
+func ParsePastelConf(data []byte) *PastelConf {
+	conf := &PastelConf{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		conf.lines = append(conf.lines, parsePastelConfLine(scanner.Text()))
+	}
+	return conf
+}
+
+func parsePastelConfLine(text string) pastelConfLine {
+	line := strings.TrimSpace(text)
+	if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "[") {
+		return pastelConfLine{raw: text}
+	}
+
+	var comment string
+	if i := strings.Index(line, "#"); i >= 0 {
+		comment = line[i:]
+		line = strings.TrimSpace(line[:i])
+	}
+
+	key, value, found := strings.Cut(line, "=")
+	key = strings.TrimSpace(key)
+	if !found || key == "" {
+		return pastelConfLine{raw: text}
+	}
+	return pastelConfLine{key: key, value: strings.TrimSpace(value), comment: comment}
+}
+
+// LoadPastelConf reads and parses pastel.conf file
+func LoadPastelConf(path string) (*PastelConf, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Errorf("failed to read %s: %v", path, err)
+	}
+	return ParsePastelConf(data), nil
+}
+
+// Bytes returns content of pastel.conf
+func (c *PastelConf) Bytes() []byte {
+	var buf bytes.Buffer
+	for _, l := range c.lines {
+		buf.WriteString(l.String())
+		buf.WriteString("\n")
+	}
+	return buf.Bytes()
+}
+
+// Save writes pastel.conf to the file, replacing it atomically. The file is made accessible by the owner only
+func (c *PastelConf) Save(path string) error {
+	// pastel.conf contains rpcpassword
+	return WriteSecretFile(path, c.Bytes())
+}
+
+// Has returns true if the option is set
+func (c *PastelConf) Has(key string) bool {
+	for _, l := range c.lines {
+		if l.key == key {
+			return true
+		}
+	}
+	return false
+}
+
+// Get returns value of the option, if option is set more than once - the last one wins as in pasteld
+func (c *PastelConf) Get(key string) string {
+	var value string
+	for _, l := range c.lines {
+		if l.key == key {
+			value = l.value
+		}
+	}
+	return value
+}
+
+// GetAll returns all values of the option in the order they appear in the file
+func (c *PastelConf) GetAll(key string) []string {
+	var values []string
+	for _, l := range c.lines {
+		if l.key == key {
+			values = append(values, l.value)
+		}
+	}
+	return values
+}
+
+// GetBool returns boolean value of the option, "1" and "true" are treated as true
+func (c *PastelConf) GetBool(key string) bool {
+	v, _ := strconv.ParseBool(c.Get(key))
+	return v
+}
+
+// GetInt returns integer value of the option, or 0 if it is not set or malformed
+func (c *PastelConf) GetInt(key string) int {
+	v, _ := strconv.Atoi(c.Get(key))
+	return v
+}
+
+// Set sets the option to the single value. The first occurrence is updated in place, other occurrences are removed
+func (c *PastelConf) Set(key, value string) {
+	var lines []pastelConfLine
+	found := false
+	for _, l := range c.lines {
+		if l.key == key {
+			if found {
+				continue
+			}
+			l.value = value
+			found = true
+		}
+		lines = append(lines, l)
+	}
+	if !found {
+		lines = append(lines, pastelConfLine{key: key, value: value})
+	}
+	c.lines = lines
+}
+
+// SetDefault sets the option only if it is not set yet
+func (c *PastelConf) SetDefault(key, value string) {
+	if !c.Has(key) {
+		c.Set(key, value)
+	}
+}
+
+// Add adds one more value to the multi value option, right after its last occurrence.
+// Returns false if the value is already present
+func (c *PastelConf) Add(key, value string) bool {
+	last := -1
+	for i, l := range c.lines {
+		if l.key == key {
+			if l.value == value {
+				return false
+			}
+			last = i
+		}
+	}
+	line := pastelConfLine{key: key, value: value}
+	if last < 0 {
+		c.lines = append(c.lines, line)
+		return true
+	}
+	c.lines = append(c.lines[:last+1], append([]pastelConfLine{line}, c.lines[last+1:]...)...)
+	return true
+}
+
+// Unset removes all occurrences of the option, returns false if it was not set
+func (c *PastelConf) Unset(key string) bool {
+	return c.remove(func(l pastelConfLine) bool { return l.key == key })
+}
+
+// RemoveValue removes single value of the multi value option, returns false if it was not set
+func (c *PastelConf) RemoveValue(key, value string) bool {
+	return c.remove(func(l pastelConfLine) bool { return l.key == key && l.value == value })
+}
+
+func (c *PastelConf) remove(match func(l pastelConfLine) bool) bool {
+	var lines []pastelConfLine
+	removed := false
+	for _, l := range c.lines {
+		if match(l) {
+			removed = true
+			continue
+		}
+		lines = append(lines, l)
+	}
+	c.lines = lines
+	return removed
+}
+
+// Keys returns names of all set options in the order of their first occurrence
+func (c *PastelConf) Keys() []string {
+	var keys []string
+	seen := make(map[string]bool)
+	for _, l := range c.lines {
+		if l.key != "" && !seen[l.key] {
+			seen[l.key] = true
+			keys = append(keys, l.key)
+		}
+	}
+	return keys
+}
+
+// Network returns network mode set in pastel.conf, mainnet if none of testnet, devnet or regtest is enabled
+func (c *PastelConf) Network() string {
+	networks := make([]string, 0, len(pastelConfNetworkKeys))
+	for network := range pastelConfNetworkKeys {
+		networks = append(networks, network)
+	}
+	sort.Strings(networks)
+	for _, network := range networks {
+		if c.GetBool(pastelConfNetworkKeys[network]) {
+			return network
+		}
+	}
+	return constants.NetworkMainnet
+}
+
+// SetNetwork enables the network mode and removes options of all other network modes
+func (c *PastelConf) SetNetwork(network string) {
+	for n, key := range pastelConfNetworkKeys {
+		if n != network {
+			c.Unset(key)
+		}
+	}
+	if key, ok := pastelConfNetworkKeys[network]; ok {
+		c.Set(key, "1")
+	}
+}
+
+// WriteFileAtomic writes data to the temporary file in the same directory and renames it over the target file
+func WriteFileAtomic(path string, data []byte, perm os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp")
+	if err != nil {
+		return errors.Errorf("failed to create temp file for %s: %v", path, err)
+	}
+	tmpName := tmp.Name()
+	defer os.Remove(tmpName)
+
+	if _, err = tmp.Write(data); err != nil {
+		tmp.Close()
+		return errors.Errorf("failed to write %s: %v", tmpName, err)
+	}
+	if err = tmp.Sync(); err != nil {
+		tmp.Close()
+		return errors.Errorf("failed to sync %s: %v", tmpName, err)
+	}
+	if err = tmp.Close(); err != nil {
+		return errors.Errorf("failed to close %s: %v", tmpName, err)
+	}
+	if err = os.Chmod(tmpName, perm); err != nil {
+		return errors.Errorf("failed to set permissions of %s: %v", tmpName, err)
+	}
+	if err = os.Rename(tmpName, path); err != nil {
+		return errors.Errorf("failed to replace %s: %v", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,170 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pastelnetwork/pastelup/constants"
+	"github.com/tj/assert"
+)
+
+const testPastelConf = `# pastel.conf managed by operator
+server=1
+rpcuser=user
+rpcport=9932 # custom port
+addnode=1.1.1.1
+addnode=2.2.2.2
+
+testnet=1
+devnet=1
+rpcport=19932
+`
+
+func TestPastelConfKeepsCommentsAndOrder(t *testing.T) {
+	conf := ParsePastelConf([]byte(testPastelConf))
+	assert.Equal(t, testPastelConf, string(conf.Bytes()))
+	assert.Equal(t, []string{"server", "rpcuser", "rpcport", "addnode", "testnet", "devnet"}, conf.Keys())
+}
+
+func TestPastelConfGet(t *testing.T) {
+	conf := ParsePastelConf([]byte(testPastelConf))
+	assert.Equal(t, "user", conf.Get("rpcuser"))
+	assert.Equal(t, 19932, conf.GetInt("rpcport"))
+	assert.Equal(t, []string{"1.1.1.1", "2.2.2.2"}, conf.GetAll("addnode"))
+	assert.Equal(t, "", conf.Get("rpcpassword"))
+	assert.False(t, conf.Has("rpcpassword"))
+}
+
+func TestPastelConfSetRemovesDuplicates(t *testing.T) {
+	conf := ParsePastelConf([]byte(testPastelConf))
+	conf.Set("rpcport", "9999")
+	conf.Set("rpcpassword", "secret")
+	assert.Equal(t, []string{"9999"}, conf.GetAll("rpcport"))
+	assert.Equal(t, `# pastel.conf managed by operator
+server=1
+rpcuser=user
+rpcport=9999 # custom port
+addnode=1.1.1.1
+addnode=2.2.2.2
+
+testnet=1
+devnet=1
+rpcpassword=secret
+`, string(conf.Bytes()))
+}
+
+func TestPastelConfMultiValue(t *testing.T) {
+	conf := ParsePastelConf([]byte(testPastelConf))
+	assert.True(t, conf.Add("addnode", "3.3.3.3"))
+	assert.False(t, conf.Add("addnode", "1.1.1.1"))
+	assert.Equal(t, []string{"1.1.1.1", "2.2.2.2", "3.3.3.3"}, conf.GetAll("addnode"))
+	assert.Equal(t, "addnode", conf.Keys()[3])
+
+	assert.True(t, conf.RemoveValue("addnode", "2.2.2.2"))
+	assert.False(t, conf.RemoveValue("addnode", "2.2.2.2"))
+	assert.Equal(t, []string{"1.1.1.1", "3.3.3.3"}, conf.GetAll("addnode"))
+
+	assert.True(t, conf.Unset("addnode"))
+	assert.False(t, conf.Has("addnode"))
+	assert.True(t, IsPastelConfMultiValueKey("externalip"))
+	assert.False(t, IsPastelConfMultiValueKey("rpcport"))
+}
+
+func TestPastelConfNetwork(t *testing.T) {
+	conf := ParsePastelConf([]byte(testPastelConf))
+	conf.SetNetwork(constants.NetworkTestnet)
+	assert.Equal(t, constants.NetworkTestnet, conf.Network())
+	assert.False(t, conf.Has("devnet"))
+
+	conf.SetNetwork(constants.NetworkMainnet)
+	assert.Equal(t, constants.NetworkMainnet, conf.Network())
+	assert.False(t, conf.Has("testnet"))
+}
+
+func TestPastelConfSaveKeepsPermissions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), constants.PastelConfName)
+	assert.NoError(t, os.WriteFile(path, []byte(testPastelConf), 0600))
+
+	conf, err := LoadPastelConf(path)
+	assert.NoError(t, err)
+	conf.Set("txindex", "1")
+	assert.NoError(t, conf.Save(path))
+
+	fi, err := os.Stat(path)
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), fi.Mode().Perm())
+
+	conf, err = LoadPastelConf(path)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, conf.GetInt("txindex"))
+}
+
+func TestPastelConfSaveLocksPermissions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), constants.PastelConfName)
+	assert.NoError(t, os.WriteFile(path, []byte(testPastelConf), 0644))
+
+	conf, err := LoadPastelConf(path)
+	assert.NoError(t, err)
+	assert.NoError(t, conf.Save(path))
+
+	fi, err := os.Stat(path)
+	assert.NoError(t, err)
+	assert.Equal(t, SecretFilePerm, fi.Mode().Perm())
+}
+
+func TestYAMLConfEdit(t *testing.T) {
+	conf, err := ParseYAMLConf([]byte(`# supernode config
+log-config:
+  log-file: /tmp/sn.log
+node:
+  pastel_id:
+  server:
+    port: 14444 # p2p
+`))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"log-config.log-file", "node.pastel_id", "node.server.port"}, conf.Keys())
+
+	value, ok := conf.Get("node.server.port")
+	assert.True(t, ok)
+	assert.Equal(t, "14444", value)
+
+	assert.Error(t, conf.Set("node.server.port", "abc"))
+	assert.NoError(t, conf.Set("node.server.port", "4444"))
+	assert.NoError(t, conf.Set("node.pastel_id", "jXY"))
+	assert.NoError(t, conf.Set("metadb.http_port", "4041"))
+	assert.Error(t, conf.Set("node.server.port.x", "1"))
+	assert.True(t, conf.Unset("log-config.log-file"))
+	assert.False(t, conf.Unset("log-config.log-file"))
+
+	data, err := conf.Bytes()
+	assert.NoError(t, err)
+	assert.Equal(t, `# supernode config
+log-config: {}
+node:
+  pastel_id: jXY
+  server:
+    port: 4444 # p2p
+metadb:
+  http_port: 4041
+`, string(data))
+}
+
+func TestTOMLConfEdit(t *testing.T) {
+	conf, err := ParseTOMLConf([]byte(`# rq-service
+grpc-service = "127.0.0.1:50051" # endpoint
+`))
+	assert.NoError(t, err)
+
+	value, ok := conf.Get("grpc-service")
+	assert.True(t, ok)
+	assert.Equal(t, "127.0.0.1:50051", value)
+
+	assert.NoError(t, conf.Set("grpc-service", "0.0.0.0:50051"))
+	assert.NoError(t, conf.Set("threads", "4"))
+	assert.Error(t, conf.Set("threads", "many"))
+	assert.Equal(t, `# rq-service
+grpc-service = "0.0.0.0:50051" # endpoint
+threads = 4
+`, string(conf.Bytes()))
+}
@@ -0,0 +1,77 @@
+package utils
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// MasternodeListEntry is the masternode from "masternode list full" output
+type MasternodeListEntry struct {
+	Outpoint string
+	Status   string
+	// Address is "ip:port" of the masternode's pasteld
+	Address string
+}
+
+// NormalizePeerAddress validates peer address in "host" or "host:port" format and returns it without extra spaces
+func NormalizePeerAddress(addr string) (string, error) {
+	addr = strings.TrimSpace(addr)
+	if addr == "" {
+		return "", fmt.Errorf("empty peer address")
+	}
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		// address without port, IPv6 address must be valid as it can't be told from host:port otherwise
+		if strings.Contains(addr, ":") && net.ParseIP(addr) == nil {
+			return "", fmt.Errorf("invalid peer address %q: %v", addr, err)
+		}
+		host, port = addr, ""
+	} else if port == "" {
+		return "", fmt.Errorf("invalid peer address %q: missing port", addr)
+	}
+	if host == "" || strings.ContainsAny(host, " /") {
+		return "", fmt.Errorf("invalid peer address %q", addr)
+	}
+	if port == "" {
+		if ip := net.ParseIP(host); ip != nil && ip.To4() == nil {
+			// IPv6 without port is kept in brackets, so the port can be added later
+			return "[" + ip.String() + "]", nil
+		}
+		return host, nil
+	}
+	if p, err := strconv.Atoi(port); err != nil || p <= 0 || p > 65535 {
+		return "", fmt.Errorf("invalid port in peer address %q", addr)
+	}
+	return net.JoinHostPort(host, port), nil
+}
+
+// PeerHost returns host part of the peer address
+func PeerHost(addr string) string {
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		return host
+	}
+	return strings.Trim(addr, "[]")
+}
+
+// ParseMasternodeListFull parses result of "masternode list full" - outpoint mapped to
+// "status protocol payee lastseen activeseconds lastpaidtime lastpaidblock address".
+// Entries are sorted by outpoint, the ones without valid address are skipped
+func ParseMasternodeListFull(list map[string]string) []MasternodeListEntry {
+	var entries []MasternodeListEntry
+	for outpoint, info := range list {
+		fields := strings.Fields(info)
+		if len(fields) < 2 {
+			continue
+		}
+		addr, err := NormalizePeerAddress(fields[len(fields)-1])
+		if err != nil {
+			continue
+		}
+		entries = append(entries, MasternodeListEntry{Outpoint: outpoint, Status: fields[0], Address: addr})
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Outpoint < entries[j].Outpoint })
+	return entries
+}
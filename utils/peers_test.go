@@ -0,0 +1,46 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/tj/assert"
+)
+
+func TestNormalizePeerAddress(t *testing.T) {
+	for addr, expected := range map[string]string{
+		"1.2.3.4":             "1.2.3.4",
+		" 1.2.3.4:9933 ":      "1.2.3.4:9933",
+		"node.pastel.network": "node.pastel.network",
+		"[::1]:19933":         "[::1]:19933",
+		"2001:db8::1":         "[2001:db8::1]",
+	} {
+		actual, err := NormalizePeerAddress(addr)
+		assert.NoError(t, err, addr)
+		assert.Equal(t, expected, actual, addr)
+	}
+
+	for _, addr := range []string{"", "1.2.3.4:", "1.2.3.4:0", "1.2.3.4:70000", "1.2.3.4:port", "a b", "10.0.0.0/8"} {
+		_, err := NormalizePeerAddress(addr)
+		assert.Error(t, err, addr)
+	}
+}
+
+func TestPeerHost(t *testing.T) {
+	assert.Equal(t, "1.2.3.4", PeerHost("1.2.3.4:9933"))
+	assert.Equal(t, "1.2.3.4", PeerHost("1.2.3.4"))
+	assert.Equal(t, "2001:db8::1", PeerHost("[2001:db8::1]"))
+	assert.Equal(t, "2001:db8::1", PeerHost("[2001:db8::1]:9933"))
+}
+
+func TestParseMasternodeListFull(t *testing.T) {
+	entries := ParseMasternodeListFull(map[string]string{
+		"b-1": "  ENABLED 170008 PtPayee 1690000000 3600 0 0 5.6.7.8:9933",
+		"a-0": "NEW_START_REQUIRED 170008 PtPayee 1690000000 0 0 0 1.2.3.4:9933",
+		"c-2": "ENABLED 170008 PtPayee 1690000000 3600 0 0 not:an:address",
+		"d-3": "",
+	})
+	assert.Equal(t, []MasternodeListEntry{
+		{Outpoint: "a-0", Status: "NEW_START_REQUIRED", Address: "1.2.3.4:9933"},
+		{Outpoint: "b-1", Status: "ENABLED", Address: "5.6.7.8:9933"},
+	}, entries)
+}
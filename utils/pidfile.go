@@ -0,0 +1,91 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// startTimeTolerance is the difference of the start times of the same process. Start time is computed from the boot
+// time on linux, which shifts by a second between reads and after NTP clock steps, and has a second resolution on macOS
+const startTimeTolerance = 2 * time.Second
+
+// ErrProcessInfoUnsupported is returned by ProcessInfo on the OS, which can't tell executable and start time of the process
+var ErrProcessInfoUnsupported = errors.New("process info is not supported")
+
+// PidFile is written for the process started by pastelup, so the process is found later without matching its name.
+// Executable and start time tell the process from another one, which has got the same pid after it exited
+type PidFile struct {
+	Pid        int       `json:"pid"`
+	Executable string    `json:"executable"`
+	StartTime  time.Time `json:"start-time"`
+}
+
+// WritePidFile records the running process with pid in the file
+func WritePidFile(path string, pid int) error {
+	pidFile := PidFile{Pid: pid}
+	var err error
+	pidFile.Executable, pidFile.StartTime, err = ProcessInfo(pid)
+	if err != nil && !errors.Is(err, ErrProcessInfoUnsupported) {
+		return fmt.Errorf("failed to get info of process %d: %v", pid, err)
+	}
+
+	data, err := json.MarshalIndent(pidFile, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// ReadPidFile returns pid of the process recorded in the file, if the process is still running. The file of the exited
+// process is stale, it is removed and 0 is returned. Missing file means the process isn't running
+func ReadPidFile(path string) (int, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return 0, nil
+	} else if err != nil {
+		return 0, err
+	}
+	var pidFile PidFile
+	if err = json.Unmarshal(data, &pidFile); err != nil {
+		return 0, fmt.Errorf("invalid pid file %s: %v", path, err)
+	}
+
+	if pidFile.isRunning() {
+		return pidFile.Pid, nil
+	}
+	if err = os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return 0, fmt.Errorf("failed to remove stale pid file: %v", err)
+	}
+	return 0, nil
+}
+
+// RemovePidFile removes the file of the process, which has exited
+func RemovePidFile(path string) error {
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// isRunning checks that the process with the pid is the recorded one
+func (p PidFile) isRunning() bool {
+	if !IsProcessRunning(p.Pid) {
+		return false
+	}
+	executable, started, err := ProcessInfo(p.Pid)
+	if errors.Is(err, ErrProcessInfoUnsupported) {
+		return true
+	} else if err != nil {
+		// the process has exited in the meantime or belongs to another user
+		return false
+	}
+	diff := started.Sub(p.StartTime)
+	return executable == p.Executable && diff <= startTimeTolerance && diff >= -startTimeTolerance
+}
@@ -0,0 +1,31 @@
+//go:build darwin
+// +build darwin
+
+package utils
+
+import (
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ProcessInfo returns the executable and start time of the running process
+func ProcessInfo(pid int) (executable string, started time.Time, err error) {
+	out, err := exec.Command("ps", "-o", "comm=", "-p", strconv.Itoa(pid)).Output()
+	if err != nil {
+		return "", started, fmt.Errorf("process %d is not found: %v", pid, err)
+	}
+	executable = strings.TrimSpace(string(out))
+
+	out, err = exec.Command("ps", "-o", "lstart=", "-p", strconv.Itoa(pid)).Output()
+	if err != nil {
+		return "", started, fmt.Errorf("process %d is not found: %v", pid, err)
+	}
+	started, err = time.ParseInLocation("Mon Jan _2 15:04:05 2006", strings.TrimSpace(string(out)), time.Local)
+	if err != nil {
+		return "", started, fmt.Errorf("invalid start time of process %d: %v", pid, err)
+	}
+	return executable, started.UTC(), nil
+}
@@ -0,0 +1,71 @@
+//go:build linux
+// +build linux
+
+package utils
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// clockTicks is USER_HZ, the unit of the process start time in /proc/<pid>/stat. It is 100 on all supported platforms
+const clockTicks = 100
+
+// ProcessInfo returns the executable and start time of the running process
+func ProcessInfo(pid int) (executable string, started time.Time, err error) {
+	executable, err = os.Readlink(fmt.Sprintf("/proc/%d/exe", pid))
+	if err != nil {
+		return "", started, err
+	}
+	// the binary has been replaced by the update, while the process is running
+	executable = strings.TrimSuffix(executable, " (deleted)")
+
+	stat, err := os.ReadFile(fmt.Sprintf("/proc/%d/stat", pid))
+	if err != nil {
+		return "", started, err
+	}
+	// the command name in parentheses may contain spaces, so the fields are counted after it
+	end := strings.LastIndexByte(string(stat), ')')
+	if end < 0 {
+		return "", started, fmt.Errorf("invalid stat of process %d", pid)
+	}
+	fields := strings.Fields(string(stat[end+1:]))
+	// starttime is the 22nd field of stat, the 20th after the command name
+	if len(fields) < 20 {
+		return "", started, fmt.Errorf("invalid stat of process %d", pid)
+	}
+	ticks, err := strconv.ParseInt(fields[19], 10, 64)
+	if err != nil {
+		return "", started, fmt.Errorf("invalid start time of process %d: %v", pid, err)
+	}
+
+	bootTime, err := systemBootTime()
+	if err != nil {
+		return "", started, err
+	}
+	started = time.Unix(bootTime, 0).Add(time.Duration(ticks) * time.Second / clockTicks).UTC()
+	return executable, started, nil
+}
+
+func systemBootTime() (int64, error) {
+	file, err := os.Open("/proc/stat")
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if value := strings.TrimPrefix(scanner.Text(), "btime "); value != scanner.Text() {
+			return strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+		}
+	}
+	if err = scanner.Err(); err != nil {
+		return 0, err
+	}
+	return 0, fmt.Errorf("boot time is not found in /proc/stat")
+}
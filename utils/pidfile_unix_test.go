@@ -0,0 +1,80 @@
+//go:build !windows
+
+package utils
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/tj/assert"
+)
+
+func TestPidFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "run", "pasteld.pid")
+	pid := startHelperProcess(t, "sleep")
+
+	assert.NoError(t, WritePidFile(path, pid))
+	got, err := ReadPidFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, pid, got)
+
+	executable, _, err := ProcessInfo(pid)
+	assert.NoError(t, err)
+	self, err := os.Executable()
+	assert.NoError(t, err)
+	assert.Equal(t, self, executable)
+
+	assert.NoError(t, syscall.Kill(pid, syscall.SIGKILL))
+	assert.True(t, WaitProcessExit(pid, 5*time.Second, 10*time.Millisecond))
+
+	got, err = ReadPidFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, got)
+	_, err = os.Stat(path)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestPidFileReusedPid(t *testing.T) {
+	pid := startHelperProcess(t, "sleep")
+	executable, started, err := ProcessInfo(pid)
+	assert.NoError(t, err)
+
+	tests := map[string]PidFile{
+		"start time": {Pid: pid, Executable: executable, StartTime: started.Add(-time.Hour)},
+		"executable": {Pid: pid, Executable: "/usr/bin/pasteld", StartTime: started},
+	}
+	for name, pidFile := range tests {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "pasteld.pid")
+			data, err := json.Marshal(pidFile)
+			assert.NoError(t, err)
+			assert.NoError(t, os.WriteFile(path, data, 0644))
+
+			got, err := ReadPidFile(path)
+			assert.NoError(t, err)
+			assert.Equal(t, 0, got)
+			_, err = os.Stat(path)
+			assert.True(t, os.IsNotExist(err))
+		})
+	}
+	assert.True(t, IsProcessRunning(pid))
+
+	// boot time of linux shifts between reads, the process with slightly different start time is the recorded one
+	path := filepath.Join(t.TempDir(), "pasteld.pid")
+	data, err := json.Marshal(PidFile{Pid: pid, Executable: executable, StartTime: started.Add(-time.Second)})
+	assert.NoError(t, err)
+	assert.NoError(t, os.WriteFile(path, data, 0644))
+	got, err := ReadPidFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, pid, got)
+}
+
+func TestReadPidFileMissing(t *testing.T) {
+	got, err := ReadPidFile(filepath.Join(t.TempDir(), "pasteld.pid"))
+	assert.NoError(t, err)
+	assert.Equal(t, 0, got)
+}
@@ -0,0 +1,11 @@
+//go:build windows
+// +build windows
+
+package utils
+
+import "time"
+
+// ProcessInfo isn't supported on windows, the pid file is checked only for the running process
+func ProcessInfo(pid int) (executable string, started time.Time, err error) {
+	return "", started, ErrProcessInfoUnsupported
+}
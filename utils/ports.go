@@ -0,0 +1,111 @@
+package utils
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// PortOwner is the process listening on the port
+type PortOwner struct {
+	Pid  int
+	Name string
+	// Args is the command line of the process, interpreters are told apart by their scripts
+	Args []string
+}
+
+func (o PortOwner) String() string {
+	if o.Pid == 0 {
+		return "unknown process"
+	}
+	return fmt.Sprintf("%s (pid %d)", o.Name, o.Pid)
+}
+
+// IsPortFree checks if the TCP port can be listened on all interfaces
+func IsPortFree(port int) bool {
+	l, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
+	if err != nil {
+		return false
+	}
+	_ = l.Close()
+	return true
+}
+
+// FindFreePort returns the first free port after the port, which is not in the exclude list
+func FindFreePort(port int, exclude []int) (int, error) {
+	for p := port + 1; p <= 65535 && p <= port+1000; p++ {
+		excluded := false
+		for _, e := range exclude {
+			excluded = excluded || e == p
+		}
+		if !excluded && IsPortFree(p) {
+			return p, nil
+		}
+	}
+	return 0, fmt.Errorf("no free port found after %d", port)
+}
+
+// FindPortOwner returns the process listening on the TCP port. It works on Linux only,
+// zero PortOwner is returned if the owner can't be found
+func FindPortOwner(port int) PortOwner {
+	inodes := map[string]bool{}
+	for _, path := range []string{"/proc/net/tcp", "/proc/net/tcp6"} {
+		for _, inode := range listeningSocketInodes(path, port) {
+			inodes[inode] = true
+		}
+	}
+	if len(inodes) == 0 {
+		return PortOwner{}
+	}
+
+	fds, _ := filepath.Glob("/proc/[0-9]*/fd/*")
+	for _, fd := range fds {
+		link, err := os.Readlink(fd)
+		if err != nil || !strings.HasPrefix(link, "socket:[") {
+			continue
+		}
+		if !inodes[strings.TrimSuffix(strings.TrimPrefix(link, "socket:["), "]")] {
+			continue
+		}
+		procDir := filepath.Dir(filepath.Dir(fd))
+		pid, _ := strconv.Atoi(filepath.Base(procDir))
+		name, _ := os.ReadFile(filepath.Join(procDir, "comm"))
+		owner := PortOwner{Pid: pid, Name: strings.TrimSpace(string(name))}
+		if cmdline, err := os.ReadFile(filepath.Join(procDir, "cmdline")); err == nil && len(cmdline) > 0 {
+			owner.Args = strings.Split(strings.TrimSuffix(string(cmdline), "\x00"), "\x00")
+		}
+		return owner
+	}
+	return PortOwner{}
+}
+
+// listeningSocketInodes parses /proc/net/tcp* file and returns inodes of the sockets listening on the port
+func listeningSocketInodes(path string, port int) []string {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+
+	const listenState = "0A"
+	var inodes []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 10 || fields[3] != listenState {
+			continue
+		}
+		i := strings.LastIndex(fields[1], ":")
+		if i < 0 {
+			continue
+		}
+		if p, err := strconv.ParseInt(fields[1][i+1:], 16, 32); err == nil && int(p) == port {
+			inodes = append(inodes, fields[9])
+		}
+	}
+	return inodes
+}
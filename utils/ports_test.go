@@ -0,0 +1,32 @@
+package utils
+
+import (
+	"net"
+	"os"
+	"runtime"
+	"testing"
+
+	"github.com/tj/assert"
+)
+
+func TestPortProbe(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	defer l.Close()
+	port := l.Addr().(*net.TCPAddr).Port
+
+	assert.False(t, IsPortFree(port))
+
+	free, err := FindFreePort(port, []int{port + 1})
+	assert.NoError(t, err)
+	assert.NotEqual(t, port+1, free)
+	assert.True(t, IsPortFree(free))
+
+	if runtime.GOOS == "linux" {
+		owner := FindPortOwner(port)
+		assert.Equal(t, os.Getpid(), owner.Pid)
+		assert.NotEmpty(t, owner.Name)
+		assert.Equal(t, os.Args, owner.Args)
+	}
+	assert.Equal(t, "unknown process", PortOwner{}.String())
+}
@@ -0,0 +1,62 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"syscall"
+	"time"
+)
+
+// killWaitTime is how long the killed process is waited for to disappear
+const killWaitTime = 10 * time.Second
+
+// IsProcessRunning checks if the process with pid exists
+func IsProcessRunning(pid int) bool {
+	if pid <= 0 {
+		return false
+	}
+	return processAlive(pid)
+}
+
+// WaitProcessExit checks every interval if the process with pid has exited, it returns false
+// if the process is still running after timeout
+func WaitProcessExit(pid int, timeout, interval time.Duration) bool {
+	deadline := time.Now().Add(timeout)
+	for IsProcessRunning(pid) {
+		if time.Now().After(deadline) {
+			return false
+		}
+		time.Sleep(interval)
+	}
+	return true
+}
+
+// TerminateProcess asks the process to exit with SIGTERM and waits up to timeout for it. The process, which is
+// still running after the timeout or doesn't support SIGTERM, is killed - killed reports that
+func TerminateProcess(pid int, timeout, interval time.Duration) (killed bool, err error) {
+	if !IsProcessRunning(pid) {
+		return false, nil
+	}
+	proc, err := os.FindProcess(pid)
+	if err != nil {
+		return false, fmt.Errorf("failed to find process %d: %v", pid, err)
+	}
+
+	// windows can't send SIGTERM, the process is killed at once
+	if err = proc.Signal(syscall.SIGTERM); err == nil {
+		if WaitProcessExit(pid, timeout, interval) {
+			return false, nil
+		}
+	} else if errors.Is(err, os.ErrProcessDone) {
+		return false, nil
+	}
+
+	if err = proc.Kill(); err != nil && !errors.Is(err, os.ErrProcessDone) {
+		return true, fmt.Errorf("failed to kill process %d: %v", pid, err)
+	}
+	if !WaitProcessExit(pid, killWaitTime, interval) {
+		return true, fmt.Errorf("process %d is still running after it was killed", pid)
+	}
+	return true, nil
+}
@@ -0,0 +1,42 @@
+//go:build !windows
+
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"syscall"
+)
+
+func processAlive(pid int) bool {
+	proc, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	err = proc.Signal(syscall.Signal(0))
+	// the process of another user can't be signaled, but it exists
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
+
+// LockHolderPid returns pid of the process, which holds POSIX lock of the file, or 0 if the file isn't locked.
+// pasteld locks .lock file in its data dir this way while it runs
+func LockHolderPid(path string) (int, error) {
+	file, err := os.Open(filepath.Clean(path))
+	if os.IsNotExist(err) {
+		return 0, nil
+	} else if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	lock := syscall.Flock_t{Type: syscall.F_WRLCK}
+	if err = syscall.FcntlFlock(file.Fd(), syscall.F_GETLK, &lock); err != nil {
+		return 0, fmt.Errorf("failed to check lock of %s: %v", path, err)
+	}
+	if lock.Type == syscall.F_UNLCK {
+		return 0, nil
+	}
+	return int(lock.Pid), nil
+}
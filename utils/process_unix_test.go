@@ -0,0 +1,110 @@
+//go:build !windows
+
+package utils
+
+import (
+	"bufio"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/tj/assert"
+)
+
+// TestHelperProcess isn't a real test, it is the fake process started by the tests below
+func TestHelperProcess(t *testing.T) {
+	mode := os.Getenv("PASTELUP_HELPER_PROCESS")
+	if mode == "" {
+		return
+	}
+	switch mode {
+	case "ignore-term":
+		signal.Ignore(syscall.SIGTERM)
+	case "lock":
+		file, err := os.OpenFile(os.Getenv("PASTELUP_HELPER_LOCK"), os.O_RDWR|os.O_CREATE, 0600)
+		if err != nil {
+			os.Exit(1)
+		}
+		lock := syscall.Flock_t{Type: syscall.F_WRLCK}
+		if err = syscall.FcntlFlock(file.Fd(), syscall.F_SETLK, &lock); err != nil {
+			os.Exit(1)
+		}
+	}
+	os.Stdout.WriteString("ready\n")
+	time.Sleep(time.Minute)
+	os.Exit(0)
+}
+
+// startHelperProcess starts the fake process and waits until it is set up, the process is reaped once it exits
+func startHelperProcess(t *testing.T, mode string, env ...string) int {
+	cmd := exec.Command(os.Args[0], "-test.run=^TestHelperProcess$")
+	cmd.Env = append(os.Environ(), append(env, "PASTELUP_HELPER_PROCESS="+mode)...)
+	stdout, err := cmd.StdoutPipe()
+	assert.NoError(t, err)
+	assert.NoError(t, cmd.Start())
+	go func() { _ = cmd.Wait() }()
+	t.Cleanup(func() { _ = cmd.Process.Kill() })
+
+	line, err := bufio.NewReader(stdout).ReadString('\n')
+	assert.NoError(t, err)
+	assert.Equal(t, "ready\n", line)
+	return cmd.Process.Pid
+}
+
+func TestTerminateProcess(t *testing.T) {
+	pid := startHelperProcess(t, "sleep")
+	assert.True(t, IsProcessRunning(pid))
+
+	killed, err := TerminateProcess(pid, 5*time.Second, 10*time.Millisecond)
+	assert.NoError(t, err)
+	assert.False(t, killed)
+	assert.False(t, IsProcessRunning(pid))
+
+	// already exited
+	killed, err = TerminateProcess(pid, time.Second, 10*time.Millisecond)
+	assert.NoError(t, err)
+	assert.False(t, killed)
+}
+
+func TestTerminateProcessEscalates(t *testing.T) {
+	pid := startHelperProcess(t, "ignore-term")
+
+	killed, err := TerminateProcess(pid, 100*time.Millisecond, 10*time.Millisecond)
+	assert.NoError(t, err)
+	assert.True(t, killed)
+	assert.False(t, IsProcessRunning(pid))
+}
+
+func TestWaitProcessExit(t *testing.T) {
+	pid := startHelperProcess(t, "sleep")
+	assert.False(t, WaitProcessExit(pid, 50*time.Millisecond, 10*time.Millisecond))
+	assert.False(t, IsProcessRunning(0))
+}
+
+func TestLockHolderPid(t *testing.T) {
+	lockPath := filepath.Join(t.TempDir(), ".lock")
+
+	pid, err := LockHolderPid(lockPath)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, pid)
+
+	assert.NoError(t, os.WriteFile(lockPath, nil, 0600))
+	pid, err = LockHolderPid(lockPath)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, pid)
+
+	holder := startHelperProcess(t, "lock", "PASTELUP_HELPER_LOCK="+lockPath)
+	pid, err = LockHolderPid(lockPath)
+	assert.NoError(t, err)
+	assert.Equal(t, holder, pid)
+
+	_, err = TerminateProcess(holder, 5*time.Second, 10*time.Millisecond)
+	assert.NoError(t, err)
+	pid, err = LockHolderPid(lockPath)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, pid)
+}
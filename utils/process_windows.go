@@ -0,0 +1,20 @@
+package utils
+
+import (
+	"os"
+)
+
+func processAlive(pid int) bool {
+	// FindProcess opens the process handle on windows, so it fails if the process is gone
+	proc, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	_ = proc.Release()
+	return true
+}
+
+// LockHolderPid isn't supported on windows, it always reports the file as not locked
+func LockHolderPid(_ string) (int, error) {
+	return 0, nil
+}
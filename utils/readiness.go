@@ -0,0 +1,53 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrWarmingUp is returned by the readiness probe of the component, which is alive, but still loading.
+// Waiting for such component is not limited by the timeout
+var ErrWarmingUp = errors.New("warming up")
+
+type permanentError struct {
+	err error
+}
+
+func (e permanentError) Error() string { return e.err.Error() }
+func (e permanentError) Unwrap() error { return e.err }
+
+// PermanentProbeError marks the probe failure, which won't be fixed by waiting, like the exited process
+func PermanentProbeError(err error) error {
+	return permanentError{err: err}
+}
+
+// WaitReady runs the probe every interval until it succeeds. It fails if the probe fails permanently,
+// the timeout expires or ctx is done, the error tells how the last probe failed.
+// Probe returning ErrWarmingUp restarts the timeout
+func WaitReady(ctx context.Context, timeout, interval time.Duration, probe func(context.Context) error) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		err := probe(ctx)
+		if err == nil {
+			return nil
+		}
+		var permanent permanentError
+		if errors.As(err, &permanent) {
+			return permanent.err
+		}
+		if errors.Is(err, ErrWarmingUp) {
+			deadline = time.Now().Add(timeout)
+		}
+		if time.Now().Add(interval).After(deadline) {
+			return fmt.Errorf("timed out after %v: %w", timeout, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%v: %w", ctx.Err(), err)
+		case <-time.After(interval):
+		}
+	}
+}
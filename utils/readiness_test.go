@@ -0,0 +1,61 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/tj/assert"
+)
+
+func TestWaitReady(t *testing.T) {
+	ctx := context.Background()
+
+	calls := 0
+	err := WaitReady(ctx, time.Second, time.Millisecond, func(context.Context) error {
+		calls++
+		if calls < 3 {
+			return errors.New("connection refused")
+		}
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, calls)
+
+	err = WaitReady(ctx, 20*time.Millisecond, 5*time.Millisecond, func(context.Context) error {
+		return errors.New("connection refused")
+	})
+	assert.EqualError(t, err, "timed out after 20ms: connection refused")
+
+	calls = 0
+	err = WaitReady(ctx, time.Minute, time.Millisecond, func(context.Context) error {
+		calls++
+		return PermanentProbeError(errors.New("process exited"))
+	})
+	assert.EqualError(t, err, "process exited")
+	assert.Equal(t, 1, calls)
+}
+
+func TestWaitReadyWarmingUp(t *testing.T) {
+	calls := 0
+	err := WaitReady(context.Background(), 20*time.Millisecond, 5*time.Millisecond, func(context.Context) error {
+		calls++
+		if calls < 10 {
+			// warming up longer than the timeout
+			return fmt.Errorf("loading block index: %w", ErrWarmingUp)
+		}
+		return nil
+	})
+	assert.NoError(t, err)
+}
+
+func TestWaitReadyCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err := WaitReady(ctx, time.Minute, time.Second, func(context.Context) error {
+		return errors.New("connection refused")
+	})
+	assert.EqualError(t, err, "context canceled: connection refused")
+}
@@ -0,0 +1,75 @@
+package utils
+
+import (
+	"bufio"
+	"crypto/rand"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// SecretFilePerm is the permission of the files containing secrets
+const SecretFilePerm os.FileMode = 0600
+
+// ReadSecretFile reads the secret from the first line of the file, "-" means stdin
+func ReadSecretFile(path string) (string, error) {
+	var r io.Reader
+	name := path
+	if path == "-" {
+		r, name = os.Stdin, "stdin"
+	} else {
+		f, err := os.Open(path)
+		if err != nil {
+			return "", fmt.Errorf("failed to read secret file %s: %v", path, err)
+		}
+		defer f.Close()
+		r = f
+	}
+
+	line, err := bufio.NewReader(r).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return "", fmt.Errorf("failed to read secret from %s: %v", name, err)
+	}
+	secret := strings.TrimRight(line, "\r\n")
+	if secret == "" {
+		return "", fmt.Errorf("secret in %s is empty", name)
+	}
+	return secret, nil
+}
+
+// IsSecretFileExposed checks if the secret file is accessible by group or others
+func IsSecretFileExposed(path string) bool {
+	fi, err := os.Stat(path)
+	return err == nil && fi.Mode().Perm()&0077 != 0
+}
+
+// WriteSecretFile atomically writes the file accessible by the owner only
+func WriteSecretFile(path string, data []byte) error {
+	return WriteFileAtomic(path, data, SecretFilePerm)
+}
+
+// ShellQuote quotes the string for POSIX shell
+func ShellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'"'"'`) + "'"
+}
+
+// GenerateSecret returns random alphanumeric string of the length from the cryptographically secure source
+func GenerateSecret(length int) (string, error) {
+	const chars = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789"
+	// bytes above the largest multiple of len(chars) are skipped, so all chars are equally likely
+	const limit = 256 - 256%len(chars)
+	secret := make([]byte, 0, length)
+	buf := make([]byte, length)
+	for len(secret) < length {
+		if _, err := rand.Read(buf); err != nil {
+			return "", err
+		}
+		for _, b := range buf {
+			if int(b) < limit && len(secret) < length {
+				secret = append(secret, chars[int(b)%len(chars)])
+			}
+		}
+	}
+	return string(secret), nil
+}
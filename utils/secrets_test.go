@@ -0,0 +1,42 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/tj/assert"
+)
+
+func TestReadSecretFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "pass")
+	assert.NoError(t, os.WriteFile(path, []byte("my secret\r\nsecond line\n"), 0644))
+	secret, err := ReadSecretFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, "my secret", secret)
+	assert.True(t, IsSecretFileExposed(path))
+
+	empty := filepath.Join(dir, "empty")
+	assert.NoError(t, WriteSecretFile(empty, []byte("\n")))
+	_, err = ReadSecretFile(empty)
+	assert.Error(t, err)
+	assert.False(t, IsSecretFileExposed(empty))
+
+	_, err = ReadSecretFile(filepath.Join(dir, "missing"))
+	assert.Error(t, err)
+}
+
+func TestShellQuote(t *testing.T) {
+	assert.Equal(t, `'it'"'"'s'`, ShellQuote("it's"))
+}
+
+func TestGenerateSecret(t *testing.T) {
+	a, err := GenerateSecret(32)
+	assert.NoError(t, err)
+	assert.Regexp(t, `^[A-Za-z0-9]{32}$`, a)
+
+	b, err := GenerateSecret(32)
+	assert.NoError(t, err)
+	assert.NotEqual(t, a, b)
+}
@@ -0,0 +1,192 @@
+package utils
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// tomlConfLine is a single line of flat TOML file, lines without key (comments, blanks, tables)
+// are kept verbatim in raw
+type tomlConfLine struct {
+	key     string
+	value   string // TOML encoded value, strings are quoted
+	comment string
+	raw     string
+}
+
+// TOMLConf is an editable model of the flat TOML config file (rqservice.toml),
+// which preserves comments and order of the keys
+type TOMLConf struct {
+	lines []tomlConfLine
+}
+
+// ParseTOMLConf parses content of the flat TOML config file
+func ParseTOMLConf(data []byte) (*TOMLConf, error) {
+	conf := &TOMLConf{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for n := 1; scanner.Scan(); n++ {
+		text := scanner.Text()
+		line := strings.TrimSpace(text)
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "[") {
+			conf.lines = append(conf.lines, tomlConfLine{raw: text})
+			continue
+		}
+		key, value, found := strings.Cut(line, "=")
+		key = strings.TrimSpace(key)
+		if !found || key == "" {
+			return nil, errors.Errorf("line %d: expected key = value", n)
+		}
+		value, comment := splitTOMLComment(strings.TrimSpace(value))
+		conf.lines = append(conf.lines, tomlConfLine{key: key, value: value, comment: comment})
+	}
+	return conf, nil
+}
+
+// splitTOMLComment splits trailing comment from the value, '#' inside quoted strings is not a comment
+func splitTOMLComment(value string) (string, string) {
+	var quote rune
+	for i, r := range value {
+		switch {
+		case quote != 0 && r == quote && (quote == '\'' || i == 0 || value[i-1] != '\\'):
+			quote = 0
+		case quote == 0 && (r == '"' || r == '\''):
+			quote = r
+		case quote == 0 && r == '#':
+			return strings.TrimSpace(value[:i]), value[i:]
+		}
+	}
+	return value, ""
+}
+
+// LoadTOMLConf reads and parses the flat TOML config file
+func LoadTOMLConf(path string) (*TOMLConf, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Errorf("failed to read %s: %v", path, err)
+	}
+	return ParseTOMLConf(data)
+}
+
+// Bytes returns content of the TOML config file
+func (c *TOMLConf) Bytes() []byte {
+	var buf bytes.Buffer
+	for _, l := range c.lines {
+		if l.key == "" {
+			buf.WriteString(l.raw)
+		} else {
+			buf.WriteString(l.key + " = " + l.value)
+			if l.comment != "" {
+				buf.WriteString(" " + l.comment)
+			}
+		}
+		buf.WriteString("\n")
+	}
+	return buf.Bytes()
+}
+
+// Save writes the TOML config to the file, replacing it atomically. Existing file permissions are kept
+func (c *TOMLConf) Save(path string) error {
+	perm := os.FileMode(0644)
+	if fi, err := os.Stat(path); err == nil {
+		perm = fi.Mode().Perm()
+	}
+	return WriteFileAtomic(path, c.Bytes(), perm)
+}
+
+// Get returns decoded value of the key
+func (c *TOMLConf) Get(key string) (string, bool) {
+	for _, l := range c.lines {
+		if l.key == key {
+			return decodeTOMLValue(l.value), true
+		}
+	}
+	return "", false
+}
+
+// Lookup returns decoded value of the key, flat TOML values are always scalars
+func (c *TOMLConf) Lookup(key string) (string, bool, error) {
+	value, ok := c.Get(key)
+	return value, ok, nil
+}
+
+// Set sets value of the key. Value is written as TOML string unless the current value or
+// the new value itself is number or boolean
+func (c *TOMLConf) Set(key, value string) error {
+	for i, l := range c.lines {
+		if l.key == key {
+			encoded, err := encodeTOMLValue(l.value, value)
+			if err != nil {
+				return errors.Errorf("invalid value for %s: %v", key, err)
+			}
+			c.lines[i].value = encoded
+			return nil
+		}
+	}
+	encoded, _ := encodeTOMLValue("", value)
+	c.lines = append(c.lines, tomlConfLine{key: key, value: encoded})
+	return nil
+}
+
+// Unset removes the key, returns false if it was not set
+func (c *TOMLConf) Unset(key string) bool {
+	for i, l := range c.lines {
+		if l.key == key {
+			c.lines = append(c.lines[:i], c.lines[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// Keys returns all keys in the file order
+func (c *TOMLConf) Keys() []string {
+	var keys []string
+	for _, l := range c.lines {
+		if l.key != "" {
+			keys = append(keys, l.key)
+		}
+	}
+	return keys
+}
+
+func decodeTOMLValue(value string) string {
+	if strings.HasPrefix(value, "'") && strings.HasSuffix(value, "'") && len(value) >= 2 {
+		return value[1 : len(value)-1]
+	}
+	if s, err := strconv.Unquote(value); err == nil {
+		return s
+	}
+	return value
+}
+
+func encodeTOMLValue(current string, value string) (string, error) {
+	isString := strings.HasPrefix(current, "\"") || strings.HasPrefix(current, "'")
+	if current != "" && !isString {
+		if current == "true" || current == "false" {
+			if value != "true" && value != "false" {
+				return "", errors.Errorf("%q is not bool", value)
+			}
+			return value, nil
+		}
+		if _, err := strconv.ParseFloat(current, 64); err == nil {
+			if _, err := strconv.ParseFloat(value, 64); err != nil {
+				return "", errors.Errorf("%q is not a number", value)
+			}
+			return value, nil
+		}
+	}
+	if current == "" {
+		if value == "true" || value == "false" {
+			return value, nil
+		}
+		if _, err := strconv.ParseFloat(value, 64); err == nil {
+			return value, nil
+		}
+	}
+	return strconv.Quote(value), nil
+}
@@ -0,0 +1,243 @@
+package utils
+
+import (
+	"bytes"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// YAMLConf is an editable model of the YAML config file (supernode.yml, walletnode.yml, hermes.yml),
+// which preserves comments and order of the keys. Nested keys are addressed by dot separated path - "node.server.port"
+type YAMLConf struct {
+	doc *yaml.Node
+}
+
+// ParseYAMLConf parses content of the YAML config file
+func ParseYAMLConf(data []byte) (*YAMLConf, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, errors.Errorf("failed to parse yaml: %v", err)
+	}
+	if doc.Kind == 0 {
+		doc = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode, Tag: "!!map"}}}
+	}
+	if doc.Kind != yaml.DocumentNode || len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return nil, errors.New("yaml config must be a mapping at the top level")
+	}
+	return &YAMLConf{doc: &doc}, nil
+}
+
+// LoadYAMLConf reads and parses the YAML config file
+func LoadYAMLConf(path string) (*YAMLConf, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Errorf("failed to read %s: %v", path, err)
+	}
+	return ParseYAMLConf(data)
+}
+
+// Bytes returns content of the YAML config file
+func (c *YAMLConf) Bytes() ([]byte, error) {
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(c.doc); err != nil {
+		return nil, errors.Errorf("failed to encode yaml: %v", err)
+	}
+	if err := enc.Close(); err != nil {
+		return nil, errors.Errorf("failed to encode yaml: %v", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// Save writes the YAML config to the file, replacing it atomically. Existing file permissions are kept
+func (c *YAMLConf) Save(path string) error {
+	data, err := c.Bytes()
+	if err != nil {
+		return err
+	}
+	perm := os.FileMode(0644)
+	if fi, err := os.Stat(path); err == nil {
+		perm = fi.Mode().Perm()
+	}
+	return WriteFileAtomic(path, data, perm)
+}
+
+func (c *YAMLConf) root() *yaml.Node {
+	return c.doc.Content[0]
+}
+
+// lookup returns the node for the dot separated key, and the mapping node containing it
+func (c *YAMLConf) lookup(key string) (parent *yaml.Node, node *yaml.Node) {
+	node = c.root()
+	for _, part := range strings.Split(key, ".") {
+		if node.Kind != yaml.MappingNode {
+			return nil, nil
+		}
+		parent = node
+		node = nil
+		for i := 0; i+1 < len(parent.Content); i += 2 {
+			if parent.Content[i].Value == part {
+				node = parent.Content[i+1]
+				break
+			}
+		}
+		if node == nil {
+			return nil, nil
+		}
+	}
+	return parent, node
+}
+
+// Get returns value of the key, nested mappings and sequences are returned as yaml
+func (c *YAMLConf) Get(key string) (string, bool) {
+	_, node := c.lookup(key)
+	if node == nil {
+		return "", false
+	}
+	if node.Kind == yaml.ScalarNode {
+		if node.ShortTag() == "!!null" {
+			return "", true
+		}
+		return node.Value, true
+	}
+	data, err := yaml.Marshal(node)
+	if err != nil {
+		return "", true
+	}
+	return strings.TrimSpace(string(data)), true
+}
+
+// Lookup returns scalar value of the key, error is returned if the key holds mapping or sequence
+func (c *YAMLConf) Lookup(key string) (string, bool, error) {
+	_, node := c.lookup(key)
+	if node == nil {
+		return "", false, nil
+	}
+	if node.Kind != yaml.ScalarNode {
+		return "", true, errors.Errorf("expected scalar value, got nested %s", yamlKindName(node.Kind))
+	}
+	if node.ShortTag() == "!!null" {
+		return "", true, nil
+	}
+	return node.Value, true, nil
+}
+
+func yamlKindName(kind yaml.Kind) string {
+	switch kind {
+	case yaml.MappingNode:
+		return "mapping"
+	case yaml.SequenceNode:
+		return "sequence"
+	}
+	return "value"
+}
+
+// Set sets the scalar value of the key, creating missing parent mappings.
+// Value must have the same type (int, float, bool) as the current value, if any
+func (c *YAMLConf) Set(key, value string) error {
+	_, node := c.lookup(key)
+	if node != nil {
+		if node.Kind != yaml.ScalarNode {
+			return errors.Errorf("%s is not a scalar value", key)
+		}
+		tag := node.ShortTag()
+		if err := checkYAMLScalarType(tag, value); err != nil {
+			return errors.Errorf("invalid value for %s: %v", key, err)
+		}
+		node.Value = value
+		if tag == "!!str" {
+			node.Tag = tag
+		} else {
+			node.Tag = ""
+			node.Style = 0
+		}
+		return nil
+	}
+
+	parent := c.root()
+	parts := strings.Split(key, ".")
+	for i, part := range parts {
+		var child *yaml.Node
+		for j := 0; j+1 < len(parent.Content); j += 2 {
+			if parent.Content[j].Value == part {
+				child = parent.Content[j+1]
+				break
+			}
+		}
+		last := i == len(parts)-1
+		if child == nil {
+			if last {
+				child = &yaml.Node{Kind: yaml.ScalarNode, Value: value}
+			} else {
+				child = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+			}
+			parent.Content = append(parent.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: part}, child)
+		} else if child.Kind == yaml.ScalarNode && child.ShortTag() == "!!null" && !last {
+			// empty section, like "log-config:" without any keys
+			child.Kind = yaml.MappingNode
+			child.Tag = "!!map"
+			child.Value = ""
+		} else if child.Kind != yaml.MappingNode {
+			return errors.Errorf("%s is not a mapping", strings.Join(parts[:i+1], "."))
+		}
+		parent = child
+	}
+	return nil
+}
+
+// Unset removes the key, returns false if it was not set
+func (c *YAMLConf) Unset(key string) bool {
+	parent, node := c.lookup(key)
+	if node == nil {
+		return false
+	}
+	for i := 0; i+1 < len(parent.Content); i += 2 {
+		if parent.Content[i+1] == node {
+			parent.Content = append(parent.Content[:i], parent.Content[i+2:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// Keys returns dot separated paths of all leaf values in the document order
+func (c *YAMLConf) Keys() []string {
+	var keys []string
+	var walk func(prefix string, node *yaml.Node)
+	walk = func(prefix string, node *yaml.Node) {
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key := node.Content[i].Value
+			if prefix != "" {
+				key = prefix + "." + key
+			}
+			if value := node.Content[i+1]; value.Kind == yaml.MappingNode {
+				walk(key, value)
+			} else {
+				keys = append(keys, key)
+			}
+		}
+	}
+	walk("", c.root())
+	return keys
+}
+
+func checkYAMLScalarType(tag string, value string) error {
+	var err error
+	switch tag {
+	case "!!int":
+		_, err = strconv.ParseInt(value, 0, 64)
+	case "!!float":
+		_, err = strconv.ParseFloat(value, 64)
+	case "!!bool":
+		_, err = strconv.ParseBool(value)
+	}
+	if err != nil {
+		return errors.Errorf("%q is not %s", value, strings.TrimPrefix(tag, "!!"))
+	}
+	return nil
+}